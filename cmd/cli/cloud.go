@@ -39,6 +39,8 @@ func cloudCmd() *cobra.Command {
 		cloudUseProfileCmd(),
 		cloudRunCmd(),
 		cloudCompareCmd(),
+		cloudTrendCmd(),
+		cloudGateCmd(),
 		cloudBenchCmd(),
 		cloudUploadCmd(),
 		cloudWaitCmd(),
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"net/url"
 	"os"
@@ -20,6 +21,7 @@ func cloudBenchCmd() *cobra.Command {
 	var runA, runB string
 	var outputFiles []string
 	var threshold float64
+	var repeat int
 	var timeout, interval time.Duration
 
 	cmd := &cobra.Command{
@@ -28,16 +30,31 @@ func cloudBenchCmd() *cobra.Command {
 		Long: `Launch baseline and candidate runs in parallel, wait for both to complete,
 then compare their metrics. Alternatively, pass existing run IDs to skip launching.
 
-A comparison table is always printed to the console. Use -o to save results
-to files — the format is determined by extension:
+A comparison table is always printed to the console, followed by every
+setting that differs between the runs (config, options, package, workload,
+captured database settings, machine facts). Use -o to save results to
+files — the format is determined by extension:
   .md    → markdown
   .json  → json
   .xml   → junit XML
 
+With --repeat N, N baseline/candidate pairs run one round after another and
+are compared as groups: each metric gets its mean, standard deviation,
+coefficient of variation and 95% confidence interval per group, and a
+Mann-Whitney U test. A metric is better or worse only when the difference is
+both significant (p < 0.05) and above the threshold; that takes at least 4
+runs per group. Existing repetitions can be passed as comma-separated IDs.
+
 Examples:
   # Launch two configs and compare
   stroppy-cloud cloud bench --baseline pg16.json --candidate pg17.json
 
+  # Five rounds of each, compared statistically
+  stroppy-cloud cloud bench --baseline pg16.json --candidate pg17.json --repeat 5
+
+  # Compare existing repetitions
+  stroppy-cloud cloud bench --run-a run-1,run-2,run-3,run-4 --run-b run-5,run-6,run-7,run-8
+
   # With custom .deb packages
   stroppy-cloud cloud bench \
     --baseline run.json --baseline-deb ./pg16-custom.deb \
@@ -51,84 +68,62 @@ Examples:
 			if err != nil {
 				return err
 			}
+			if repeat < 1 {
+				return fmt.Errorf("--repeat must be at least 1")
+			}
 
-			idA := runA
-			idB := runB
+			idsA := splitRunIDs(runA)
+			idsB := splitRunIDs(runB)
 
 			// Launch runs if configs provided.
 			if baselineConfig != "" || candidateConfig != "" {
 				if baselineConfig == "" || candidateConfig == "" {
 					return fmt.Errorf("both --baseline and --candidate are required when launching runs")
 				}
-
-				var wg sync.WaitGroup
-				var errA, errB error
-
-				wg.Add(2)
-				go func() {
-					defer wg.Done()
-					idA, errA = submitRun(c, baselineConfig, "", baselineDeb)
-					if errA == nil {
-						fmt.Printf("Baseline started: %s\n", idA)
+				// One baseline/candidate pair per round, so drift in the
+				// environment hits both groups alike.
+				for round := 1; round <= repeat; round++ {
+					if repeat > 1 {
+						fmt.Printf("\nRound %d/%d\n", round, repeat)
 					}
-				}()
-				go func() {
-					defer wg.Done()
-					idB, errB = submitRun(c, candidateConfig, "", candidateDeb)
-					if errB == nil {
-						fmt.Printf("Candidate started: %s\n", idB)
+					idA, idB, err := launchPair(c, baselineConfig, baselineDeb, candidateConfig, candidateDeb)
+					if err != nil {
+						return err
 					}
-				}()
-				wg.Wait()
-
-				if errA != nil {
-					return fmt.Errorf("baseline launch failed: %w", errA)
+					if err := waitPair(c, idA, idB, timeout, interval); err != nil {
+						return err
+					}
+					idsA = append(idsA, idA)
+					idsB = append(idsB, idB)
 				}
-				if errB != nil {
-					return fmt.Errorf("candidate launch failed: %w", errB)
+			} else {
+				if repeat > 1 {
+					return fmt.Errorf("--repeat needs --baseline and --candidate; pass repeated runs to --run-a/--run-b as comma-separated IDs")
+				}
+				if len(idsA) == 0 || len(idsB) == 0 {
+					return fmt.Errorf("provide either --baseline/--candidate configs or --run-a/--run-b IDs")
+				}
+				// Wait for the given runs in case they are still going.
+				for i := 0; i < max(len(idsA), len(idsB)); i++ {
+					if err := waitPair(c, at(idsA, i), at(idsB, i), timeout, interval); err != nil {
+						return err
+					}
 				}
-			}
-
-			if idA == "" || idB == "" {
-				return fmt.Errorf("provide either --baseline/--candidate configs or --run-a/--run-b IDs")
-			}
-
-			// Wait for both runs in parallel.
-			fmt.Println("\nWaiting for both runs to complete...")
-			var wg sync.WaitGroup
-			var errA, errB error
-
-			wg.Add(2)
-			go func() {
-				defer wg.Done()
-				errA = waitForRun(c, idA, timeout, interval)
-			}()
-			go func() {
-				defer wg.Done()
-				errB = waitForRun(c, idB, timeout, interval)
-			}()
-			wg.Wait()
-
-			fmt.Println() // newline after progress output
-			if errA != nil {
-				return fmt.Errorf("baseline run failed: %w", errA)
-			}
-			if errB != nil {
-				return fmt.Errorf("candidate run failed: %w", errB)
 			}
 
 			// Compare.
 			fmt.Println("Comparing results...")
-			return runCompare(c, idA, idB, threshold, outputFiles)
+			return runCompare(c, strings.Join(idsA, ","), strings.Join(idsB, ","), threshold, outputFiles)
 		},
 	}
 
-	cmd.Flags().StringVar(&baselineConfig, "baseline", "", "path to baseline run config JSON")
-	cmd.Flags().StringVar(&candidateConfig, "candidate", "", "path to candidate run config JSON")
+	cmd.Flags().StringVar(&baselineConfig, "baseline", "", "path to baseline run config (YAML or JSON)")
+	cmd.Flags().StringVar(&candidateConfig, "candidate", "", "path to candidate run config (YAML or JSON)")
 	cmd.Flags().StringVar(&baselineDeb, "baseline-deb", "", "path to .deb for baseline run")
 	cmd.Flags().StringVar(&candidateDeb, "candidate-deb", "", "path to .deb for candidate run")
-	cmd.Flags().StringVar(&runA, "run-a", "", "existing baseline run ID (skip launch)")
-	cmd.Flags().StringVar(&runB, "run-b", "", "existing candidate run ID (skip launch)")
+	cmd.Flags().StringVar(&runA, "run-a", "", "existing baseline run ID(s), comma-separated (skip launch)")
+	cmd.Flags().StringVar(&runB, "run-b", "", "existing candidate run ID(s), comma-separated (skip launch)")
+	cmd.Flags().IntVar(&repeat, "repeat", 1, "launch this many baseline/candidate pairs and compare them as groups")
 	cmd.Flags().StringArrayVarP(&outputFiles, "output", "o", nil, "output file (format from extension: .md .json .xml); repeatable")
 	cmd.Flags().Float64Var(&threshold, "threshold", 0, "custom threshold percentage (0 = server default)")
 	cmd.Flags().DurationVar(&timeout, "timeout", 60*time.Minute, "max wait time per run")
@@ -137,9 +132,91 @@ Examples:
 	return cmd
 }
 
+// launchPair submits the baseline and candidate runs in parallel.
+func launchPair(c *cloudHTTPClient, baselineConfig, baselineDeb, candidateConfig, candidateDeb string) (string, string, error) {
+	var wg sync.WaitGroup
+	var idA, idB string
+	var errA, errB error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		idA, errA = submitRun(c, baselineConfig, "", baselineDeb)
+		if errA == nil {
+			fmt.Printf("Baseline started: %s\n", idA)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		idB, errB = submitRun(c, candidateConfig, "", candidateDeb)
+		if errB == nil {
+			fmt.Printf("Candidate started: %s\n", idB)
+		}
+	}()
+	wg.Wait()
+
+	if errA != nil {
+		return "", "", fmt.Errorf("baseline launch failed: %w", errA)
+	}
+	if errB != nil {
+		return "", "", fmt.Errorf("candidate launch failed: %w", errB)
+	}
+	return idA, idB, nil
+}
+
+// waitPair waits for a baseline and a candidate run in parallel; an empty
+// ID is skipped.
+func waitPair(c *cloudHTTPClient, idA, idB string, timeout, interval time.Duration) error {
+	fmt.Println("\nWaiting for both runs to complete...")
+	var wg sync.WaitGroup
+	var errA, errB error
+
+	wait := func(id string, errp *error) {
+		defer wg.Done()
+		if id != "" {
+			*errp = waitForRun(c, id, timeout, interval)
+		}
+	}
+	wg.Add(2)
+	go wait(idA, &errA)
+	go wait(idB, &errB)
+	wg.Wait()
+
+	fmt.Println() // newline after progress output
+	if errA != nil {
+		return fmt.Errorf("baseline run failed: %w", errA)
+	}
+	if errB != nil {
+		return fmt.Errorf("candidate run failed: %w", errB)
+	}
+	return nil
+}
+
+func splitRunIDs(v string) []string {
+	var ids []string
+	for _, id := range strings.Split(v, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func at(ids []string, i int) string {
+	if i < len(ids) {
+		return ids[i]
+	}
+	return ""
+}
+
 type compareResult struct {
-	RunA    string `json:"run_a"`
-	RunB    string `json:"run_b"`
+	RunA  string   `json:"run_a"`
+	RunB  string   `json:"run_b"`
+	RunsA []string `json:"runs_a"`
+	RunsB []string `json:"runs_b"`
+	// WindowA and WindowB are the ranges each run's metrics cover.
+	WindowA timeWindow `json:"window_a"`
+	WindowB timeWindow `json:"window_b"`
 	Metrics []struct {
 		Key        string  `json:"key"`
 		Name       string  `json:"name"`
@@ -148,12 +225,73 @@ type compareResult struct {
 		AvgB       float64 `json:"avg_b"`
 		DiffAvgPct float64 `json:"diff_avg_pct"`
 		Verdict    string  `json:"verdict"`
+		// Stats is set when groups of repeated runs are compared.
+		Stats *groupStats `json:"stats"`
 	} `json:"metrics"`
 	Summary struct {
 		Better int `json:"better"`
 		Worse  int `json:"worse"`
 		Same   int `json:"same"`
 	} `json:"summary"`
+	// ConfigDiff lists the settings that differ between the runs, so a
+	// regression can be traced to the knob that changed.
+	ConfigDiff []configChange `json:"config_diff"`
+}
+
+type groupStats struct {
+	A           sampleStats `json:"a"`
+	B           sampleStats `json:"b"`
+	PValue      float64     `json:"p_value"`
+	Significant bool        `json:"significant"`
+}
+
+type sampleStats struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	CV     float64 `json:"cv"`
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
+
+// halfCI is the half width of the 95% confidence interval of the mean.
+func (s sampleStats) halfCI() float64 { return (s.CIHigh - s.CILow) / 2 }
+
+func (r *compareResult) grouped() bool {
+	return len(r.RunsA) > 1 || len(r.RunsB) > 1
+}
+
+func (r *compareResult) title() string {
+	if r.grouped() {
+		return fmt.Sprintf("%d baseline runs vs %d candidate runs", len(r.RunsA), len(r.RunsB))
+	}
+	return fmt.Sprintf("%s vs %s", r.RunA, r.RunB)
+}
+
+type timeWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (tw timeWindow) String() string {
+	if tw.Start.IsZero() {
+		return "-"
+	}
+	return fmt.Sprintf("%s (%s)", tw.Start.Format(time.RFC3339), tw.End.Sub(tw.Start).Round(time.Second))
+}
+
+type configChange struct {
+	Section string `json:"section"`
+	Path    string `json:"path"`
+	A       string `json:"a"`
+	B       string `json:"b"`
+}
+
+// orUnset shows an empty diff value as "(unset)".
+func orUnset(v string) string {
+	if v == "" {
+		return "(unset)"
+	}
+	return v
 }
 
 // runCompare fetches the comparison, prints a table to stdout,
@@ -216,31 +354,61 @@ func formatFromExt(path string) string {
 		return "json"
 	case ".xml":
 		return "junit"
+	case ".csv":
+		return "csv"
 	default:
 		return "table"
 	}
 }
 
 func renderTable(w *strings.Builder, r *compareResult) {
-	fmt.Fprintf(w, "\nCompare: %s vs %s\n\n", r.RunA, r.RunB)
-	fmt.Fprintf(w, "%-35s %12s %12s %10s %8s\n", "METRIC", "BASELINE", "CANDIDATE", "DIFF %", "VERDICT")
-	fmt.Fprintln(w, strings.Repeat("-", 82))
+	fmt.Fprintf(w, "\nCompare: %s\n", r.title())
+	if r.grouped() {
+		fmt.Fprintf(w, "Baseline:  %s\nCandidate: %s\n\n", strings.Join(r.RunsA, ", "), strings.Join(r.RunsB, ", "))
+		fmt.Fprintf(w, "%-30s %20s %20s %9s %7s %8s\n", "METRIC", "BASELINE ±95% CI", "CANDIDATE ±95% CI", "DIFF %", "P", "VERDICT")
+		fmt.Fprintln(w, strings.Repeat("-", 99))
+	} else {
+		fmt.Fprintf(w, "Windows: %s vs %s\n\n", r.WindowA, r.WindowB)
+		fmt.Fprintf(w, "%-35s %12s %12s %10s %8s\n", "METRIC", "BASELINE", "CANDIDATE", "DIFF %", "VERDICT")
+		fmt.Fprintln(w, strings.Repeat("-", 82))
+	}
 	for _, m := range r.Metrics {
 		verdict := m.Verdict
 		if verdict == "same" {
 			verdict = "= same"
 		}
+		if st := m.Stats; st != nil {
+			fmt.Fprintf(w, "%-30s %20s %20s %+8.1f%% %7.3f %8s\n",
+				m.Name, fmt.Sprintf("%.2f ±%.2f", st.A.Mean, st.A.halfCI()),
+				fmt.Sprintf("%.2f ±%.2f", st.B.Mean, st.B.halfCI()), m.DiffAvgPct, st.PValue, verdict)
+			continue
+		}
 		fmt.Fprintf(w, "%-35s %12.2f %12.2f %+9.1f%% %8s\n",
 			m.Name, m.AvgA, m.AvgB, m.DiffAvgPct, verdict)
 	}
 	fmt.Fprintf(w, "\nSummary: %d better, %d worse, %d same\n",
 		r.Summary.Better, r.Summary.Worse, r.Summary.Same)
+
+	if len(r.ConfigDiff) == 0 {
+		fmt.Fprintln(w, "\nNo configuration differences.")
+		return
+	}
+	fmt.Fprintf(w, "\nConfiguration differences (%d):\n", len(r.ConfigDiff))
+	for _, c := range r.ConfigDiff {
+		fmt.Fprintf(w, "  %-11s %s: %s -> %s\n", c.Section, c.Path, orUnset(c.A), orUnset(c.B))
+	}
 }
 
 func renderMarkdown(w *strings.Builder, r *compareResult) {
-	fmt.Fprintf(w, "## Benchmark: %s vs %s\n\n", r.RunA, r.RunB)
-	fmt.Fprintln(w, "| Metric | Baseline | Candidate | Diff % | Verdict |")
-	fmt.Fprintln(w, "|--------|----------|-----------|--------|---------|")
+	fmt.Fprintf(w, "## Benchmark: %s\n\n", r.title())
+	if r.grouped() {
+		fmt.Fprintf(w, "Baseline: %s  \nCandidate: %s\n\n", strings.Join(r.RunsA, ", "), strings.Join(r.RunsB, ", "))
+		fmt.Fprintln(w, "| Metric | Baseline (±95% CI) | Candidate (±95% CI) | CV A / B | Diff % | p | Verdict |")
+		fmt.Fprintln(w, "|--------|--------------------|---------------------|----------|--------|---|---------|")
+	} else {
+		fmt.Fprintln(w, "| Metric | Baseline | Candidate | Diff % | Verdict |")
+		fmt.Fprintln(w, "|--------|----------|-----------|--------|---------|")
+	}
 	for _, m := range r.Metrics {
 		verdict := m.Verdict
 		switch verdict {
@@ -251,24 +419,67 @@ func renderMarkdown(w *strings.Builder, r *compareResult) {
 		case "same":
 			verdict = ":heavy_minus_sign: same"
 		}
+		if st := m.Stats; st != nil {
+			fmt.Fprintf(w, "| %s | %.2f ±%.2f %s | %.2f ±%.2f %s | %.1f%% / %.1f%% | %+.1f%% | %.3f | %s |\n",
+				m.Name, st.A.Mean, st.A.halfCI(), m.Unit, st.B.Mean, st.B.halfCI(), m.Unit,
+				st.A.CV*100, st.B.CV*100, m.DiffAvgPct, st.PValue, verdict)
+			continue
+		}
 		fmt.Fprintf(w, "| %s | %.2f %s | %.2f %s | %+.1f%% | %s |\n",
 			m.Name, m.AvgA, m.Unit, m.AvgB, m.Unit, m.DiffAvgPct, verdict)
 	}
 	fmt.Fprintf(w, "\n**Summary:** %d better, %d worse, %d same\n",
 		r.Summary.Better, r.Summary.Worse, r.Summary.Same)
+
+	fmt.Fprint(w, "\n### Configuration differences\n\n")
+	if len(r.ConfigDiff) == 0 {
+		fmt.Fprintln(w, "_None._")
+		return
+	}
+	fmt.Fprintln(w, "| Section | Setting | Baseline | Candidate |")
+	fmt.Fprintln(w, "|---------|---------|----------|-----------|")
+	for _, c := range r.ConfigDiff {
+		fmt.Fprintf(w, "| %s | `%s` | %s | %s |\n",
+			c.Section, c.Path, markdownCell(orUnset(c.A)), markdownCell(orUnset(c.B)))
+	}
+}
+
+// markdownCell keeps a value from breaking the table row it sits in.
+func markdownCell(v string) string {
+	return strings.ReplaceAll(v, "|", `\|`)
 }
 
 func renderJUnit(w *strings.Builder, r *compareResult) {
 	fmt.Fprintln(w, `<?xml version="1.0" encoding="UTF-8"?>`)
 	fmt.Fprintf(w, "<testsuite name=\"stroppy-compare\" tests=\"%d\" failures=\"%d\">\n",
 		len(r.Metrics), r.Summary.Worse)
+	// Config differences ride along as suite properties so CI shows them
+	// next to the failures they may explain.
+	if len(r.ConfigDiff) > 0 {
+		fmt.Fprintln(w, "  <properties>")
+		for _, c := range r.ConfigDiff {
+			fmt.Fprintf(w, "    <property name=\"config.%s.%s\" value=\"%s -&gt; %s\"/>\n",
+				xmlEscape(c.Section), xmlEscape(c.Path), xmlEscape(orUnset(c.A)), xmlEscape(orUnset(c.B)))
+		}
+		fmt.Fprintln(w, "  </properties>")
+	}
 	for _, m := range r.Metrics {
-		fmt.Fprintf(w, "  <testcase name=\"%s\" classname=\"stroppy.%s\">\n", m.Name, m.Key)
+		fmt.Fprintf(w, "  <testcase name=\"%s\" classname=\"stroppy.%s\">\n", xmlEscape(m.Name), xmlEscape(m.Key))
 		if m.Verdict == "worse" {
-			fmt.Fprintf(w, "    <failure message=\"%s regressed by %.1f%%\">avg_a=%.2f avg_b=%.2f diff=%.1f%%</failure>\n",
-				m.Name, m.DiffAvgPct, m.AvgA, m.AvgB, m.DiffAvgPct)
+			detail := ""
+			if m.Stats != nil {
+				detail = fmt.Sprintf(" n_a=%d n_b=%d p=%.4f", m.Stats.A.N, m.Stats.B.N, m.Stats.PValue)
+			}
+			fmt.Fprintf(w, "    <failure message=\"%s regressed by %.1f%%\">avg_a=%.2f avg_b=%.2f diff=%.1f%%%s</failure>\n",
+				xmlEscape(m.Name), m.DiffAvgPct, m.AvgA, m.AvgB, m.DiffAvgPct, detail)
 		}
 		fmt.Fprintln(w, "  </testcase>")
 	}
 	fmt.Fprintln(w, "</testsuite>")
 }
+
+func xmlEscape(v string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(v))
+	return b.String()
+}
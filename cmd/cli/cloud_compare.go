@@ -1,17 +1,50 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
 func cloudCompareCmd() *cobra.Command {
-	var runA, runB string
+	var runA, runB, runs, ref string
 	var outputFiles []string
 	var threshold float64
 	cmd := &cobra.Command{
 		Use:   "compare",
-		Short: "Compare metrics between two runs",
+		Short: "Compare metrics between two runs, or many runs against a reference",
+		Long: `Compare metrics between two runs (--run-a/--run-b), or between many runs
+and one reference run (--runs r1,r2,r3 [--ref r2]; the first run is the
+reference by default). The many-run form prints a matrix of averages and
+diffs against the reference, marking the best and worst run per metric.
+Output files take their format from the extension: .md .json .xml .csv.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if runs != "" {
+				if runA != "" || runB != "" {
+					return fmt.Errorf("--runs cannot be combined with --run-a/--run-b")
+				}
+				ids := splitRunIDs(runs)
+				if len(ids) < 2 {
+					return fmt.Errorf("--runs needs at least two run IDs")
+				}
+				c, err := newCloudClient()
+				if err != nil {
+					return err
+				}
+				return runCompareMany(c, ids, ref, threshold, outputFiles)
+			}
+			if runA == "" || runB == "" {
+				return fmt.Errorf("either --run-a and --run-b, or --runs is required")
+			}
+			if ref != "" {
+				return fmt.Errorf("--ref only applies with --runs")
+			}
 			c, err := newCloudClient()
 			if err != nil {
 				return err
@@ -21,9 +54,240 @@ func cloudCompareCmd() *cobra.Command {
 	}
 	cmd.Flags().StringVar(&runA, "run-a", "", "first run ID (baseline)")
 	cmd.Flags().StringVar(&runB, "run-b", "", "second run ID")
-	cmd.Flags().StringArrayVarP(&outputFiles, "output", "o", nil, "output file (format from extension: .md .json .xml); repeatable")
+	cmd.Flags().StringVar(&runs, "runs", "", "comma-separated run IDs to compare against one reference")
+	cmd.Flags().StringVar(&ref, "ref", "", "reference run for --runs (default: the first)")
+	cmd.Flags().StringArrayVarP(&outputFiles, "output", "o", nil, "output file (format from extension: .md .json .xml .csv); repeatable")
 	cmd.Flags().Float64Var(&threshold, "threshold", 0, "custom threshold percentage (0 = server default)")
-	cmd.MarkFlagRequired("run-a")
-	cmd.MarkFlagRequired("run-b")
 	return cmd
 }
+
+type matrixResult struct {
+	Runs      []string     `json:"runs"`
+	Reference string       `json:"reference"`
+	Windows   []timeWindow `json:"windows"`
+	Metrics   []struct {
+		Key   string `json:"key"`
+		Name  string `json:"name"`
+		Unit  string `json:"unit"`
+		Cells []struct {
+			Run     string  `json:"run"`
+			Avg     float64 `json:"avg"`
+			DiffPct float64 `json:"diff_pct"`
+			Verdict string  `json:"verdict"`
+			Missing bool    `json:"missing"`
+		} `json:"cells"`
+		Best  string `json:"best"`
+		Worst string `json:"worst"`
+	} `json:"metrics"`
+	// ConfigDiff maps each non-reference run to its differences from the
+	// reference's settings.
+	ConfigDiff map[string][]configChange `json:"config_diff"`
+}
+
+// runCompareMany fetches an N-way comparison, prints the matrix to stdout,
+// and writes each -o file in the format matching its extension.
+func runCompareMany(c *cloudHTTPClient, runs []string, ref string, threshold float64, outputFiles []string) error {
+	path := "/api/v1/compare?runs=" + url.QueryEscape(strings.Join(runs, ","))
+	if ref != "" {
+		path += "&ref=" + url.QueryEscape(ref)
+	}
+	if threshold > 0 {
+		path += fmt.Sprintf("&threshold=%.1f", threshold)
+	}
+
+	body, status, err := c.doJSON("GET", path, nil)
+	if err != nil {
+		return fmt.Errorf("compare request: %w", err)
+	}
+	if status != 200 {
+		return fmt.Errorf("server error %d: %s", status, string(body))
+	}
+
+	var result matrixResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+
+	var table strings.Builder
+	renderMatrixTable(&table, &result)
+	fmt.Print(table.String())
+
+	for _, outPath := range outputFiles {
+		var buf strings.Builder
+		switch formatFromExt(outPath) {
+		case "json":
+			var pretty bytes.Buffer
+			json.Indent(&pretty, body, "", "  ")
+			buf.WriteString(pretty.String())
+			buf.WriteString("\n")
+		case "markdown":
+			renderMatrixMarkdown(&buf, &result)
+		case "csv":
+			if err := renderMatrixCSV(&buf, &result); err != nil {
+				return fmt.Errorf("render %s: %w", outPath, err)
+			}
+		case "junit":
+			return fmt.Errorf("%s: JUnit output needs --run-a/--run-b", outPath)
+		default:
+			renderMatrixTable(&buf, &result)
+		}
+		if err := os.WriteFile(outPath, []byte(buf.String()), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", outPath, err)
+		}
+		fmt.Fprintf(os.Stderr, "Saved: %s\n", outPath)
+	}
+
+	return nil
+}
+
+// runLabel marks the reference run in column headers.
+func (r *matrixResult) runLabel(id string) string {
+	if id == r.Reference {
+		return id + " (ref)"
+	}
+	return id
+}
+
+func renderMatrixTable(w *strings.Builder, r *matrixResult) {
+	fmt.Fprintf(w, "\nCompare: %d runs against %s\n", len(r.Runs), r.Reference)
+	for i, id := range r.Runs {
+		if i < len(r.Windows) {
+			fmt.Fprintf(w, "  %-38s %s\n", r.runLabel(id), r.Windows[i])
+		}
+	}
+	fmt.Fprintf(w, "\n%-30s", "METRIC")
+	for _, id := range r.Runs {
+		fmt.Fprintf(w, " %20s", shortRunID(id))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, strings.Repeat("-", 30+21*len(r.Runs)))
+	for _, m := range r.Metrics {
+		fmt.Fprintf(w, "%-30s", m.Name)
+		for _, cell := range m.Cells {
+			var v string
+			switch {
+			case cell.Missing:
+				v = "-"
+			case cell.Verdict == "reference":
+				v = fmt.Sprintf("%.2f", cell.Avg)
+			default:
+				v = fmt.Sprintf("%.2f (%+.1f%%)", cell.Avg, cell.DiffPct)
+			}
+			switch cell.Run {
+			case m.Best:
+				v = "+" + v
+			case m.Worst:
+				v = "!" + v
+			}
+			fmt.Fprintf(w, " %20s", v)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "\n+ best, ! worst per metric; diffs are against the reference run.")
+	renderMatrixConfigDiff(w, r)
+}
+
+func renderMatrixConfigDiff(w *strings.Builder, r *matrixResult) {
+	for _, id := range r.Runs {
+		changes, ok := r.ConfigDiff[id]
+		if !ok || len(changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nConfiguration differences, %s vs %s (%d):\n", id, r.Reference, len(changes))
+		for _, c := range changes {
+			fmt.Fprintf(w, "  %-11s %s: %s -> %s\n", c.Section, c.Path, orUnset(c.A), orUnset(c.B))
+		}
+	}
+}
+
+func renderMatrixMarkdown(w *strings.Builder, r *matrixResult) {
+	fmt.Fprintf(w, "## Benchmark: %d runs against %s\n\n", len(r.Runs), r.Reference)
+	fmt.Fprint(w, "| Metric |")
+	for _, id := range r.Runs {
+		fmt.Fprintf(w, " %s |", r.runLabel(id))
+	}
+	fmt.Fprint(w, "\n|--------|")
+	fmt.Fprintln(w, strings.Repeat("------|", len(r.Runs)))
+	for _, m := range r.Metrics {
+		fmt.Fprintf(w, "| %s |", m.Name)
+		for _, cell := range m.Cells {
+			var v string
+			switch {
+			case cell.Missing:
+				v = "–"
+			case cell.Verdict == "reference":
+				v = fmt.Sprintf("%.2f %s", cell.Avg, m.Unit)
+			default:
+				v = fmt.Sprintf("%.2f %s (%+.1f%%)", cell.Avg, m.Unit, cell.DiffPct)
+			}
+			switch cell.Run {
+			case m.Best:
+				v = "**" + v + "**"
+			case m.Worst:
+				v = "_" + v + "_"
+			}
+			fmt.Fprintf(w, " %s |", v)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "\n**Bold** is the best run per metric, _italic_ the worst; diffs are against the reference run.")
+
+	fmt.Fprint(w, "\n### Configuration differences\n\n")
+	listed := false
+	for _, id := range r.Runs {
+		changes := r.ConfigDiff[id]
+		if len(changes) == 0 {
+			continue
+		}
+		listed = true
+		fmt.Fprintf(w, "**%s** vs %s\n\n", id, r.Reference)
+		fmt.Fprintln(w, "| Section | Setting | Reference | Run |")
+		fmt.Fprintln(w, "|---------|---------|-----------|-----|")
+		for _, c := range changes {
+			fmt.Fprintf(w, "| %s | `%s` | %s | %s |\n",
+				c.Section, c.Path, markdownCell(orUnset(c.A)), markdownCell(orUnset(c.B)))
+		}
+		fmt.Fprintln(w)
+	}
+	if !listed {
+		fmt.Fprintln(w, "_None._")
+	}
+}
+
+// renderMatrixCSV writes one row per metric: the average and diff against
+// the reference for every run, then the best and worst run.
+func renderMatrixCSV(w *strings.Builder, r *matrixResult) error {
+	cw := csv.NewWriter(w)
+	header := []string{"key", "metric", "unit"}
+	for _, id := range r.Runs {
+		header = append(header, id+" avg", id+" diff %")
+	}
+	header = append(header, "best", "worst")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, m := range r.Metrics {
+		row := []string{m.Key, m.Name, m.Unit}
+		for _, cell := range m.Cells {
+			if cell.Missing {
+				row = append(row, "", "")
+				continue
+			}
+			row = append(row, fmt.Sprintf("%.4f", cell.Avg), fmt.Sprintf("%.2f", cell.DiffPct))
+		}
+		row = append(row, m.Best, m.Worst)
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// shortRunID keeps matrix columns narrow; full IDs are listed above the table.
+func shortRunID(id string) string {
+	if len(id) > 20 {
+		return id[:19] + "…"
+	}
+	return id
+}
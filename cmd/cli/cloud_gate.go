@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+func cloudGateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gate",
+		Short: "Manage regression gate policies of baselines",
+		Long: `A gate policy is stored under a baseline name. Runs submitted with
+"gate": "<name>" (or cloud run --gate <name>) are compared with the
+baseline's run once their workload finishes, and pass or fail the gate.`,
+	}
+	cmd.AddCommand(gateSetCmd(), gateGetCmd(), gateListCmd(), gateDeleteCmd())
+	return cmd
+}
+
+type gatePolicy struct {
+	MaxDegradationPct map[string]float64 `json:"max_degradation_pct"`
+	MaxPValue         float64            `json:"max_p_value,omitempty"`
+	Ignore            []string           `json:"ignore,omitempty"`
+}
+
+func gatePath(name string) string {
+	return "/api/v1/baseline/" + url.PathEscape(name) + "/gate"
+}
+
+func gateSetCmd() *cobra.Command {
+	var file string
+	var limits, ignore []string
+	var maxPValue float64
+	cmd := &cobra.Command{
+		Use:   "set [baseline]",
+		Short: "Create or replace a baseline's gate policy",
+		Long: `Create or replace a baseline's gate policy, from a JSON file or flags.
+
+Each --max-degradation limits how much worse a metric may get, in percent
+of the baseline; the key "*" covers every metric not listed. With
+--max-p-value, a degradation over its limit fails only if the two runs'
+samples differ significantly.
+
+Examples:
+  stroppy-cloud cloud gate set postgres-16 \
+    --max-degradation '*=5' --max-degradation stroppy_ops=3 \
+    --max-p-value 0.01 --ignore cpu_usage
+  stroppy-cloud cloud gate set postgres-16 -f gate.json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var policy gatePolicy
+			if file != "" {
+				data, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				if err := json.Unmarshal(data, &policy); err != nil {
+					return fmt.Errorf("parse %s: %w", file, err)
+				}
+			}
+			for _, l := range limits {
+				key, v, ok := strings.Cut(l, "=")
+				pct, err := strconv.ParseFloat(v, 64)
+				if !ok || key == "" || err != nil {
+					return fmt.Errorf("--max-degradation %q: want metric=percent", l)
+				}
+				if policy.MaxDegradationPct == nil {
+					policy.MaxDegradationPct = map[string]float64{}
+				}
+				policy.MaxDegradationPct[key] = pct
+			}
+			if cmd.Flags().Changed("max-p-value") {
+				policy.MaxPValue = maxPValue
+			}
+			policy.Ignore = append(policy.Ignore, ignore...)
+
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+			body, _ := json.Marshal(policy)
+			data, status, err := c.doJSON("PUT", gatePath(args[0]), bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			if status != 200 {
+				return fmt.Errorf("server error %d: %s", status, string(data))
+			}
+			fmt.Printf("Gate policy of %s saved.\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&file, "file", "f", "", "policy JSON file")
+	cmd.Flags().StringArrayVar(&limits, "max-degradation", nil, "metric=percent, or *=percent for all other metrics (repeatable)")
+	cmd.Flags().Float64Var(&maxPValue, "max-p-value", 0, "fail degradations only at or below this p-value (0 = no significance test)")
+	cmd.Flags().StringSliceVar(&ignore, "ignore", nil, "metric keys the gate skips")
+	return cmd
+}
+
+func gateGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get [baseline]",
+		Short: "Show a baseline's gate policy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+			data, status, err := c.doJSON("GET", gatePath(args[0]), nil)
+			if err != nil {
+				return err
+			}
+			if status == 404 {
+				return fmt.Errorf("baseline %q has no gate policy", args[0])
+			}
+			if status != 200 {
+				return fmt.Errorf("server error %d: %s", status, string(data))
+			}
+			var pretty bytes.Buffer
+			if err := json.Indent(&pretty, data, "", "  "); err != nil {
+				fmt.Println(string(data))
+				return nil
+			}
+			fmt.Println(pretty.String())
+			return nil
+		},
+	}
+}
+
+func gateListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List gate policies",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+			data, status, err := c.doJSON("GET", "/api/v1/gates", nil)
+			if err != nil {
+				return err
+			}
+			if status != 200 {
+				return fmt.Errorf("server error %d: %s", status, string(data))
+			}
+			var gates []struct {
+				Name   string     `json:"name"`
+				Policy gatePolicy `json:"policy"`
+			}
+			if err := json.Unmarshal(data, &gates); err != nil {
+				return fmt.Errorf("parse response: %w", err)
+			}
+
+			fmt.Printf("%-24s %-40s %-8s %s\n", "BASELINE", "MAX DEGRADATION", "P-VALUE", "IGNORE")
+			fmt.Println(strings.Repeat("-", 90))
+			for _, g := range gates {
+				keys := make([]string, 0, len(g.Policy.MaxDegradationPct))
+				for k := range g.Policy.MaxDegradationPct {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				limits := make([]string, len(keys))
+				for i, k := range keys {
+					limits[i] = fmt.Sprintf("%s=%g%%", k, g.Policy.MaxDegradationPct[k])
+				}
+				p := "-"
+				if g.Policy.MaxPValue > 0 {
+					p = fmt.Sprint(g.Policy.MaxPValue)
+				}
+				fmt.Printf("%-24s %-40s %-8s %s\n", g.Name, strings.Join(limits, " "), p, strings.Join(g.Policy.Ignore, ","))
+			}
+			fmt.Printf("\n%d gates\n", len(gates))
+			return nil
+		},
+	}
+}
+
+func gateDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete [baseline]",
+		Short: "Delete a baseline's gate policy",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+			data, status, err := c.doJSON("DELETE", gatePath(args[0]), nil)
+			if err != nil {
+				return err
+			}
+			if status == 404 {
+				return fmt.Errorf("baseline %q has no gate policy", args[0])
+			}
+			if status != 204 {
+				return fmt.Errorf("delete failed %d: %s", status, string(data))
+			}
+			fmt.Println("Gate policy deleted.")
+			return nil
+		},
+	}
+}
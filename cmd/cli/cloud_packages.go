@@ -13,11 +13,14 @@ import (
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/pkgmeta"
 )
 
 func cloudPackagesCmd() *cobra.Command {
 	cmd := &cobra.Command{Use: "packages", Short: "Manage database packages"}
-	cmd.AddCommand(pkgListCmd(), pkgGetCmd(), pkgCreateCmd(), pkgUpdateCmd(), pkgDeleteCmd(), pkgCloneCmd(), pkgUploadDebCmd())
+	cmd.AddCommand(pkgListCmd(), pkgGetCmd(), pkgCreateCmd(), pkgUpdateCmd(), pkgDeleteCmd(), pkgCloneCmd(), pkgUploadDebCmd(),
+		pkgRevisionsCmd(), pkgDiffCmd())
 	return cmd
 }
 
@@ -59,6 +62,12 @@ func pkgListCmd() *cobra.Command {
 				DBVersion string `json:"db_version"`
 				BuiltIn   bool   `json:"built_in"`
 				DebFile   string `json:"deb_file"`
+				Source    *struct {
+					Ref string `json:"ref"`
+				} `json:"source"`
+				Tarball *struct {
+					URL string `json:"url"`
+				} `json:"tarball"`
 			}
 			if err := json.Unmarshal(data, &pkgs); err != nil {
 				return fmt.Errorf("parse response: %w", err)
@@ -75,6 +84,15 @@ func pkgListCmd() *cobra.Command {
 				if p.DebFile != "" {
 					deb = p.DebFile
 				}
+				if p.Source != nil {
+					deb = "source@" + p.Source.Ref
+				}
+				if p.Tarball != nil {
+					deb = "tarball"
+					if p.Tarball.URL != "" {
+						deb = p.Tarball.URL
+					}
+				}
 				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", p.ID, p.Name, p.DBKind, p.DBVersion, builtin, deb)
 			}
 			w.Flush()
@@ -130,6 +148,8 @@ func pkgCreateCmd() *cobra.Command {
 		customRepo    string
 		customRepoKey string
 		debFile       string
+		source        sourceFlags
+		tarball       tarballFlags
 	)
 	cmd := &cobra.Command{
 		Use:   "create",
@@ -140,6 +160,22 @@ func pkgCreateCmd() *cobra.Command {
 				return err
 			}
 
+			if debFile != "" && (dbKind == "" || dbVersion == "") {
+				kind, version, err := detectDebDatabase(debFile)
+				if err != nil {
+					return err
+				}
+				if dbKind == "" {
+					dbKind = kind
+				}
+				if dbVersion == "" && kind == dbKind {
+					dbVersion = version
+				}
+			}
+			if dbKind == "" {
+				return fmt.Errorf("--db-kind is required (could not detect it from --deb)")
+			}
+
 			body := map[string]any{
 				"name":    name,
 				"db_kind": dbKind,
@@ -162,6 +198,14 @@ func pkgCreateCmd() *cobra.Command {
 			if customRepoKey != "" {
 				body["custom_repo_key"] = customRepoKey
 			}
+			if source.gitURL != "" {
+				body["source"] = source.body()
+			}
+			if tarball.set(cmd) {
+				if body["tarball"], err = tarball.body(); err != nil {
+					return err
+				}
+			}
 
 			raw, _ := json.Marshal(body)
 			data, status, err := c.doJSON("POST", "/api/v1/packages", bytes.NewReader(raw))
@@ -183,12 +227,17 @@ func pkgCreateCmd() *cobra.Command {
 					return err
 				}
 			}
+			if tarball.file != "" {
+				if err := uploadDebToPackage(c, result.ID, tarball.file); err != nil {
+					return err
+				}
+			}
 
 			return nil
 		},
 	}
 	cmd.Flags().StringVar(&name, "name", "", "package name (required)")
-	cmd.Flags().StringVar(&dbKind, "db-kind", "", "database kind (required)")
+	cmd.Flags().StringVar(&dbKind, "db-kind", "", "database kind (detected from --deb when omitted)")
 	cmd.Flags().StringVar(&description, "description", "", "package description")
 	cmd.Flags().StringVar(&dbVersion, "db-version", "", "database version")
 	cmd.Flags().StringSliceVar(&aptPkgs, "apt-pkg", nil, "apt packages to install")
@@ -196,8 +245,10 @@ func pkgCreateCmd() *cobra.Command {
 	cmd.Flags().StringVar(&customRepo, "custom-repo", "", "custom APT repository")
 	cmd.Flags().StringVar(&customRepoKey, "custom-repo-key", "", "custom repository GPG key URL")
 	cmd.Flags().StringVar(&debFile, "deb", "", "path to .deb file to upload after creation")
+	source.register(cmd)
+	tarball.register(cmd)
+	cmd.Flags().StringVar(&tarball.file, "tarball", "", "path to a tarball of prebuilt binaries to upload after creation")
 	cmd.MarkFlagRequired("name")
-	cmd.MarkFlagRequired("db-kind")
 	return cmd
 }
 
@@ -211,6 +262,8 @@ func pkgUpdateCmd() *cobra.Command {
 		preInstall    []string
 		customRepo    string
 		customRepoKey string
+		source        sourceFlags
+		tarball       tarballFlags
 	)
 	cmd := &cobra.Command{
 		Use:   "update [package-id]",
@@ -247,6 +300,16 @@ func pkgUpdateCmd() *cobra.Command {
 			if cmd.Flags().Changed("custom-repo-key") {
 				body["custom_repo_key"] = customRepoKey
 			}
+			if source.gitURL != "" {
+				// The server replaces the whole recipe, so all source flags must be given.
+				body["source"] = source.body()
+			}
+			if tarball.set(cmd) {
+				// Likewise for the tarball recipe.
+				if body["tarball"], err = tarball.body(); err != nil {
+					return err
+				}
+			}
 
 			raw, _ := json.Marshal(body)
 			data, status, err := c.doJSON("PUT", "/api/v1/packages/"+args[0], bytes.NewReader(raw))
@@ -272,9 +335,62 @@ func pkgUpdateCmd() *cobra.Command {
 	cmd.Flags().StringSliceVar(&preInstall, "pre-install", nil, "pre-install commands")
 	cmd.Flags().StringVar(&customRepo, "custom-repo", "", "custom APT repository")
 	cmd.Flags().StringVar(&customRepoKey, "custom-repo-key", "", "custom repository GPG key URL")
+	source.register(cmd)
+	tarball.register(cmd)
 	return cmd
 }
 
+// sourceFlags are the flags describing a package built from git on a builder machine.
+type sourceFlags struct {
+	gitURL         string
+	ref            string
+	buildSystem    string
+	configureFlags []string
+	makeFlags      []string
+	prefix         string
+	buildDeps      []string
+	depends        []string
+	postInstall    []string
+}
+
+func (f *sourceFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.gitURL, "git-url", "", "build from this git repository instead of apt/.deb")
+	cmd.Flags().StringVar(&f.ref, "ref", "master", "branch, tag or commit to build (with --git-url)")
+	cmd.Flags().StringVar(&f.buildSystem, "build-system", "", "autotools, cmake or meson (default by db kind)")
+	cmd.Flags().StringArrayVar(&f.configureFlags, "configure-flag", nil, "configure/cmake/meson flag (repeatable)")
+	cmd.Flags().StringArrayVar(&f.makeFlags, "make-flag", nil, "make/ninja flag (repeatable)")
+	cmd.Flags().StringVar(&f.prefix, "prefix", "", "install prefix (default by db kind)")
+	cmd.Flags().StringSliceVar(&f.buildDeps, "build-dep", nil, "apt packages needed to compile (default by db kind)")
+	cmd.Flags().StringArrayVar(&f.depends, "depends", nil, "runtime dependency of the built .deb (repeatable)")
+	cmd.Flags().StringArrayVar(&f.postInstall, "post-install", nil, "postinst script line of the built .deb (repeatable)")
+}
+
+func (f *sourceFlags) body() map[string]any {
+	src := map[string]any{"git_url": f.gitURL, "ref": f.ref}
+	if f.buildSystem != "" {
+		src["build_system"] = f.buildSystem
+	}
+	if len(f.configureFlags) > 0 {
+		src["configure_flags"] = f.configureFlags
+	}
+	if len(f.makeFlags) > 0 {
+		src["make_flags"] = f.makeFlags
+	}
+	if f.prefix != "" {
+		src["prefix"] = f.prefix
+	}
+	if len(f.buildDeps) > 0 {
+		src["build_deps"] = f.buildDeps
+	}
+	if len(f.depends) > 0 {
+		src["depends"] = f.depends
+	}
+	if len(f.postInstall) > 0 {
+		src["post_install"] = f.postInstall
+	}
+	return src
+}
+
 func pkgDeleteCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "delete [package-id]",
@@ -300,6 +416,129 @@ func pkgDeleteCmd() *cobra.Command {
 	}
 }
 
+func pkgRevisionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "revisions [package-id]",
+		Short: "List the revision history of a package",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+
+			data, status, err := c.doJSON("GET", "/api/v1/packages/"+args[0]+"/revisions", nil)
+			if err != nil {
+				return err
+			}
+			if status == 404 {
+				return fmt.Errorf("package %q not found", args[0])
+			}
+			if status != 200 {
+				return fmt.Errorf("server error %d: %s", status, string(data))
+			}
+
+			var revs []struct {
+				Revision  int    `json:"revision"`
+				Hash      string `json:"hash"`
+				CreatedBy string `json:"created_by"`
+				CreatedAt string `json:"created_at"`
+			}
+			if err := json.Unmarshal(data, &revs); err != nil {
+				return fmt.Errorf("parse response: %w", err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "REV\tHASH\tBY\tCREATED")
+			for _, r := range revs {
+				by := r.CreatedBy
+				if by == "" {
+					by = "-"
+				}
+				fmt.Fprintf(w, "%d\t%.12s\t%s\t%s\n", r.Revision, r.Hash, by, r.CreatedAt)
+			}
+			w.Flush()
+			return nil
+		},
+	}
+}
+
+func pkgDiffCmd() *cobra.Command {
+	var from, to int
+	cmd := &cobra.Command{
+		Use:   "diff [package-id]",
+		Short: "Show what changed between two package revisions",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+
+			path := "/api/v1/packages/" + args[0] + "/revisions/diff"
+			var params []string
+			if from > 0 {
+				params = append(params, fmt.Sprintf("from=%d", from))
+			}
+			if to > 0 {
+				params = append(params, fmt.Sprintf("to=%d", to))
+			}
+			if len(params) > 0 {
+				path += "?" + strings.Join(params, "&")
+			}
+
+			data, status, err := c.doJSON("GET", path, nil)
+			if err != nil {
+				return err
+			}
+			if status != 200 {
+				return fmt.Errorf("server error %d: %s", status, string(data))
+			}
+
+			var diff struct {
+				From    struct{ Revision int } `json:"from"`
+				To      struct{ Revision int } `json:"to"`
+				Changes []struct {
+					Path string `json:"path"`
+					From any    `json:"from"`
+					To   any    `json:"to"`
+				} `json:"changes"`
+			}
+			if err := json.Unmarshal(data, &diff); err != nil {
+				return fmt.Errorf("parse response: %w", err)
+			}
+
+			fmt.Printf("Revision %d -> %d\n", diff.From.Revision, diff.To.Revision)
+			if len(diff.Changes) == 0 {
+				fmt.Println("No changes.")
+				return nil
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "FIELD\tFROM\tTO")
+			for _, ch := range diff.Changes {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", ch.Path, diffValue(ch.From), diffValue(ch.To))
+			}
+			w.Flush()
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&from, "from", 0, "older revision (default: the one before --to)")
+	cmd.Flags().IntVar(&to, "to", 0, "newer revision (default: current)")
+	return cmd
+}
+
+// diffValue renders one side of a field change compactly.
+func diffValue(v any) string {
+	if v == nil {
+		return "-"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
 func pkgCloneCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "clone [package-id]",
@@ -330,6 +569,87 @@ func pkgCloneCmd() *cobra.Command {
 	}
 }
 
+// tarballFlags are the flags describing a package installed from a tarball of
+// prebuilt binaries.
+type tarballFlags struct {
+	url             string
+	file            string // local archive uploaded to the package (create only)
+	sha256          string
+	prefix          string
+	stripComponents int
+	binDirs         []string
+	libDirs         []string
+	depends         []string
+	units           []string
+	postInstall     []string
+}
+
+func (f *tarballFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.url, "tarball-url", "", "install prebuilt binaries from this tarball URL instead of apt/.deb")
+	cmd.Flags().StringVar(&f.sha256, "tarball-sha256", "", "expected sha256 of the --tarball-url archive")
+	cmd.Flags().StringVar(&f.prefix, "install-prefix", "", "directory the tarball is unpacked into (default by db kind)")
+	cmd.Flags().IntVar(&f.stripComponents, "strip-components", 0, "leading path components dropped when unpacking")
+	cmd.Flags().StringSliceVar(&f.binDirs, "bin-dir", nil, "tarball directory added to PATH, relative to the prefix (default bin)")
+	cmd.Flags().StringSliceVar(&f.libDirs, "lib-dir", nil, "tarball directory added to LD_LIBRARY_PATH, relative to the prefix (default lib)")
+	cmd.Flags().StringArrayVar(&f.depends, "tarball-dep", nil, "apt package the tarball binaries need (repeatable)")
+	cmd.Flags().StringArrayVar(&f.units, "unit", nil, "systemd unit template as NAME=FILE, e.g. postgres.service=./pg.service.tmpl (repeatable)")
+	cmd.Flags().StringArrayVar(&f.postInstall, "tarball-post-install", nil, "command run after unpacking the tarball (repeatable)")
+}
+
+// set reports whether any tarball flag was given, making this a tarball package.
+func (f *tarballFlags) set(cmd *cobra.Command) bool {
+	for _, name := range []string{"tarball", "tarball-url", "tarball-sha256", "install-prefix", "strip-components", "bin-dir", "lib-dir", "tarball-dep", "unit", "tarball-post-install"} {
+		if fl := cmd.Flags().Lookup(name); fl != nil && fl.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *tarballFlags) body() (map[string]any, error) {
+	t := map[string]any{}
+	if f.url != "" {
+		t["url"] = f.url
+	}
+	if f.sha256 != "" {
+		t["sha256"] = f.sha256
+	}
+	if f.prefix != "" {
+		t["prefix"] = f.prefix
+	}
+	if f.stripComponents > 0 {
+		t["strip_components"] = f.stripComponents
+	}
+	if len(f.binDirs) > 0 {
+		t["bin_dirs"] = f.binDirs
+	}
+	if len(f.libDirs) > 0 {
+		t["lib_dirs"] = f.libDirs
+	}
+	if len(f.depends) > 0 {
+		t["depends"] = f.depends
+	}
+	if len(f.postInstall) > 0 {
+		t["post_install"] = f.postInstall
+	}
+	var units []map[string]string
+	for _, u := range f.units {
+		name, file, ok := strings.Cut(u, "=")
+		if !ok || name == "" || file == "" {
+			return nil, fmt.Errorf("--unit %q: want NAME=FILE", u)
+		}
+		tmpl, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("--unit %s: %w", name, err)
+		}
+		units = append(units, map[string]string{"name": name, "template": string(tmpl)})
+	}
+	if len(units) > 0 {
+		t["units"] = units
+	}
+	return t, nil
+}
+
 func pkgUploadDebCmd() *cobra.Command {
 	var filePath string
 	cmd := &cobra.Command{
@@ -387,14 +707,34 @@ func uploadDebToPackage(c *cloudHTTPClient, packageID, debPath string) error {
 	}
 
 	var result struct {
-		Filename string `json:"filename"`
-		Size     string `json:"size"`
+		Filename string            `json:"filename"`
+		Size     string            `json:"size"`
+		SHA256   string            `json:"sha256"`
+		Metadata *pkgmeta.Metadata `json:"metadata"`
 	}
 	json.Unmarshal(respBody, &result)
-	fmt.Printf("Uploaded deb: %s (%s bytes)\n", result.Filename, result.Size)
+	fmt.Printf("Uploaded: %s (%s bytes, sha256 %s)\n", result.Filename, result.Size, result.SHA256)
+	if m := result.Metadata; m != nil {
+		fmt.Printf("  %s %s (%s)\n", m.Name, m.Version, m.Architecture)
+	}
 	return nil
 }
 
+// detectDebDatabase reads a local .deb and guesses which database it installs.
+func detectDebDatabase(debPath string) (kind, version string, err error) {
+	f, err := os.Open(debPath)
+	if err != nil {
+		return "", "", fmt.Errorf("open deb file: %w", err)
+	}
+	defer f.Close()
+	meta, err := pkgmeta.Parse(f)
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", debPath, err)
+	}
+	k, v := meta.Database()
+	return string(k), v, nil
+}
+
 // createPackageWithDeb creates a package and optionally uploads a .deb to it.
 // Returns the package ID.
 func createPackageWithDeb(c *cloudHTTPClient, name, dbKind, dbVersion, debPath string) (string, error) {
@@ -9,45 +9,58 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/runconfig"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 )
 
 func cloudRunCmd() *cobra.Command {
-	var configPath, runID, debFile, presetID string
+	var configPath, runID, debFile, presetID, gate string
 	var wait, skipValidation bool
 	var timeout, interval time.Duration
 	cmd := &cobra.Command{
 		Use:   "run",
 		Short: "Start a run on the remote server",
-		Long: `Start a run on the remote server from a JSON config file.
+		Long: `Start a run on the remote server from a YAML or JSON config file.
+
+The config may reference environment variables as ${VAR} or ${VAR:-default},
+build on a base config with "extends: base.yaml", merge fragments with
+"include: [a.yaml, b.yaml]", and be adjusted with --set path=value.
 
 If --deb is provided, a temporary package is created with the .deb file
 and its ID is injected into the config as package_id before launching.
 
 If --preset-id is provided, the preset topology is used (server resolves it).
 
+With --gate, the run is checked against the named baseline's gate policy
+once its workload finishes (see "cloud gate"). --wait then waits for the
+outcome too and exits 2 when the gate fails, 1 when the run fails.
+
 The config is validated before launch unless --skip-validation is set.
 
 Examples:
   stroppy-cloud cloud run -c run.json
   stroppy-cloud cloud run -c run.json --wait
   stroppy-cloud cloud run -c run.json --preset-id <id>
-  stroppy-cloud cloud run -c run.json --deb ./custom-pg.deb --wait`,
+  stroppy-cloud cloud run -c run.json --deb ./custom-pg.deb --wait
+  stroppy-cloud cloud run -c run.json --gate postgres-16 --wait
+  stroppy-cloud cloud run -c pg-tpcc.yaml --set stroppy.vus=128 --set database.version=17`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c, err := newCloudClient()
 			if err != nil {
 				return err
 			}
+			if gate != "" {
+				// Quoted, so a name like "16" stays a string.
+				configSets = append(configSets, fmt.Sprintf("gate=%q", gate))
+			}
 
 			// Validate first (unless skipped).
 			if !skipValidation {
 				fmt.Print("Validating config... ")
-				data, err := os.ReadFile(configPath)
+				cfg, err := runconfig.LoadDocument(configPath, runconfig.Options{Set: configSets})
 				if err != nil {
-					return fmt.Errorf("read config: %w", err)
-				}
-				var cfg map[string]any
-				if err := json.Unmarshal(data, &cfg); err != nil {
-					return fmt.Errorf("parse config: %w", err)
+					return err
 				}
 				if presetID != "" {
 					cfg["preset_id"] = presetID
@@ -57,10 +70,20 @@ Examples:
 				if err != nil {
 					return fmt.Errorf("validation request: %w", err)
 				}
+				var res struct {
+					Issues []types.ConfigIssue `json:"issues"`
+				}
+				parsed := json.Unmarshal(body, &res) == nil
 				if status != 200 {
-					return fmt.Errorf("validation failed: %s", string(body))
+					if !parsed || len(res.Issues) == 0 {
+						return fmt.Errorf("validation failed: %s", string(body))
+					}
+					fmt.Println("FAILED")
+					printIssues(os.Stdout, res.Issues)
+					return fmt.Errorf("validation failed: %s", types.IssuesError(res.Issues))
 				}
 				fmt.Println("OK")
+				printIssues(os.Stdout, res.Issues)
 			}
 
 			id, err := submitRun(c, configPath, runID, debFile)
@@ -74,14 +97,18 @@ Examples:
 				return nil
 			}
 
-			return waitForRun(c, id, timeout, interval)
+			if err := waitForRun(c, id, timeout, interval); err != nil {
+				return err
+			}
+			return waitForGate(c, id, timeout, interval)
 		},
 	}
-	cmd.Flags().StringVarP(&configPath, "config", "c", "", "path to run config JSON")
+	cmd.Flags().StringVarP(&configPath, "config", "c", "", "path to run config (YAML or JSON)")
 	cmd.Flags().StringVar(&runID, "id", "", "custom run ID (auto-generated if omitted)")
 	cmd.Flags().StringVar(&presetID, "preset-id", "", "topology preset ID (server resolves topology)")
 	cmd.Flags().StringVar(&debFile, "deb", "", "path to .deb file (creates package and injects package_id)")
-	cmd.Flags().BoolVar(&wait, "wait", false, "wait for run to complete")
+	cmd.Flags().StringVar(&gate, "gate", "", "check the run against this baseline's gate policy")
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for run to complete (and its gate, if any)")
 	cmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "skip server-side validation")
 	cmd.Flags().DurationVar(&timeout, "timeout", 30*time.Minute, "max wait time (with --wait)")
 	cmd.Flags().DurationVar(&interval, "interval", 5*time.Second, "poll interval (with --wait)")
@@ -92,14 +119,11 @@ Examples:
 // submitRun reads a config file, optionally creates a package from a .deb,
 // and POSTs to the server. Returns the run ID.
 func submitRun(c *cloudHTTPClient, configPath, runID, debPath string) (string, error) {
-	data, err := os.ReadFile(configPath)
+	// YAML configs, ${VAR}s, extends/include and --set are resolved here;
+	// the server only takes the resolved JSON.
+	cfg, err := runconfig.LoadDocument(configPath, runconfig.Options{Set: configSets})
 	if err != nil {
-		return "", fmt.Errorf("read config: %w", err)
-	}
-
-	var cfg map[string]any
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return "", fmt.Errorf("parse config: %w", err)
+		return "", err
 	}
 
 	if runID != "" {
@@ -120,7 +144,7 @@ func submitRun(c *cloudHTTPClient, configPath, runID, debPath string) (string, e
 		cfg["package_id"] = pkgID
 	}
 
-	data, _ = json.Marshal(cfg)
+	data, _ := json.Marshal(cfg)
 	body, status, err := c.doJSON("POST", "/api/v1/run", strings.NewReader(string(data)))
 	if err != nil {
 		return "", fmt.Errorf("start run: %w", err)
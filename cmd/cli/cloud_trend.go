@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func cloudTrendCmd() *cobra.Command {
+	var metric, tag, baseline, dbKind string
+	var limit int
+	var details bool
+	var outputFiles []string
+	cmd := &cobra.Command{
+		Use:   "trend",
+		Short: "Show how metrics moved over recent runs",
+		Long: `Show how metrics moved over the latest runs, from the result summaries
+stored when each run's workload finished.
+
+Select runs by --tag (runs whose config lists the tag) or --baseline (runs
+configured exactly like the baseline's run), or both. Each metric prints as
+a sparkline, oldest run first; --details adds one row per run, marking
+runs whose config changed from the previous one with '*'.
+
+Example:
+  stroppy-cloud cloud trend --metric stroppy_ops,db_tps --tag nightly --limit 50`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := newCloudClient()
+			if err != nil {
+				return err
+			}
+			return runTrend(c, metric, tag, baseline, dbKind, limit, details, outputFiles)
+		},
+	}
+	cmd.Flags().StringVar(&metric, "metric", "", "comma-separated metric keys, e.g. stroppy_ops,db_tps")
+	cmd.Flags().StringVar(&tag, "tag", "", "only runs with this tag")
+	cmd.Flags().StringVar(&baseline, "baseline", "", "only runs configured like this baseline's run")
+	cmd.Flags().StringVar(&dbKind, "db-kind", "", "only runs of this database kind")
+	cmd.Flags().IntVar(&limit, "limit", 50, "number of latest runs")
+	cmd.Flags().BoolVar(&details, "details", false, "print one row per run")
+	cmd.Flags().StringArrayVarP(&outputFiles, "output", "o", nil, "output file (format from extension: .json .csv); repeatable")
+	cmd.MarkFlagRequired("metric")
+	return cmd
+}
+
+type trendResult struct {
+	Tag         string `json:"tag"`
+	Baseline    string `json:"baseline"`
+	BaselineRun string `json:"baseline_run"`
+	ConfigHash  string `json:"config_hash"`
+	Runs        int    `json:"runs"`
+	Trends      []struct {
+		Key    string `json:"key"`
+		Name   string `json:"name"`
+		Unit   string `json:"unit"`
+		Points []struct {
+			RunID      string    `json:"run_id"`
+			Time       time.Time `json:"time"`
+			ConfigHash string    `json:"config_hash"`
+			Avg        float64   `json:"avg"`
+			P95        float64   `json:"p95"`
+			CV         float64   `json:"cv"`
+			Stable     bool      `json:"stable"`
+			ChangePct  float64   `json:"change_pct"`
+		} `json:"points"`
+	} `json:"trends"`
+}
+
+// runTrend fetches the trends, prints them to stdout, and writes each -o
+// file in the format matching its extension.
+func runTrend(c *cloudHTTPClient, metric, tag, baseline, dbKind string, limit int, details bool, outputFiles []string) error {
+	q := url.Values{"metric": {metric}, "limit": {fmt.Sprint(limit)}}
+	for k, v := range map[string]string{"tag": tag, "baseline": baseline, "db_kind": dbKind} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	body, status, err := c.doJSON("GET", "/api/v1/trends?"+q.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("trend request: %w", err)
+	}
+	if status != 200 {
+		return fmt.Errorf("server error %d: %s", status, string(body))
+	}
+
+	var result trendResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("parse response: %w", err)
+	}
+
+	var table strings.Builder
+	renderTrendTable(&table, &result, details)
+	fmt.Print(table.String())
+
+	for _, outPath := range outputFiles {
+		var buf strings.Builder
+		switch formatFromExt(outPath) {
+		case "json":
+			var pretty bytes.Buffer
+			json.Indent(&pretty, body, "", "  ")
+			buf.WriteString(pretty.String())
+			buf.WriteString("\n")
+		case "csv":
+			if err := renderTrendCSV(&buf, &result); err != nil {
+				return fmt.Errorf("render %s: %w", outPath, err)
+			}
+		default:
+			renderTrendTable(&buf, &result, true)
+		}
+		if err := os.WriteFile(outPath, []byte(buf.String()), 0644); err != nil {
+			return fmt.Errorf("write %s: %w", outPath, err)
+		}
+		fmt.Fprintf(os.Stderr, "Saved: %s\n", outPath)
+	}
+	return nil
+}
+
+func (r *trendResult) title() string {
+	var sel []string
+	if r.Tag != "" {
+		sel = append(sel, "tag "+r.Tag)
+	}
+	if r.Baseline != "" {
+		sel = append(sel, fmt.Sprintf("baseline %s (%s, config %s)", r.Baseline, r.BaselineRun, r.ConfigHash))
+	}
+	if len(sel) == 0 {
+		sel = append(sel, "all runs")
+	}
+	return fmt.Sprintf("%s · %d runs", strings.Join(sel, ", "), r.Runs)
+}
+
+// sparkWidth caps a sparkline; longer trends are averaged down to it.
+const sparkWidth = 50
+
+func renderTrendTable(w *strings.Builder, r *trendResult, details bool) {
+	fmt.Fprintf(w, "\nTrend: %s\n\n", r.title())
+	fmt.Fprintf(w, "%-28s %-*s %12s %12s %9s %5s\n", "METRIC", sparkWidth, "OLDEST → NEWEST", "FIRST", "LAST", "CHANGE", "RUNS")
+	fmt.Fprintln(w, strings.Repeat("-", 72+sparkWidth))
+	for _, t := range r.Trends {
+		name := t.Name
+		if name == "" {
+			name = t.Key
+		}
+		if len(t.Points) == 0 {
+			fmt.Fprintf(w, "%-28s %-*s\n", name, sparkWidth, "(no data)")
+			continue
+		}
+		avgs := make([]float64, len(t.Points))
+		for i, p := range t.Points {
+			avgs[i] = p.Avg
+		}
+		first, last := avgs[0], avgs[len(avgs)-1]
+		change := "-"
+		if first != 0 {
+			change = fmt.Sprintf("%+.1f%%", (last-first)/first*100)
+		}
+		fmt.Fprintf(w, "%-28s %-*s %12.2f %12.2f %9s %5d\n",
+			name, sparkWidth, sparkline(avgs, sparkWidth), first, last, change, len(t.Points))
+	}
+
+	if !details {
+		return
+	}
+	for _, t := range r.Trends {
+		if len(t.Points) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s (%s)\n", t.Name, t.Unit)
+		fmt.Fprintf(w, "  %-36s %-20s %-13s %12s %12s %8s %9s\n", "RUN", "TIME", "CONFIG", "AVG", "P95", "CV", "CHANGE")
+		for i, p := range t.Points {
+			cfg := p.ConfigHash
+			if i > 0 && p.ConfigHash != t.Points[i-1].ConfigHash {
+				cfg += "*"
+			}
+			noisy := ""
+			if !p.Stable {
+				noisy = " noisy"
+			}
+			fmt.Fprintf(w, "  %-36s %-20s %-13s %12.2f %12.2f %7.1f%% %+8.1f%%%s\n",
+				p.RunID, p.Time.Local().Format("2006-01-02 15:04"), cfg, p.Avg, p.P95, p.CV*100, p.ChangePct, noisy)
+		}
+	}
+}
+
+// renderTrendCSV writes one row per run and metric.
+func renderTrendCSV(w *strings.Builder, r *trendResult) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"metric", "unit", "run_id", "time", "config_hash", "avg", "p95", "cv", "stable", "change_pct"}); err != nil {
+		return err
+	}
+	for _, t := range r.Trends {
+		for _, p := range t.Points {
+			row := []string{
+				t.Key, t.Unit, p.RunID, p.Time.Format(time.RFC3339), p.ConfigHash,
+				fmt.Sprintf("%.4f", p.Avg), fmt.Sprintf("%.4f", p.P95), fmt.Sprintf("%.4f", p.CV),
+				fmt.Sprint(p.Stable), fmt.Sprintf("%.2f", p.ChangePct),
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var sparkBars = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws values as block characters scaled between their min and
+// max, averaging neighbours when there are more values than width.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for b := range width {
+			lo, hi := b*len(values)/width, (b+1)*len(values)/width
+			var sum float64
+			for _, v := range values[lo:hi] {
+				sum += v
+			}
+			buckets[b] = sum / float64(hi-lo)
+		}
+		values = buckets
+	}
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := len(sparkBars) / 2
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(sparkBars)-1))
+		}
+		b.WriteRune(sparkBars[i])
+	}
+	return b.String()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,12 +17,18 @@ func cloudWaitCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "wait",
 		Short: "Wait for a run to complete",
+		Long: `Wait for a run to complete, then for its gate when it has one.
+
+Exits 1 when the run fails and 2 when it completes but fails its gate.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c, err := newCloudClient()
 			if err != nil {
 				return err
 			}
-			return waitForRun(c, runID, timeout, interval)
+			if err := waitForRun(c, runID, timeout, interval); err != nil {
+				return err
+			}
+			return waitForGate(c, runID, timeout, interval)
 		},
 	}
 	cmd.Flags().StringVar(&runID, "run-id", "", "run ID to wait for")
@@ -99,3 +106,104 @@ func waitForRun(c *cloudHTTPClient, runID string, timeout, interval time.Duratio
 		time.Sleep(interval)
 	}
 }
+
+// gateExitCode is the exit status of a run that finished but failed its
+// gate, so CI can tell a regression from a broken run.
+const gateExitCode = 2
+
+// exitError makes the command exit with code instead of 1.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+type gateOutcome struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	BaselineRun string `json:"baseline_run"`
+	Error       string `json:"error"`
+	Result      *struct {
+		Checks []struct {
+			Key               string   `json:"key"`
+			Name              string   `json:"name"`
+			Baseline          float64  `json:"baseline"`
+			Candidate         float64  `json:"candidate"`
+			DegradationPct    float64  `json:"degradation_pct"`
+			MaxDegradationPct float64  `json:"max_degradation_pct"`
+			PValue            *float64 `json:"p_value"`
+			Passed            bool     `json:"passed"`
+			Reason            string   `json:"reason"`
+		} `json:"checks"`
+	} `json:"result"`
+}
+
+// waitForGate polls a finished run's status until its gate is decided and
+// prints the outcome. Runs without a gate pass; a failed gate returns an
+// exitError with gateExitCode.
+func waitForGate(c *cloudHTTPClient, runID string, timeout, interval time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	path := fmt.Sprintf("/api/v1/run/%s/status", url.PathEscape(runID))
+
+	for {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timeout after %s waiting for the gate of run %s", timeout, runID)
+		}
+
+		data, status, err := c.doJSON("GET", path, nil)
+		if err != nil || status != 200 {
+			log.Printf("[%s] gate check failed: %v (HTTP %d, retrying)", runID, err, status)
+			time.Sleep(interval)
+			continue
+		}
+		var snap struct {
+			Gate *gateOutcome `json:"gate"`
+		}
+		if err := json.Unmarshal(data, &snap); err != nil {
+			log.Printf("[%s] parse error: %v (retrying)", runID, err)
+			time.Sleep(interval)
+			continue
+		}
+		gate := snap.Gate
+		if gate == nil {
+			return nil
+		}
+		if gate.Status == "pending" {
+			fmt.Printf("\r[%s] gate %s: pending  ", runID, gate.Name)
+			time.Sleep(interval)
+			continue
+		}
+
+		fmt.Printf("\rGate %s against %s: %s\n", gate.Name, orUnset(gate.BaselineRun), strings.ToUpper(gate.Status))
+		if gate.Result != nil {
+			for _, ch := range gate.Result.Checks {
+				mark := "ok  "
+				if !ch.Passed {
+					mark = "FAIL"
+				}
+				name := ch.Name
+				if name == "" {
+					name = ch.Key
+				}
+				fmt.Printf("  %s %-28s %12.2f → %-12.2f %+7.1f%% worse (limit %.1f%%)", mark, name, ch.Baseline, ch.Candidate, ch.DegradationPct, ch.MaxDegradationPct)
+				if ch.Reason != "" {
+					fmt.Printf("  %s", ch.Reason)
+				}
+				fmt.Println()
+			}
+		}
+		switch gate.Status {
+		case "passed":
+			return nil
+		case "failed":
+			return &exitError{gateExitCode, fmt.Errorf("run %s failed gate %s", runID, gate.Name)}
+		default:
+			if gate.Error != "" {
+				return fmt.Errorf("gate %s: %s: %s", gate.Name, gate.Status, gate.Error)
+			}
+			return fmt.Errorf("gate %s: %s", gate.Name, gate.Status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/schema"
+)
+
+func schemaCmd() *cobra.Command {
+	var outDir string
+	var list bool
+	cmd := &cobra.Command{
+		Use:   "schema [name]",
+		Short: "Print the JSON Schema of run configs, presets or topologies",
+		Long: `Print a JSON Schema for editor validation and autocompletion.
+
+Schemas: ` + strings.Join(schema.Names(), ", ") + `. Without a name the
+run config schema is printed. --out writes every schema to
+<dir>/<name>.schema.json.
+
+The server serves the same documents at /api/v1/schema/<name>.
+
+Examples:
+  stroppy-cloud schema > run-config.schema.json
+  stroppy-cloud schema preset
+  stroppy-cloud schema --out .schemas
+
+  # VS Code / yaml-language-server, first line of a YAML config:
+  # yaml-language-server: $schema=./.schemas/run-config.schema.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if list {
+				for _, name := range schema.Names() {
+					fmt.Println(name)
+				}
+				return nil
+			}
+			if outDir != "" {
+				if len(args) > 0 {
+					return fmt.Errorf("--out writes every schema; drop the name")
+				}
+				if err := os.MkdirAll(outDir, 0o755); err != nil {
+					return err
+				}
+				for _, name := range schema.Names() {
+					data, err := marshalSchema(name)
+					if err != nil {
+						return err
+					}
+					path := filepath.Join(outDir, name+".schema.json")
+					if err := os.WriteFile(path, data, 0o644); err != nil {
+						return err
+					}
+					fmt.Println("wrote", path)
+				}
+				return nil
+			}
+			name := schema.RunConfig
+			if len(args) > 0 {
+				name = args[0]
+			}
+			data, err := marshalSchema(name)
+			if err != nil {
+				return err
+			}
+			fmt.Print(string(data))
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&outDir, "out", "o", "", "write every schema to this directory")
+	cmd.Flags().BoolVar(&list, "list", false, "list schema names")
+	return cmd
+}
+
+func marshalSchema(name string) ([]byte, error) {
+	doc, ok := schema.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema %q (available: %s)", name, strings.Join(schema.Names(), ", "))
+	}
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -157,6 +157,61 @@ func TestExecutorHappyPath(t *testing.T) {
 	}
 }
 
+func TestExecutorRecordsNodeTimings(t *testing.T) {
+	g := New()
+	must(t, g.Add(&Node{ID: "a", Type: "x", Task: noopTask{}}))
+	must(t, g.Add(&Node{ID: "b", Type: "x", Task: noopTask{}, Deps: []string{"a"}}))
+
+	store := &memStorage{}
+	must(t, NewExecutor("", "test", g, store, nil, nil).Run(context.Background()))
+
+	snap, _ := store.Load(context.Background(), "", "test")
+	a, _ := snap.Node("a")
+	b, _ := snap.Node("b")
+	for _, ns := range []NodeStatus{a, b} {
+		if ns.StartedAt == nil || ns.FinishedAt == nil {
+			t.Fatalf("node %s has no timings: %+v", ns.ID, ns)
+		}
+		if ns.FinishedAt.Before(*ns.StartedAt) {
+			t.Errorf("node %s finished before it started", ns.ID)
+		}
+	}
+	if b.StartedAt.Before(*a.FinishedAt) {
+		t.Error("b started before its dependency finished")
+	}
+	if _, ok := snap.Node("missing"); ok {
+		t.Error("Node found a node that is not in the graph")
+	}
+}
+
+func TestExecutorNodeDoneHook(t *testing.T) {
+	boom := errors.New("boom")
+
+	g := New()
+	must(t, g.Add(&Node{ID: "a", Type: "x", Task: noopTask{}}))
+	must(t, g.Add(&Node{ID: "b", Type: "x", Task: noopTask{}, Deps: []string{"a"}}))
+	must(t, g.Add(&Node{ID: "c", Type: "x", Task: &failTask{boom}, Deps: []string{"b"}}))
+
+	store := &memStorage{}
+	exec := NewExecutor("", "test", g, store, nil, nil)
+	exec.SetRetryPolicy(RetryPolicy{})
+	var done []string
+	exec.SetNodeDoneHook(func(ctx context.Context, id string) {
+		// The snapshot already records the node as done.
+		snap, _ := store.Load(ctx, "", "test")
+		if ns, _ := snap.Node(id); ns.Status != StatusDone || ns.FinishedAt == nil {
+			t.Errorf("hook for %s ran before the snapshot was saved: %+v", id, ns)
+		}
+		done = append(done, id)
+	})
+	if err := exec.Run(context.Background()); !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got: %v", err)
+	}
+	if len(done) != 2 || done[0] != "a" || done[1] != "b" {
+		t.Fatalf("hook ran for %v, want [a b]", done)
+	}
+}
+
 func TestExecutorFailFast(t *testing.T) {
 	boom := errors.New("boom")
 
@@ -48,15 +48,22 @@ type Executor struct {
 	// Node state machine: pending → running → done/failed/cancelled.
 	nodeStatus map[string]Status
 	nodeErrors map[string]string // id → error message
-	errs       []error           // original errors for return
-	cancelled  bool              // true when cancel was requested
-	mu         sync.Mutex
+	// nodeStarted/nodeFinished record when each node last started and
+	// stopped running, for the per-node timings in snapshots.
+	nodeStarted  map[string]time.Time
+	nodeFinished map[string]time.Time
+	errs         []error // original errors for return
+	cancelled    bool    // true when cancel was requested
+	mu           sync.Mutex
 
 	startedAt time.Time
 
 	// stateExporter is called before each snapshot save to capture
 	// the current run state (targets, container IDs, etc.) for recovery.
 	stateExporter func() *RunState
+
+	// nodeDone is called after a node completes and its snapshot is saved.
+	nodeDone func(ctx context.Context, id string)
 }
 
 // NewExecutor creates an executor for the given graph.
@@ -74,6 +81,9 @@ func NewExecutor(tenantID, id string, g *Graph, storage Storage, log *zap.Logger
 		retry:      DefaultRetryPolicy(),
 		nodeStatus: make(map[string]Status, len(g.Nodes)),
 		nodeErrors: make(map[string]string),
+
+		nodeStarted:  make(map[string]time.Time),
+		nodeFinished: make(map[string]time.Time),
 	}
 }
 
@@ -88,6 +98,13 @@ func (e *Executor) SetStateExporter(fn func() *RunState) {
 	e.stateExporter = fn
 }
 
+// SetNodeDoneHook registers a callback run after each node completes
+// successfully, once the snapshot recording it is saved. It runs on the
+// node's goroutine, so slow work belongs in a goroutine of its own.
+func (e *Executor) SetNodeDoneHook(fn func(ctx context.Context, id string)) {
+	e.nodeDone = fn
+}
+
 // MarkNodeDone marks a node as completed without executing it.
 // Used during recovery to restore previously completed nodes.
 func (e *Executor) MarkNodeDone(id string) {
@@ -96,6 +113,20 @@ func (e *Executor) MarkNodeDone(id string) {
 	e.nodeStatus[id] = StatusDone
 }
 
+// RestoreNode marks a node completed in an earlier execution, keeping the
+// timings recorded for it. Used during recovery.
+func (e *Executor) RestoreNode(ns NodeStatus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.nodeStatus[ns.ID] = StatusDone
+	if ns.StartedAt != nil {
+		e.nodeStarted[ns.ID] = *ns.StartedAt
+	}
+	if ns.FinishedAt != nil {
+		e.nodeFinished[ns.ID] = *ns.FinishedAt
+	}
+}
+
 // Run executes the graph to completion using a proper state machine.
 //
 // Node FSM: pending → running → done | failed | cancelled
@@ -135,7 +166,7 @@ func (e *Executor) Run(ctx context.Context) error {
 		// Mark nodes as running.
 		e.mu.Lock()
 		for _, n := range ready {
-			e.nodeStatus[n.ID] = StatusRunning
+			e.markRunning(n.ID)
 		}
 		e.mu.Unlock()
 		_ = e.save(context.Background())
@@ -212,7 +243,7 @@ func (e *Executor) runAlwaysRunNodes(ctx context.Context) {
 
 		e.mu.Lock()
 		for _, n := range ready {
-			e.nodeStatus[n.ID] = StatusRunning
+			e.markRunning(n.ID)
 		}
 		e.mu.Unlock()
 
@@ -253,6 +284,7 @@ func (e *Executor) executeWithRetry(ctx context.Context, n *Node) {
 				e.mu.Lock()
 				e.nodeStatus[n.ID] = StatusCancelled
 				e.nodeErrors[n.ID] = "cancelled"
+				e.nodeFinished[n.ID] = time.Now()
 				e.mu.Unlock()
 				return
 			case <-time.After(delay):
@@ -301,11 +333,22 @@ func (e *Executor) getReady() []*Node {
 	return e.graph.Ready(doneCopy)
 }
 
+// markRunning must be called with e.mu held.
+func (e *Executor) markRunning(id string) {
+	e.nodeStatus[id] = StatusRunning
+	e.nodeStarted[id] = time.Now()
+	delete(e.nodeFinished, id)
+}
+
 func (e *Executor) markDone(ctx context.Context, id string) {
 	e.mu.Lock()
 	e.nodeStatus[id] = StatusDone
+	e.nodeFinished[id] = time.Now()
 	e.mu.Unlock()
 	_ = e.save(ctx)
+	if e.nodeDone != nil {
+		e.nodeDone(ctx, id)
+	}
 }
 
 func (e *Executor) markFailed(id string, err error) {
@@ -313,6 +356,7 @@ func (e *Executor) markFailed(id string, err error) {
 	defer e.mu.Unlock()
 	e.nodeStatus[id] = StatusFailed
 	e.nodeErrors[id] = err.Error()
+	e.nodeFinished[id] = time.Now()
 	e.errs = append(e.errs, fmt.Errorf("node %q: %w", id, err))
 }
 
@@ -353,6 +397,12 @@ func (e *Executor) snapshot() *Snapshot {
 		if msg := e.nodeErrors[n.ID]; msg != "" {
 			ns.Error = msg
 		}
+		if t, ok := e.nodeStarted[n.ID]; ok {
+			ns.StartedAt = &t
+		}
+		if t, ok := e.nodeFinished[n.ID]; ok {
+			ns.FinishedAt = &t
+		}
 		nodes = append(nodes, ns)
 	}
 
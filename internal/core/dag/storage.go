@@ -11,6 +11,10 @@ type NodeStatus struct {
 	ID     string `json:"id"`
 	Status Status `json:"status"`
 	Error  string `json:"error,omitempty"`
+	// StartedAt and FinishedAt bound the node's last execution, retries
+	// included. Unset for nodes that never ran.
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
 
 // Status is the execution state of a node.
@@ -36,6 +40,19 @@ type RunState struct {
 	// EffectiveConfigs stores the actual resolved config per component (e.g. "database", "monitoring").
 	// Set by config tasks at execution time so the UI shows real values, not derived estimates.
 	EffectiveConfigs map[string]map[string]string `json:"effective_configs,omitempty"`
+	// DBSettings holds the settings each database node reported running
+	// with after configuration, keyed by target ID.
+	DBSettings map[string]DBSettings `json:"db_settings,omitempty"`
+}
+
+// DBSettings is the live configuration read back from one database node.
+type DBSettings struct {
+	Role string `json:"role"` // e.g. "master", "replica", "instance", "node"
+	// Sources are the rendered config keys the node was configured from,
+	// e.g. "postgresql.conf:master"; the settings diff compares against them.
+	Sources []string          `json:"sources,omitempty"`
+	Values  map[string]string `json:"values,omitempty"`
+	Error   string            `json:"error,omitempty"` // capture failed; Values is empty
 }
 
 // TargetInfo is a serializable representation of an agent target.
@@ -56,6 +73,16 @@ type Snapshot struct {
 	FinishedAt time.Time    `json:"finished_at,omitempty"`
 }
 
+// Node returns the status of node id.
+func (s *Snapshot) Node(id string) (NodeStatus, bool) {
+	for _, ns := range s.Nodes {
+		if ns.ID == id {
+			return ns, true
+		}
+	}
+	return NodeStatus{}, false
+}
+
 // RunSummary is a compact view of a saved snapshot for listing.
 type RunSummary struct {
 	ID         string       `json:"id"`
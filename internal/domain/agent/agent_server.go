@@ -12,14 +12,21 @@ import (
 	"time"
 )
 
+// DefaultMaxParallel is how many commands an agent runs at once when
+// NewAgentServer is given no explicit limit.
+const DefaultMaxParallel = 4
+
 // AgentServer polls the orchestration server for commands and executes them.
 // It does NOT listen for inbound connections — all communication is agent→server.
+// Up to maxParallel commands run concurrently; the executor serialises those
+// that declare the same lock.
 type AgentServer struct {
 	executor   *Executor
 	serverAddr string
 	machineID  string
 	token      string // JWT token for authenticating with the server
 	httpClient *http.Client
+	slots      chan struct{} // one token per concurrently running command
 
 	// Log dedup + batching.
 	logMu      sync.Mutex
@@ -32,13 +39,18 @@ type AgentServer struct {
 	logDone    chan struct{}
 }
 
-// NewAgentServer creates a new polling-based agent.
-func NewAgentServer(serverAddr string, machineID string, _ int) *AgentServer {
+// NewAgentServer creates a new polling-based agent that runs at most
+// maxParallel commands at once (DefaultMaxParallel when <= 0).
+func NewAgentServer(serverAddr string, machineID string, maxParallel int) *AgentServer {
+	if maxParallel <= 0 {
+		maxParallel = DefaultMaxParallel
+	}
 	s := &AgentServer{
 		executor:   NewExecutor(),
 		serverAddr: serverAddr,
 		machineID:  machineID,
 		httpClient: &http.Client{Timeout: 90 * time.Second},
+		slots:      make(chan struct{}, maxParallel),
 		logTicker:  time.NewTicker(200 * time.Millisecond),
 		logDone:    make(chan struct{}),
 	}
@@ -154,19 +166,26 @@ func (s *AgentServer) setAuth(req *http.Request) {
 	}
 }
 
-// Run starts the poll loop. Blocks until ctx is cancelled.
+// Run starts the poll loop. Blocks until ctx is cancelled and all
+// in-flight commands have finished.
 func (s *AgentServer) Run(ctx context.Context) error {
-	log.Printf("agent: starting poll loop (server=%s, machine=%s)", s.serverAddr, s.machineID)
+	log.Printf("agent: starting poll loop (server=%s, machine=%s, parallel=%d)", s.serverAddr, s.machineID, cap(s.slots))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
 
 	for {
+		// Only take a command from the server when there is a free slot to
+		// run it — otherwise it stays queued server-side.
 		select {
 		case <-ctx.Done():
 			return nil
-		default:
+		case s.slots <- struct{}{}:
 		}
 
 		cmd, err := s.poll(ctx)
 		if err != nil {
+			<-s.slots
 			log.Printf("agent: poll error: %v (retrying in 2s)", err)
 			select {
 			case <-ctx.Done():
@@ -178,16 +197,23 @@ func (s *AgentServer) Run(ctx context.Context) error {
 
 		if cmd == nil {
 			// No pending command — long-poll returned empty. Loop immediately.
+			<-s.slots
 			continue
 		}
 
-		// Execute command and report back.
-		report := s.executor.Run(ctx, *cmd)
-		report.CommandID = cmd.ID
+		// Execute command in the background and report back.
+		wg.Add(1)
+		go func(cmd Command) {
+			defer wg.Done()
+			defer func() { <-s.slots }()
 
-		if err := s.sendReport(ctx, report); err != nil {
-			log.Printf("agent: failed to send report: %v", err)
-		}
+			report := s.executor.Run(ctx, cmd)
+			report.CommandID = cmd.ID
+
+			if err := s.sendReport(ctx, report); err != nil {
+				log.Printf("agent: failed to send report for %s: %v", cmd.ID, err)
+			}
+		}(*cmd)
 	}
 }
 
@@ -8,6 +8,9 @@ import (
 type Client interface {
 	// Send dispatches a command to a single agent and waits for completion.
 	Send(nc *dag.NodeContext, target Target, cmd Command) error
+	// Call is like Send but returns the agent's report, for commands whose
+	// Output carries data back to the server (e.g. effective OS settings).
+	Call(nc *dag.NodeContext, target Target, cmd Command) (Report, error)
 	// SendAll dispatches the same command to all targets in parallel, fails on first error.
 	SendAll(nc *dag.NodeContext, targets []Target, cmd Command) error
 }
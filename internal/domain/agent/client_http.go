@@ -36,6 +36,12 @@ func NewHTTPClient() *HTTPClient {
 // Send dispatches a command to a single agent and waits for the report.
 // It first waits for the agent to become healthy, then POSTs the command.
 func (c *HTTPClient) Send(nc *dag.NodeContext, target Target, cmd Command) error {
+	_, err := c.Call(nc, target, cmd)
+	return err
+}
+
+// Call dispatches a command to a single agent and returns its report.
+func (c *HTTPClient) Call(nc *dag.NodeContext, target Target, cmd Command) (Report, error) {
 	log := nc.Log().With(
 		zap.String("target", target.ID),
 		zap.String("action", string(cmd.Action)),
@@ -43,52 +49,53 @@ func (c *HTTPClient) Send(nc *dag.NodeContext, target Target, cmd Command) error
 
 	log.Info("waiting for agent to become healthy", zap.String("addr", target.Addr()))
 	if err := c.waitForAgent(nc, target); err != nil {
-		return fmt.Errorf("agent %s not healthy: %w", target.ID, err)
+		return Report{}, fmt.Errorf("agent %s not healthy: %w", target.ID, err)
 	}
 	log.Info("agent is healthy, sending command")
 
+	cmd.Locks = cmd.RequiredLocks()
 	body, err := json.Marshal(cmd)
 	if err != nil {
-		return fmt.Errorf("agent: marshal command: %w", err)
+		return Report{}, fmt.Errorf("agent: marshal command: %w", err)
 	}
 
 	url := target.Addr() + "/execute"
 	req, err := http.NewRequestWithContext(nc, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
-		return fmt.Errorf("agent: create request: %w", err)
+		return Report{}, fmt.Errorf("agent: create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("agent: POST %s: %w", url, err)
+		return Report{}, fmt.Errorf("agent: POST %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("agent: read response from %s: %w", target.ID, err)
+		return Report{}, fmt.Errorf("agent: read response from %s: %w", target.ID, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("agent %s returned HTTP %d: %s", target.ID, resp.StatusCode, string(respBody))
+		return Report{}, fmt.Errorf("agent %s returned HTTP %d: %s", target.ID, resp.StatusCode, string(respBody))
 	}
 
 	var report Report
 	if err := json.Unmarshal(respBody, &report); err != nil {
-		return fmt.Errorf("agent: decode report from %s: %w", target.ID, err)
+		return Report{}, fmt.Errorf("agent: decode report from %s: %w", target.ID, err)
 	}
 
 	if report.Status == ReportFailed {
 		log.Error("command failed on agent", zap.String("error", report.Error))
-		return fmt.Errorf("agent %s: command %s failed: %s", target.ID, cmd.ID, report.Error)
+		return report, fmt.Errorf("agent %s: command %s failed: %s", target.ID, cmd.ID, report.Error)
 	}
 
 	log.Info("command completed",
 		zap.String("status", string(report.Status)),
 		zap.String("output", report.Output),
 	)
-	return nil
+	return report, nil
 }
 
 // SendAll dispatches the same command to all targets in parallel.
@@ -18,6 +18,10 @@ func (NoopClient) Send(nc *dag.NodeContext, target Target, cmd Command) error {
 	return nil
 }
 
+func (c NoopClient) Call(nc *dag.NodeContext, target Target, cmd Command) (Report, error) {
+	return Report{CommandID: cmd.ID, Status: ReportCompleted}, c.Send(nc, target, cmd)
+}
+
 func (NoopClient) SendAll(nc *dag.NodeContext, targets []Target, cmd Command) error {
 	for _, t := range targets {
 		nc.Log().Info("noop: would send command",
@@ -15,9 +15,11 @@ import (
 // PollClient implements the Client interface using a server-side command queue.
 // Instead of pushing commands to agents, it places commands in a per-machine
 // queue. Agents poll the server for pending commands via /api/agent/poll.
+// Queues are unbounded so several phases can be dispatched to one machine at
+// once; the agent decides how many to run concurrently based on their locks.
 type PollClient struct {
 	mu      sync.Mutex
-	queues  map[string]chan Command  // machineID → pending command
+	queues  map[string]*commandQueue // machineID → pending commands
 	results map[string]chan Report   // commandID → report channel
 	healthy map[string]chan struct{} // machineID → closed when agent first polls
 	logger  *zap.Logger
@@ -27,7 +29,7 @@ type PollClient struct {
 // NewPollClient creates a poll-based client.
 func NewPollClient(logger *zap.Logger) *PollClient {
 	return &PollClient{
-		queues:  make(map[string]chan Command),
+		queues:  make(map[string]*commandQueue),
 		results: make(map[string]chan Report),
 		healthy: make(map[string]chan struct{}),
 		logger:  logger,
@@ -36,6 +38,12 @@ func NewPollClient(logger *zap.Logger) *PollClient {
 
 // Send enqueues a command for the target agent and waits for the report.
 func (c *PollClient) Send(nc *dag.NodeContext, target Target, cmd Command) error {
+	_, err := c.Call(nc, target, cmd)
+	return err
+}
+
+// Call enqueues a command for the target agent and returns its report.
+func (c *PollClient) Call(nc *dag.NodeContext, target Target, cmd Command) (Report, error) {
 	log := nc.Log().With(
 		zap.String("target", target.ID),
 		zap.String("action", string(cmd.Action)),
@@ -45,11 +53,13 @@ func (c *PollClient) Send(nc *dag.NodeContext, target Target, cmd Command) error
 	if cmd.ID == "" {
 		cmd.ID = fmt.Sprintf("cmd-%s-%d", target.ID, c.cmdSeq.Add(1))
 	}
+	// Declare locks on the wire so the agent knows what the command needs.
+	cmd.Locks = cmd.RequiredLocks()
 
 	// Wait for agent to start polling (i.e. become healthy).
 	log.Info("waiting for agent to start polling")
 	if err := c.waitForAgent(nc, target.ID); err != nil {
-		return fmt.Errorf("agent %s did not connect: %w", target.ID, err)
+		return Report{}, fmt.Errorf("agent %s did not connect: %w", target.ID, err)
 	}
 	log.Info("agent connected, sending command")
 
@@ -67,25 +77,23 @@ func (c *PollClient) Send(nc *dag.NodeContext, target Target, cmd Command) error
 
 	// Enqueue command — the agent's next poll will pick it up.
 	q := c.getQueue(target.ID)
-	select {
-	case q <- cmd:
-	case <-nc.Done():
-		return nc.Err()
-	}
+	q.push(cmd)
 
 	// Wait for the report from the agent.
 	select {
 	case report := <-resultCh:
 		if report.Status == ReportFailed {
 			log.Error("command failed on agent", zap.String("error", report.Error))
-			return fmt.Errorf("agent %s: command %s failed: %s", target.ID, cmd.ID, report.Error)
+			return report, fmt.Errorf("agent %s: command %s failed: %s", target.ID, cmd.ID, report.Error)
 		}
 		log.Info("command completed",
 			zap.String("status", string(report.Status)),
 		)
-		return nil
+		return report, nil
 	case <-nc.Done():
-		return nc.Err()
+		// Drop the command if the agent has not picked it up yet.
+		q.remove(cmd.ID)
+		return Report{}, nc.Err()
 	}
 }
 
@@ -142,13 +150,7 @@ func (c *PollClient) Poll(machineID string, timeout time.Duration) *Command {
 	}
 	c.mu.Unlock()
 
-	q := c.getQueue(machineID)
-	select {
-	case cmd := <-q:
-		return &cmd
-	case <-time.After(timeout):
-		return nil
-	}
+	return c.getQueue(machineID).pop(timeout)
 }
 
 // DeliverReport routes a report from an agent to the waiting Send() call.
@@ -174,17 +176,72 @@ func (c *PollClient) MarkAgentReady(machineID string) {
 	}
 }
 
-func (c *PollClient) getQueue(machineID string) chan Command {
+func (c *PollClient) getQueue(machineID string) *commandQueue {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	q, ok := c.queues[machineID]
 	if !ok {
-		q = make(chan Command, 8)
+		q = newCommandQueue()
 		c.queues[machineID] = q
 	}
 	return q
 }
 
+// commandQueue is an unbounded FIFO of commands waiting for one agent.
+type commandQueue struct {
+	mu      sync.Mutex
+	pending []Command
+	notify  chan struct{} // signalled (non-blocking) on every push
+}
+
+func newCommandQueue() *commandQueue {
+	return &commandQueue{notify: make(chan struct{}, 1)}
+}
+
+func (q *commandQueue) push(cmd Command) {
+	q.mu.Lock()
+	q.pending = append(q.pending, cmd)
+	q.mu.Unlock()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the oldest pending command, waiting up to timeout for one.
+func (q *commandQueue) pop(timeout time.Duration) *Command {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	for {
+		q.mu.Lock()
+		if len(q.pending) > 0 {
+			cmd := q.pending[0]
+			q.pending = q.pending[1:]
+			q.mu.Unlock()
+			return &cmd
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.notify:
+		case <-deadline.C:
+			return nil
+		}
+	}
+}
+
+// remove drops a not-yet-delivered command by ID.
+func (q *commandQueue) remove(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, cmd := range q.pending {
+		if cmd.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return
+		}
+	}
+}
+
 func (c *PollClient) waitForAgent(ctx context.Context, machineID string) error {
 	c.mu.Lock()
 	ch, ok := c.healthy[machineID]
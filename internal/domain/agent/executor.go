@@ -9,6 +9,7 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"runtime"
 	"strings"
 	"sync"
 	"syscall"
@@ -25,15 +26,17 @@ type LogCallback func(commandID, action, line, stream string)
 // Executor runs agent commands on the local machine.
 // Long-running processes (DB servers, exporters, vmagent) are tracked in a
 // pool and killed on Shutdown(). Their stdout/stderr are streamed via logCallback.
+//
+// Commands run concurrently; each one holds the named locks it declares
+// (see Command.RequiredLocks) for its whole duration, so only commands that
+// touch the same host resource serialise.
 type Executor struct {
-	aptMu        sync.Mutex
+	locks        lockSet
 	bootstrapMu  sync.Once
 	bootstrapErr error
 
-	logMu         sync.RWMutex
-	logCallback   LogCallback
-	currentCmd    string
-	currentAction string
+	logMu       sync.RWMutex
+	logCallback LogCallback
 
 	// Process pool — tracked background processes killed on shutdown.
 	procMu sync.Mutex
@@ -66,8 +69,18 @@ func (e *Executor) Shutdown() {
 
 // startDaemon launches a long-running process, tracks it in the pool,
 // and streams its output through logCallback. Returns immediately.
-func (e *Executor) startDaemon(name string, binPath string, args ...string) error {
+// Output lines stay attributed to the command carried by ctx.
+func (e *Executor) startDaemon(ctx context.Context, name string, binPath string, args ...string) error {
+	return e.startDaemonEnv(ctx, name, nil, binPath, args...)
+}
+
+// startDaemonEnv is startDaemon with env added to the process environment
+// only, leaving the agent's own environment untouched.
+func (e *Executor) startDaemonEnv(ctx context.Context, name string, env []string, binPath string, args ...string) error {
 	cmd := exec.Command(binPath, args...)
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	pr, pw := io.Pipe()
 	cmd.Stdout = pw
@@ -84,7 +97,7 @@ func (e *Executor) startDaemon(name string, binPath string, args ...string) erro
 		scanner := bufio.NewScanner(pr)
 		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
 		for scanner.Scan() {
-			e.emitLine(scanner.Text())
+			e.emitLine(ctx, scanner.Text())
 		}
 	}()
 
@@ -114,40 +127,32 @@ func (e *Executor) SetLogCallback(cb LogCallback) {
 	e.logCallback = cb
 }
 
-// setCurrentCommand stores the currently executing command ID and action for log correlation.
-func (e *Executor) setCurrentCommand(id string, action Action) {
-	e.logMu.Lock()
-	defer e.logMu.Unlock()
-	e.currentCmd = id
-	e.currentAction = string(action)
-}
+type commandKey struct{}
 
-// emitLine sends a single output line to the registered callback (if any).
-func (e *Executor) emitLine(line string) {
-	// Always print to stderr so `docker logs` captures everything.
-	fmt.Fprintln(os.Stderr, line)
+// commandInfo identifies the command a log line belongs to.
+type commandInfo struct {
+	id     string
+	action Action
+}
 
-	e.logMu.RLock()
-	cb := e.logCallback
-	cmdID := e.currentCmd
-	action := e.currentAction
-	e.logMu.RUnlock()
-	if cb != nil {
-		cb(cmdID, action, line, "stdout")
-	}
+// withCommand stores the executing command ID and action on ctx so log lines
+// stay correlated while several commands run at once.
+func withCommand(ctx context.Context, id string, action Action) context.Context {
+	return context.WithValue(ctx, commandKey{}, commandInfo{id: id, action: action})
 }
 
-// emitLineWithAction sends a line with a fixed action (for long-lived daemons whose
-// parent command has already completed and reset currentAction).
-func (e *Executor) emitLineWithAction(action, line string) {
+// emitLine sends a single output line to the registered callback (if any),
+// tagged with the command carried by ctx.
+func (e *Executor) emitLine(ctx context.Context, line string) {
+	// Always print to stderr so `docker logs` captures everything.
 	fmt.Fprintln(os.Stderr, line)
 
 	e.logMu.RLock()
 	cb := e.logCallback
-	cmdID := e.currentCmd
 	e.logMu.RUnlock()
 	if cb != nil {
-		cb(cmdID, action, line, "stdout")
+		ci, _ := ctx.Value(commandKey{}).(commandInfo)
+		cb(ci.id, string(ci.action), line, "stdout")
 	}
 }
 
@@ -170,10 +175,14 @@ func (e *Executor) bootstrap(ctx context.Context) error {
 	return e.bootstrapErr
 }
 
-// shellWithAptLock runs a shell command while holding the apt mutex.
+// shellWithAptLock runs a shell command while holding the apt lock.
+// Commands that declared LockApt already hold it for their whole run.
 func (e *Executor) shellWithAptLock(ctx context.Context, script string) (string, error) {
-	e.aptMu.Lock()
-	defer e.aptMu.Unlock()
+	release, err := e.locks.acquire(ctx, []Lock{LockApt})
+	if err != nil {
+		return "", err
+	}
+	defer release()
 	return e.shell(ctx, script)
 }
 
@@ -198,6 +207,7 @@ func (e *Executor) aptPreInstall(ctx context.Context, cmds []string) error {
 // 2. Pre-install commands (shell)
 // 3. .deb file download + dpkg -i (if deb_url set)
 // 4. apt-get install (if apt_packages set)
+// 5. tarball download + unpack under its prefix (if tarball set)
 func (e *Executor) installPackage(ctx context.Context, pkg types.Package) error {
 	// 1. Add custom repo if specified
 	if pkg.CustomRepo != "" {
@@ -222,8 +232,9 @@ func (e *Executor) installPackage(ctx context.Context, pkg types.Package) error
 		}
 	}
 
-	// 3. .deb file (downloaded via URL injected by server at run start)
-	if pkg.DebFilename != "" {
+	// 3. .deb file (downloaded via URL injected by server at run start).
+	// Tarball packages reuse the upload slot for their archive.
+	if pkg.DebFilename != "" && pkg.Tarball == nil {
 		debURL := pkg.DebFilename // server has replaced filename with download URL
 		debPath := "/tmp/custom_package.deb"
 		// Build curl command with auth header if token is provided.
@@ -231,8 +242,12 @@ func (e *Executor) installPackage(ctx context.Context, pkg types.Package) error
 		if pkg.DebToken != "" {
 			curlAuth = fmt.Sprintf(` -H "Authorization: Bearer %s"`, pkg.DebToken)
 		}
+		verify := ""
+		if pkg.DebSHA256 != "" {
+			verify = fmt.Sprintf(` && echo "%s  %s" | sha256sum -c --quiet -`, pkg.DebSHA256, debPath)
+		}
 		// Use apt-get install (not dpkg -i) so dependencies are resolved automatically.
-		script := fmt.Sprintf(`curl -fsSL%s "%s" -o %s && DEBIAN_FRONTEND=noninteractive apt-get install -y %s`, curlAuth, debURL, debPath, debPath)
+		script := fmt.Sprintf(`curl -fsSL%s "%s" -o %s%s && DEBIAN_FRONTEND=noninteractive apt-get install -y %s`, curlAuth, debURL, debPath, verify, debPath)
 		if _, err := e.shellWithAptLock(ctx, script); err != nil {
 			return fmt.Errorf("install deb %s: %w", debURL, err)
 		}
@@ -245,24 +260,62 @@ func (e *Executor) installPackage(ctx context.Context, pkg types.Package) error
 		}
 	}
 
+	// 5. Tarball of prebuilt binaries
+	if pkg.Tarball != nil {
+		if err := e.installTarball(ctx, pkg); err != nil {
+			return fmt.Errorf("install tarball: %w", err)
+		}
+	}
+
 	return nil
 }
 
+// installTarball installs the runtime dependencies of a tarball package,
+// then unpacks it (see renderTarballScript).
+func (e *Executor) installTarball(ctx context.Context, pkg types.Package) error {
+	t := pkg.Tarball.WithDefaults(pkg.DbKind, pkg.DbVersion)
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	script, err := renderTarballScript(pkg, t)
+	if err != nil {
+		return err
+	}
+	if len(t.Depends) > 0 {
+		if err := e.aptInstall(ctx, strings.Join(t.Depends, " ")); err != nil {
+			return fmt.Errorf("depends: %w", err)
+		}
+	}
+	e.emitLine(ctx, fmt.Sprintf("installing tarball into %s", t.Prefix))
+	_, err = e.shell(ctx, script)
+	return err
+}
+
 // Run executes a Command and returns a Report.
 func (e *Executor) Run(ctx context.Context, cmd Command) Report {
 	report := Report{CommandID: cmd.ID, Status: ReportCompleted}
 
-	// Store command ID and action so streamed log lines are correlated.
-	e.setCurrentCommand(cmd.ID, cmd.Action)
-	defer e.setCurrentCommand("", "")
+	// Carry command ID and action so streamed log lines are correlated.
+	ctx = withCommand(ctx, cmd.ID, cmd.Action)
 
+	// Bootstrap before taking command locks: it needs the apt lock itself,
+	// and a concurrent command blocked on the sync.Once may be holding it.
 	if err := e.bootstrap(ctx); err != nil {
 		report.Status = ReportFailed
 		report.Error = err.Error()
 		return report
 	}
 
-	var err error
+	locks := cmd.RequiredLocks()
+	release, err := e.locks.acquire(ctx, locks)
+	if err != nil {
+		report.Status = ReportFailed
+		report.Error = fmt.Sprintf("acquire locks %v: %v", locks, err)
+		return report
+	}
+	defer release()
+	ctx = withHeldLocks(ctx, locks)
+
 	switch cmd.Action {
 	case ActionInstallPostgres:
 		err = e.installPostgres(ctx, cmd)
@@ -312,6 +365,14 @@ func (e *Executor) Run(ctx context.Context, cmd Command) Report {
 		err = e.initYDB(ctx, cmd)
 	case ActionStartYDBDB:
 		err = e.startYDBDB(ctx, cmd)
+	case ActionApplyTuning:
+		report.Output, err = e.applyTuning(ctx, cmd)
+	case ActionCaptureSettings:
+		report.Output, err = e.captureSettings(ctx, cmd)
+	case ActionResolveGitRef:
+		report.Output, err = e.resolveGitRef(ctx, cmd)
+	case ActionBuildPackage:
+		err = e.buildPackage(ctx, cmd)
 	default:
 		err = fmt.Errorf("unknown action: %s", cmd.Action)
 	}
@@ -343,7 +404,7 @@ func (e *Executor) shell(ctx context.Context, script string) (string, error) {
 		for scanner.Scan() {
 			line := scanner.Text()
 			fullOutput.WriteString(line + "\n")
-			e.emitLine(line)
+			e.emitLine(ctx, line)
 		}
 	}()
 
@@ -387,6 +448,15 @@ func resolveMemoryDefaults(m map[string]string) {
 	}
 }
 
+// cpusOrHost returns cpus, or the number of CPUs visible to the agent when
+// the run side did not pass one.
+func cpusOrHost(cpus int) int {
+	if cpus > 0 {
+		return cpus
+	}
+	return runtime.NumCPU()
+}
+
 // getTotalMemoryMB reads total system memory from /proc/meminfo.
 // Falls back to 1024 MB if unable to read.
 func getTotalMemoryMB() int {
@@ -464,6 +534,9 @@ func (e *Executor) configPostgres(ctx context.Context, cmd Command) error {
 			Options:       cfg.Options,
 			Patroni:       cfg.Patroni,
 			TotalMemoryMB: memMB,
+			AutoTune:      cfg.AutoTune,
+			CPUs:          cpusOrHost(cfg.CPUs),
+			DiskType:      cfg.DiskType,
 		})
 	}
 
@@ -572,6 +645,9 @@ func (e *Executor) configMySQL(ctx context.Context, cmd Command) error {
 			GroupRepl:     cfg.GroupRepl,
 			Options:       cfg.Options,
 			TotalMemoryMB: memMB,
+			AutoTune:      cfg.AutoTune,
+			CPUs:          cpusOrHost(cfg.CPUs),
+			DiskType:      cfg.DiskType,
 		})
 	}
 	body = dbconfig.SubstituteMySQLPlaceholders(body, cfg.NodeIndex, localHost)
@@ -715,13 +791,15 @@ func (e *Executor) configPicodata(ctx context.Context, cmd Command) error {
 		firstPeer = firstPeer + ":3301"
 	}
 
+	picoOpts := dbconfig.RenderPicodataConfOpts{
+		Replication:   cfg.Replication,
+		Options:       cfg.Options,
+		TotalMemoryMB: memMB,
+		AutoTune:      cfg.AutoTune,
+	}
 	body := cfg.ConfOverride
 	if body == "" {
-		body = dbconfig.RenderPicodataConf(dbconfig.RenderPicodataConfOpts{
-			Replication:   cfg.Replication,
-			Options:       cfg.Options,
-			TotalMemoryMB: memMB,
-		})
+		body = dbconfig.RenderPicodataConf(picoOpts)
 	}
 	body = dbconfig.SubstitutePicodataPlaceholders(body, hostname)
 
@@ -729,12 +807,7 @@ func (e *Executor) configPicodata(ctx context.Context, cmd Command) error {
 	// (User may have edited the body to a different value, but the env var
 	// historically came from the same defaults map; keep that behavior so an
 	// override of memtx in the YAML doesn't silently disagree with the env.)
-	defaults := types.PicodataDefaults("25.3")
-	dbconfig.ResolveMemoryPercents(defaults, memMB)
-	for k, v := range cfg.Options {
-		defaults[k] = v
-	}
-	memtxBytes := picodataMemtxBytesFromDefault(defaults["memtx_memory"])
+	memtxBytes := picodataMemtxBytesFromDefault(dbconfig.PicodataSettings(picoOpts)["memtx_memory"])
 
 	dataDir := "/var/lib/picodata"
 
@@ -893,14 +966,14 @@ func (e *Executor) configMonitor(ctx context.Context, cmd Command) error {
 	machineID := os.Getenv("STROPPY_MACHINE_ID")
 
 	// --- node_exporter on EVERY machine ---
-	if err := e.startDaemon("node_exporter", "/usr/local/bin/node_exporter"); err != nil {
+	if err := e.startDaemon(ctx, "node_exporter", "/usr/local/bin/node_exporter"); err != nil {
 		return fmt.Errorf("start node_exporter: %w", err)
 	}
 
 	// --- postgres_exporter on database machines (connects to LOCAL postgres) ---
 	if strings.Contains(machineID, "-database-") && cfg.DatabaseKind == "postgres" {
-		os.Setenv("DATA_SOURCE_NAME", "postgresql://postgres@localhost:5432/postgres?sslmode=disable")
-		if err := e.startDaemon("postgres_exporter", "/usr/local/bin/postgres_exporter"); err != nil {
+		dsn := "DATA_SOURCE_NAME=postgresql://postgres@localhost:5432/postgres?sslmode=disable"
+		if err := e.startDaemonEnv(ctx, "postgres_exporter", []string{dsn}, "/usr/local/bin/postgres_exporter"); err != nil {
 			log.Printf("WARNING: postgres_exporter failed to start: %v", err)
 		}
 	}
@@ -909,9 +982,9 @@ func (e *Executor) configMonitor(ctx context.Context, cmd Command) error {
 	if strings.Contains(machineID, "-database-") && cfg.DatabaseKind == "mysql" {
 		// Create exporter user with required grants.
 		e.shell(ctx, `mysql -h 127.0.0.1 -u root -e "SET sql_log_bin=0; CREATE USER IF NOT EXISTS 'exporter'@'localhost' IDENTIFIED BY 'exporter' WITH MAX_USER_CONNECTIONS 3; GRANT PROCESS, REPLICATION CLIENT, SELECT ON *.* TO 'exporter'@'localhost'; FLUSH PRIVILEGES; SET sql_log_bin=1;" 2>/dev/null`)
-		// Password via env (must be set before startDaemon).
-		os.Setenv("MYSQLD_EXPORTER_PASSWORD", "exporter")
-		if err := e.startDaemon("mysqld_exporter", "/usr/local/bin/mysqld_exporter",
+		// Password via the exporter's environment, not its command line.
+		password := "MYSQLD_EXPORTER_PASSWORD=exporter"
+		if err := e.startDaemonEnv(ctx, "mysqld_exporter", []string{password}, "/usr/local/bin/mysqld_exporter",
 			"--mysqld.address=localhost:3306",
 			"--mysqld.username=exporter",
 		); err != nil {
@@ -981,7 +1054,7 @@ func (e *Executor) configMonitor(ctx context.Context, cmd Command) error {
 		if cfg.BearerToken != "" {
 			vmagentArgs = append(vmagentArgs, "-remoteWrite.bearerToken="+cfg.BearerToken)
 		}
-		if err := e.startDaemon("vmagent", "/usr/local/bin/vmagent", vmagentArgs...); err != nil {
+		if err := e.startDaemon(ctx, "vmagent", "/usr/local/bin/vmagent", vmagentArgs...); err != nil {
 			return fmt.Errorf("start vmagent: %w", err)
 		}
 	}
@@ -1455,7 +1528,7 @@ func (e *Executor) configProxySQL(ctx context.Context, cmd Command) error {
 
 	// Start proxysql in background.
 	e.shell(ctx, "mkdir -p /var/lib/proxysql")
-	if err := e.startDaemon("proxysql", "proxysql", "--initial", "-f", "-D", "/var/lib/proxysql", "-c", "/etc/proxysql.cnf"); err != nil {
+	if err := e.startDaemon(ctx, "proxysql", "proxysql", "--initial", "-f", "-D", "/var/lib/proxysql", "-c", "/etc/proxysql.cnf"); err != nil {
 		return fmt.Errorf("start proxysql: %w", err)
 	}
 
@@ -1466,46 +1539,23 @@ func (e *Executor) configProxySQL(ctx context.Context, cmd Command) error {
 // YDB handlers
 // ---------------------------------------------------------------------------
 
-// ydbVersionMap maps short version labels (from UI) to full patch versions
-// available at binaries.ydb.tech/release/. Update when new YDB releases ship.
-var ydbVersionMap = map[string]string{
-	"25.2": "25.2.1.24",
-	"25.1": "25.1.4.7",
-	"24.4": "24.4.4.12",
-	"24.3": "24.3.15.5",
-	"24.2": "24.2.7",
-	"24.1": "24.1.18",
-}
-
-// resolveYDBVersion maps a short version like "25.2" to the full "25.2.1.24".
-// If the input is already a full version (e.g. "25.2.1.24"), returns it as-is.
-func resolveYDBVersion(v string) string {
-	if v == "" {
-		return "25.2.1.24"
-	}
-	if full, ok := ydbVersionMap[v]; ok {
-		return full
-	}
-	return v // assume full version was passed directly
-}
-
 func (e *Executor) installYDB(ctx context.Context, cmd Command) error {
 	var cfg YDBInstallConfig
 	if err := parseConfig(cmd, &cfg); err != nil {
 		return err
 	}
 
-	if _, err := exec.LookPath("/opt/ydb/bin/ydbd"); err == nil {
-		e.emitLine("ydbd already installed, skipping download")
-		return nil
+	// A package without anything to install (no package, or a built-in
+	// created before tarball packages existed) gets the ydbd release tarball.
+	pkg := types.Package{Name: "ydb", DbKind: string(types.DatabaseYDB), DbVersion: cfg.Version}
+	if cfg.Package != nil {
+		pkg = *cfg.Package
 	}
-
-	// Map short versions (e.g. "25.2") to full patch versions available at binaries.ydb.tech.
-	ydbVersion := resolveYDBVersion(cfg.Version)
-	downloadURL := fmt.Sprintf("https://binaries.ydb.tech/release/%s/ydbd-%s-linux-amd64.tar.gz", ydbVersion, ydbVersion)
-	e.emitLine(fmt.Sprintf("downloading ydbd %s from %s...", ydbVersion, downloadURL))
-	if _, err := e.shell(ctx, fmt.Sprintf(`mkdir -p /opt/ydb && curl -fSL %s | tar -xz --strip-component=1 -C /opt/ydb`, downloadURL)); err != nil {
-		return fmt.Errorf("download ydbd: %w", err)
+	if pkg.Tarball == nil && pkg.DebFilename == "" && len(pkg.AptPackages) == 0 {
+		pkg.Tarball = types.YDBTarball(cfg.Version)
+	}
+	if err := e.installPackage(ctx, pkg); err != nil {
+		return fmt.Errorf("install ydb: %w", err)
 	}
 
 	// YDB CLI not needed — ydbd admin commands are used directly. Skip to save time.
@@ -1554,6 +1604,8 @@ func (e *Executor) configYDB(ctx context.Context, cmd Command) error {
 			CPUs:            cfg.CPUs,
 			MemoryMB:        memMB,
 			FaultTolerance:  cfg.FaultTolerance,
+			AutoTune:        cfg.AutoTune,
+			DiskType:        cfg.DiskType,
 		})
 	}
 	body = dbconfig.SubstituteYDBHostPlaceholders(body, cfg.Hosts)
@@ -1575,7 +1627,7 @@ func (e *Executor) configYDB(ctx context.Context, cmd Command) error {
 		pdiskTarget = diskPath + "/pdisk.data"
 	}
 
-	e.emitLine("preparing YDB disk...")
+	e.emitLine(ctx, "preparing YDB disk...")
 	if _, err := e.shell(ctx, fmt.Sprintf(
 		"LD_LIBRARY_PATH=/opt/ydb/lib /opt/ydb/bin/ydbd admin bs disk obliterate %s", pdiskTarget)); err != nil {
 		return fmt.Errorf("obliterate disk: %w", err)
@@ -1590,7 +1642,7 @@ func (e *Executor) configYDB(ctx context.Context, cmd Command) error {
 
 	// Start static node.
 	e.shell(ctx, "systemctl stop ydbd-storage 2>/dev/null; systemctl reset-failed ydbd-storage 2>/dev/null")
-	e.emitLine("starting YDB static (storage) node...")
+	e.emitLine(ctx, "starting YDB static (storage) node...")
 	startCmd := fmt.Sprintf(
 		`systemd-run --unit=ydbd-storage --uid=ydb --gid=ydb `+
 			`--setenv=LD_LIBRARY_PATH=/opt/ydb/lib `+
@@ -1609,7 +1661,7 @@ func (e *Executor) configYDB(ctx context.Context, cmd Command) error {
 		return fmt.Errorf("ydbd-storage did not start: %w", err)
 	}
 
-	e.emitLine("YDB static node started")
+	e.emitLine(ctx, "YDB static node started")
 	return nil
 }
 
@@ -1633,21 +1685,21 @@ func (e *Executor) initYDB(ctx context.Context, cmd Command) error {
 	}
 
 	// Initialize blobstorage (retry — cluster needs time to form quorum).
-	e.emitLine("initializing YDB blobstorage...")
+	e.emitLine(ctx, "initializing YDB blobstorage...")
 	if _, err := e.shell(ctx, fmt.Sprintf(
 		`for i in $(seq 1 30); do LD_LIBRARY_PATH=/opt/ydb/lib /opt/ydb/bin/ydbd -s %s admin blobstorage config init --yaml-file %s 2>&1 && exit 0; sleep 2; done; exit 1`,
 		endpoint, confPath)); err != nil {
 		return fmt.Errorf("blobstorage init: %w", err)
 	}
 
-	e.emitLine(fmt.Sprintf("creating YDB database %s...", dbPath))
+	e.emitLine(ctx, fmt.Sprintf("creating YDB database %s...", dbPath))
 	if _, err := e.shell(ctx, fmt.Sprintf(
 		`for i in $(seq 1 15); do LD_LIBRARY_PATH=/opt/ydb/lib /opt/ydb/bin/ydbd -s %s admin database %s create ssd:1 2>&1 && exit 0; sleep 2; done; exit 1`,
 		endpoint, dbPath)); err != nil {
 		return fmt.Errorf("create database: %w", err)
 	}
 
-	e.emitLine("YDB cluster initialized")
+	e.emitLine(ctx, "YDB cluster initialized")
 	return nil
 }
 
@@ -1680,6 +1732,8 @@ func (e *Executor) startYDBDB(ctx context.Context, cmd Command) error {
 			CPUs:            cfg.CPUs,
 			MemoryMB:        memMB,
 			FaultTolerance:  cfg.FaultTolerance,
+			AutoTune:        cfg.AutoTune,
+			DiskType:        cfg.DiskType,
 		})
 	}
 	body = dbconfig.SubstituteYDBHostPlaceholders(body, cfg.StorageHosts)
@@ -1696,7 +1750,7 @@ func (e *Executor) startYDBDB(ctx context.Context, cmd Command) error {
 	}
 
 	e.shell(ctx, "systemctl stop ydbd-database 2>/dev/null; systemctl reset-failed ydbd-database 2>/dev/null")
-	e.emitLine("starting YDB dynamic (database) node...")
+	e.emitLine(ctx, "starting YDB dynamic (database) node...")
 	startCmd := fmt.Sprintf(
 		`systemd-run --unit=ydbd-database --uid=ydb --gid=ydb `+
 			`--setenv=LD_LIBRARY_PATH=/opt/ydb/lib `+
@@ -1713,6 +1767,143 @@ func (e *Executor) startYDBDB(ctx context.Context, cmd Command) error {
 		return fmt.Errorf("ydbd-database did not start: %w", err)
 	}
 
-	e.emitLine("YDB database node started")
+	e.emitLine(ctx, "YDB database node started")
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// applyTuning
+// ---------------------------------------------------------------------------
+
+// applyTuning applies an OS tuning profile and returns the effective values
+// as a JSON object in the report output.
+func (e *Executor) applyTuning(ctx context.Context, cmd Command) (string, error) {
+	var cfg TuningConfig
+	if err := parseConfig(cmd, &cfg); err != nil {
+		return "", err
+	}
+
+	if script := renderTuningScript(cfg.Profile); script != "" {
+		e.emitLine(ctx, fmt.Sprintf("applying OS tuning profile %q", cfg.Profile.Name))
+		if _, err := e.shell(ctx, script); err != nil {
+			return "", fmt.Errorf("apply tuning profile %q: %w", cfg.Profile.Name, err)
+		}
+	}
+
+	out, err := e.shell(ctx, renderTuningReadback(cfg.Profile))
+	if err != nil {
+		return "", fmt.Errorf("read effective tuning: %w", err)
+	}
+	data, err := json.Marshal(parseTuningOutput(out))
+	if err != nil {
+		return "", fmt.Errorf("marshal effective tuning: %w", err)
+	}
+	return string(data), nil
+}
+
+// ---------------------------------------------------------------------------
+// captureSettings
+// ---------------------------------------------------------------------------
+
+// captureSettings reads the settings the local database server runs with and
+// returns them as a JSON object in the report output.
+func (e *Executor) captureSettings(ctx context.Context, cmd Command) (string, error) {
+	var cfg CaptureSettingsConfig
+	if err := parseConfig(cmd, &cfg); err != nil {
+		return "", err
+	}
+
+	var values map[string]string
+	switch cfg.Kind {
+	case "postgres", "mysql", "picodata":
+		out, err := e.shell(ctx, renderSettingsQuery(cfg.Kind))
+		if err != nil {
+			return "", fmt.Errorf("query %s settings: %w", cfg.Kind, err)
+		}
+		values = parseSettingsOutput(out)
+	case "ydb":
+		// The viewer is optional: a node that does not answer still has
+		// its config files.
+		sysinfo, err := e.shell(ctx, "curl -sf http://localhost:8765/viewer/json/sysinfo")
+		if err != nil {
+			sysinfo = ""
+		}
+		if values, err = captureYDBSettings(os.ReadFile, strings.TrimSpace(sysinfo)); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("capture settings: unsupported database kind %q", cfg.Kind)
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("capture %s settings: server reported none", cfg.Kind)
+	}
+
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("marshal %s settings: %w", cfg.Kind, err)
+	}
+	return string(data), nil
+}
+
+// ---------------------------------------------------------------------------
+// resolveGitRef / buildPackage
+// ---------------------------------------------------------------------------
+
+// resolveGitRef returns the commit SHA a branch or tag currently points to.
+func (e *Executor) resolveGitRef(ctx context.Context, cmd Command) (string, error) {
+	var cfg ResolveGitRefConfig
+	if err := parseConfig(cmd, &cfg); err != nil {
+		return "", err
+	}
+	if types.IsCommitSHA(cfg.Ref) {
+		return cfg.Ref, nil
+	}
+	if err := (types.SourceBuild{GitURL: cfg.GitURL, Ref: cfg.Ref}).ValidateGit(); err != nil {
+		return "", err
+	}
+	if err := e.aptInstall(ctx, "git ca-certificates"); err != nil {
+		return "", fmt.Errorf("install git: %w", err)
+	}
+	out, err := e.shell(ctx, fmt.Sprintf("git ls-remote -- %s %s", shellQuote(cfg.GitURL), shellQuote(cfg.Ref)))
+	if err != nil {
+		return "", fmt.Errorf("git ls-remote %s: %w", cfg.GitURL, err)
+	}
+	sha := ParseLsRemote(out, cfg.Ref)
+	if sha == "" {
+		return "", fmt.Errorf("ref %q not found in %s", cfg.Ref, cfg.GitURL)
+	}
+	return sha, nil
+}
+
+// buildPackage compiles a source package into a .deb and uploads it to the server.
+func (e *Executor) buildPackage(ctx context.Context, cmd Command) error {
+	var cfg BuildPackageConfig
+	if err := parseConfig(cmd, &cfg); err != nil {
+		return err
+	}
+	if !types.IsCommitSHA(cfg.Source.CommitSHA) {
+		return fmt.Errorf("build package: commit sha %q is not resolved", cfg.Source.CommitSHA)
+	}
+	if err := cfg.Source.ValidateGit(); err != nil {
+		return fmt.Errorf("build package: %w", err)
+	}
+
+	deps := append([]string{"git", "ca-certificates", "curl", "dpkg-dev"}, cfg.Source.BuildDeps...)
+	if err := e.aptInstall(ctx, strings.Join(deps, " ")); err != nil {
+		return fmt.Errorf("install build deps: %w", err)
+	}
+
+	e.emitLine(ctx, fmt.Sprintf("building %s at %s", cfg.Source.GitURL, cfg.Source.CommitSHA))
+	out, err := e.shell(ctx, renderBuildScript(cfg))
+	if err != nil {
+		return fmt.Errorf("build %s: %w", cfg.DebName, err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	debPath := strings.TrimSpace(lines[len(lines)-1])
+
+	e.emitLine(ctx, "uploading "+debPath)
+	if _, err := e.shell(ctx, renderUploadScript(debPath, cfg.UploadURL, cfg.UploadToken)); err != nil {
+		return fmt.Errorf("upload %s: %w", debPath, err)
+	}
 	return nil
 }
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// lockSet is a set of named, context-aware mutexes guarding host-wide
+// resources (apt, data dir, systemd). Commands acquire the locks they
+// declare before running; everything else runs concurrently.
+type lockSet struct {
+	mu    sync.Mutex
+	locks map[Lock]chan struct{}
+}
+
+func (s *lockSet) get(name Lock) chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locks == nil {
+		s.locks = make(map[Lock]chan struct{})
+	}
+	l, ok := s.locks[name]
+	if !ok {
+		l = make(chan struct{}, 1)
+		s.locks[name] = l
+	}
+	return l
+}
+
+// acquire takes all named locks in a stable order — so two commands asking
+// for overlapping sets cannot deadlock — and returns a release func.
+// Locks already held by ctx (see withHeldLocks) are skipped.
+func (s *lockSet) acquire(ctx context.Context, names []Lock) (func(), error) {
+	sorted := make([]Lock, 0, len(names))
+	for _, n := range names {
+		if !holdsLock(ctx, n) {
+			sorted = append(sorted, n)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var taken []chan struct{}
+	release := func() {
+		for i := len(taken) - 1; i >= 0; i-- {
+			<-taken[i]
+		}
+	}
+	for i, n := range sorted {
+		if i > 0 && n == sorted[i-1] {
+			continue
+		}
+		l := s.get(n)
+		select {
+		case l <- struct{}{}:
+			taken = append(taken, l)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+	return release, nil
+}
+
+type heldLocksKey struct{}
+
+// withHeldLocks records on ctx the locks the current command already holds,
+// so helpers like shellWithAptLock don't try to take them a second time.
+func withHeldLocks(ctx context.Context, names []Lock) context.Context {
+	if len(names) == 0 {
+		return ctx
+	}
+	held := make(map[Lock]bool, len(names))
+	for _, n := range names {
+		held[n] = true
+	}
+	return context.WithValue(ctx, heldLocksKey{}, held)
+}
+
+func holdsLock(ctx context.Context, name Lock) bool {
+	held, _ := ctx.Value(heldLocksKey{}).(map[Lock]bool)
+	return held[name]
+}
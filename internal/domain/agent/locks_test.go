@@ -0,0 +1,116 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLockSet_SerialisesSameLock(t *testing.T) {
+	var s lockSet
+	ctx := context.Background()
+
+	release, err := s.acquire(ctx, []Lock{LockApt})
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(waitCtx, []Lock{LockApt}); err == nil {
+		t.Fatal("second acquire of apt should block until released")
+	}
+
+	release()
+	release2, err := s.acquire(ctx, []Lock{LockApt})
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	release2()
+}
+
+func TestLockSet_DisjointLocksRunConcurrently(t *testing.T) {
+	var s lockSet
+	ctx := context.Background()
+
+	release, err := s.acquire(ctx, []Lock{LockApt})
+	if err != nil {
+		t.Fatalf("acquire apt: %v", err)
+	}
+	defer release()
+
+	waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	release2, err := s.acquire(waitCtx, []Lock{LockDataDir, LockSystemd})
+	if err != nil {
+		t.Fatalf("disjoint locks should not block: %v", err)
+	}
+	release2()
+}
+
+func TestLockSet_SkipsLocksHeldByContext(t *testing.T) {
+	var s lockSet
+	ctx := context.Background()
+
+	release, err := s.acquire(ctx, []Lock{LockApt})
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer release()
+
+	held := withHeldLocks(ctx, []Lock{LockApt})
+	waitCtx, cancel := context.WithTimeout(held, time.Second)
+	defer cancel()
+	inner, err := s.acquire(waitCtx, []Lock{LockApt})
+	if err != nil {
+		t.Fatalf("re-acquire of held lock should be a no-op: %v", err)
+	}
+	inner()
+}
+
+func TestCommand_RequiredLocks(t *testing.T) {
+	cmd := Command{Action: ActionInstallPostgres}
+	if got := cmd.RequiredLocks(); len(got) != 1 || got[0] != LockApt {
+		t.Errorf("install_postgres default locks = %v, want [apt]", got)
+	}
+
+	cmd.Locks = []Lock{LockSystemd}
+	if got := cmd.RequiredLocks(); len(got) != 1 || got[0] != LockSystemd {
+		t.Errorf("explicit locks should win, got %v", got)
+	}
+
+	if got := (Command{Action: ActionRunStroppy}).RequiredLocks(); len(got) != 0 {
+		t.Errorf("run_stroppy should be lock-free, got %v", got)
+	}
+
+	// Actions that manage services share the systemd lock.
+	for _, a := range []Action{ActionConfigPgBouncer, ActionConfigHAProxy, ActionConfigProxySQL, ActionConfigMonitor} {
+		if got := (Command{Action: a}).RequiredLocks(); len(got) != 1 || got[0] != LockSystemd {
+			t.Errorf("%s default locks = %v, want [systemd]", a, got)
+		}
+	}
+}
+
+func TestCommandQueue_UnboundedFIFO(t *testing.T) {
+	q := newCommandQueue()
+	for i := 0; i < 20; i++ {
+		q.push(Command{ID: string(rune('a' + i))})
+	}
+	q.remove("b")
+
+	first := q.pop(time.Second)
+	if first == nil || first.ID != "a" {
+		t.Fatalf("first pop = %v, want a", first)
+	}
+	second := q.pop(time.Second)
+	if second == nil || second.ID != "c" {
+		t.Fatalf("second pop = %v, want c (b was removed)", second)
+	}
+}
+
+func TestCommandQueue_PopTimesOut(t *testing.T) {
+	q := newCommandQueue()
+	if cmd := q.pop(10 * time.Millisecond); cmd != nil {
+		t.Fatalf("expected nil on empty queue, got %v", cmd)
+	}
+}
@@ -5,6 +5,59 @@ type Command struct {
 	ID     string `json:"id"`
 	Action Action `json:"action"`
 	Config any    `json:"config"` // action-specific payload
+	// Locks names the host resources the command needs exclusively. Commands
+	// with disjoint locks run concurrently on the same agent. Empty means
+	// DefaultLocks(Action) — older servers never set the field.
+	Locks []Lock `json:"locks,omitempty"`
+}
+
+// RequiredLocks returns the locks the agent must hold while running the command.
+func (c Command) RequiredLocks() []Lock {
+	if len(c.Locks) > 0 {
+		return c.Locks
+	}
+	return DefaultLocks(c.Action)
+}
+
+// Lock names a host-wide resource that concurrent commands must not share.
+type Lock string
+
+const (
+	LockApt     Lock = "apt"      // dpkg database and apt sources
+	LockDataDir Lock = "data_dir" // database data directory and its config files
+	LockSystemd Lock = "systemd"  // systemctl / systemd-run unit management
+)
+
+// actionLocks maps each action to the locks it needs by default.
+// Downloads of standalone binaries (etcd, exporters, stroppy, ydbd) and
+// stroppy itself touch nothing shared, so they run lock-free; so does
+// capturing settings, which only queries the running server.
+var actionLocks = map[Action][]Lock{
+	ActionInstallPostgres:  {LockApt},
+	ActionConfigPostgres:   {LockDataDir, LockSystemd},
+	ActionInstallMySQL:     {LockApt},
+	ActionConfigMySQL:      {LockDataDir, LockSystemd},
+	ActionInstallPicodata:  {LockApt},
+	ActionConfigPicodata:   {LockDataDir, LockSystemd},
+	ActionConfigEtcd:       {LockSystemd},
+	ActionInstallPatroni:   {LockApt},
+	ActionConfigPatroni:    {LockDataDir, LockSystemd},
+	ActionInstallPgBouncer: {LockApt},
+	ActionConfigPgBouncer:  {LockSystemd},
+	ActionInstallHAProxy:   {LockApt},
+	ActionConfigHAProxy:    {LockSystemd},
+	ActionInstallProxySQL:  {LockApt},
+	ActionConfigProxySQL:   {LockSystemd},
+	ActionConfigYDB:        {LockDataDir, LockSystemd},
+	ActionStartYDBDB:       {LockDataDir, LockSystemd},
+	ActionApplyTuning:      {LockSystemd},
+	ActionConfigMonitor:    {LockSystemd},
+}
+
+// DefaultLocks returns the locks an action needs when the command does not
+// declare its own.
+func DefaultLocks(a Action) []Lock {
+	return actionLocks[a]
 }
 
 // Action identifies what the agent should do.
@@ -35,6 +88,10 @@ const (
 	ActionConfigYDB        Action = "config_ydb"
 	ActionInitYDB          Action = "init_ydb"
 	ActionStartYDBDB       Action = "start_ydb_db"
+	ActionApplyTuning      Action = "apply_tuning"
+	ActionCaptureSettings  Action = "capture_db_settings"
+	ActionResolveGitRef    Action = "resolve_git_ref"
+	ActionBuildPackage     Action = "build_package"
 	ActionShutdown         Action = "shutdown"
 )
 
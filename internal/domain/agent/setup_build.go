@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// ResolveGitRefConfig is the agent payload for resolving a git ref to a commit SHA.
+type ResolveGitRefConfig struct {
+	GitURL string `json:"git_url"`
+	Ref    string `json:"ref"`
+}
+
+// BuildPackageConfig is the agent payload for compiling a source package into
+// a .deb and uploading it to the server's package build cache.
+type BuildPackageConfig struct {
+	// DebName is the Package: field of the produced .deb.
+	DebName string            `json:"deb_name"`
+	Source  types.SourceBuild `json:"source"`
+	// UploadURL receives the .deb via HTTP PUT, authorized by UploadToken.
+	UploadURL   string `json:"upload_url"`
+	UploadToken string `json:"upload_token"`
+}
+
+const (
+	buildWorkDir = "/opt/stroppy-build"
+	buildSrcDir  = buildWorkDir + "/src"
+	buildRootDir = buildWorkDir + "/root"
+)
+
+// buildDebVersion is the .deb version of a source build: sortable below any
+// real release and unique per commit.
+func buildDebVersion(commitSHA string) string {
+	sha := commitSHA
+	if len(sha) > 12 {
+		sha = sha[:12]
+	}
+	return "0.0.0+g" + sha
+}
+
+// BuildDebFilename is the file name of the .deb produced for a commit.
+func BuildDebFilename(debName, commitSHA string) string {
+	return debName + "_" + buildDebVersion(commitSHA) + ".deb"
+}
+
+// ParseLsRemote picks the commit SHA for ref out of `git ls-remote` output.
+// Annotated tags are listed twice; the peeled "^{}" line is the commit.
+func ParseLsRemote(out, ref string) string {
+	var sha, peeled string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || !types.IsCommitSHA(fields[0]) {
+			continue
+		}
+		name := fields[1]
+		switch {
+		case name == ref || name == "refs/heads/"+ref || name == "refs/tags/"+ref:
+			if sha == "" {
+				sha = fields[0]
+			}
+		case name == "refs/tags/"+ref+"^{}" || name == ref+"^{}":
+			peeled = fields[0]
+		}
+	}
+	if peeled != "" {
+		return peeled
+	}
+	return sha
+}
+
+// renderBuildScript builds the bash script that checks out the commit,
+// compiles it into a staging root and packs that root into a .deb.
+// Prints the path of the produced .deb as its last line.
+func renderBuildScript(cfg BuildPackageConfig) string {
+	src := cfg.Source
+	var b strings.Builder
+	b.WriteString("set -euo pipefail\n")
+	fmt.Fprintf(&b, "rm -rf %s && mkdir -p %s %s\n", buildWorkDir, buildSrcDir, buildRootDir)
+	fmt.Fprintf(&b, "cd %s\n", buildSrcDir)
+	b.WriteString("git init -q .\n")
+	fmt.Fprintf(&b, "git remote add -- origin %s\n", shellQuote(src.GitURL))
+	// Shallow fetch of a single commit works on most hosts; fall back to a full fetch.
+	fmt.Fprintf(&b, "git fetch -q --depth 1 -- origin %s || git fetch -q -- origin\n", shellQuote(src.CommitSHA))
+	fmt.Fprintf(&b, "git -c advice.detachedHead=false checkout -q %s --\n", shellQuote(src.CommitSHA))
+
+	jobs := `-j"$(nproc)"`
+	makeFlags := quoteAll(src.MakeFlags)
+	switch src.BuildSystem {
+	case types.BuildCMake:
+		fmt.Fprintf(&b, "cmake -B build -DCMAKE_BUILD_TYPE=RelWithDebInfo -DCMAKE_INSTALL_PREFIX=%s%s\n",
+			shellQuote(src.Prefix), joinArgs(quoteAll(src.ConfigureFlags)))
+		fmt.Fprintf(&b, "cmake --build build %s%s\n", jobs, prefixArgs(" --", makeFlags))
+		fmt.Fprintf(&b, "DESTDIR=%s cmake --install build\n", buildRootDir)
+	case types.BuildMeson:
+		fmt.Fprintf(&b, "meson setup build --prefix=%s%s\n",
+			shellQuote(src.Prefix), joinArgs(quoteAll(src.ConfigureFlags)))
+		fmt.Fprintf(&b, "ninja -C build%s\n", joinArgs(makeFlags))
+		fmt.Fprintf(&b, "DESTDIR=%s meson install -C build\n", buildRootDir)
+	default:
+		b.WriteString("[ -x ./configure ] || ./autogen.sh\n")
+		fmt.Fprintf(&b, "./configure --prefix=%s%s\n",
+			shellQuote(src.Prefix), joinArgs(quoteAll(src.ConfigureFlags)))
+		fmt.Fprintf(&b, "make %s%s\n", jobs, joinArgs(makeFlags))
+		fmt.Fprintf(&b, "make install DESTDIR=%s\n", buildRootDir)
+	}
+
+	fmt.Fprintf(&b, "mkdir -p %s/DEBIAN\n", buildRootDir)
+	control := []string{
+		"Package: " + cfg.DebName,
+		"Version: " + buildDebVersion(src.CommitSHA),
+		"Maintainer: stroppy-cloud <noreply@stroppy.io>",
+	}
+	if len(src.Depends) > 0 {
+		control = append(control, "Depends: "+strings.Join(src.Depends, ", "))
+	}
+	control = append(control, "Description: "+cfg.DebName+" built from "+src.GitURL+" at "+src.CommitSHA)
+	fmt.Fprintf(&b, "printf '%%s\\n'%s > %s/DEBIAN/control\n", joinArgs(quoteAll(control)), buildRootDir)
+	fmt.Fprintf(&b, "echo \"Architecture: $(dpkg --print-architecture)\" >> %s/DEBIAN/control\n", buildRootDir)
+	if len(src.PostInstall) > 0 {
+		fmt.Fprintf(&b, "cat > %s/DEBIAN/postinst <<'STROPPY_POSTINST'\n#!/bin/sh\nset -e\n", buildRootDir)
+		for _, line := range src.PostInstall {
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("STROPPY_POSTINST\n")
+		fmt.Fprintf(&b, "chmod 755 %s/DEBIAN/postinst\n", buildRootDir)
+	}
+
+	deb := buildWorkDir + "/" + BuildDebFilename(cfg.DebName, src.CommitSHA)
+	fmt.Fprintf(&b, "dpkg-deb --build --root-owner-group %s %s >/dev/null\n", buildRootDir, shellQuote(deb))
+	fmt.Fprintf(&b, "echo %s\n", shellQuote(deb))
+	return b.String()
+}
+
+// renderUploadScript PUTs the built .deb to the server.
+func renderUploadScript(debPath, url, token string) string {
+	auth := ""
+	if token != "" {
+		auth = " -H " + shellQuote("Authorization: Bearer "+token)
+	}
+	return fmt.Sprintf("curl -fsS -X PUT%s -H 'Content-Type: application/vnd.debian.binary-package' -T %s %s",
+		auth, shellQuote(debPath), shellQuote(url))
+}
+
+func quoteAll(args []string) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = shellQuote(a)
+	}
+	return out
+}
+
+// joinArgs renders args as " a b c" (empty for no args).
+func joinArgs(args []string) string {
+	return prefixArgs("", args)
+}
+
+// prefixArgs renders args after a separator such as " --", or "" for no args.
+func prefixArgs(sep string, args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return sep + " " + strings.Join(args, " ")
+}
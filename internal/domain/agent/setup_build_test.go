@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+const testSHA = "0123456789abcdef0123456789abcdef01234567"
+
+func TestParseLsRemote(t *testing.T) {
+	out := "1111111111111111111111111111111111111111\trefs/heads/main\n" +
+		"2222222222222222222222222222222222222222\trefs/tags/v1.0\n" +
+		"3333333333333333333333333333333333333333\trefs/tags/v1.0^{}\n"
+	if got := ParseLsRemote(out, "main"); got != "1111111111111111111111111111111111111111" {
+		t.Errorf("branch: got %q", got)
+	}
+	if got := ParseLsRemote(out, "v1.0"); got != "3333333333333333333333333333333333333333" {
+		t.Errorf("annotated tag should resolve to the peeled commit, got %q", got)
+	}
+	if got := ParseLsRemote(out, "missing"); got != "" {
+		t.Errorf("missing ref: got %q", got)
+	}
+}
+
+func TestRenderBuildScript_Postgres(t *testing.T) {
+	src := types.SourceBuild{
+		GitURL:         "https://git.postgresql.org/git/postgresql.git",
+		Ref:            "master",
+		ConfigureFlags: []string{"--with-openssl"},
+		CommitSHA:      testSHA,
+	}.WithDefaults("postgres", "17")
+	script := renderBuildScript(BuildPackageConfig{DebName: "stroppy-postgres-17-src", Source: src})
+	for _, want := range []string{
+		"git remote add -- origin 'https://git.postgresql.org/git/postgresql.git'",
+		"checkout -q '" + testSHA + "' --",
+		"./configure --prefix='/usr/lib/postgresql/17' '--with-openssl'",
+		"make install DESTDIR=" + buildRootDir,
+		"'Version: 0.0.0+g0123456789ab'",
+		"pg_createcluster 17 main",
+		"dpkg-deb --build --root-owner-group",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestRenderBuildScript_CMake(t *testing.T) {
+	src := types.SourceBuild{GitURL: "https://github.com/mysql/mysql-server.git", CommitSHA: testSHA, MakeFlags: []string{"-k"}}.
+		WithDefaults("mysql", "8.4")
+	script := renderBuildScript(BuildPackageConfig{DebName: "stroppy-mysql-8.4-src", Source: src})
+	for _, want := range []string{
+		"cmake -B build -DCMAKE_BUILD_TYPE=RelWithDebInfo -DCMAKE_INSTALL_PREFIX='/usr/local'",
+		"cmake --build build -j\"$(nproc)\" -- '-k'",
+		"DESTDIR=" + buildRootDir + " cmake --install build",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
@@ -22,6 +22,9 @@ type MySQLClusterConfig struct {
 	GroupSeeds   []string          `json:"group_seeds,omitempty"` // all host:33061 addresses for GR
 	GroupName    string            `json:"group_name,omitempty"`  // UUID for group_replication_group_name
 	MemoryMB     int               `json:"memory_mb,omitempty"`   // budget for "25%"-style defaults; falls back to /proc/meminfo when 0
+	CPUs         int               `json:"cpus,omitempty"`        // vCPUs for auto-tuning; falls back to runtime.NumCPU when 0
+	DiskType     string            `json:"disk_type,omitempty"`   // cloud disk type for auto-tuning I/O settings
+	AutoTune     string            `json:"auto_tune,omitempty"`   // types.AutoTune* profile; empty keeps static defaults
 	Options      map[string]string `json:"options,omitempty"`
 	// ConfOverride, when non-empty, replaces the rendered my.cnf body. Set on
 	// the run side from DatabaseConfig.RenderedConfigOverrides for the
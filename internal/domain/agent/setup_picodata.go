@@ -19,6 +19,7 @@ type PicodataClusterConfig struct {
 	Replication   int               `json:"replication_factor"`
 	Shards        int               `json:"shards"`
 	MemoryMB      int               `json:"memory_mb,omitempty"` // budget for "25%"-style defaults; falls back to /proc/meminfo when 0
+	AutoTune      string            `json:"auto_tune,omitempty"` // types.AutoTune* profile; empty keeps static defaults
 	Options       map[string]string `json:"options,omitempty"`
 	// ConfOverride, when non-empty, replaces the rendered picodata.yaml body.
 	// Set on the run side from DatabaseConfig.RenderedConfigOverrides for
@@ -19,6 +19,9 @@ type PostgresClusterConfig struct {
 	Patroni      bool              `json:"patroni"`
 	SyncReplicas int               `json:"sync_replicas"`
 	MemoryMB     int               `json:"memory_mb,omitempty"` // budget for "25%"-style defaults; falls back to /proc/meminfo when 0
+	CPUs         int               `json:"cpus,omitempty"`      // vCPUs for auto-tuning; falls back to runtime.NumCPU when 0
+	DiskType     string            `json:"disk_type,omitempty"` // cloud disk type for auto-tuning I/O settings
+	AutoTune     string            `json:"auto_tune,omitempty"` // types.AutoTune* profile; empty keeps static defaults
 	Options      map[string]string `json:"options,omitempty"`
 	// ConfOverride, when non-empty, replaces the rendered postgresql.conf body.
 	// Set on the run side from DatabaseConfig.RenderedConfigOverrides for the
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/dbconfig"
+)
+
+// CaptureSettingsConfig is the agent payload for reading back the settings a
+// database server runs with.
+type CaptureSettingsConfig struct {
+	Kind string `json:"kind"` // "postgres", "mysql", "picodata" or "ydb"
+}
+
+// settingsOutputPrefix marks readback lines in query output, as
+// tuningOutputPrefix does for OS settings.
+const settingsOutputPrefix = "setting:"
+
+// ydbConfigFiles are the YDB configs the agent writes, keyed by the prefix
+// their settings are reported under.
+var ydbConfigFiles = []struct{ prefix, path string }{
+	{"storage.", "/opt/ydb/cfg/config.yaml"},
+	{"database.", "/opt/ydb/cfg/database.yaml"},
+}
+
+// renderSettingsQuery returns a script printing one "setting:<name>=<value>"
+// line per server setting, or "" for engines read another way.
+func renderSettingsQuery(kind string) string {
+	switch kind {
+	case "postgres":
+		// current_setting keeps the units postgresql.conf uses (128MB, 5min).
+		return `psql -h 127.0.0.1 -U postgres -d postgres -Atc "SELECT '` + settingsOutputPrefix + `' || name || '=' || current_setting(name) FROM pg_settings"`
+	case "mysql":
+		return `mysql -h 127.0.0.1 -u root -N -B -e "SELECT CONCAT('` + settingsOutputPrefix + `', LOWER(VARIABLE_NAME), '=', VARIABLE_VALUE) FROM performance_schema.global_variables"`
+	case "picodata":
+		// The admin console answers in YAML; a list of prefixed strings
+		// survives that as one "- setting:..." line each.
+		return `printf '%s\n' '\lua' "local t = {} for k, v in pairs(box.cfg) do if type(v) ~= 'table' then table.insert(t, '` + settingsOutputPrefix + `' .. k .. '=' .. tostring(v)) end end return t" | picodata admin /var/lib/picodata/admin.sock`
+	}
+	return ""
+}
+
+// parseSettingsOutput extracts "setting:<name>=<value>" lines, tolerating
+// the YAML list markers and quotes picodata's console adds.
+func parseSettingsOutput(out string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.Trim(strings.TrimLeft(strings.TrimSpace(line), "- "), `'"`)
+		rest, ok := strings.CutPrefix(line, settingsOutputPrefix)
+		if !ok {
+			continue
+		}
+		k, v, ok := strings.Cut(rest, "=")
+		if !ok || k == "" {
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+// ydbSysinfo picks the runtime facts the YDB viewer reports for a node.
+func ydbSysinfo(body string) (map[string]string, error) {
+	var doc struct {
+		SystemStateInfo []map[string]any `json:"SystemStateInfo"`
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	if len(doc.SystemStateInfo) == 0 {
+		return values, nil
+	}
+	for _, k := range []string{"Version", "NumberOfCpus", "MemoryLimit", "MemoryUsed", "Roles"} {
+		if v, ok := doc.SystemStateInfo[0][k]; ok {
+			values["sysinfo."+k] = fmt.Sprint(v)
+		}
+	}
+	return values, nil
+}
+
+// captureYDBSettings flattens the config files the nodes were started with
+// and adds what the running storage node reports about itself.
+func captureYDBSettings(readFile func(string) ([]byte, error), sysinfo string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, f := range ydbConfigFiles {
+		body, err := readFile(f.path)
+		if err != nil {
+			continue // database.yaml exists only once start_ydb_database ran
+		}
+		flat, err := dbconfig.FlattenYDBConfig(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", f.path, err)
+		}
+		for k, v := range flat {
+			values[f.prefix+k] = v
+		}
+	}
+	if sysinfo != "" {
+		info, err := ydbSysinfo(sysinfo)
+		if err != nil {
+			return nil, fmt.Errorf("parse ydb sysinfo: %w", err)
+		}
+		for k, v := range info {
+			values[k] = v
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no ydb config found")
+	}
+	return values, nil
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseSettingsOutput(t *testing.T) {
+	out := "setting:shared_buffers=1GB\n" +
+		"setting:search_path=\"$user\", public\n" +
+		"---\n" +
+		"- 'setting:memtx_memory=1073741824'\n" +
+		"- setting:listen=0.0.0.0:3301\n" +
+		"noise\n"
+	got := parseSettingsOutput(out)
+	want := map[string]string{
+		"shared_buffers": "1GB",
+		"search_path":    "\"$user\", public",
+		"memtx_memory":   "1073741824",
+		"listen":         "0.0.0.0:3301",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestCaptureYDBSettings(t *testing.T) {
+	files := map[string]string{
+		"/opt/ydb/cfg/config.yaml": "hosts: [{host: a}]\nactor_system_config:\n  cpu_count: 4\n",
+	}
+	readFile := func(path string) ([]byte, error) {
+		if body, ok := files[path]; ok {
+			return []byte(body), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	got, err := captureYDBSettings(readFile, `{"SystemStateInfo":[{"NumberOfCpus":4,"Version":"24.3"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"storage.actor_system_config.cpu_count": "4",
+		"sysinfo.NumberOfCpus":                  "4",
+		"sysinfo.Version":                       "24.3",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+
+	if _, err := captureYDBSettings(readFile, ""); err != nil {
+		t.Errorf("capture without sysinfo: %v", err)
+	}
+	files = nil
+	if _, err := captureYDBSettings(readFile, ""); err == nil {
+		t.Error("expected an error when no config is present")
+	}
+}
@@ -0,0 +1,151 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+const (
+	tarballDownloadPath = "/tmp/stroppy-package.tar"
+	// tarballMarker, under the prefix, records which archive was unpacked
+	// there so re-running install_db on the same machine is a no-op.
+	tarballMarker = ".stroppy-tarball"
+	systemdDir    = "/etc/systemd/system"
+)
+
+// tarballSource is the archive a tarball package installs: Tarball.URL, or
+// the uploaded file the server exposes through DebFilename.
+func tarballSource(pkg types.Package) (url, token, sum string, err error) {
+	if pkg.Tarball.URL != "" {
+		return pkg.Tarball.URL, "", pkg.Tarball.SHA256, nil
+	}
+	if pkg.DebFilename != "" {
+		return pkg.DebFilename, pkg.DebToken, pkg.DebSHA256, nil
+	}
+	return "", "", "", fmt.Errorf("tarball package %q has neither a url nor an uploaded archive", pkg.Name)
+}
+
+// tarballID identifies an archive for the install marker. Presigned and
+// token URLs differ per run, so the checksum wins when there is one.
+func tarballID(url, sum string) string {
+	if sum != "" {
+		return sum
+	}
+	h := sha256.Sum256([]byte(url))
+	return "url-" + hex.EncodeToString(h[:8])
+}
+
+// tarballUnit is the data systemd unit templates are rendered with.
+type tarballUnit struct {
+	Name    string
+	Prefix  string
+	Version string
+}
+
+// renderTarballScript builds the bash script that downloads a tarball
+// package, unpacks it under its prefix and sets up PATH, the loader path and
+// systemd units. t must already have its defaults applied.
+func renderTarballScript(pkg types.Package, t types.Tarball) (string, error) {
+	url, token, sum, err := tarballSource(pkg)
+	if err != nil {
+		return "", err
+	}
+	prefix := path.Clean(t.Prefix)
+	marker := prefix + "/" + tarballMarker
+	id := tarballID(url, sum)
+	name := profileName(pkg)
+
+	var b strings.Builder
+	b.WriteString("set -euo pipefail\n")
+	fmt.Fprintf(&b, "if [ \"$(cat %s 2>/dev/null)\" = %s ]; then echo %s; exit 0; fi\n",
+		shellQuote(marker), shellQuote(id), shellQuote(prefix+" is up to date, skipping download"))
+
+	auth := ""
+	if token != "" {
+		auth = " -H " + shellQuote("Authorization: Bearer "+token)
+	}
+	fmt.Fprintf(&b, "curl -fsSL%s %s -o %s\n", auth, shellQuote(url), tarballDownloadPath)
+	if sum != "" {
+		fmt.Fprintf(&b, "echo %s | sha256sum -c --quiet -\n", shellQuote(sum+"  "+tarballDownloadPath))
+	}
+	fmt.Fprintf(&b, "mkdir -p %s\n", shellQuote(prefix))
+	strip := ""
+	if t.StripComponents > 0 {
+		strip = fmt.Sprintf(" --strip-components=%d", t.StripComponents)
+	}
+	// GNU tar detects gzip/xz/zstd/bzip2 compression from the file itself.
+	fmt.Fprintf(&b, "tar -xf %s%s -C %s\n", tarballDownloadPath, strip, shellQuote(prefix))
+	fmt.Fprintf(&b, "rm -f %s\n", tarballDownloadPath)
+
+	var binDirs, libDirs []string
+	for _, d := range t.BinDirs {
+		dir := path.Join(prefix, d)
+		binDirs = append(binDirs, dir)
+		// Non-login shells (the agent's own commands) do not read
+		// /etc/profile.d, so binaries are also linked onto the default PATH.
+		fmt.Fprintf(&b, "for f in %s/*; do if [ -f \"$f\" ] && [ -x \"$f\" ]; then ln -sf \"$f\" /usr/local/bin/; fi; done\n",
+			shellQuote(dir))
+	}
+	for _, d := range t.LibDirs {
+		libDirs = append(libDirs, path.Join(prefix, d))
+	}
+	profile := []string{
+		`export PATH="` + strings.Join(binDirs, ":") + `:$PATH"`,
+	}
+	if len(libDirs) > 0 {
+		profile = append(profile, `export LD_LIBRARY_PATH="`+strings.Join(libDirs, ":")+`${LD_LIBRARY_PATH:+:$LD_LIBRARY_PATH}"`)
+		fmt.Fprintf(&b, "printf '%%s\\n'%s > /etc/ld.so.conf.d/%s.conf\n", joinArgs(quoteAll(libDirs)), name)
+		b.WriteString("ldconfig\n")
+	}
+	fmt.Fprintf(&b, "printf '%%s\\n'%s > /etc/profile.d/%s.sh\n", joinArgs(quoteAll(profile)), name)
+
+	for _, u := range t.Units {
+		body, err := renderUnit(u, tarballUnit{Name: u.Name, Prefix: prefix, Version: pkg.DbVersion})
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "cat > %s/%s <<'STROPPY_UNIT'\n%s\nSTROPPY_UNIT\n", systemdDir, u.Name, strings.TrimRight(body, "\n"))
+	}
+	if len(t.Units) > 0 {
+		// Containers without systemd still get the unit files for reference.
+		b.WriteString("systemctl daemon-reload 2>/dev/null || true\n")
+	}
+	for _, line := range t.PostInstall {
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "echo %s > %s\n", shellQuote(id), shellQuote(marker))
+	return b.String(), nil
+}
+
+func renderUnit(u types.SystemdUnit, data tarballUnit) (string, error) {
+	tmpl, err := template.New(u.Name).Parse(u.Template)
+	if err != nil {
+		return "", fmt.Errorf("unit %s: %w", u.Name, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("unit %s: %w", u.Name, err)
+	}
+	return b.String(), nil
+}
+
+// profileName names the /etc/profile.d and ld.so.conf.d files of a package.
+func profileName(pkg types.Package) string {
+	name := "stroppy-" + pkg.DbKind
+	if pkg.DbVersion != "" {
+		name += "-" + pkg.DbVersion
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '-'
+	}, name)
+}
@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+func TestRenderTarballScript_URL(t *testing.T) {
+	pkg := types.Package{
+		Name: "pg-custom", DbKind: "postgres", DbVersion: "17",
+		Tarball: &types.Tarball{
+			URL:             "https://example.com/pg-17.tar.xz",
+			SHA256:          strings.Repeat("a", 64),
+			StripComponents: 1,
+			Units: []types.SystemdUnit{{
+				Name:     "pg-custom.service",
+				Template: "[Service]\nExecStart={{.Prefix}}/bin/postgres -D /var/lib/pg/{{.Version}}\n",
+			}},
+		},
+	}
+	script, err := renderTarballScript(pkg, pkg.Tarball.WithDefaults(pkg.DbKind, pkg.DbVersion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"curl -fsSL 'https://example.com/pg-17.tar.xz' -o " + tarballDownloadPath,
+		"sha256sum -c --quiet -",
+		"tar -xf " + tarballDownloadPath + " --strip-components=1 -C '/usr/lib/postgresql/17'",
+		"ln -sf \"$f\" /usr/local/bin/",
+		"> /etc/ld.so.conf.d/stroppy-postgres-17.conf",
+		`'export PATH="/usr/lib/postgresql/17/bin:$PATH"'`,
+		"LD_LIBRARY_PATH=\"/usr/lib/postgresql/17/lib",
+		"ExecStart=/usr/lib/postgresql/17/bin/postgres -D /var/lib/pg/17\n",
+		"cat > /etc/systemd/system/pg-custom.service",
+		"pg_createcluster 17 main",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+	if strings.Contains(script, "Authorization") {
+		t.Error("a public URL must not get the server token")
+	}
+}
+
+func TestRenderTarballScript_Uploaded(t *testing.T) {
+	pkg := types.Package{
+		Name: "ydb-nightly", DbKind: "ydb", DbVersion: "25.3",
+		DebFilename: "http://server/api/v1/packages/p1/deb", DebToken: "tok",
+		Tarball: &types.Tarball{},
+	}
+	script, err := renderTarballScript(pkg, pkg.Tarball.WithDefaults(pkg.DbKind, pkg.DbVersion))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"'Authorization: Bearer tok'",
+		"'http://server/api/v1/packages/p1/deb'",
+		"-C '/opt/ydb'",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+
+	pkg.DebFilename = ""
+	if _, err := renderTarballScript(pkg, *pkg.Tarball); err == nil {
+		t.Error("a tarball without url or upload must fail")
+	}
+}
+
+func TestRenderTarballScript_BadUnitTemplate(t *testing.T) {
+	pkg := types.Package{DbKind: "mysql", Tarball: &types.Tarball{
+		URL:   "https://example.com/mysql.tar.gz",
+		Units: []types.SystemdUnit{{Name: "mysql.service", Template: "{{.DataDir}}"}},
+	}}
+	if _, err := renderTarballScript(pkg, pkg.Tarball.WithDefaults(pkg.DbKind, "")); err == nil {
+		t.Error("unknown template field must fail")
+	}
+}
@@ -0,0 +1,216 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// TuningConfig is the agent payload for applying an OS tuning profile.
+type TuningConfig struct {
+	Profile types.TuningProfile `json:"profile"`
+}
+
+const (
+	tuningSysctlFile  = "/etc/sysctl.d/60-stroppy-tuning.conf"
+	tuningLimitsFile  = "/etc/security/limits.d/60-stroppy-tuning.conf"
+	tuningSystemdFile = "/etc/systemd/system.conf.d/60-stroppy-tuning.conf"
+
+	// tuningOutputPrefix marks readback lines in the script output so they
+	// can be told apart from warnings and other noise.
+	tuningOutputPrefix = "tuning:"
+)
+
+// watchedSysctls are always reported, even for the "default" profile, so that
+// runs on differently provisioned images can be compared.
+var watchedSysctls = []string{
+	"vm.swappiness",
+	"vm.dirty_ratio",
+	"vm.dirty_background_ratio",
+	"vm.nr_hugepages",
+	"kernel.sched_autogroup_enabled",
+	"net.core.somaxconn",
+}
+
+// watchedUlimits are reported as the systemd defaults DB services inherit.
+var watchedUlimits = []string{"nofile", "nproc", "memlock"}
+
+// systemdLimitNames maps limits.conf items to systemd DefaultLimit* keys.
+var systemdLimitNames = map[string]string{
+	"nofile":  "NOFILE",
+	"nproc":   "NPROC",
+	"memlock": "MEMLOCK",
+	"core":    "CORE",
+	"stack":   "STACK",
+}
+
+// renderTuningScript builds a best-effort bash script applying the profile.
+// A setting the host refuses (e.g. no cpufreq in a VM) only prints a warning:
+// the readback afterwards records what actually took effect.
+// Returns "" when the profile changes nothing.
+func renderTuningScript(p types.TuningProfile) string {
+	var b strings.Builder
+
+	if len(p.Sysctls) > 0 {
+		keys := sortedKeys(p.Sysctls)
+		b.WriteString("cat > " + tuningSysctlFile + " <<'EOF'\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "%s = %s\n", k, p.Sysctls[k])
+		}
+		b.WriteString("EOF\n")
+		for _, k := range keys {
+			fmt.Fprintf(&b, "sysctl -w %s >/dev/null || warn %s\n",
+				shellQuote(k+"="+p.Sysctls[k]), shellQuote("sysctl "+k))
+		}
+	}
+
+	if p.TransparentHugepages != "" {
+		fmt.Fprintf(&b, "for f in /sys/kernel/mm/transparent_hugepage/enabled /sys/kernel/mm/transparent_hugepage/defrag; do echo %s > \"$f\" 2>/dev/null || warn \"thp $f\"; done\n",
+			shellQuote(p.TransparentHugepages))
+	}
+
+	if p.HugePagesPercent > 0 {
+		fmt.Fprintf(&b, "pages=$(awk '/^MemTotal:/ {printf \"%%d\", $2 * %d / 100 / 2048}' /proc/meminfo)\n", p.HugePagesPercent)
+		b.WriteString("sysctl -w vm.nr_hugepages=\"$pages\" >/dev/null || warn 'huge pages'\n")
+	}
+
+	if p.IOScheduler != "" {
+		fmt.Fprintf(&b, `for q in /sys/block/*/queue/scheduler; do
+  dev=${q#/sys/block/}; dev=${dev%%%%/*}
+  case "$dev" in loop*|ram*|sr*|zram*) continue;; esac
+  echo %s > "$q" 2>/dev/null || warn "io scheduler $dev"
+done
+`, shellQuote(p.IOScheduler))
+	}
+
+	if p.CPUGovernor != "" {
+		fmt.Fprintf(&b, `for g in /sys/devices/system/cpu/cpu*/cpufreq/scaling_governor; do
+  [ -e "$g" ] || { warn 'cpufreq not available'; break; }
+  echo %s > "$g" 2>/dev/null || warn "governor $g"
+done
+`, shellQuote(p.CPUGovernor))
+	}
+
+	if len(p.Ulimits) > 0 {
+		keys := sortedKeys(p.Ulimits)
+		b.WriteString("cat > " + tuningLimitsFile + " <<'EOF'\n")
+		for _, k := range keys {
+			for _, who := range []string{"*", "root"} {
+				fmt.Fprintf(&b, "%s soft %s %s\n%s hard %s %s\n", who, k, p.Ulimits[k], who, k, p.Ulimits[k])
+			}
+		}
+		b.WriteString("EOF\n")
+		b.WriteString("mkdir -p /etc/systemd/system.conf.d\n")
+		b.WriteString("cat > " + tuningSystemdFile + " <<'EOF'\n[Manager]\n")
+		for _, k := range keys {
+			name, ok := systemdLimitNames[k]
+			if !ok {
+				continue
+			}
+			v := p.Ulimits[k]
+			if v == "unlimited" {
+				v = "infinity"
+			}
+			fmt.Fprintf(&b, "DefaultLimit%s=%s\n", name, v)
+		}
+		b.WriteString("EOF\n")
+		b.WriteString("if command -v systemctl >/dev/null 2>&1; then systemctl daemon-reexec || warn 'systemd reexec'; fi\n")
+	}
+
+	if b.Len() == 0 {
+		return ""
+	}
+	return "warn() { echo \"WARN: tuning: $*\" >&2; }\n" + b.String()
+}
+
+// renderTuningReadback builds a script printing the effective value of every
+// setting the profile touches plus the watched baseline and a few machine
+// facts (CPUs, memory, kernel), one "tuning:<key>=<value>" line each.
+func renderTuningReadback(p types.TuningProfile) string {
+	var b strings.Builder
+	b.WriteString("emit() { printf '" + tuningOutputPrefix + "%s=%s\\n' \"$1\" \"${2:-n/a}\"; }\n")
+
+	sysctls := append([]string{}, watchedSysctls...)
+	for k := range p.Sysctls {
+		sysctls = append(sysctls, k)
+	}
+	for _, k := range dedupSorted(sysctls) {
+		fmt.Fprintf(&b, "emit %s \"$(sysctl -n %s 2>/dev/null)\"\n", shellQuote("sysctl."+k), shellQuote(k))
+	}
+
+	b.WriteString("emit transparent_hugepages \"$(sed -n 's/.*\\[\\(.*\\)\\].*/\\1/p' /sys/kernel/mm/transparent_hugepage/enabled 2>/dev/null)\"\n")
+	b.WriteString(`for q in /sys/block/*/queue/scheduler; do
+  [ -e "$q" ] || break
+  dev=${q#/sys/block/}; dev=${dev%%/*}
+  case "$dev" in loop*|ram*|sr*|zram*) continue;; esac
+  emit "io_scheduler.$dev" "$(sed -n 's/.*\[\(.*\)\].*/\1/p' "$q" 2>/dev/null)"
+done
+`)
+	b.WriteString("emit cpu_governor \"$(cat /sys/devices/system/cpu/cpu0/cpufreq/scaling_governor 2>/dev/null)\"\n")
+
+	// Machine facts: what the provider actually handed out, so comparisons
+	// can tell a config change from a hardware change.
+	b.WriteString(`emit machine.cpus "$(nproc 2>/dev/null)"
+emit machine.memory_mb "$(awk '/^MemTotal:/ {printf "%d", $2 / 1024}' /proc/meminfo 2>/dev/null)"
+emit machine.cpu_model "$(sed -n 's/^model name[[:space:]]*: //p' /proc/cpuinfo 2>/dev/null | head -n1)"
+emit machine.kernel "$(uname -r 2>/dev/null)"
+emit machine.os "$(. /etc/os-release 2>/dev/null && echo "$PRETTY_NAME")"
+`)
+
+	ulimits := append([]string{}, watchedUlimits...)
+	for k := range p.Ulimits {
+		ulimits = append(ulimits, k)
+	}
+	for _, k := range dedupSorted(ulimits) {
+		name, ok := systemdLimitNames[k]
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&b, "emit %s \"$(systemctl show -p DefaultLimit%s --value 2>/dev/null)\"\n", shellQuote("ulimit."+k), name)
+	}
+	return b.String()
+}
+
+// parseTuningOutput extracts "tuning:<key>=<value>" lines from script output.
+func parseTuningOutput(out string) map[string]string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), tuningOutputPrefix)
+		if !ok {
+			continue
+		}
+		k, v, ok := strings.Cut(rest, "=")
+		if !ok || k == "" {
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+// shellQuote wraps s in single quotes for safe use as one bash word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func dedupSorted(s []string) []string {
+	sort.Strings(s)
+	out := s[:0]
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,54 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+func TestRenderTuningScript_DefaultIsNoop(t *testing.T) {
+	p, _ := types.ResolveTuningProfile(nil, types.TuningDefault)
+	if script := renderTuningScript(p); script != "" {
+		t.Errorf("default profile should not change anything, got:\n%s", script)
+	}
+}
+
+func TestRenderTuningScript_PostgresOLTP(t *testing.T) {
+	p, _ := types.ResolveTuningProfile(nil, types.TuningPostgresOLTP)
+	script := renderTuningScript(p)
+	for _, want := range []string{
+		"sysctl -w 'vm.swappiness=1'",
+		"echo 'never' > \"$f\"",
+		"echo 'none' > \"$q\"",
+		"echo 'performance' > \"$g\"",
+		"* soft nofile 1048576",
+		"DefaultLimitMEMLOCK=infinity",
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script missing %q:\n%s", want, script)
+		}
+	}
+}
+
+func TestParseTuningOutput(t *testing.T) {
+	out := "WARN: tuning: cpufreq not available\n" +
+		"tuning:sysctl.vm.swappiness=1\n" +
+		"tuning:sysctl.net.ipv4.ip_local_port_range=1024\t65535\n" +
+		"tuning:cpu_governor=n/a\n" +
+		"noise=ignored\n"
+	got := parseTuningOutput(out)
+	want := map[string]string{
+		"sysctl.vm.swappiness":                "1",
+		"sysctl.net.ipv4.ip_local_port_range": "1024\t65535",
+		"cpu_governor":                        "n/a",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
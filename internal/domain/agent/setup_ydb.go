@@ -1,8 +1,14 @@
 package agent
 
+import (
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
 // YDBInstallConfig is the agent payload for YDB binary installation.
+// Without a package, the ydbd release tarball for Version is installed.
 type YDBInstallConfig struct {
-	Version string `json:"version"`
+	Version string         `json:"version"`
+	Package *types.Package `json:"package,omitempty"`
 }
 
 // YDBStaticConfig is the agent payload for starting a YDB static (storage) node.
@@ -15,6 +21,8 @@ type YDBStaticConfig struct {
 	DiskGB         int               `json:"disk_gb"`   // allocated disk size; pdisk is sized to this when file-backed
 	MemoryMB       int               `json:"memory_mb"` // total machine RAM for memory limits
 	CPUs           int               `json:"cpus"`      // vCPUs for actor system tuning
+	DiskType       string            `json:"disk_type,omitempty"` // cloud disk type; picks the pdisk drive type when auto-tuning
+	AutoTune       string            `json:"auto_tune,omitempty"` // types.AutoTune* profile; empty keeps YDB's own auto config only
 	FaultTolerance string            `json:"fault_tolerance"`
 	Options        map[string]string `json:"options,omitempty"`
 	// ConfOverride, when non-empty, replaces the rendered YDB config.yaml
@@ -37,6 +45,8 @@ type YDBDatabaseConfig struct {
 	DatabasePath    string            `json:"database_path"` // /Root/testdb
 	MemoryMB        int               `json:"memory_mb"`     // total machine RAM for memory limits
 	CPUs            int               `json:"cpus"`          // vCPUs for actor system tuning
+	DiskType        string            `json:"disk_type,omitempty"` // cloud disk type; picks the pdisk drive type when auto-tuning
+	AutoTune        string            `json:"auto_tune,omitempty"` // types.AutoTune* profile; empty keeps YDB's own auto config only
 	FaultTolerance  string            `json:"fault_tolerance,omitempty"`
 	StorageHosts    []string          `json:"storage_hosts,omitempty"`     // mirrors YDBStaticConfig.Hosts; needed to substitute placeholders in the database yaml
 	BlockDevicePath string            `json:"block_device_path,omitempty"` // mirrors the storage value so the database yaml's path: lines stay consistent with the cluster's
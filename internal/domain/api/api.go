@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
-	"os"
 	"runtime"
 	"strings"
 
@@ -16,6 +15,7 @@ import (
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/agent"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/run"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/runconfig"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres"
 )
@@ -41,6 +41,11 @@ type App struct {
 	// jwtIssuer generates JWT tokens for agent auth.
 	// Set by Server after construction.
 	jwtIssuer *auth.JWTIssuer
+	// nodeDoneFunc is called after each node of a run completes.
+	// Set by Server after construction.
+	nodeDoneFunc func(tenantID, runID, nodeID string)
+	// packageBuilds caches source package builds per commit; nil without a database.
+	packageBuilds run.PackageBuildCache
 }
 
 // Config holds application-level settings.
@@ -55,14 +60,21 @@ func New(cfg Config) *App {
 		cfg.Logger = zap.NewNop()
 	}
 
-	return &App{
+	a := &App{
 		storage: postgres.NewRunStorage(cfg.Pool),
 		logger:  cfg.Logger,
 	}
+	if cfg.Pool != nil {
+		a.packageBuilds = postgres.NewPackageBuildStore(cfg.Pool)
+	}
+	return a
 }
 
 // Start builds a DAG from RunConfig and executes it.
 func (a *App) Start(ctx context.Context, tenantID string, cfg types.RunConfig) error {
+	if _, err := types.UpgradeRunConfig(&cfg); err != nil {
+		return fmt.Errorf("api: validate: %w", err)
+	}
 	if err := run.ValidateConfig(cfg); err != nil {
 		return fmt.Errorf("api: validate: %w", err)
 	}
@@ -78,6 +90,9 @@ func (a *App) Start(ctx context.Context, tenantID string, cfg types.RunConfig) e
 	if err != nil {
 		return fmt.Errorf("api: build graph: %w", err)
 	}
+	if facts := run.PackageFacts(cfg.ResolvedPackage); facts != nil {
+		deps.State.SetEffectiveConfig(run.PackageConfigComponent, facts)
+	}
 
 	exec := dag.NewExecutor(tenantID, cfg.ID, graph, a.storage, a.logger, a.sink)
 
@@ -89,12 +104,18 @@ func (a *App) Start(ctx context.Context, tenantID string, cfg types.RunConfig) e
 		rs.RunConfig = cfgJSON
 		return rs
 	})
+	a.wireNodeDone(exec, tenantID, cfg.ID)
 
 	return exec.Run(ctx)
 }
 
 // Validate checks that a RunConfig produces a valid DAG without executing it.
+// Deprecated fields are upgraded first; use types.UpgradeRunConfig to see
+// the warnings.
 func (a *App) Validate(cfg types.RunConfig) error {
+	if _, err := types.UpgradeRunConfig(&cfg); err != nil {
+		return err
+	}
 	if err := run.ValidateConfig(cfg); err != nil {
 		return err
 	}
@@ -107,6 +128,9 @@ func (a *App) Validate(cfg types.RunConfig) error {
 
 // DryRun builds the DAG and returns graph JSON + the resolved RunConfig.
 func (a *App) DryRun(cfg types.RunConfig) ([]byte, *types.RunConfig, error) {
+	if _, err := types.UpgradeRunConfig(&cfg); err != nil {
+		return nil, nil, err
+	}
 	// Fold MachineOverride into the topology so the resolved config returned
 	// to the client (and rendered in the review-step textarea) reflects the
 	// final database-node sizing without any hidden override.
@@ -134,6 +158,9 @@ func (a *App) RecoverRun(ctx context.Context, tenantID string, snap *dag.Snapsho
 	if err := json.Unmarshal(snap.State.RunConfig, &cfg); err != nil {
 		return fmt.Errorf("api: unmarshal run config: %w", err)
 	}
+	if _, err := types.UpgradeRunConfig(&cfg); err != nil {
+		return fmt.Errorf("api: upgrade run config: %w", err)
+	}
 
 	run.FillMachinesFromTopology(&cfg)
 
@@ -164,7 +191,7 @@ func (a *App) RecoverRun(ctx context.Context, tenantID string, snap *dag.Snapsho
 	// Mark previously completed nodes so they are skipped.
 	for _, ns := range snap.Nodes {
 		if ns.Status == dag.StatusDone {
-			exec.MarkNodeDone(ns.ID)
+			exec.RestoreNode(ns)
 		}
 	}
 
@@ -176,24 +203,32 @@ func (a *App) RecoverRun(ctx context.Context, tenantID string, snap *dag.Snapsho
 		rs.RunConfig = cfgJSON
 		return rs
 	})
+	a.wireNodeDone(exec, tenantID, cfg.ID)
 
 	return exec.Run(ctx)
 }
 
+// wireNodeDone forwards the executor's node completions to nodeDoneFunc.
+func (a *App) wireNodeDone(exec *dag.Executor, tenantID, runID string) {
+	if a.nodeDoneFunc == nil {
+		return
+	}
+	exec.SetNodeDoneHook(func(_ context.Context, nodeID string) {
+		a.nodeDoneFunc(tenantID, runID, nodeID)
+	})
+}
+
 // Storage returns the DAG storage (used by server for status queries).
 func (a *App) Storage() dag.Storage {
 	return a.storage
 }
 
-// LoadConfig reads a RunConfig from a JSON file.
-func LoadConfig(path string) (types.RunConfig, error) {
-	data, err := os.ReadFile(path)
+// LoadConfig reads a RunConfig from a YAML or JSON file, resolving ${VAR}
+// references, extends/include and the path=value overrides in sets.
+func LoadConfig(path string, sets []string) (types.RunConfig, error) {
+	cfg, err := runconfig.Load(path, runconfig.Options{Set: sets})
 	if err != nil {
-		return types.RunConfig{}, fmt.Errorf("api: read config: %w", err)
-	}
-	var cfg types.RunConfig
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return types.RunConfig{}, fmt.Errorf("api: parse config: %w", err)
+		return types.RunConfig{}, fmt.Errorf("api: %w", err)
 	}
 	return cfg, nil
 }
@@ -205,7 +240,7 @@ func (a *App) buildDeps(tenantID string, cfg types.RunConfig) (run.Deps, func(),
 		// Fallback for CLI mode (no server) — direct HTTP push.
 		cl = agent.NewHTTPClient()
 	}
-	deps := run.Deps{Client: cl, State: state, MonitoringURL: a.monitoringURL, MonitoringToken: a.monitoringToken, TenantID: tenantID, JWTIssuer: a.jwtIssuer}
+	deps := run.Deps{Client: cl, State: state, MonitoringURL: a.monitoringURL, MonitoringToken: a.monitoringToken, TenantID: tenantID, JWTIssuer: a.jwtIssuer, PackageBuilds: a.packageBuilds}
 	noop := func() {}
 
 	// Resolve per-tenant victoria accountID.
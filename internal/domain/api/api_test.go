@@ -113,3 +113,22 @@ func TestDryRun_UnsupportedKindErrors(t *testing.T) {
 		t.Fatal("expected error for unsupported kind, got nil")
 	}
 }
+
+func TestDryRun_UpgradesDeprecatedFields(t *testing.T) {
+	app := newTestApp(t)
+	cfg := postgresSingleConfig()
+
+	_, resolved, err := app.DryRun(cfg)
+	if err != nil {
+		t.Fatalf("DryRun() error: %v", err)
+	}
+	s := resolved.Stroppy
+	if resolved.APIVersion != types.RunConfigAPIVersion || s.Script != "ycsb" || s.VUs != 4 || s.Workload != "" || s.Workers != 0 {
+		t.Errorf("resolved config not upgraded: api_version %q, stroppy %+v", resolved.APIVersion, s)
+	}
+
+	cfg.APIVersion = types.RunConfigAPIVersion
+	if err := app.Validate(cfg); err == nil {
+		t.Error("a v2 config with deprecated fields must not validate")
+	}
+}
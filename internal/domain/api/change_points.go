@@ -0,0 +1,233 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/metrics"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/webhook"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+const (
+	// changePointRuns is how many of a tag's latest runs are scanned.
+	changePointRuns = 200
+	// changeAlertMaxAge keeps the first scan of a long history from
+	// alerting on shifts long past; those are still recorded.
+	changeAlertMaxAge = 7 * 24 * time.Hour
+)
+
+// metricEvent is a recorded change point, as listed by the API and sent to
+// webhooks.
+type metricEvent struct {
+	ID            string    `json:"id"`
+	Tag           string    `json:"tag"`
+	Key           string    `json:"key"`
+	Kind          string    `json:"kind"`
+	LastBeforeRun string    `json:"last_before_run"`
+	FirstAfterRun string    `json:"first_after_run"`
+	Before        float64   `json:"before"`
+	After         float64   `json:"after"`
+	ChangePct     float64   `json:"change_pct"`
+	PValue        float64   `json:"p_value"`
+	Time          time.Time `json:"time"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+func metricEventFromRow(row pgdb.MetricEvent) metricEvent {
+	return metricEvent{
+		ID: row.ID, Tag: row.Tag, Key: row.MetricKey, Kind: row.Kind,
+		LastBeforeRun: row.LastBeforeRun, FirstAfterRun: row.FirstAfterRun,
+		Before: row.BeforeMean, After: row.AfterMean, ChangePct: row.ChangePct, PValue: row.PValue,
+		Time: row.RunTime.Time, DetectedAt: row.DetectedAt.Time,
+	}
+}
+
+// WatchChangePoints scans every tagged series of stored run results for
+// change points, now and then every interval, until ctx ends. New results
+// are also scanned as they are stored; the sweep catches the rest.
+func (s *Server) WatchChangePoints(ctx context.Context, interval time.Duration) {
+	if s.pool == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		s.scanChangePoints(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Server) scanChangePoints(ctx context.Context) {
+	tags, err := pgdb.New(s.pool).ListRunResultTags(ctx)
+	if err != nil {
+		s.logger.Error("change points: list tags", zap.Error(err))
+		return
+	}
+	for _, t := range tags {
+		if err := s.detectTagChanges(ctx, t.TenantID, t.Tag); err != nil {
+			s.logger.Warn("change points", zap.String("tenant", t.TenantID), zap.String("tag", t.Tag), zap.Error(err))
+		}
+	}
+}
+
+// detectTagChanges runs change-point detection over every metric of a
+// tag's latest runs, records the changes not seen before and alerts the
+// tenant's webhooks about them.
+func (s *Server) detectTagChanges(ctx context.Context, tenantID, tag string) error {
+	q := pgdb.New(s.pool)
+	rows, err := q.ListRunResults(ctx, pgdb.ListRunResultsParams{TenantID: tenantID, Tag: tag, MaxRows: changePointRuns})
+	if err != nil {
+		return err
+	}
+	slices.Reverse(rows) // oldest first
+
+	var runs []*metrics.RunMetrics
+	var trends []*metrics.Trend
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		rm := &metrics.RunMetrics{}
+		if err := json.Unmarshal([]byte(row.Metrics), rm); err != nil {
+			continue
+		}
+		runs = append(runs, rm)
+		for _, m := range rm.Metrics {
+			if !seen[m.Key] {
+				seen[m.Key] = true
+				trends = append(trends, metrics.NewTrend(m.Key))
+			}
+		}
+	}
+	for i, rm := range runs {
+		for _, t := range trends {
+			t.Add(rm, rows[i].WindowStart.Time, rows[i].ConfigHash, rows[i].Tags)
+		}
+	}
+
+	existing, err := q.ListMetricEvents(ctx, pgdb.ListMetricEventsParams{TenantID: tenantID, Tag: tag, MaxRows: 1000})
+	if err != nil {
+		return err
+	}
+	var sender *webhook.Sender
+	for _, t := range trends {
+		opts := metrics.ChangePointOptions{MinChangePct: t.Threshold}
+		position := make(map[string]runPosition, len(t.Points))
+		seen := map[string]int{}
+		for _, p := range t.Points {
+			position[p.RunID] = runPosition{config: p.ConfigHash, n: seen[p.ConfigHash]}
+			seen[p.ConfigHash]++
+		}
+		for _, c := range t.ChangePoints(opts) {
+			if knownChange(existing, position, c) {
+				continue
+			}
+			ev := metricEvent{
+				ID: uuid.New().String(), Tag: tag, Key: c.Key, Kind: c.Kind,
+				LastBeforeRun: c.LastBeforeRun, FirstAfterRun: c.FirstAfterRun,
+				Before: c.Before, After: c.After, ChangePct: c.ChangePct, PValue: c.PValue,
+				Time: c.Time, DetectedAt: time.Now(),
+			}
+			n, err := q.CreateMetricEvent(ctx, pgdb.CreateMetricEventParams{
+				ID: ev.ID, TenantID: tenantID, Tag: tag, MetricKey: ev.Key, Kind: ev.Kind,
+				LastBeforeRun: ev.LastBeforeRun, FirstAfterRun: ev.FirstAfterRun,
+				BeforeMean: ev.Before, AfterMean: ev.After, ChangePct: ev.ChangePct, PValue: ev.PValue,
+				RunTime: pgtype.Timestamptz{Time: ev.Time, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			if n == 0 || time.Since(ev.Time) > changeAlertMaxAge {
+				continue
+			}
+			s.logger.Info("change point", zap.String("tenant", tenantID), zap.String("tag", tag),
+				zap.String("metric", ev.Key), zap.String("kind", ev.Kind), zap.String("run", ev.FirstAfterRun),
+				zap.Float64("change_pct", ev.ChangePct))
+			if sender == nil {
+				sender = webhook.NewSender(s.settingsForTenant(tenantID).Webhooks, s.logger)
+			}
+			event := webhook.EventMetricRegression
+			if ev.Kind == metrics.ChangeImprovement {
+				event = webhook.EventMetricImprovement
+			}
+			sender.Send(context.Background(), webhook.Payload{Event: event, RunID: ev.FirstAfterRun, Data: ev})
+		}
+	}
+	return nil
+}
+
+// runPosition places a run among the runs of its config, the series a
+// change point is searched in.
+type runPosition struct {
+	config string
+	n      int
+}
+
+// knownChange reports whether a recorded event of the same metric and kind
+// sits within a segment of c among the runs of its config. Binary
+// segmentation can move a change by a run or two as more runs arrive; that
+// is the same shift, not a new one.
+func knownChange(existing []pgdb.MetricEvent, position map[string]runPosition, c metrics.TrendChange) bool {
+	cur := position[c.FirstAfterRun]
+	for _, e := range existing {
+		if e.MetricKey != c.Key || e.Kind != c.Kind {
+			continue
+		}
+		at, ok := position[e.FirstAfterRun]
+		if ok && at.config == cur.config && abs(at.n-cur.n) < metrics.DefaultMinSegment {
+			return true
+		}
+	}
+	return false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// listMetricEvents handles GET /api/v1/metrics/events?tag=nightly&limit=100:
+// recorded change points, newest first.
+func (s *Server) listMetricEvents(w http.ResponseWriter, r *http.Request) {
+	if s.pool == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "events need a database"})
+		return
+	}
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be 1 to 1000"})
+			return
+		}
+		limit = n
+	}
+	rows, err := pgdb.New(s.pool).ListMetricEvents(r.Context(), pgdb.ListMetricEventsParams{
+		TenantID: auth.TenantID(r.Context()),
+		Tag:      r.URL.Query().Get("tag"),
+		MaxRows:  int32(limit),
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	events := make([]metricEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, metricEventFromRow(row))
+	}
+	writeJSON(w, http.StatusOK, events)
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/metrics"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/webhook"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+// A regression gate is a policy stored under a baseline name. A run
+// submitted with that name as its gate is checked against the baseline's
+// run once its result is stored, and passes or fails.
+
+// Gate statuses reported with a run's status.
+const (
+	gatePending = "pending" // the workload has not finished or is being checked
+	gatePassed  = "passed"
+	gateFailed  = "failed"
+	gateError   = "error"   // the check could not be made
+	gateSkipped = "skipped" // the workload did not finish
+)
+
+// gateStatus is a run's gate outcome.
+type gateStatus struct {
+	Name        string              `json:"name"`
+	Status      string              `json:"status"`
+	BaselineRun string              `json:"baseline_run,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	Result      *metrics.GateResult `json:"result,omitempty"`
+	EvaluatedAt *time.Time          `json:"evaluated_at,omitempty"`
+}
+
+func gateStatusFromRow(row pgdb.RunGate) *gateStatus {
+	st := &gateStatus{Name: row.Gate, Status: row.Status, BaselineRun: row.BaselineRun, Error: row.Error}
+	if row.Result != "" {
+		var res metrics.GateResult
+		if json.Unmarshal([]byte(row.Result), &res) == nil {
+			st.Result = &res
+		}
+	}
+	if row.EvaluatedAt.Valid {
+		st.EvaluatedAt = &row.EvaluatedAt.Time
+	}
+	return st
+}
+
+// runGateName returns the gate of a run's recorded config.
+func runGateName(snap *dag.Snapshot) string {
+	if snap == nil || snap.State == nil || len(snap.State.RunConfig) == 0 {
+		return ""
+	}
+	var cfg struct {
+		Gate string `json:"gate"`
+	}
+	_ = json.Unmarshal(snap.State.RunConfig, &cfg)
+	return cfg.Gate
+}
+
+// checkGateConfig reports why a run cannot be submitted with gate name.
+func (s *Server) checkGateConfig(ctx context.Context, tenantID, name string) error {
+	if s.pool == nil || s.monitoringURL == "" {
+		return fmt.Errorf("gates need a database and monitoring")
+	}
+	_, err := pgdb.New(s.pool).GetGatePolicy(ctx, pgdb.GetGatePolicyParams{Name: name, TenantID: tenantID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("gate %q has no policy", name)
+	}
+	if err != nil {
+		return err
+	}
+	baseRun, err := s.app.Storage().GetBaseline(ctx, tenantID, name)
+	if err != nil {
+		return err
+	}
+	if baseRun == "" {
+		return fmt.Errorf("gate %q: baseline is not set", name)
+	}
+	return nil
+}
+
+// checkGate evaluates a run's gate, records the outcome and sends it to the
+// tenant's webhooks. rm is the run's result, or nil to look it up; a
+// collection error is recorded as the outcome so waiting clients finish.
+// A check already under way for the run is not repeated.
+func (s *Server) checkGate(ctx context.Context, tenantID, runID string, snap *dag.Snapshot, rm *metrics.RunMetrics, collectErr error) {
+	name := runGateName(snap)
+	if name == "" || s.pool == nil {
+		return
+	}
+	key := tenantID + "/" + runID
+	if _, busy := s.gatesInFlight.LoadOrStore(key, true); busy {
+		return
+	}
+	defer s.gatesInFlight.Delete(key)
+
+	st := &gateStatus{Name: name, Status: gateError}
+	if collectErr != nil {
+		st.Error = "collect run result: " + collectErr.Error()
+	} else if err := s.evaluateGate(ctx, tenantID, runID, snap, rm, st); err != nil {
+		st.Status, st.Error = gateError, err.Error()
+	}
+
+	var result string
+	if st.Result != nil {
+		data, _ := json.Marshal(st.Result)
+		result = string(data)
+	}
+	if err := pgdb.New(s.pool).SaveRunGate(ctx, pgdb.SaveRunGateParams{
+		RunID: runID, TenantID: tenantID, Gate: name, BaselineRun: st.BaselineRun,
+		Status: st.Status, Error: st.Error, Result: result,
+	}); err != nil {
+		s.logger.Warn("gate: save", zap.String("run", runID), zap.Error(err))
+		return
+	}
+	s.logger.Info("gate", zap.String("run", runID), zap.String("gate", name), zap.String("status", st.Status))
+
+	event := webhook.EventGateFailed
+	if st.Status == gatePassed {
+		event = webhook.EventGatePassed
+	}
+	webhook.NewSender(s.settingsForTenant(tenantID).Webhooks, s.logger).
+		Send(context.Background(), webhook.Payload{Event: event, RunID: runID, Error: st.Error, Data: st})
+}
+
+// evaluateGate checks the run's result against its gate's baseline run and
+// fills in st.
+func (s *Server) evaluateGate(ctx context.Context, tenantID, runID string, snap *dag.Snapshot, rm *metrics.RunMetrics, st *gateStatus) error {
+	row, err := pgdb.New(s.pool).GetGatePolicy(ctx, pgdb.GetGatePolicyParams{Name: st.Name, TenantID: tenantID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("gate %q has no policy", st.Name)
+	}
+	if err != nil {
+		return err
+	}
+	var policy metrics.GatePolicy
+	if err := json.Unmarshal([]byte(row.Policy), &policy); err != nil {
+		return fmt.Errorf("decode gate policy: %w", err)
+	}
+
+	baseRun, err := s.app.Storage().GetBaseline(ctx, tenantID, st.Name)
+	if err != nil {
+		return err
+	}
+	if baseRun == "" {
+		return fmt.Errorf("baseline %q is not set", st.Name)
+	}
+	st.BaselineRun = baseRun
+	baseSnap, err := s.app.storage.Load(ctx, tenantID, baseRun)
+	if err != nil {
+		return err
+	}
+	if baseSnap == nil {
+		return fmt.Errorf("baseline run %s not found", baseRun)
+	}
+
+	accountID, err := s.tenantAccountID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	base, err := s.runResult(ctx, tenantID, baseRun, baseSnap, s.metricsCollector(ctx, tenantID, accountID, extractDBKind(baseSnap)))
+	if err != nil {
+		return fmt.Errorf("baseline result: %w", err)
+	}
+	if rm == nil {
+		if rm, err = s.runResult(ctx, tenantID, runID, snap, s.metricsCollector(ctx, tenantID, accountID, extractDBKind(snap))); err != nil {
+			return err
+		}
+	}
+
+	st.Result = metrics.EvaluateGate(policy, base, rm)
+	st.Status = gateFailed
+	if st.Result.Passed {
+		st.Status = gatePassed
+	}
+	return nil
+}
+
+// runGateStatus returns the gate outcome reported with a run's status; nil
+// when the run has no gate. A settled run still pending, e.g. because the
+// server restarted before checking it, is checked in the background.
+func (s *Server) runGateStatus(ctx context.Context, tenantID, runID string, snap *dag.Snapshot) *gateStatus {
+	name := runGateName(snap)
+	if name == "" || s.pool == nil {
+		return nil
+	}
+	row, err := pgdb.New(s.pool).GetRunGate(ctx, pgdb.GetRunGateParams{RunID: runID, TenantID: tenantID})
+	if err == nil {
+		return gateStatusFromRow(row)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return &gateStatus{Name: name, Status: gateError, Error: err.Error()}
+	}
+
+	ns, _ := snap.Node(string(types.PhaseRunStroppy))
+	switch {
+	case ns.Status == dag.StatusFailed || ns.Status == dag.StatusCancelled,
+		ns.Status != dag.StatusDone && !snap.FinishedAt.IsZero():
+		return &gateStatus{Name: name, Status: gateSkipped}
+	case resultSettled(snap) && time.Since(workloadFinishedAt(snap)) > resultFlushDelay+resultCollectTimeout:
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), resultCollectTimeout)
+			defer cancel()
+			s.checkGate(ctx, tenantID, runID, snap, nil, nil)
+		}()
+	}
+	return &gateStatus{Name: name, Status: gatePending}
+}
+
+// ============================================================
+// Gate policy handlers
+// ============================================================
+
+type gatePolicyResponse struct {
+	Name      string             `json:"name"`
+	Policy    metrics.GatePolicy `json:"policy"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+func gatePolicyFromRow(row pgdb.GatePolicy) gatePolicyResponse {
+	out := gatePolicyResponse{Name: row.Name, UpdatedAt: row.UpdatedAt.Time}
+	_ = json.Unmarshal([]byte(row.Policy), &out.Policy)
+	return out
+}
+
+// setGatePolicy handles PUT /api/v1/baseline/{name}/gate.
+func (s *Server) setGatePolicy(w http.ResponseWriter, r *http.Request) {
+	if s.pool == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "gates need a database"})
+		return
+	}
+	tenantID := auth.TenantID(r.Context())
+	name := chi.URLParam(r, "name")
+	var policy metrics.GatePolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	data, _ := json.Marshal(policy)
+	q := pgdb.New(s.pool)
+	if err := q.SetGatePolicy(r.Context(), pgdb.SetGatePolicyParams{Name: name, TenantID: tenantID, Policy: string(data)}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	row, err := q.GetGatePolicy(r.Context(), pgdb.GetGatePolicyParams{Name: name, TenantID: tenantID})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, gatePolicyFromRow(row))
+}
+
+// getGatePolicy handles GET /api/v1/baseline/{name}/gate.
+func (s *Server) getGatePolicy(w http.ResponseWriter, r *http.Request) {
+	if s.pool == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "gates need a database"})
+		return
+	}
+	row, err := pgdb.New(s.pool).GetGatePolicy(r.Context(), pgdb.GetGatePolicyParams{
+		Name: chi.URLParam(r, "name"), TenantID: auth.TenantID(r.Context()),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "gate policy not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, gatePolicyFromRow(row))
+}
+
+// deleteGatePolicy handles DELETE /api/v1/baseline/{name}/gate.
+func (s *Server) deleteGatePolicy(w http.ResponseWriter, r *http.Request) {
+	if s.pool == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "gates need a database"})
+		return
+	}
+	n, err := pgdb.New(s.pool).DeleteGatePolicy(r.Context(), pgdb.DeleteGatePolicyParams{
+		Name: chi.URLParam(r, "name"), TenantID: auth.TenantID(r.Context()),
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "gate policy not found"})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listGatePolicies handles GET /api/v1/gates.
+func (s *Server) listGatePolicies(w http.ResponseWriter, r *http.Request) {
+	if s.pool == nil {
+		writeJSON(w, http.StatusOK, []gatePolicyResponse{})
+		return
+	}
+	rows, err := pgdb.New(s.pool).ListGatePolicies(r.Context(), auth.TenantID(r.Context()))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	out := make([]gatePolicyResponse, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, gatePolicyFromRow(row))
+	}
+	writeJSON(w, http.StatusOK, out)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/metrics"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+type metricDefItem struct {
+	ID string `json:"id"`
+	metrics.MetricDef
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func metricDefFromRow(row pgdb.MetricDef) metrics.MetricDef {
+	return metrics.MetricDef{
+		Key:       row.Key,
+		Name:      row.Name,
+		Query:     row.Query,
+		Unit:      row.Unit,
+		Direction: row.Direction,
+		Threshold: row.Threshold,
+		DBKinds:   row.DbKinds,
+	}
+}
+
+func metricDefRowToItem(row pgdb.MetricDef) metricDefItem {
+	return metricDefItem{
+		ID:        row.ID,
+		MetricDef: metricDefFromRow(row),
+		CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		UpdatedAt: row.UpdatedAt.Time.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// customMetricDefs loads the tenant's own metric definitions. Failing to
+// load them must not break metrics, so errors are logged and the built-ins
+// carry on alone.
+func (s *Server) customMetricDefs(ctx context.Context, tenantID string) []metrics.MetricDef {
+	if s.pool == nil {
+		return nil
+	}
+	rows, err := pgdb.New(s.pool).ListMetricDefs(ctx, tenantID)
+	if err != nil {
+		s.logger.Warn("load custom metric defs", zap.String("tenant", tenantID), zap.Error(err))
+		return nil
+	}
+	defs := make([]metrics.MetricDef, 0, len(rows))
+	for _, row := range rows {
+		defs = append(defs, metricDefFromRow(row))
+	}
+	return defs
+}
+
+// checkMetricQuery test-runs a definition's query against the tenant's
+// metrics store. Without monitoring configured the query is saved unchecked.
+func (s *Server) checkMetricQuery(ctx context.Context, tenantID string, def metrics.MetricDef) error {
+	if s.monitoringURL == "" {
+		return nil
+	}
+	accountID, err := s.tenantAccountID(ctx, tenantID)
+	if err != nil {
+		s.logger.Warn("check metric query: account", zap.String("tenant", tenantID), zap.Error(err))
+		return nil
+	}
+	return def.CheckQuery(ctx, s.metricsClient(accountID))
+}
+
+// ─── List ────────────────────────────────────────────────────────
+
+// listMetricDefs handles GET /api/v1/metrics/defs: the built-in metrics
+// (only those of ?db_kind= when given) and the tenant's custom ones.
+func (s *Server) listMetricDefs(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	dbKind := r.URL.Query().Get("db_kind")
+
+	builtin := make([]metrics.MetricDef, 0)
+	for _, def := range metrics.BuiltinDefs() {
+		if dbKind == "" || def.AppliesTo(dbKind) {
+			builtin = append(builtin, def)
+		}
+	}
+
+	rows, err := pgdb.New(s.pool).ListMetricDefs(r.Context(), tenantID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	custom := make([]metricDefItem, 0, len(rows))
+	for _, row := range rows {
+		if dbKind == "" || metricDefFromRow(row).AppliesTo(dbKind) {
+			custom = append(custom, metricDefRowToItem(row))
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"builtin": builtin, "custom": custom})
+}
+
+// ─── Create ──────────────────────────────────────────────────────
+
+func decodeMetricDef(r *http.Request) (metrics.MetricDef, error) {
+	var def metrics.MetricDef
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		return def, err
+	}
+	def.Key = strings.TrimSpace(def.Key)
+	def.Name = strings.TrimSpace(def.Name)
+	if def.DBKinds == nil {
+		def.DBKinds = []string{}
+	}
+	return def, nil
+}
+
+func (s *Server) createMetricDef(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	def, err := decodeMetricDef(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if err := def.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := s.checkMetricQuery(r.Context(), tenantID, def); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	id := uuid.New().String()
+	if err := pgdb.New(s.pool).CreateMetricDef(r.Context(), pgdb.CreateMetricDefParams{
+		ID: id, TenantID: tenantID, Key: def.Key, Name: def.Name, Query: def.Query, Unit: def.Unit,
+		Direction: def.Direction, Threshold: def.Threshold, DbKinds: def.DBKinds,
+	}); err != nil {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "key already exists"})
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// ─── Update ──────────────────────────────────────────────────────
+
+// updateMetricDef replaces a definition. The key is fixed: past runs were
+// compared under it.
+func (s *Server) updateMetricDef(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	id := chi.URLParam(r, "id")
+	q := pgdb.New(s.pool)
+
+	existing, err := q.GetMetricDef(r.Context(), pgdb.GetMetricDefParams{ID: id, TenantID: tenantID})
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "metric definition not found"})
+		return
+	}
+	def, err := decodeMetricDef(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if def.Key != "" && def.Key != existing.Key {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "key cannot be changed"})
+		return
+	}
+	def.Key = existing.Key
+	if err := def.Validate(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := s.checkMetricQuery(r.Context(), tenantID, def); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	if err := q.UpdateMetricDef(r.Context(), pgdb.UpdateMetricDefParams{
+		ID: id, TenantID: tenantID, Name: def.Name, Query: def.Query, Unit: def.Unit,
+		Direction: def.Direction, Threshold: def.Threshold, DbKinds: def.DBKinds,
+	}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// ─── Delete ──────────────────────────────────────────────────────
+
+func (s *Server) deleteMetricDef(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	id := chi.URLParam(r, "id")
+
+	if err := pgdb.New(s.pool).DeleteMetricDef(r.Context(), pgdb.DeleteMetricDefParams{ID: id, TenantID: tenantID}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/s3"
+)
+
+// debPresignTTL bounds presigned .deb URLs handed to agents. Runs install the
+// database shortly after machines come up, well within this window.
+const debPresignTTL = 2 * time.Hour
+
+// storeDeb saves a package binary. With object storage configured the blob is
+// uploaded under its content address and inline is nil; otherwise the bytes
+// are returned for the deb_data column. The checksum is computed either way.
+func (s *Server) storeDeb(ctx context.Context, r io.Reader) (inline []byte, blob s3.Blob, err error) {
+	if s.blobs != nil {
+		blob, err = s.blobs.Put(ctx, r)
+		return nil, blob, err
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, s3.Blob{}, err
+	}
+	sum := sha256.Sum256(data)
+	return data, s3.Blob{SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))}, nil
+}
+
+// serveDeb answers a .deb download: blobs in object storage redirect to a
+// presigned URL, inline blobs are written directly.
+func (s *Server) serveDeb(w http.ResponseWriter, r *http.Request, data []byte, filename, key, sha string) {
+	if key != "" {
+		if s.blobs == nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "package is in object storage, which is not configured"})
+			return
+		}
+		url, err := s.blobs.PresignGet(r.Context(), key, filename, debPresignTTL)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		http.Redirect(w, r, url, http.StatusFound)
+		return
+	}
+	if len(data) == 0 {
+		http.Error(w, "no deb file", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.debian.binary-package")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	if sha != "" {
+		w.Header().Set("X-Checksum-Sha256", sha)
+	}
+	w.Write(data)
+}
+
+// presignedDebURL returns a presigned download URL for a blob in object
+// storage, or "" when the blob is inline or presigning fails (callers then
+// fall back to the server download endpoint).
+func (s *Server) presignedDebURL(ctx context.Context, key, filename string) string {
+	if key == "" || s.blobs == nil {
+		return ""
+	}
+	url, err := s.blobs.PresignGet(ctx, key, filename, debPresignTTL)
+	if err != nil {
+		s.logger.Warn("presign package blob", zap.String("key", key), zap.Error(err))
+		return ""
+	}
+	return url
+}
+
+// MigratePackageBlobs moves package binaries still stored in Postgres into
+// object storage, one row at a time. Safe to run repeatedly; a no-op without
+// object storage.
+func (s *Server) MigratePackageBlobs(ctx context.Context) {
+	if s.blobs == nil || s.pool == nil {
+		return
+	}
+	q := pgdb.New(s.pool)
+	moved, failed := 0, 0
+
+	pkgs, err := q.ListInlinePackageDebs(ctx)
+	if err != nil {
+		s.logger.Error("list inline package blobs", zap.Error(err))
+		return
+	}
+	for _, p := range pkgs {
+		row, err := q.GetPackageDebData(ctx, pgdb.GetPackageDebDataParams{ID: p.ID, TenantID: p.TenantID})
+		if err == nil {
+			var blob s3.Blob
+			if blob, err = s.moveBlob(ctx, row.DebData, row.DebSha256); err == nil {
+				err = q.SetPackageDebBlob(ctx, pgdb.SetPackageDebBlobParams{
+					ID: p.ID, TenantID: p.TenantID, DebKey: blob.Key, DebSha256: blob.SHA256, DebSize: blob.Size,
+				})
+			}
+		}
+		if err != nil {
+			failed++
+			s.logger.Error("migrate package blob", zap.String("package_id", p.ID), zap.Error(err))
+			continue
+		}
+		moved++
+	}
+
+	builds, err := q.ListInlinePackageBuilds(ctx)
+	if err != nil {
+		s.logger.Error("list inline package build blobs", zap.Error(err))
+		return
+	}
+	for _, b := range builds {
+		row, err := q.GetPackageBuildData(ctx, pgdb.GetPackageBuildDataParams{
+			TenantID: b.TenantID, PackageID: b.PackageID, CommitSha: b.CommitSha, RecipeHash: b.RecipeHash,
+		})
+		if err == nil {
+			var blob s3.Blob
+			if blob, err = s.moveBlob(ctx, row.DebData, row.DebSha256); err == nil {
+				err = q.SetPackageBuildBlob(ctx, pgdb.SetPackageBuildBlobParams{
+					TenantID: b.TenantID, PackageID: b.PackageID, CommitSha: b.CommitSha, RecipeHash: b.RecipeHash,
+					DebKey: blob.Key, DebSha256: blob.SHA256, DebSize: blob.Size,
+				})
+			}
+		}
+		if err != nil {
+			failed++
+			s.logger.Error("migrate package build blob",
+				zap.String("package_id", b.PackageID), zap.String("commit", b.CommitSha), zap.Error(err))
+			continue
+		}
+		moved++
+	}
+
+	if moved > 0 || failed > 0 {
+		s.logger.Info("package blobs migrated to object storage", zap.Int("moved", moved), zap.Int("failed", failed))
+	}
+}
+
+// moveBlob uploads an inline blob, refusing it if it no longer matches the
+// checksum recorded for it.
+func (s *Server) moveBlob(ctx context.Context, data []byte, wantSHA string) (s3.Blob, error) {
+	sum := sha256.Sum256(data)
+	if got := hex.EncodeToString(sum[:]); wantSHA != "" && got != wantSHA {
+		return s3.Blob{}, fmt.Errorf("checksum mismatch: recorded %s, content %s", wantSHA, got)
+	}
+	return s.blobs.Put(ctx, bytes.NewReader(data))
+}
@@ -3,17 +3,24 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/agent"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/pkgmeta"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/s3"
 )
 
 type pkgListItem struct {
@@ -29,6 +36,9 @@ type pkgListItem struct {
 	DebFilename string   `json:"deb_filename,omitempty"`
 	HasDeb      bool     `json:"has_deb"`
 	CreatedAt   string   `json:"created_at"`
+
+	Source  *types.SourceBuild `json:"source,omitempty"`
+	Tarball *types.Tarball     `json:"tarball,omitempty"`
 }
 
 // ─── List ────────────────────────────────────────────────────────
@@ -41,11 +51,12 @@ func (s *Server) listPackages(w http.ResponseWriter, r *http.Request) {
 	dbVersion := r.URL.Query().Get("db_version")
 
 	// Helper to map any of the three row types to pkgListItem.
-	mapRow := func(id, name, desc, kind, ver string, builtin bool, apt, pre []string, repo, debFn string, ts string) pkgListItem {
+	mapRow := func(id, name, desc, kind, ver string, builtin bool, apt, pre []string, repo, debFn, source, tarball string, ts string) pkgListItem {
 		return pkgListItem{
 			ID: id, Name: name, Description: desc, DbKind: kind, DbVersion: ver,
 			IsBuiltin: builtin, AptPackages: apt, PreInstall: pre,
 			CustomRepo: repo, DebFilename: debFn, HasDeb: debFn != "", CreatedAt: ts,
+			Source: decodeSource(source), Tarball: decodeTarball(tarball),
 		}
 	}
 
@@ -59,7 +70,7 @@ func (s *Server) listPackages(w http.ResponseWriter, r *http.Request) {
 		})
 		err = e
 		for _, r := range rows {
-			out = append(out, mapRow(r.ID, r.Name, r.Description, r.DbKind, r.DbVersion, r.IsBuiltin, r.AptPackages, r.PreInstall, r.CustomRepo, r.DebFilename, r.CreatedAt.Time.Format("2006-01-02T15:04:05Z")))
+			out = append(out, mapRow(r.ID, r.Name, r.Description, r.DbKind, r.DbVersion, r.IsBuiltin, r.AptPackages, r.PreInstall, r.CustomRepo, r.DebFilename, r.Source, r.Tarball, r.CreatedAt.Time.Format("2006-01-02T15:04:05Z")))
 		}
 	case dbKind != "":
 		rows, e := q.ListPackagesByKind(r.Context(), pgdb.ListPackagesByKindParams{
@@ -67,13 +78,13 @@ func (s *Server) listPackages(w http.ResponseWriter, r *http.Request) {
 		})
 		err = e
 		for _, r := range rows {
-			out = append(out, mapRow(r.ID, r.Name, r.Description, r.DbKind, r.DbVersion, r.IsBuiltin, r.AptPackages, r.PreInstall, r.CustomRepo, r.DebFilename, r.CreatedAt.Time.Format("2006-01-02T15:04:05Z")))
+			out = append(out, mapRow(r.ID, r.Name, r.Description, r.DbKind, r.DbVersion, r.IsBuiltin, r.AptPackages, r.PreInstall, r.CustomRepo, r.DebFilename, r.Source, r.Tarball, r.CreatedAt.Time.Format("2006-01-02T15:04:05Z")))
 		}
 	default:
 		rows, e := q.ListPackages(r.Context(), tenantID)
 		err = e
 		for _, r := range rows {
-			out = append(out, mapRow(r.ID, r.Name, r.Description, r.DbKind, r.DbVersion, r.IsBuiltin, r.AptPackages, r.PreInstall, r.CustomRepo, r.DebFilename, r.CreatedAt.Time.Format("2006-01-02T15:04:05Z")))
+			out = append(out, mapRow(r.ID, r.Name, r.Description, r.DbKind, r.DbVersion, r.IsBuiltin, r.AptPackages, r.PreInstall, r.CustomRepo, r.DebFilename, r.Source, r.Tarball, r.CreatedAt.Time.Format("2006-01-02T15:04:05Z")))
 		}
 	}
 	if err != nil {
@@ -97,12 +108,43 @@ func (s *Server) getPackage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	type buildItem struct {
+		CommitSHA   string `json:"commit_sha"`
+		RecipeHash  string `json:"recipe_hash"`
+		DebFilename string `json:"deb_filename"`
+		SHA256      string `json:"sha256"`
+		SizeBytes   int64  `json:"size_bytes"`
+		RunID       string `json:"run_id,omitempty"`
+		CreatedAt   string `json:"created_at"`
+	}
+	var builds []buildItem
+	if pkg.Source != "" {
+		rows, err := q.ListPackageBuilds(r.Context(), pgdb.ListPackageBuildsParams{TenantID: tenantID, PackageID: id})
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		for _, b := range rows {
+			builds = append(builds, buildItem{
+				CommitSHA: b.CommitSha, RecipeHash: b.RecipeHash, DebFilename: b.DebFilename,
+				SHA256: b.DebSha256, SizeBytes: b.DebSize, RunID: b.RunID, CreatedAt: b.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+			})
+		}
+	}
+
+	var deb pgdb.GetPackageDebMetaRow
+	if pkg.DebFilename != "" {
+		deb, _ = q.GetPackageDebMeta(r.Context(), pgdb.GetPackageDebMetaParams{ID: id, TenantID: tenantID})
+	}
+
 	writeJSON(w, http.StatusOK, map[string]any{
 		"id": pkg.ID, "name": pkg.Name, "description": pkg.Description,
 		"db_kind": pkg.DbKind, "db_version": pkg.DbVersion, "is_builtin": pkg.IsBuiltin,
 		"apt_packages": pkg.AptPackages, "pre_install": pkg.PreInstall,
 		"custom_repo": pkg.CustomRepo, "custom_repo_key": pkg.CustomRepoKey,
 		"deb_filename": pkg.DebFilename, "has_deb": pkg.DebFilename != "",
+		"deb_sha256": deb.DebSha256, "deb_size": deb.DebSize, "deb_meta": decodeDebMeta(deb.DebMeta),
+		"source": decodeSource(pkg.Source), "tarball": decodeTarball(pkg.Tarball), "builds": ensureSlice(builds),
 		"created_at": pkg.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
 	})
 }
@@ -118,17 +160,110 @@ type packageReq struct {
 	PreInstall    []string `json:"pre_install"`
 	CustomRepo    string   `json:"custom_repo"`
 	CustomRepoKey string   `json:"custom_repo_key"`
+	// Source makes the package a source build; nil on update keeps the current one.
+	Source *types.SourceBuild `json:"source,omitempty"`
+	// Tarball makes the package a prebuilt tarball; nil on update keeps the current one.
+	Tarball *types.Tarball `json:"tarball,omitempty"`
+}
+
+// encodeSource validates a source build recipe and serializes it for the
+// packages.source column ("" for a regular package).
+func encodeSource(dbKind string, src *types.SourceBuild) (string, error) {
+	if src == nil {
+		return "", nil
+	}
+	switch types.DatabaseKind(dbKind) {
+	case types.DatabasePostgres, types.DatabaseMySQL:
+	default:
+		return "", fmt.Errorf("source builds are supported for postgres and mysql, not %q", dbKind)
+	}
+	if src.GitURL == "" || src.Ref == "" {
+		return "", fmt.Errorf("source.git_url and source.ref are required")
+	}
+	if err := src.ValidateGit(); err != nil {
+		return "", err
+	}
+	switch src.BuildSystem {
+	case "", types.BuildAutotools, types.BuildCMake, types.BuildMeson:
+	default:
+		return "", fmt.Errorf("unknown source.build_system %q", src.BuildSystem)
+	}
+	src.CommitSHA = "" // resolved per run
+	data, err := json.Marshal(src)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// encodeTarball validates a tarball recipe and serializes it for the
+// packages.tarball column ("" for a regular package).
+func encodeTarball(t *types.Tarball) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// decodeTarball parses the packages.tarball column; nil for a regular package.
+func decodeTarball(raw string) *types.Tarball {
+	if raw == "" {
+		return nil
+	}
+	var t types.Tarball
+	if err := json.Unmarshal([]byte(raw), &t); err != nil {
+		return nil
+	}
+	return &t
+}
+
+// decodeSource parses the packages.source column; nil for a regular package.
+func decodeSource(raw string) *types.SourceBuild {
+	if raw == "" {
+		return nil
+	}
+	var src types.SourceBuild
+	if err := json.Unmarshal([]byte(raw), &src); err != nil {
+		return nil
+	}
+	return &src
 }
 
 func (s *Server) createPackage(w http.ResponseWriter, r *http.Request) {
 	tenantID := auth.TenantID(r.Context())
 	var req packageReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" || req.DbKind == "" {
-		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name and db_kind are required"})
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name is required"})
+		return
+	}
+	// A package installed from an uploaded .deb may leave db_kind to the
+	// upload, which fills it from the package metadata.
+	if req.DbKind == "" && (req.Source != nil || req.Tarball != nil || len(req.AptPackages) > 0) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "db_kind is required unless the package is a .deb upload"})
+		return
+	}
+	if req.Source != nil && req.Tarball != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "a package is either a source build or a tarball, not both"})
+		return
+	}
+	source, err := encodeSource(req.DbKind, req.Source)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	tarball, err := encodeTarball(req.Tarball)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
 
-	q := pgdb.New(s.pool)
 	id := uuid.New().String()
 	if req.AptPackages == nil {
 		req.AptPackages = []string{}
@@ -137,18 +272,28 @@ func (s *Server) createPackage(w http.ResponseWriter, r *http.Request) {
 		req.PreInstall = []string{}
 	}
 
-	if err := q.CreatePackage(r.Context(), pgdb.CreatePackageParams{
-		ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
-		DbKind: req.DbKind, DbVersion: req.DbVersion, IsBuiltin: false,
-		AptPackages: req.AptPackages, PreInstall: req.PreInstall,
-		CustomRepo: req.CustomRepo, CustomRepoKey: req.CustomRepoKey,
-		DebFilename: "",
-	}); err != nil {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "name already exists"})
+	var rev int
+	status := http.StatusInternalServerError
+	err = s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.CreatePackage(r.Context(), pgdb.CreatePackageParams{
+			ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
+			DbKind: req.DbKind, DbVersion: req.DbVersion, IsBuiltin: false,
+			AptPackages: req.AptPackages, PreInstall: req.PreInstall,
+			CustomRepo: req.CustomRepo, CustomRepoKey: req.CustomRepoKey,
+			DebFilename: "", Source: source, Tarball: tarball,
+		}); err != nil {
+			status = http.StatusConflict
+			return errors.New("name already exists")
+		}
+		rev, err = recordRevision(r.Context(), packageHistory{q: q, tenantID: tenantID, id: id})
+		return err
+	})
+	if err != nil {
+		writeJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]string{"id": id})
+	writeJSON(w, http.StatusCreated, map[string]any{"id": id, "revision": rev})
 }
 
 // ─── Update ──────────────────────────────────────────────────────
@@ -178,19 +323,51 @@ func (s *Server) updatePackage(w http.ResponseWriter, r *http.Request) {
 	if req.PreInstall == nil {
 		req.PreInstall = []string{}
 	}
+	source := pkg.Source
+	if req.Source != nil {
+		kind := req.DbKind
+		if kind == "" {
+			kind = pkg.DbKind
+		}
+		if source, err = encodeSource(kind, req.Source); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	tarball := pkg.Tarball
+	if req.Tarball != nil {
+		if tarball, err = encodeTarball(req.Tarball); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	if source != "" && tarball != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "a package is either a source build or a tarball, not both"})
+		return
+	}
 
-	if err := q.UpdatePackage(r.Context(), pgdb.UpdatePackageParams{
-		ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
-		DbKind: req.DbKind, DbVersion: req.DbVersion,
-		AptPackages: req.AptPackages, PreInstall: req.PreInstall,
-		CustomRepo: req.CustomRepo, CustomRepoKey: req.CustomRepoKey,
-		DebFilename: pkg.DebFilename, // preserve existing deb
-	}); err != nil {
+	var rev int
+	err = s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.UpdatePackage(r.Context(), pgdb.UpdatePackageParams{
+			ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
+			DbKind: req.DbKind, DbVersion: req.DbVersion,
+			AptPackages: req.AptPackages, PreInstall: req.PreInstall,
+			CustomRepo: req.CustomRepo, CustomRepoKey: req.CustomRepoKey,
+			DebFilename: pkg.DebFilename, // preserve existing deb
+			Source:      source,
+			Tarball:     tarball,
+		}); err != nil {
+			return err
+		}
+		rev, err = recordRevision(r.Context(), packageHistory{q: q, tenantID: tenantID, id: id})
+		return err
+	})
+	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+	writeJSON(w, http.StatusOK, map[string]any{"status": "updated", "revision": rev})
 }
 
 // ─── Delete ──────────────────────────────────────────────────────
@@ -223,18 +400,30 @@ func (s *Server) clonePackage(w http.ResponseWriter, r *http.Request) {
 	newID := uuid.New().String()
 	newName := src.Name + " (copy)"
 
-	if err := q.CreatePackage(r.Context(), pgdb.CreatePackageParams{
-		ID: newID, TenantID: tenantID, Name: newName, Description: src.Description,
-		DbKind: src.DbKind, DbVersion: src.DbVersion, IsBuiltin: false,
-		AptPackages: src.AptPackages, PreInstall: src.PreInstall,
-		CustomRepo: src.CustomRepo, CustomRepoKey: src.CustomRepoKey,
-		DebFilename: "", // don't copy deb binary, user re-uploads
-	}); err != nil {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "clone failed: " + err.Error()})
+	var rev int
+	status := http.StatusInternalServerError
+	err = s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.CreatePackage(r.Context(), pgdb.CreatePackageParams{
+			ID: newID, TenantID: tenantID, Name: newName, Description: src.Description,
+			DbKind: src.DbKind, DbVersion: src.DbVersion, IsBuiltin: false,
+			AptPackages: src.AptPackages, PreInstall: src.PreInstall,
+			CustomRepo: src.CustomRepo, CustomRepoKey: src.CustomRepoKey,
+			DebFilename: "", // don't copy deb binary, user re-uploads
+			Source:      src.Source,
+			Tarball:     src.Tarball,
+		}); err != nil {
+			status = http.StatusConflict
+			return fmt.Errorf("clone failed: %w", err)
+		}
+		rev, err = recordRevision(r.Context(), packageHistory{q: q, tenantID: tenantID, id: newID})
+		return err
+	})
+	if err != nil {
+		writeJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]string{"id": newID, "name": newName})
+	writeJSON(w, http.StatusCreated, map[string]any{"id": newID, "name": newName, "revision": rev})
 }
 
 // ─── Upload .deb to a package ────────────────────────────────────
@@ -254,27 +443,128 @@ func (s *Server) uploadPackageDeb(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	data, err := io.ReadAll(file)
+	q := pgdb.New(s.pool)
+	pkg, err := q.GetPackage(r.Context(), pgdb.GetPackageParams{ID: id, TenantID: tenantID})
 	if err != nil {
-		http.Error(w, "read file: "+err.Error(), http.StatusInternalServerError)
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "package not found"})
 		return
 	}
 
-	q := pgdb.New(s.pool)
-	if err := q.UpdatePackageDebData(r.Context(), pgdb.UpdatePackageDebDataParams{
-		ID: id, TenantID: tenantID, DebData: data, DebFilename: header.Filename,
-	}); err != nil {
+	// Check the package before storing it, so mistakes surface here rather
+	// than in install_db. Tarball packages take any tar archive as is.
+	dbKind, dbVersion := pkg.DbKind, pkg.DbVersion
+	var meta *pkgmeta.Metadata
+	if pkg.Tarball != "" {
+		if _, err := pkgmeta.TarballCompression(file); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "invalid tarball: " + err.Error()})
+			return
+		}
+	} else {
+		if meta, err = checkDeb(pkg, file); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+		if dbKind, dbVersion, err = debDatabase(pkg, meta); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	rawMeta := ""
+	if meta != nil {
+		data, _ := json.Marshal(meta)
+		rawMeta = string(data)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
+	data, blob, err := s.storeDeb(r.Context(), file)
+	if err != nil {
+		http.Error(w, "store file: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	writeJSON(w, http.StatusOK, map[string]string{
-		"status":   "uploaded",
-		"filename": header.Filename,
-		"size":     fmt.Sprintf("%d", len(data)),
+	var rev int
+	err = s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.UpdatePackageDebData(r.Context(), pgdb.UpdatePackageDebDataParams{
+			ID: id, TenantID: tenantID, DebData: data, DebFilename: header.Filename,
+			DebKey: blob.Key, DebSha256: blob.SHA256, DebSize: blob.Size,
+			DebMeta: rawMeta, DbKind: dbKind, DbVersion: dbVersion,
+		}); err != nil {
+			return err
+		}
+		rev, err = recordRevision(r.Context(), packageHistory{q: q, tenantID: tenantID, id: id})
+		return err
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":     "uploaded",
+		"filename":   header.Filename,
+		"size":       fmt.Sprintf("%d", blob.Size),
+		"sha256":     blob.SHA256,
+		"db_kind":    dbKind,
+		"db_version": dbVersion,
+		"metadata":   meta,
+		"revision":   rev,
 	})
 }
 
+// checkDeb parses an uploaded package and checks that agents can install it
+// for pkg: a .deb for their architecture, of the package's database kind.
+func checkDeb(pkg pgdb.GetPackageRow, file io.Reader) (*pkgmeta.Metadata, error) {
+	meta, err := pkgmeta.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package: %w", err)
+	}
+	if err := meta.Installable(); err != nil {
+		return nil, err
+	}
+	if err := meta.CheckArch(agentArch); err != nil {
+		return nil, err
+	}
+	if kind, _ := meta.Database(); kind != "" && pkg.DbKind != "" && string(kind) != pkg.DbKind {
+		return nil, fmt.Errorf("%s installs %s, but package %q is for %s", meta.Name, kind, pkg.Name, pkg.DbKind)
+	}
+	return meta, nil
+}
+
+// debDatabase returns the database kind and version of pkg once meta is
+// uploaded to it: fields left empty on the package are taken from meta.
+func debDatabase(pkg pgdb.GetPackageRow, meta *pkgmeta.Metadata) (kind, version string, err error) {
+	kind, version = pkg.DbKind, pkg.DbVersion
+	metaKind, metaVersion := meta.Database()
+	if kind == "" {
+		if metaKind == "" {
+			return "", "", fmt.Errorf("cannot tell which database %s installs; set db_kind on package %q", meta.Name, pkg.Name)
+		}
+		kind = string(metaKind)
+	}
+	if version == "" {
+		version = metaVersion
+	}
+	return kind, version, nil
+}
+
+// agentArch is the Debian architecture of agent images and cloud VMs.
+const agentArch = "amd64"
+
+// decodeDebMeta parses the packages.deb_meta column (nil when unset).
+func decodeDebMeta(raw string) *pkgmeta.Metadata {
+	if raw == "" {
+		return nil
+	}
+	var meta pkgmeta.Metadata
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return nil
+	}
+	return &meta
+}
+
 // ─── Download .deb from a package ────────────────────────────────
 
 func (s *Server) downloadPackageDeb(w http.ResponseWriter, r *http.Request) {
@@ -283,14 +573,81 @@ func (s *Server) downloadPackageDeb(w http.ResponseWriter, r *http.Request) {
 	q := pgdb.New(s.pool)
 
 	row, err := q.GetPackageDebData(r.Context(), pgdb.GetPackageDebDataParams{ID: id, TenantID: tenantID})
-	if err != nil || len(row.DebData) == 0 {
+	if err != nil {
 		http.Error(w, "no deb file", http.StatusNotFound)
 		return
 	}
+	s.serveDeb(w, r, row.DebData, row.DebFilename, row.DebKey, row.DebSha256)
+}
+
+// ─── Source build artifacts ──────────────────────────────────────
+
+// maxBuildDebSize bounds a .deb uploaded by a builder machine.
+const maxBuildDebSize = 2 << 30
+
+// uploadPackageBuild stores a .deb compiled by the build_package phase,
+// keyed by commit SHA and recipe hash. Called by the builder agent.
+func (s *Server) uploadPackageBuild(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	id := chi.URLParam(r, "id")
+	sha := chi.URLParam(r, "sha")
+	recipe := r.URL.Query().Get("recipe")
+	filename := r.URL.Query().Get("filename")
+	if !types.IsCommitSHA(sha) || recipe == "" || filename == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "full commit sha, recipe and filename are required"})
+		return
+	}
+
+	// Only source packages of the caller's tenant have builds; check before
+	// storing anything.
+	q := pgdb.New(s.pool)
+	pkg, err := q.GetPackage(r.Context(), pgdb.GetPackageParams{ID: id, TenantID: tenantID})
+	if err != nil || pkg.Source == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "source package not found"})
+		return
+	}
+
+	data, blob, err := s.storeDeb(r.Context(), http.MaxBytesReader(w, r.Body, maxBuildDebSize))
+	if err != nil {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{"error": "store body: " + err.Error()})
+		return
+	}
+	if blob.Size == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "empty body"})
+		return
+	}
+
+	if err := q.UpsertPackageBuild(r.Context(), pgdb.UpsertPackageBuildParams{
+		TenantID: tenantID, PackageID: id, CommitSha: sha, RecipeHash: recipe,
+		DebData: data, DebFilename: filename, RunID: r.URL.Query().Get("run_id"),
+		DebKey: blob.Key, DebSha256: blob.SHA256, DebSize: blob.Size,
+	}); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
 
-	w.Header().Set("Content-Type", "application/vnd.debian.binary-package")
-	w.Header().Set("Content-Disposition", `attachment; filename="`+row.DebFilename+`"`)
-	w.Write(row.DebData)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status":   "uploaded",
+		"filename": filename,
+		"size":     fmt.Sprintf("%d", blob.Size),
+		"sha256":   blob.SHA256,
+	})
+}
+
+// downloadPackageBuild serves a cached source build .deb.
+func (s *Server) downloadPackageBuild(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	q := pgdb.New(s.pool)
+
+	row, err := q.GetPackageBuildData(r.Context(), pgdb.GetPackageBuildDataParams{
+		TenantID: tenantID, PackageID: chi.URLParam(r, "id"),
+		CommitSha: chi.URLParam(r, "sha"), RecipeHash: r.URL.Query().Get("recipe"),
+	})
+	if err != nil {
+		http.Error(w, "no build for this commit", http.StatusNotFound)
+		return
+	}
+	s.serveDeb(w, r, row.DebData, row.DebFilename, row.DebKey, row.DebSha256)
 }
 
 // ─── Seed built-ins for new tenant ───────────────────────────────
@@ -304,13 +661,14 @@ func (s *Server) seedBuiltinPackages(ctx context.Context, tenantID string) {
 		if bp.PreInstall == nil {
 			bp.PreInstall = []string{}
 		}
+		tarball, _ := encodeTarball(bp.Tarball)
 		_ = q.CreatePackage(ctx, pgdb.CreatePackageParams{
 			ID: uuid.New().String(), TenantID: tenantID,
 			Name: bp.Name, Description: bp.Description,
 			DbKind: bp.DbKind, DbVersion: bp.DbVersion, IsBuiltin: true,
 			AptPackages: bp.AptPackages, PreInstall: bp.PreInstall,
 			CustomRepo: bp.CustomRepo, CustomRepoKey: bp.CustomRepoKey,
-			DebFilename: "",
+			DebFilename: "", Tarball: tarball,
 		})
 	}
 }
@@ -320,11 +678,6 @@ func (s *Server) seedBuiltinPackages(ctx context.Context, tenantID string) {
 // resolveRunPackage loads a Package by ID or finds the default built-in for db_kind+version.
 // It sets the DebFilename to the download URL so the agent can curl it.
 func (s *Server) resolveRunPackage(ctx context.Context, tenantID string, cfg *types.RunConfig) error {
-	// YDB doesn't use apt packages — it downloads the ydbd binary directly.
-	if cfg.Database.Kind == types.DatabaseYDB {
-		return nil
-	}
-
 	q := pgdb.New(s.pool)
 
 	var pkg pgdb.GetPackageRow
@@ -337,6 +690,11 @@ func (s *Server) resolveRunPackage(ctx context.Context, tenantID string, cfg *ty
 		rows, e := q.ListPackagesByKindVersion(ctx, pgdb.ListPackagesByKindVersionParams{
 			TenantID: tenantID, DbKind: string(cfg.Database.Kind), DbVersion: cfg.Database.Version,
 		})
+		if e == nil && len(rows) == 0 && cfg.Database.Kind == types.DatabaseYDB {
+			// Any YDB version installs without a package: the agent falls
+			// back to the release tarball for cfg.Database.Version.
+			return nil
+		}
 		if e != nil || len(rows) == 0 {
 			return fmt.Errorf("no package found for %s %s", cfg.Database.Kind, cfg.Database.Version)
 		}
@@ -347,7 +705,7 @@ func (s *Server) resolveRunPackage(ctx context.Context, tenantID string, cfg *ty
 			DbKind: r.DbKind, DbVersion: r.DbVersion, IsBuiltin: r.IsBuiltin,
 			AptPackages: r.AptPackages, PreInstall: r.PreInstall,
 			CustomRepo: r.CustomRepo, CustomRepoKey: r.CustomRepoKey,
-			DebFilename: r.DebFilename,
+			DebFilename: r.DebFilename, Source: r.Source, Tarball: r.Tarball,
 		}
 		err = nil
 	}
@@ -355,39 +713,135 @@ func (s *Server) resolveRunPackage(ctx context.Context, tenantID string, cfg *ty
 		return fmt.Errorf("package not found: %w", err)
 	}
 
+	// Install from an immutable revision so the run records exactly what it used.
+	var rev revisionDetail
+	err = s.inTx(ctx, func(q *pgdb.Queries) error {
+		rev, err = pinRevision(ctx, packageHistory{q: q, tenantID: tenantID, id: pkg.ID}, cfg.PackageRevision)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("package %s: %w", pkg.Name, err)
+	}
+	var spec types.PackageSpec
+	if err := json.Unmarshal(rev.Spec, &spec); err != nil {
+		return fmt.Errorf("package %s revision %d: %w", pkg.Name, rev.Revision, err)
+	}
+	cfg.PackageID = pkg.ID
+	cfg.PackageRevision = rev.ref()
+
 	resolved := &types.Package{
 		ID:            pkg.ID,
-		Name:          pkg.Name,
-		DbKind:        pkg.DbKind,
-		DbVersion:     pkg.DbVersion,
-		AptPackages:   pkg.AptPackages,
-		PreInstall:    pkg.PreInstall,
-		CustomRepo:    pkg.CustomRepo,
-		CustomRepoKey: pkg.CustomRepoKey,
+		Name:          spec.Name,
+		DbKind:        spec.DbKind,
+		DbVersion:     spec.DbVersion,
+		AptPackages:   spec.AptPackages,
+		PreInstall:    spec.PreInstall,
+		CustomRepo:    spec.CustomRepo,
+		CustomRepoKey: spec.CustomRepoKey,
+		Tarball:       spec.Tarball,
 	}
 
-	// If the package has a .deb, set DebFilename to the download URL + auth token.
-	if pkg.DebFilename != "" {
-		serverAddr := ""
-		if settings := s.settingsForTenant(tenantID); settings != nil {
-			serverAddr = settings.Cloud.ServerAddr
-		}
-		if serverAddr == "" {
-			serverAddr = dockerHostAddr(s.app.listenAddr)
-		}
-		resolved.DebFilename = fmt.Sprintf("%s/api/v1/packages/%s/deb", serverAddr, pkg.ID)
+	serverAddr := ""
+	if settings := s.settingsForTenant(tenantID); settings != nil {
+		serverAddr = settings.Cloud.ServerAddr
+	}
+	if serverAddr == "" {
+		serverAddr = dockerHostAddr(s.app.listenAddr)
+	}
 
-		// Generate a short-lived token for the agent to download this .deb.
-		if s.jwtIssuer != nil {
-			token, err := s.jwtIssuer.Issue(auth.Claims{
-				UserID: "deb-download", TenantID: tenantID, Role: "viewer",
-			}, 2*time.Hour)
+	switch {
+	case spec.Source != nil:
+		// Source package: reuse a cached build when the ref resolves to a
+		// commit built before; otherwise the build_package phase compiles it.
+		src := spec.Source.WithDefaults(spec.DbKind, spec.DbVersion)
+		src.CommitSHA = resolveCommitSHA(ctx, src.GitURL, src.Ref)
+		resolved.Source = &src
+		if src.CommitSHA != "" {
+			recipe := src.RecipeHash()
+			build, err := q.GetPackageBuildMeta(ctx, pgdb.GetPackageBuildMetaParams{
+				TenantID: tenantID, PackageID: pkg.ID, CommitSha: src.CommitSHA, RecipeHash: recipe,
+			})
 			if err == nil {
-				resolved.DebToken = token
+				params := url.Values{"recipe": {recipe}}
+				resolved.DebSHA256 = build.DebSha256
+				resolved.DebFilename = s.presignedDebURL(ctx, build.DebKey, build.DebFilename)
+				if resolved.DebFilename == "" {
+					path := fmt.Sprintf("/api/v1/packages/%s/builds/%s/deb", pkg.ID, src.CommitSHA)
+					resolved.DebFilename = serverAddr + path + "?" + params.Encode()
+					resolved.DebToken = s.debDownloadToken(tenantID, path)
+				}
 			}
 		}
+
+	case spec.DebFilename != "":
+		// Blobs in object storage are fetched straight from a presigned URL;
+		// inline ones through the server download endpoint + auth token.
+		meta, err := q.GetPackageDebMeta(ctx, pgdb.GetPackageDebMetaParams{ID: pkg.ID, TenantID: tenantID})
+		if err != nil {
+			return fmt.Errorf("package %s: %w", pkg.Name, err)
+		}
+		if meta.DebSha256 != spec.DebSHA256 {
+			// Pinned to a revision whose .deb was replaced since: only object
+			// storage keeps it, under its content address.
+			if s.blobs == nil {
+				return fmt.Errorf("package %s revision %d: its .deb was replaced and object storage is not configured", pkg.Name, rev.Revision)
+			}
+			meta.DebKey, meta.DebFilename = s3.BlobKey(spec.DebSHA256), spec.DebFilename
+		}
+		resolved.DebSHA256 = spec.DebSHA256
+		resolved.DebFilename = s.presignedDebURL(ctx, meta.DebKey, meta.DebFilename)
+		if resolved.DebFilename == "" {
+			path := fmt.Sprintf("/api/v1/packages/%s/deb", pkg.ID)
+			resolved.DebFilename = serverAddr + path
+			resolved.DebToken = s.debDownloadToken(tenantID, path)
+		}
 	}
 
 	cfg.ResolvedPackage = resolved
 	return nil
 }
+
+// debDownloadToken generates a short-lived, read-only token for the agent
+// to download a .deb; it is valid only for requests to path.
+func (s *Server) debDownloadToken(tenantID, path string) string {
+	if s.jwtIssuer == nil {
+		return ""
+	}
+	token, err := s.jwtIssuer.Issue(auth.Claims{
+		UserID: "deb-download", TenantID: tenantID, Role: "viewer", Scope: path,
+	}, 2*time.Hour)
+	if err != nil {
+		return ""
+	}
+	return token
+}
+
+// resolveCommitSHA maps a git ref to a commit SHA. A full SHA is returned as
+// is; branches and tags are looked up with git ls-remote. Returns "" when the
+// lookup fails or the URL is not an allowed remote, leaving resolution to the
+// builder machine.
+func resolveCommitSHA(ctx context.Context, gitURL, ref string) string {
+	if types.IsCommitSHA(ref) {
+		return ref
+	}
+	if err := (types.SourceBuild{GitURL: gitURL, Ref: ref}).ValidateGit(); err != nil {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+	out, err := lsRemote(ctx, gitURL, ref)
+	if err != nil {
+		return ""
+	}
+	return agent.ParseLsRemote(out, ref)
+}
+
+// lsRemote runs git ls-remote on the server; a variable so tests can stub it.
+// GIT_ALLOW_PROTOCOL keeps git to network transports (no file:// or ext::)
+// whatever the URL resolves to.
+var lsRemote = func(ctx context.Context, gitURL, ref string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--", gitURL, ref)
+	cmd.Env = append(os.Environ(), "GIT_ALLOW_PROTOCOL=https:ssh:git", "GIT_TERMINAL_PROMPT=0")
+	out, err := cmd.Output()
+	return string(out), err
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/pkgmeta"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+const testCommitSHA = "0123456789abcdef0123456789abcdef01234567"
+
+// stubLsRemote answers git ls-remote with sha for refs/heads/main and
+// counts the lookups.
+func stubLsRemote(t *testing.T, sha string) *int {
+	t.Helper()
+	calls := 0
+	orig := lsRemote
+	lsRemote = func(_ context.Context, _, _ string) (string, error) {
+		calls++
+		return sha + "\trefs/heads/main\n", nil
+	}
+	t.Cleanup(func() { lsRemote = orig })
+	return &calls
+}
+
+func TestResolveCommitSHA(t *testing.T) {
+	calls := stubLsRemote(t, testCommitSHA)
+	cases := []struct {
+		url, ref, want string
+		lookup         bool
+	}{
+		{"https://github.com/postgres/postgres.git", testCommitSHA, testCommitSHA, false},
+		{"https://github.com/postgres/postgres.git", "main", testCommitSHA, true},
+		{"git@github.com:postgres/postgres.git", "main", testCommitSHA, true},
+		{"file:///srv/git/postgres.git", "main", "", false},
+		{"ext::sh -c touch% /tmp/pwned", "main", "", false},
+		{"/srv/git/postgres.git", "main", "", false},
+		{"https://github.com/postgres/postgres.git", "--upload-pack=touch", "", false},
+	}
+	for _, c := range cases {
+		before := *calls
+		if got := resolveCommitSHA(context.Background(), c.url, c.ref); got != c.want {
+			t.Errorf("resolveCommitSHA(%q, %q) = %q, want %q", c.url, c.ref, got, c.want)
+		}
+		if looked := *calls > before; looked != c.lookup {
+			t.Errorf("resolveCommitSHA(%q, %q): ls-remote called = %v, want %v", c.url, c.ref, looked, c.lookup)
+		}
+	}
+}
+
+func TestResolveRunPackage_BranchRefHitsCachedBuild(t *testing.T) {
+	pool := testPool(t)
+	stubLsRemote(t, testCommitSHA)
+	ctx := context.Background()
+	q := pgdb.New(pool)
+
+	tenantID := "test-" + uuid.New().String()
+	if err := q.CreateTenant(ctx, pgdb.CreateTenantParams{ID: tenantID, Name: tenantID}); err != nil {
+		t.Fatalf("CreateTenant: %v", err)
+	}
+	t.Cleanup(func() { _ = q.DeleteTenant(context.Background(), tenantID) })
+
+	src := types.SourceBuild{GitURL: "https://github.com/postgres/postgres.git", Ref: "main"}
+	source, err := json.Marshal(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgID := uuid.New().String()
+	if err := q.CreatePackage(ctx, pgdb.CreatePackageParams{
+		ID: pkgID, TenantID: tenantID, Name: "pg-main", DbKind: "postgres", DbVersion: "17",
+		AptPackages: []string{}, PreInstall: []string{}, Source: string(source),
+	}); err != nil {
+		t.Fatalf("CreatePackage: %v", err)
+	}
+	recipe := src.WithDefaults("postgres", "17").RecipeHash()
+	if err := q.UpsertPackageBuild(ctx, pgdb.UpsertPackageBuildParams{
+		TenantID: tenantID, PackageID: pkgID, CommitSha: testCommitSHA, RecipeHash: recipe,
+		DebData: []byte("deb"), DebFilename: "pg-main.deb", DebSha256: "cafe", DebSize: 3,
+	}); err != nil {
+		t.Fatalf("UpsertPackageBuild: %v", err)
+	}
+
+	issuer := auth.NewJWTIssuer("test-secret")
+	s := &Server{app: New(Config{Pool: pool}), pool: pool, logger: zap.NewNop(), jwtIssuer: issuer}
+	cfg := postgresSingleConfig()
+	cfg.PackageID = pkgID
+	if err := s.resolveRunPackage(ctx, tenantID, &cfg); err != nil {
+		t.Fatalf("resolveRunPackage: %v", err)
+	}
+	pkg := cfg.ResolvedPackage
+	if pkg.Source == nil || pkg.Source.CommitSHA != testCommitSHA {
+		t.Fatalf("source = %+v, want commit %s", pkg.Source, testCommitSHA)
+	}
+	if pkg.DebSHA256 != "cafe" || !strings.Contains(pkg.DebFilename, "/builds/"+testCommitSHA+"/deb") {
+		t.Errorf("resolved deb = %q (sha %q), want the cached build of %s", pkg.DebFilename, pkg.DebSHA256, testCommitSHA)
+	}
+	claims, err := issuer.Parse(pkg.DebToken)
+	if err != nil {
+		t.Fatalf("deb token: %v", err)
+	}
+	if want := "/api/v1/packages/" + pkgID + "/builds/" + testCommitSHA + "/deb"; claims.Role != "viewer" || claims.Scope != want {
+		t.Errorf("deb token role %q scope %q, want viewer for %s", claims.Role, claims.Scope, want)
+	}
+}
+
+func TestUploadPackageBuild_RequiresSourcePackage(t *testing.T) {
+	pool := testPool(t)
+	ctx := context.Background()
+	q := pgdb.New(pool)
+
+	tenantID := "test-" + uuid.New().String()
+	if err := q.CreateTenant(ctx, pgdb.CreateTenantParams{ID: tenantID, Name: tenantID}); err != nil {
+		t.Fatalf("CreateTenant: %v", err)
+	}
+	t.Cleanup(func() { _ = q.DeleteTenant(context.Background(), tenantID) })
+	aptID := uuid.New().String()
+	if err := q.CreatePackage(ctx, pgdb.CreatePackageParams{
+		ID: aptID, TenantID: tenantID, Name: "pg-apt", DbKind: "postgres", DbVersion: "17",
+		AptPackages: []string{"postgresql-17"}, PreInstall: []string{},
+	}); err != nil {
+		t.Fatalf("CreatePackage: %v", err)
+	}
+
+	s := &Server{app: New(Config{Pool: pool}), pool: pool, logger: zap.NewNop()}
+	for name, id := range map[string]string{"no source": aptID, "unknown": uuid.New().String()} {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/packages/"+id+"/builds/"+testCommitSHA+"/deb?recipe=r&filename=pg.deb", strings.NewReader("deb"))
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		rctx.URLParams.Add("sha", testCommitSHA)
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(auth.WithClaims(ctx, &auth.Claims{TenantID: tenantID, Role: "operator"}))
+		w := httptest.NewRecorder()
+		s.uploadPackageBuild(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", name, w.Code)
+		}
+	}
+	if builds, err := q.ListPackageBuilds(ctx, pgdb.ListPackageBuildsParams{TenantID: tenantID, PackageID: aptID}); err != nil || len(builds) != 0 {
+		t.Errorf("builds = %v, %v; want none stored", builds, err)
+	}
+}
+
+func TestDebDatabase_FillsEmptyFieldsFromMetadata(t *testing.T) {
+	meta := &pkgmeta.Metadata{Format: pkgmeta.FormatDeb, Name: "postgresql-17", Version: "17.2-1.pgdg22.04+1"}
+	cases := []struct {
+		name              string
+		pkg               pgdb.GetPackageRow
+		wantKind, wantVer string
+	}{
+		{"empty", pgdb.GetPackageRow{Name: "custom"}, "postgres", "17"},
+		{"kind set", pgdb.GetPackageRow{Name: "custom", DbKind: "postgres"}, "postgres", "17"},
+		{"both set", pgdb.GetPackageRow{Name: "custom", DbKind: "postgres", DbVersion: "17.2"}, "postgres", "17.2"},
+	}
+	for _, c := range cases {
+		kind, version, err := debDatabase(c.pkg, meta)
+		if err != nil || kind != c.wantKind || version != c.wantVer {
+			t.Errorf("%s: debDatabase = %q, %q, %v; want %q, %q", c.name, kind, version, err, c.wantKind, c.wantVer)
+		}
+	}
+
+	unknown := &pkgmeta.Metadata{Format: pkgmeta.FormatDeb, Name: "libfoo", Version: "1.0"}
+	if _, _, err := debDatabase(pgdb.GetPackageRow{Name: "custom"}, unknown); err == nil {
+		t.Error("debDatabase of an unknown package without db_kind succeeded")
+	}
+}
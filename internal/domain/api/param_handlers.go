@@ -0,0 +1,46 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/dbparams"
+)
+
+// listParameterCatalogs handles GET /api/v1/parameters: the engines with a
+// parameter catalog and the versions each covers.
+func (s *Server) listParameterCatalogs(w http.ResponseWriter, r *http.Request) {
+	type item struct {
+		Engine         string   `json:"engine"`
+		File           string   `json:"file"`
+		DefaultVersion string   `json:"default_version"`
+		Versions       []string `json:"versions"`
+		URL            string   `json:"url"`
+	}
+	var out []item
+	for _, engine := range dbparams.Engines() {
+		c, _ := dbparams.Get(engine, "")
+		out = append(out, item{
+			Engine: engine, File: c.File, DefaultVersion: c.DefaultVersion,
+			Versions: c.Versions, URL: "/api/v1/parameters/" + engine,
+		})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// getParameterCatalog handles GET /api/v1/parameters/{engine}?version=16:
+// the parameters available in that version (default: the engine's default).
+func (s *Server) getParameterCatalog(w http.ResponseWriter, r *http.Request) {
+	engine := chi.URLParam(r, "engine")
+	c, ok := dbparams.Get(engine, r.URL.Query().Get("version"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"error": fmt.Sprintf("no parameter catalog for %q (available: %s)", engine, strings.Join(dbparams.Engines(), ", ")),
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, c)
+}
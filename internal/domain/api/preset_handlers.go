@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -108,18 +109,28 @@ func (s *Server) createPreset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	q := pgdb.New(s.pool)
 	id := uuid.New().String()
 
-	if err := q.CreatePreset(r.Context(), pgdb.CreatePresetParams{
-		ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
-		DbKind: req.DbKind, Topology: string(req.Topology), IsBuiltin: false,
-	}); err != nil {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "name already exists"})
+	var rev int
+	status := http.StatusInternalServerError
+	err := s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.CreatePreset(r.Context(), pgdb.CreatePresetParams{
+			ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
+			DbKind: req.DbKind, Topology: string(req.Topology), IsBuiltin: false,
+		}); err != nil {
+			status = http.StatusConflict
+			return errors.New("name already exists")
+		}
+		var err error
+		rev, err = recordRevision(r.Context(), presetHistory{q: q, tenantID: tenantID, id: id})
+		return err
+	})
+	if err != nil {
+		writeJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]string{"id": id})
+	writeJSON(w, http.StatusCreated, map[string]any{"id": id, "revision": rev})
 }
 
 // ─── Update ──────────────────────────────────────────────────────
@@ -160,15 +171,23 @@ func (s *Server) updatePreset(w http.ResponseWriter, r *http.Request) {
 		req.Name = existing.Name
 	}
 
-	if err := q.UpdatePreset(r.Context(), pgdb.UpdatePresetParams{
-		ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
-		Topology: topoStr,
-	}); err != nil {
+	var rev int
+	err = s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.UpdatePreset(r.Context(), pgdb.UpdatePresetParams{
+			ID: id, TenantID: tenantID, Name: req.Name, Description: req.Description,
+			Topology: topoStr,
+		}); err != nil {
+			return err
+		}
+		rev, err = recordRevision(r.Context(), presetHistory{q: q, tenantID: tenantID, id: id})
+		return err
+	})
+	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"status": "updated"})
+	writeJSON(w, http.StatusOK, map[string]any{"status": "updated", "revision": rev})
 }
 
 // ─── Delete ──────────────────────────────────────────────────────
@@ -201,15 +220,25 @@ func (s *Server) clonePreset(w http.ResponseWriter, r *http.Request) {
 	newID := uuid.New().String()
 	newName := src.Name + " (copy)"
 
-	if err := q.CreatePreset(r.Context(), pgdb.CreatePresetParams{
-		ID: newID, TenantID: tenantID, Name: newName, Description: src.Description,
-		DbKind: src.DbKind, Topology: src.Topology, IsBuiltin: false,
-	}); err != nil {
-		writeJSON(w, http.StatusConflict, map[string]string{"error": "clone failed: " + err.Error()})
+	var rev int
+	status := http.StatusInternalServerError
+	err = s.inTx(r.Context(), func(q *pgdb.Queries) error {
+		if err := q.CreatePreset(r.Context(), pgdb.CreatePresetParams{
+			ID: newID, TenantID: tenantID, Name: newName, Description: src.Description,
+			DbKind: src.DbKind, Topology: src.Topology, IsBuiltin: false,
+		}); err != nil {
+			status = http.StatusConflict
+			return fmt.Errorf("clone failed: %w", err)
+		}
+		rev, err = recordRevision(r.Context(), presetHistory{q: q, tenantID: tenantID, id: newID})
+		return err
+	})
+	if err != nil {
+		writeJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, map[string]string{"id": newID, "name": newName})
+	writeJSON(w, http.StatusCreated, map[string]any{"id": newID, "name": newName, "revision": rev})
 }
 
 // ─── Seed built-ins for new tenant ───────────────────────────────
@@ -238,15 +267,23 @@ func (s *Server) resolveRunPreset(ctx context.Context, tenantID string, cfg *typ
 		return nil
 	}
 
-	q := pgdb.New(s.pool)
-	row, err := q.GetPreset(ctx, pgdb.GetPresetParams{ID: cfg.PresetID, TenantID: tenantID})
+	var rev revisionDetail
+	err := s.inTx(ctx, func(q *pgdb.Queries) error {
+		var err error
+		rev, err = pinRevision(ctx, presetHistory{q: q, tenantID: tenantID, id: cfg.PresetID}, cfg.PresetRevision)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("preset not found: %w", err)
+		return fmt.Errorf("preset: %w", err)
+	}
+	var spec types.PresetSpec
+	if err := json.Unmarshal(rev.Spec, &spec); err != nil {
+		return fmt.Errorf("preset revision %d: %w", rev.Revision, err)
 	}
 
 	// Set db_kind from preset if not specified.
 	if cfg.Database.Kind == "" {
-		cfg.Database.Kind = types.DatabaseKind(row.DbKind)
+		cfg.Database.Kind = types.DatabaseKind(spec.DbKind)
 	}
 
 	// Topology from request takes priority over preset.
@@ -254,13 +291,14 @@ func (s *Server) resolveRunPreset(ctx context.Context, tenantID string, cfg *typ
 		return nil
 	}
 
-	// Apply topology from preset.
-	preset := types.Preset{DbKind: row.DbKind}
-	if err := preset.ParseTopology(row.Topology); err != nil {
+	// Apply topology from the preset revision, and record which one it was.
+	preset := types.Preset{DbKind: spec.DbKind}
+	if err := preset.ParseTopology(string(spec.Topology)); err != nil {
 		return fmt.Errorf("invalid preset topology: %w", err)
 	}
+	cfg.PresetRevision = rev.ref()
 
-	switch types.DatabaseKind(row.DbKind) {
+	switch types.DatabaseKind(spec.DbKind) {
 	case types.DatabasePostgres:
 		cfg.Database.Postgres = preset.Postgres
 	case types.DatabaseMySQL:
@@ -0,0 +1,421 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+// revisionDetail is one stored revision of a package or preset.
+type revisionDetail struct {
+	Revision  int             `json:"revision"`
+	Hash      string          `json:"hash"`
+	CreatedBy string          `json:"created_by,omitempty"`
+	CreatedAt string          `json:"created_at,omitempty"`
+	Spec      json.RawMessage `json:"spec,omitempty"`
+}
+
+// ref is what a run records to pin this revision.
+func (d revisionDetail) ref() *types.Revision {
+	return &types.Revision{Number: d.Revision, Hash: d.Hash}
+}
+
+// revisionHistory is the revision log of one package or preset.
+type revisionHistory interface {
+	// lock holds the package or preset row until the transaction ends, so
+	// concurrent writers number their revisions one after another.
+	lock(ctx context.Context) error
+	// current returns the revision holding the present content, appending
+	// one when the content changed since the latest revision.
+	current(ctx context.Context) (revisionDetail, error)
+	// head returns the latest recorded revision without recording one; the
+	// zero revisionDetail when none is. Fails when the package or preset
+	// does not exist.
+	head(ctx context.Context) (revisionDetail, error)
+	at(ctx context.Context, n int) (revisionDetail, error)
+	list(ctx context.Context) ([]revisionDetail, error)
+}
+
+func revisionActor(ctx context.Context) string {
+	if c := auth.GetClaims(ctx); c != nil {
+		if c.Username != "" {
+			return c.Username
+		}
+		return c.UserID
+	}
+	return ""
+}
+
+// ─── Packages ────────────────────────────────────────────────────
+
+type packageHistory struct {
+	q        *pgdb.Queries
+	tenantID string
+	id       string
+}
+
+func (h packageHistory) spec(ctx context.Context) (types.PackageSpec, error) {
+	pkg, err := h.q.GetPackage(ctx, pgdb.GetPackageParams{ID: h.id, TenantID: h.tenantID})
+	if err != nil {
+		return types.PackageSpec{}, err
+	}
+	deb, err := h.q.GetPackageDebMeta(ctx, pgdb.GetPackageDebMetaParams{ID: h.id, TenantID: h.tenantID})
+	if err != nil {
+		return types.PackageSpec{}, err
+	}
+	return types.PackageSpec{
+		Name: pkg.Name, Description: pkg.Description,
+		DbKind: pkg.DbKind, DbVersion: pkg.DbVersion,
+		AptPackages: pkg.AptPackages, PreInstall: pkg.PreInstall,
+		CustomRepo: pkg.CustomRepo, CustomRepoKey: pkg.CustomRepoKey,
+		DebFilename: pkg.DebFilename, DebSHA256: deb.DebSha256,
+		Source: decodeSource(pkg.Source), Tarball: decodeTarball(pkg.Tarball),
+	}, nil
+}
+
+func (h packageHistory) lock(ctx context.Context) error {
+	return h.q.LockPackage(ctx, pgdb.LockPackageParams{TenantID: h.tenantID, ID: h.id})
+}
+
+func (h packageHistory) current(ctx context.Context) (revisionDetail, error) {
+	spec, err := h.spec(ctx)
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	latest := func() (revisionDetail, bool, error) {
+		d, err := h.latest(ctx)
+		return d, err == nil && string(d.Spec) == string(raw), err
+	}
+	if d, same, err := latest(); err != nil || same {
+		return d, err
+	}
+	hash := spec.Hash()
+	n, err := h.q.CreatePackageRevision(ctx, pgdb.CreatePackageRevisionParams{
+		TenantID: h.tenantID, PackageID: h.id, ContentHash: hash, Spec: string(raw), CreatedBy: revisionActor(ctx),
+	})
+	if err != nil {
+		// A concurrent writer may have recorded the same content first.
+		if d, same, lerr := latest(); lerr == nil && same {
+			return d, nil
+		}
+		return revisionDetail{}, fmt.Errorf("record package revision: %w", err)
+	}
+	return revisionDetail{Revision: int(n), Hash: hash, Spec: raw}, nil
+}
+
+func (h packageHistory) latest(ctx context.Context) (revisionDetail, error) {
+	row, err := h.q.GetLatestPackageRevision(ctx, pgdb.GetLatestPackageRevisionParams{TenantID: h.tenantID, PackageID: h.id})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return revisionDetail{}, nil
+	}
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	return revisionDetail{Revision: int(row.Revision), Hash: row.ContentHash, Spec: json.RawMessage(row.Spec)}, nil
+}
+
+func (h packageHistory) head(ctx context.Context) (revisionDetail, error) {
+	if _, err := h.q.GetPackage(ctx, pgdb.GetPackageParams{ID: h.id, TenantID: h.tenantID}); err != nil {
+		return revisionDetail{}, err
+	}
+	return h.latest(ctx)
+}
+
+func (h packageHistory) at(ctx context.Context, n int) (revisionDetail, error) {
+	row, err := h.q.GetPackageRevision(ctx, pgdb.GetPackageRevisionParams{TenantID: h.tenantID, PackageID: h.id, Revision: int32(n)})
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	return revisionDetail{
+		Revision: int(row.Revision), Hash: row.ContentHash, CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z"), Spec: json.RawMessage(row.Spec),
+	}, nil
+}
+
+func (h packageHistory) list(ctx context.Context) ([]revisionDetail, error) {
+	rows, err := h.q.ListPackageRevisions(ctx, pgdb.ListPackageRevisionsParams{TenantID: h.tenantID, PackageID: h.id})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]revisionDetail, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, revisionDetail{
+			Revision: int(row.Revision), Hash: row.ContentHash, CreatedBy: row.CreatedBy,
+			CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+	return out, nil
+}
+
+func (s *Server) packageHistory(r *http.Request) packageHistory {
+	return packageHistory{q: pgdb.New(s.pool), tenantID: auth.TenantID(r.Context()), id: chi.URLParam(r, "id")}
+}
+
+func (s *Server) listPackageRevisions(w http.ResponseWriter, r *http.Request) {
+	s.listRevisions(w, r, s.packageHistory(r))
+}
+
+func (s *Server) getPackageRevision(w http.ResponseWriter, r *http.Request) {
+	s.getRevision(w, r, s.packageHistory(r))
+}
+
+func (s *Server) diffPackageRevisions(w http.ResponseWriter, r *http.Request) {
+	s.diffRevisions(w, r, s.packageHistory(r))
+}
+
+// ─── Presets ─────────────────────────────────────────────────────
+
+type presetHistory struct {
+	q        *pgdb.Queries
+	tenantID string
+	id       string
+}
+
+func (h presetHistory) lock(ctx context.Context) error {
+	return h.q.LockPreset(ctx, pgdb.LockPresetParams{TenantID: h.tenantID, ID: h.id})
+}
+
+func (h presetHistory) current(ctx context.Context) (revisionDetail, error) {
+	row, err := h.q.GetPreset(ctx, pgdb.GetPresetParams{ID: h.id, TenantID: h.tenantID})
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	spec := types.PresetSpec{
+		Name: row.Name, Description: row.Description, DbKind: row.DbKind, Topology: json.RawMessage(row.Topology),
+	}
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	latest := func() (revisionDetail, bool, error) {
+		d, err := h.latest(ctx)
+		return d, err == nil && string(d.Spec) == string(raw), err
+	}
+	if d, same, err := latest(); err != nil || same {
+		return d, err
+	}
+	hash := spec.Hash()
+	n, err := h.q.CreatePresetRevision(ctx, pgdb.CreatePresetRevisionParams{
+		TenantID: h.tenantID, PresetID: h.id, ContentHash: hash, Spec: string(raw), CreatedBy: revisionActor(ctx),
+	})
+	if err != nil {
+		if d, same, lerr := latest(); lerr == nil && same {
+			return d, nil
+		}
+		return revisionDetail{}, fmt.Errorf("record preset revision: %w", err)
+	}
+	return revisionDetail{Revision: int(n), Hash: hash, Spec: raw}, nil
+}
+
+func (h presetHistory) latest(ctx context.Context) (revisionDetail, error) {
+	row, err := h.q.GetLatestPresetRevision(ctx, pgdb.GetLatestPresetRevisionParams{TenantID: h.tenantID, PresetID: h.id})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return revisionDetail{}, nil
+	}
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	return revisionDetail{Revision: int(row.Revision), Hash: row.ContentHash, Spec: json.RawMessage(row.Spec)}, nil
+}
+
+func (h presetHistory) head(ctx context.Context) (revisionDetail, error) {
+	if _, err := h.q.GetPreset(ctx, pgdb.GetPresetParams{ID: h.id, TenantID: h.tenantID}); err != nil {
+		return revisionDetail{}, err
+	}
+	return h.latest(ctx)
+}
+
+func (h presetHistory) at(ctx context.Context, n int) (revisionDetail, error) {
+	row, err := h.q.GetPresetRevision(ctx, pgdb.GetPresetRevisionParams{TenantID: h.tenantID, PresetID: h.id, Revision: int32(n)})
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	return revisionDetail{
+		Revision: int(row.Revision), Hash: row.ContentHash, CreatedBy: row.CreatedBy,
+		CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z"), Spec: json.RawMessage(row.Spec),
+	}, nil
+}
+
+func (h presetHistory) list(ctx context.Context) ([]revisionDetail, error) {
+	rows, err := h.q.ListPresetRevisions(ctx, pgdb.ListPresetRevisionsParams{TenantID: h.tenantID, PresetID: h.id})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]revisionDetail, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, revisionDetail{
+			Revision: int(row.Revision), Hash: row.ContentHash, CreatedBy: row.CreatedBy,
+			CreatedAt: row.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		})
+	}
+	return out, nil
+}
+
+func (s *Server) presetHistory(r *http.Request) presetHistory {
+	return presetHistory{q: pgdb.New(s.pool), tenantID: auth.TenantID(r.Context()), id: chi.URLParam(r, "id")}
+}
+
+func (s *Server) listPresetRevisions(w http.ResponseWriter, r *http.Request) {
+	s.listRevisions(w, r, s.presetHistory(r))
+}
+
+func (s *Server) getPresetRevision(w http.ResponseWriter, r *http.Request) {
+	s.getRevision(w, r, s.presetHistory(r))
+}
+
+func (s *Server) diffPresetRevisions(w http.ResponseWriter, r *http.Request) {
+	s.diffRevisions(w, r, s.presetHistory(r))
+}
+
+// ─── Shared ──────────────────────────────────────────────────────
+
+// inTx runs fn with queries bound to a transaction, committed when fn
+// returns nil and rolled back otherwise. A change and its revision are
+// written in one, so neither is saved without the other.
+func (s *Server) inTx(ctx context.Context, fn func(q *pgdb.Queries) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	if err := fn(pgdb.New(s.pool).WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// recordRevision records the revision after a package or preset changed,
+// within the transaction of the change. Only writes record revisions;
+// reads of the history never do.
+func recordRevision(ctx context.Context, h revisionHistory) (int, error) {
+	if err := h.lock(ctx); err != nil {
+		return 0, err
+	}
+	d, err := h.current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return d.Revision, nil
+}
+
+// pinRevision returns the revision a run uses: pin when the run asks for a
+// specific one, else the current content. A pinned hash must match. Run it
+// in a transaction, as it may record the current content.
+func pinRevision(ctx context.Context, h revisionHistory, pin *types.Revision) (revisionDetail, error) {
+	if err := h.lock(ctx); err != nil {
+		return revisionDetail{}, err
+	}
+	d, err := h.current(ctx)
+	if err != nil {
+		return revisionDetail{}, err
+	}
+	if pin == nil || pin.Number == 0 {
+		return d, nil
+	}
+	if pin.Number != d.Revision {
+		if d, err = h.at(ctx, pin.Number); err != nil {
+			return revisionDetail{}, fmt.Errorf("revision %d: %w", pin.Number, err)
+		}
+	}
+	if pin.Hash != "" && pin.Hash != d.Hash {
+		return revisionDetail{}, fmt.Errorf("revision %d has hash %s, not %s", pin.Number, d.Hash, pin.Hash)
+	}
+	return d, nil
+}
+
+func (s *Server) listRevisions(w http.ResponseWriter, r *http.Request, h revisionHistory) {
+	if _, err := h.head(r.Context()); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	items, err := h.list(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, items)
+}
+
+func (s *Server) getRevision(w http.ResponseWriter, r *http.Request, h revisionHistory) {
+	n, err := strconv.Atoi(chi.URLParam(r, "rev"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid revision"})
+		return
+	}
+	d, err := h.at(r.Context(), n)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "revision not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// diffRevisions compares ?from= with ?to=. to defaults to the latest
+// revision, from to the one before to.
+func (s *Server) diffRevisions(w http.ResponseWriter, r *http.Request, h revisionHistory) {
+	ctx := r.Context()
+	cur, err := h.head(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if cur.Revision == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no revisions recorded"})
+		return
+	}
+	revParam := func(name string, def int) (int, error) {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			return def, nil
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s revision %q", name, v)
+		}
+		return n, nil
+	}
+	toN, err := revParam("to", cur.Revision)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	fromN, err := revParam("from", max(toN-1, 1))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	from, err := h.at(ctx, fromN)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("revision %d not found", fromN)})
+		return
+	}
+	to, err := h.at(ctx, toN)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("revision %d not found", toN)})
+		return
+	}
+	changes, err := types.DiffJSON(from.Spec, to.Spec)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	from.Spec, to.Spec = nil, nil
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from": from, "to": to, "same_content": from.Hash == to.Hash,
+		"changes": ensureSlice(changes),
+	})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// fakeHistory is an in-memory revision log whose current revision is the last.
+type fakeHistory []revisionDetail
+
+func (h fakeHistory) lock(context.Context) error { return nil }
+
+func (h fakeHistory) current(context.Context) (revisionDetail, error) { return h[len(h)-1], nil }
+
+func (h fakeHistory) head(context.Context) (revisionDetail, error) { return h[len(h)-1], nil }
+
+func (h fakeHistory) at(_ context.Context, n int) (revisionDetail, error) {
+	for _, d := range h {
+		if d.Revision == n {
+			return d, nil
+		}
+	}
+	return revisionDetail{}, fmt.Errorf("no revision %d", n)
+}
+
+func (h fakeHistory) list(context.Context) ([]revisionDetail, error) { return h, nil }
+
+func TestPinRevision(t *testing.T) {
+	ctx := context.Background()
+	h := fakeHistory{{Revision: 1, Hash: "aaa"}, {Revision: 2, Hash: "bbb"}}
+
+	if d, err := pinRevision(ctx, h, nil); err != nil || d.Revision != 2 {
+		t.Errorf("unpinned: got %d, %v; want current revision 2", d.Revision, err)
+	}
+	if d, err := pinRevision(ctx, h, &types.Revision{Number: 1}); err != nil || d.Hash != "aaa" {
+		t.Errorf("pinned: got %+v, %v", d, err)
+	}
+	if _, err := pinRevision(ctx, h, &types.Revision{Number: 1, Hash: "bbb"}); err == nil {
+		t.Error("hash mismatch must fail")
+	}
+	if _, err := pinRevision(ctx, h, &types.Revision{Number: 7}); err == nil {
+		t.Error("unknown revision must fail")
+	}
+}
@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/metrics"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/run"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+// Run results are the metrics of a finished workload, stored in Postgres so
+// comparisons outlive the VictoriaMetrics retention period.
+
+const (
+	// resultFlushDelay gives vmagent time to push the last samples of a
+	// workload before its result is collected.
+	resultFlushDelay = 30 * time.Second
+	// resultSeriesPoints caps each stored series.
+	resultSeriesPoints = 300
+	// resultCollectTimeout bounds collecting and storing one result.
+	resultCollectTimeout = 2 * time.Minute
+)
+
+// onNodeDone stores the run's result once run_stroppy completes, looks for
+// change points in the series of each of its tags and checks its gate.
+func (s *Server) onNodeDone(tenantID, runID, nodeID string) {
+	if nodeID != string(types.PhaseRunStroppy) || s.pool == nil || s.monitoringURL == "" {
+		return
+	}
+	go func() {
+		time.Sleep(resultFlushDelay)
+		ctx, cancel := context.WithTimeout(context.Background(), resultCollectTimeout)
+		defer cancel()
+		snap, err := s.app.storage.Load(ctx, tenantID, runID)
+		if err != nil || snap == nil {
+			s.logger.Warn("run result: load snapshot", zap.String("run", runID), zap.Error(err))
+			return
+		}
+		accountID, err := s.tenantAccountID(ctx, tenantID)
+		if err != nil {
+			s.logger.Warn("run result: account", zap.String("run", runID), zap.Error(err))
+			return
+		}
+		collector := s.metricsCollector(ctx, tenantID, accountID, extractDBKind(snap))
+		rm, err := s.collectRunResult(ctx, tenantID, runID, snap, collector)
+		s.checkGate(ctx, tenantID, runID, snap, rm, err)
+		if err != nil {
+			s.logger.Warn("run result: collect", zap.String("run", runID), zap.Error(err))
+			return
+		}
+		tags, _ := runLabels(snap)
+		for _, tag := range tags {
+			if err := s.detectTagChanges(ctx, tenantID, tag); err != nil {
+				s.logger.Warn("change points", zap.String("run", runID), zap.String("tag", tag), zap.Error(err))
+			}
+		}
+	}()
+}
+
+// runResult returns a run's metrics over its workload window: the stored
+// result when there is one, else a live collection. A live collection of a
+// settled workload is stored, so runs finished before results were kept, or
+// while the server was down, fill in on first use.
+func (s *Server) runResult(ctx context.Context, tenantID, runID string, snap *dag.Snapshot, collector *metrics.Collector) (*metrics.RunMetrics, error) {
+	if rm, err := s.storedRunResult(ctx, tenantID, runID); err != nil {
+		s.logger.Warn("run result: load", zap.String("run", runID), zap.Error(err))
+	} else if rm != nil {
+		return rm, nil
+	}
+	if resultSettled(snap) {
+		return s.collectRunResult(ctx, tenantID, runID, snap, collector)
+	}
+	tr, ok := workloadWindow(snap)
+	if !ok {
+		return nil, fmt.Errorf("%s: not found or has no timestamps and no explicit time range provided", runID)
+	}
+	return collector.Collect(ctx, runID, tr)
+}
+
+// collectRunResult collects a finished run over its workload window and
+// stores the result with downsampled series. A partial result, missing a
+// custom metric whose query failed, is returned but not stored, so the next
+// read collects it again.
+func (s *Server) collectRunResult(ctx context.Context, tenantID, runID string, snap *dag.Snapshot, collector *metrics.Collector) (*metrics.RunMetrics, error) {
+	tr, ok := workloadWindow(snap)
+	if !ok {
+		return nil, fmt.Errorf("%s: has no timestamps", runID)
+	}
+	rm, err := collector.Collect(ctx, runID, tr)
+	if err != nil {
+		return nil, err
+	}
+	for key, points := range rm.Series {
+		rm.Series[key] = metrics.Downsample(points, resultSeriesPoints)
+	}
+	if s.pool == nil || rm.Partial {
+		return rm, nil
+	}
+	data, err := json.Marshal(rm)
+	if err != nil {
+		return nil, fmt.Errorf("marshal run result: %w", err)
+	}
+	tags, hash := runLabels(snap)
+	if err := pgdb.New(s.pool).SaveRunResult(ctx, pgdb.SaveRunResultParams{
+		RunID:       runID,
+		TenantID:    tenantID,
+		DbKind:      extractDBKind(snap),
+		WindowStart: pgtype.Timestamptz{Time: tr.Start, Valid: true},
+		WindowEnd:   pgtype.Timestamptz{Time: tr.End, Valid: true},
+		Metrics:     string(data),
+		Tags:        tags,
+		ConfigHash:  hash,
+	}); err != nil {
+		// The caller still gets the metrics; the next read retries the save.
+		s.logger.Warn("run result: save", zap.String("run", runID), zap.Error(err))
+	}
+	return rm, nil
+}
+
+// storedRunResult loads a stored result; nil when the run has none.
+func (s *Server) storedRunResult(ctx context.Context, tenantID, runID string) (*metrics.RunMetrics, error) {
+	if s.pool == nil {
+		return nil, nil
+	}
+	row, err := pgdb.New(s.pool).GetRunResult(ctx, pgdb.GetRunResultParams{RunID: runID, TenantID: tenantID})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rm metrics.RunMetrics
+	if err := json.Unmarshal([]byte(row.Metrics), &rm); err != nil {
+		return nil, fmt.Errorf("unmarshal run result: %w", err)
+	}
+	return &rm, nil
+}
+
+// runLabels returns the tags and config hash of a run's recorded config.
+func runLabels(snap *dag.Snapshot) ([]string, string) {
+	if snap == nil || snap.State == nil || len(snap.State.RunConfig) == 0 {
+		return []string{}, ""
+	}
+	var cfg struct {
+		Tags []string `json:"tags"`
+	}
+	_ = json.Unmarshal(snap.State.RunConfig, &cfg)
+	if cfg.Tags == nil {
+		cfg.Tags = []string{}
+	}
+	hash, _ := run.ConfigHash(snap.State.RunConfig)
+	return cfg.Tags, hash
+}
+
+// resultSettled reports whether a run's workload completed long enough ago
+// for all its samples to have reached VictoriaMetrics.
+func resultSettled(snap *dag.Snapshot) bool {
+	if snap == nil {
+		return false
+	}
+	ns, ok := snap.Node(string(types.PhaseRunStroppy))
+	if !ok || ns.Status != dag.StatusDone {
+		return false
+	}
+	finished := workloadFinishedAt(snap)
+	return !finished.IsZero() && time.Since(finished) > resultFlushDelay
+}
+
+// workloadFinishedAt is when run_stroppy finished, or the run when the node
+// has no time recorded.
+func workloadFinishedAt(snap *dag.Snapshot) time.Time {
+	if ns, ok := snap.Node(string(types.PhaseRunStroppy)); ok && ns.FinishedAt != nil {
+		return *ns.FinishedAt
+	}
+	return snap.FinishedAt
+}
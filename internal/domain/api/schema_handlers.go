@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/schema"
+)
+
+// listSchemas handles GET /api/v1/schema.
+func (s *Server) listSchemas(w http.ResponseWriter, r *http.Request) {
+	type item struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+	var out []item
+	for _, name := range schema.Names() {
+		out = append(out, item{Name: name, URL: "/api/v1/schema/" + name})
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// getSchema handles GET /api/v1/schema/{name}. Schemas are public so editors
+// can fetch them from a config file's $schema without credentials.
+func (s *Server) getSchema(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimSuffix(chi.URLParam(r, "name"), ".json")
+	doc, ok := schema.Get(name)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"error": fmt.Sprintf("unknown schema %q (available: %s)", name, strings.Join(schema.Names(), ", ")),
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
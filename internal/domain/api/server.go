@@ -8,6 +8,7 @@ import (
 	"io/fs"
 	"net/http"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,6 +30,7 @@ import (
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/run"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/s3"
 	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/victoria"
 )
 
@@ -81,8 +83,20 @@ type Server struct {
 
 	// spaFS serves the embedded SPA files. If nil, SPA is not served.
 	spaFS http.FileSystem
+
+	// blobs stores package binaries in object storage. If nil, they stay in Postgres.
+	blobs *s3.BlobStore
+
+	// gatesInFlight holds the tenant/run keys of gates being checked.
+	gatesInFlight sync.Map
+
+	// metricsCache keeps collected metrics of finished windows across requests.
+	metricsCache *metrics.ResultCache
 }
 
+// metricsCacheSize is how many collected results the server keeps.
+const metricsCacheSize = 256
+
 // NewServer creates an HTTP server backed by the App.
 // monitoringURL is the vmauth base URL (empty = monitoring disabled).
 // grafanaURL is the Grafana base URL (empty = Grafana integration disabled).
@@ -102,6 +116,7 @@ func NewServer(app *App, logger *zap.Logger, pool *pgxpool.Pool, jwtSecret, moni
 		monitoringURL:   monitoringURL,
 		monitoringToken: monitoringToken,
 		grafanaURL:      grafanaURL,
+		metricsCache:    metrics.NewResultCache(metricsCacheSize),
 		grafanaDashboards: map[string]string{
 			"system":   "stroppy-system",
 			"postgres": "stroppy-postgres",
@@ -141,6 +156,8 @@ func NewServer(app *App, logger *zap.Logger, pool *pgxpool.Pool, jwtSecret, moni
 	app.listenAddr = listenAddr
 	// Wire JWT issuer for agent token generation.
 	app.jwtIssuer = s.jwtIssuer
+	// Wire the node hook that stores a run's result when its workload ends.
+	app.nodeDoneFunc = s.onNodeDone
 	return s
 }
 
@@ -181,6 +198,14 @@ func (s *Server) Router() http.Handler {
 	r.Post("/api/v1/auth/select-tenant", s.selectTenant)
 	r.Put("/api/v1/auth/password", s.changePassword)
 
+	// --- JSON Schemas for hand-written configs (public) ---
+	r.Get("/api/v1/schema", s.listSchemas)
+	r.Get("/api/v1/schema/{name}", s.getSchema)
+
+	// --- Database parameter catalog (UI autocomplete) ---
+	r.Get("/api/v1/parameters", s.listParameterCatalogs)
+	r.Get("/api/v1/parameters/{engine}", s.getParameterCatalog)
+
 	// --- Grafana config (infrastructure, not tenant) ---
 	r.Get("/api/v1/grafana", s.getGrafanaConfig)
 
@@ -192,15 +217,26 @@ func (s *Server) Router() http.Handler {
 		r.Get("/packages", s.listPackages)
 		r.Get("/packages/{id}", s.getPackage)
 		r.Get("/packages/{id}/deb", s.downloadPackageDeb)
+		r.Get("/packages/{id}/builds/{sha}/deb", s.downloadPackageBuild)
+		r.Get("/packages/{id}/revisions", s.listPackageRevisions)
+		r.Get("/packages/{id}/revisions/diff", s.diffPackageRevisions)
+		r.Get("/packages/{id}/revisions/{rev}", s.getPackageRevision)
 		r.Get("/runs", s.listRuns)
 		r.Get("/run/{runID}/status", s.runStatus)
 		r.Get("/run/{runID}/logs", s.runLogs)
 		r.Get("/run/{runID}/metrics", s.runMetrics)
+		r.Get("/run/{runID}/settings", s.runSettings)
 		r.Get("/compare", s.compareRuns)
+		r.Get("/metrics/defs", s.listMetricDefs)
+		r.Get("/trends", s.getTrends)
+		r.Get("/metrics/events", s.listMetricEvents)
 		r.Get("/stroppy-versions", s.stroppyVersions)
 		r.Get("/stroppy-commits", s.stroppyCommits)
 		r.Get("/presets", s.listPresetsTenant)
 		r.Get("/presets/{id}", s.getPreset)
+		r.Get("/presets/{id}/revisions", s.listPresetRevisions)
+		r.Get("/presets/{id}/revisions/diff", s.diffPresetRevisions)
+		r.Get("/presets/{id}/revisions/{rev}", s.getPresetRevision)
 		r.Get("/settings", s.getSettings)
 
 		// Operator+
@@ -216,8 +252,15 @@ func (s *Server) Router() http.Handler {
 			r.Put("/baseline/{name}", s.setBaseline)
 			r.Get("/baseline/{name}", s.getBaseline)
 			r.Get("/baselines", s.listBaselines)
+			r.Put("/baseline/{name}/gate", s.setGatePolicy)
+			r.Get("/baseline/{name}/gate", s.getGatePolicy)
+			r.Delete("/baseline/{name}/gate", s.deleteGatePolicy)
+			r.Get("/gates", s.listGatePolicies)
 			r.Post("/upload/deb", s.uploadPackage)
 			r.Post("/upload/rpm", s.uploadPackage)
+			r.Post("/metrics/defs", s.createMetricDef)
+			r.Put("/metrics/defs/{id}", s.updateMetricDef)
+			r.Delete("/metrics/defs/{id}", s.deleteMetricDef)
 			r.Post("/presets", s.createPreset)
 			r.Put("/presets/{id}", s.updatePreset)
 			r.Delete("/presets/{id}", s.deletePreset)
@@ -227,6 +270,7 @@ func (s *Server) Router() http.Handler {
 			r.Delete("/packages/{id}", s.deletePackage)
 			r.Post("/packages/{id}/clone", s.clonePackage)
 			r.Post("/packages/{id}/deb", s.uploadPackageDeb)
+			r.Put("/packages/{id}/builds/{sha}/deb", s.uploadPackageBuild)
 		})
 
 		// Owner+
@@ -289,6 +333,11 @@ func (s *Server) SetSPA(fsys fs.FS) {
 	s.spaFS = http.FS(fsys)
 }
 
+// SetBlobStore moves package binary storage to object storage.
+func (s *Server) SetBlobStore(b *s3.BlobStore) {
+	s.blobs = b
+}
+
 func (s *Server) serveSPA(w http.ResponseWriter, r *http.Request) {
 	// Try to serve the exact file first.
 	path := r.URL.Path
@@ -418,6 +467,11 @@ func (s *Server) runStart(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	// Convert deprecated fields so the stored snapshot uses the current schema.
+	if _, err := types.UpgradeRunConfig(&cfg); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
 
 	// Auto-generate ID if empty.
 	if cfg.ID == "" {
@@ -436,6 +490,20 @@ func (s *Server) runStart(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Report every problem at once rather than failing on the first node build.
+	if issues := run.CheckConfig(cfg); types.IssuesError(issues) != nil {
+		writeIssues(w, issues)
+		return
+	}
+
+	// A gated run must have something to be checked against.
+	if cfg.Gate != "" {
+		if err := s.checkGateConfig(r.Context(), tenantID, cfg.Gate); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+
 	// Fill machines from topology so quota checks see actual node specs.
 	run.FillMachinesFromTopology(&cfg)
 
@@ -504,11 +572,29 @@ func (s *Server) runValidate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.app.Validate(cfg); err != nil {
+	issues, err := types.UpgradeRunConfig(&cfg)
+	if err != nil {
 		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]string{"status": "valid"})
+	issues = append(issues, run.CheckConfig(cfg)...)
+	if types.IssuesError(issues) != nil {
+		writeIssues(w, issues)
+		return
+	}
+	if err := s.app.Validate(cfg); err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": err.Error(), "issues": ensureSlice(issues)})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "valid", "issues": ensureSlice(issues)})
+}
+
+// writeIssues answers 422 with every problem found in a run config.
+func writeIssues(w http.ResponseWriter, issues []types.ConfigIssue) {
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+		"error":  types.IssuesError(issues).Error(),
+		"issues": ensureSlice(issues),
+	})
 }
 
 func (s *Server) runDryRun(w http.ResponseWriter, r *http.Request) {
@@ -519,11 +605,22 @@ func (s *Server) runDryRun(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	issues, err := types.UpgradeRunConfig(&cfg)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+		return
+	}
+
 	// Resolve preset so dry-run sees the full topology.
 	if err := s.resolveRunPreset(r.Context(), tenantID, &cfg); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
 		return
 	}
+	issues = append(issues, run.CheckConfig(cfg)...)
+	if types.IssuesError(issues) != nil {
+		writeIssues(w, issues)
+		return
+	}
 
 	graphJSON, resolvedCfg, err := s.app.DryRun(cfg)
 	if err != nil {
@@ -541,7 +638,8 @@ func (s *Server) runDryRun(w http.ResponseWriter, r *http.Request) {
 		EffectiveConfig map[string]map[string]string `json:"effective_config,omitempty"`
 		StroppyConfig   string                       `json:"stroppy_config,omitempty"`
 		RenderedConfigs map[string]string            `json:"rendered_configs,omitempty"`
-	}{}
+		Issues          []types.ConfigIssue          `json:"issues,omitempty"`
+	}{Issues: issues}
 	// The graph JSON is the full graph object — extract nodes from it.
 	var graphObj map[string]json.RawMessage
 	if json.Unmarshal(graph, &graphObj) == nil {
@@ -580,7 +678,38 @@ func (s *Server) runStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "not found", http.StatusNotFound)
 		return
 	}
-	writeJSON(w, http.StatusOK, snap)
+	writeJSON(w, http.StatusOK, struct {
+		*dag.Snapshot
+		Gate *gateStatus `json:"gate,omitempty"`
+	}{snap, s.runGateStatus(r.Context(), tenantID, runID, snap)})
+}
+
+// runSettings compares the settings each database node reported after
+// configure against its rendered config. ?values=true adds the full capture.
+func (s *Server) runSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID := auth.TenantID(r.Context())
+	runID := chi.URLParam(r, "runID")
+
+	snap, err := s.app.storage.Load(r.Context(), tenantID, runID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if snap == nil || snap.State == nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	var cfg types.RunConfig
+	if err := json.Unmarshal(snap.State.RunConfig, &cfg); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "decode run config: " + err.Error()})
+		return
+	}
+	withValues := r.URL.Query().Get("values") == "true"
+	writeJSON(w, http.StatusOK, map[string]any{
+		"run_id": runID,
+		"kind":   cfg.Database.Kind,
+		"nodes":  run.DiffDBSettings(&cfg, snap.State.DBSettings, withValues),
+	})
 }
 
 func (s *Server) listRuns(w http.ResponseWriter, r *http.Request) {
@@ -918,6 +1047,25 @@ func extractDBKind(snap *dag.Snapshot) string {
 	return "postgres"
 }
 
+// extractOSTuning returns the effective OS settings per machine role from a snapshot.
+func extractOSTuning(snap *dag.Snapshot) map[string]map[string]string {
+	if snap == nil || snap.State == nil {
+		return nil
+	}
+	var out map[string]map[string]string
+	for component, values := range snap.State.EffectiveConfigs {
+		role, ok := strings.CutPrefix(component, run.TuningConfigPrefix)
+		if !ok {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]map[string]string)
+		}
+		out[role] = values
+	}
+	return out
+}
+
 func extractRunID(machineID string) string {
 	// Find the last two "-" separated segments and strip them.
 	parts := strings.Split(machineID, "-")
@@ -1295,10 +1443,22 @@ func (s *Server) tenantAccountID(ctx context.Context, tenantID string) (int32, e
 	return t.AccountID.Int32, nil
 }
 
-// metricsCollector returns a metrics.Collector configured for the given accountID and DB kind.
-func (s *Server) metricsCollector(accountID int32, dbKind string) *metrics.Collector {
-	prefix := fmt.Sprintf("%s/select/%d/prometheus", s.monitoringURL, accountID)
-	return metrics.NewCollectorForDB(victoria.NewClient(prefix, s.monitoringToken), dbKind)
+// metricsCollector returns a metrics.Collector configured for the given accountID and DB kind,
+// collecting the built-in metrics and the tenant's custom ones that apply to the kind.
+func (s *Server) metricsCollector(ctx context.Context, tenantID string, accountID int32, dbKind string) *metrics.Collector {
+	defs := metrics.MergeDefs(dbKind, s.customMetricDefs(ctx, tenantID))
+	return metrics.NewCollectorWithDefs(s.metricsClient(accountID), defs).
+		WithCache(s.metricsCache, s.metricsPrefix(accountID))
+}
+
+// metricsPrefix is the Prometheus API base URL of the given accountID.
+func (s *Server) metricsPrefix(accountID int32) string {
+	return fmt.Sprintf("%s/select/%d/prometheus", s.monitoringURL, accountID)
+}
+
+// metricsClient returns a VictoriaMetrics client for the given accountID.
+func (s *Server) metricsClient(accountID int32) *victoria.Client {
+	return victoria.NewClient(s.metricsPrefix(accountID), s.monitoringToken)
 }
 
 // logsBaseURL returns the VictoriaLogs query base URL for the given accountID.
@@ -1333,7 +1493,7 @@ func (s *Server) runMetrics(w http.ResponseWriter, r *http.Request) {
 	snap, _ := s.app.storage.Load(r.Context(), tenantID, runID)
 	dbKind := extractDBKind(snap)
 
-	collector := s.metricsCollector(accountID, dbKind)
+	collector := s.metricsCollector(r.Context(), tenantID, accountID, dbKind)
 	tr, err := parseTimeRange(r)
 	if err != nil {
 		if snap != nil && !snap.StartedAt.IsZero() {
@@ -1369,81 +1529,248 @@ func (s *Server) compareRuns(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
-	runA := r.URL.Query().Get("a")
-	runB := r.URL.Query().Get("b")
-	if runA == "" || runB == "" {
+	if r.URL.Query().Has("runs") {
+		s.compareMany(w, r, tenantID, accountID)
+		return
+	}
+	// Each side is one run or a group of repetitions: "a=r1,r2" or "a=r1&a=r2".
+	runsA := runIDList(r.URL.Query()["a"])
+	runsB := runIDList(r.URL.Query()["b"])
+	if len(runsA) == 0 || len(runsB) == 0 {
 		http.Error(w, "query params 'a' and 'b' (run IDs) are required", http.StatusBadRequest)
 		return
 	}
 
-	// Auto-resolve time range from run snapshots if not provided.
-	tr, err := parseTimeRange(r)
+	snaps := s.loadSnapshots(r.Context(), tenantID, append(slices.Clone(runsA), runsB...))
+	snapsA, snapsB := snaps[:len(runsA)], snaps[len(runsA):]
+	snapA, snapB := snapsA[0], snapsB[0]
+
+	// An explicit range applies to every run; otherwise each run is
+	// collected over its own workload window.
+	var explicit *metrics.TimeRange
+	if tr, err := parseTimeRange(r); err == nil {
+		explicit = &tr
+	}
+
+	metricsA, status, err := s.collectGroup(r.Context(), tenantID, accountID, runsA, snapsA, explicit)
 	if err != nil {
-		// Try to derive from run snapshots.
-		snapA, _ := s.app.storage.Load(r.Context(), tenantID, runA)
-		snapB, _ := s.app.storage.Load(r.Context(), tenantID, runB)
-		if snapA == nil || snapB == nil {
-			http.Error(w, "runs not found and no explicit time range provided", http.StatusBadRequest)
+		writeJSON(w, status, map[string]string{"error": "run A: " + err.Error()})
+		return
+	}
+	metricsB, status, err := s.collectGroup(r.Context(), tenantID, accountID, runsB, snapsB, explicit)
+	if err != nil {
+		writeJSON(w, status, map[string]string{"error": "run B: " + err.Error()})
+		return
+	}
+
+	comp := metrics.CompareGroups(metricsA, metricsB, compareThreshold(r))
+
+	// Enrich with run configs so the UI can show hardware info.
+	type enriched struct {
+		*metrics.Comparison
+		ConfigA json.RawMessage `json:"config_a,omitempty"`
+		ConfigB json.RawMessage `json:"config_b,omitempty"`
+		// OSTuningA/B map machine role → effective OS settings recorded by the tune_os phase.
+		OSTuningA map[string]map[string]string `json:"os_tuning_a,omitempty"`
+		OSTuningB map[string]map[string]string `json:"os_tuning_b,omitempty"`
+		// ConfigDiff lists every setting that differs between the runs.
+		ConfigDiff []run.ConfigChange `json:"config_diff"`
+	}
+	resp := enriched{Comparison: comp, ConfigDiff: []run.ConfigChange{}}
+	var stateA, stateB *dag.RunState
+	if snapA != nil && snapA.State != nil {
+		stateA = snapA.State
+		resp.ConfigA = snapA.State.RunConfig
+		resp.OSTuningA = extractOSTuning(snapA)
+	}
+	if snapB != nil && snapB.State != nil {
+		stateB = snapB.State
+		resp.ConfigB = snapB.State.RunConfig
+		resp.OSTuningB = extractOSTuning(snapB)
+	}
+	if changes, err := run.DiffRuns(stateA, stateB); err != nil {
+		s.logger.Warn("compare: config diff", zap.Error(err))
+	} else if changes != nil {
+		resp.ConfigDiff = changes
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// maxCompareRuns caps an N-way comparison; each run costs a round of queries.
+const maxCompareRuns = 20
+
+// compareMany handles GET /compare?runs=r1,r2,...&ref=r2: every run against
+// the reference (the first run unless ref names another), each collected
+// over its own workload window or the explicit start/end.
+func (s *Server) compareMany(w http.ResponseWriter, r *http.Request, tenantID string, accountID int32) {
+	runs := runIDList(r.URL.Query()["runs"])
+	if len(runs) < 2 || len(runs) > maxCompareRuns {
+		http.Error(w, fmt.Sprintf("query param 'runs' needs 2 to %d run IDs", maxCompareRuns), http.StatusBadRequest)
+		return
+	}
+	ref := 0
+	seen := make(map[string]bool, len(runs))
+	for i, id := range runs {
+		if seen[id] {
+			http.Error(w, "run "+id+" is listed twice", http.StatusBadRequest)
 			return
 		}
-		start := snapA.StartedAt
-		if !snapB.StartedAt.IsZero() && snapB.StartedAt.Before(start) {
-			start = snapB.StartedAt
-		}
-		end := snapA.FinishedAt
-		if !snapB.FinishedAt.IsZero() && snapB.FinishedAt.After(end) {
-			end = snapB.FinishedAt
-		}
-		if start.IsZero() || end.IsZero() {
-			http.Error(w, "runs have no timestamps and no explicit time range provided", http.StatusBadRequest)
-			return
+		seen[id] = true
+		if id == r.URL.Query().Get("ref") {
+			ref = i
 		}
-		// Add padding to capture metrics at boundaries.
-		tr = metrics.TimeRange{Start: start.Add(-30 * time.Second), End: end.Add(30 * time.Second)}
+	}
+	if refID := r.URL.Query().Get("ref"); refID != "" && !seen[refID] {
+		http.Error(w, "reference run "+refID+" is not in 'runs'", http.StatusBadRequest)
+		return
 	}
 
-	// Use dbKind from run A for metric queries.
-	snapA2, _ := s.app.storage.Load(r.Context(), tenantID, runA)
-	collector := s.metricsCollector(accountID, extractDBKind(snapA2))
+	var explicit *metrics.TimeRange
+	if tr, err := parseTimeRange(r); err == nil {
+		explicit = &tr
+	}
 
-	metricsA, err := collector.Collect(r.Context(), runA, tr)
+	snaps := s.loadSnapshots(r.Context(), tenantID, runs)
+	collected, status, err := s.collectGroup(r.Context(), tenantID, accountID, runs, snaps, explicit)
 	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "run A: " + err.Error()})
+		writeJSON(w, status, map[string]string{"error": err.Error()})
 		return
 	}
+	matrix := metrics.CompareMany(collected, ref, compareThreshold(r))
 
-	metricsB, err := collector.Collect(r.Context(), runB, tr)
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "run B: " + err.Error()})
-		return
+	// ConfigDiff maps each run to what differs from the reference.
+	resp := struct {
+		*metrics.Matrix
+		ConfigDiff map[string][]run.ConfigChange `json:"config_diff"`
+	}{Matrix: matrix, ConfigDiff: make(map[string][]run.ConfigChange, len(runs)-1)}
+	refState := snapState(snaps[ref])
+	for i, id := range runs {
+		if i == ref {
+			continue
+		}
+		changes, err := run.DiffRuns(refState, snapState(snaps[i]))
+		if err != nil {
+			s.logger.Warn("compare: config diff", zap.String("run", id), zap.Error(err))
+			continue
+		}
+		resp.ConfigDiff[id] = ensureSlice(changes)
 	}
+	writeJSON(w, http.StatusOK, resp)
+}
 
-	threshold := 5.0 // default
+// compareThreshold is the ?threshold= percentage, 5 by default.
+func compareThreshold(r *http.Request) float64 {
 	if t := r.URL.Query().Get("threshold"); t != "" {
 		if v, err := strconv.ParseFloat(t, 64); err == nil && v > 0 {
-			threshold = v
+			return v
 		}
 	}
-	comp := metrics.Compare(metricsA, metricsB, threshold)
-	comp.Start = tr.Start
-	comp.End = tr.End
+	return 5.0
+}
 
-	// Enrich with run configs so the UI can show hardware info.
-	snapAFull, _ := s.app.storage.Load(r.Context(), tenantID, runA)
-	snapBFull, _ := s.app.storage.Load(r.Context(), tenantID, runB)
-	type enriched struct {
-		*metrics.Comparison
-		ConfigA json.RawMessage `json:"config_a,omitempty"`
-		ConfigB json.RawMessage `json:"config_b,omitempty"`
+func snapState(snap *dag.Snapshot) *dag.RunState {
+	if snap == nil {
+		return nil
 	}
-	resp := enriched{Comparison: comp}
-	if snapAFull != nil && snapAFull.State != nil {
-		resp.ConfigA = snapAFull.State.RunConfig
+	return snap.State
+}
+
+// runIDList splits repeated and comma-separated run ID params.
+func runIDList(values []string) []string {
+	var ids []string
+	for _, v := range values {
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
 	}
-	if snapBFull != nil && snapBFull.State != nil {
-		resp.ConfigB = snapBFull.State.RunConfig
+	return ids
+}
+
+// loadSnapshots loads the snapshot of each run, once per distinct ID; a run
+// listed twice shares its snapshot.
+func (s *Server) loadSnapshots(ctx context.Context, tenantID string, runIDs []string) []*dag.Snapshot {
+	snaps := make([]*dag.Snapshot, len(runIDs))
+	loaded := make(map[string]*dag.Snapshot, len(runIDs))
+	for i, id := range runIDs {
+		snap, ok := loaded[id]
+		if !ok {
+			snap, _ = s.app.storage.Load(ctx, tenantID, id)
+			loaded[id] = snap
+		}
+		snaps[i] = snap
 	}
-	writeJSON(w, http.StatusOK, resp)
+	return snaps
+}
+
+// collectGroup collects each run over explicit, or takes its result over its
+// own workload window when explicit is nil. Each run is collected with the
+// metrics of its own database kind, so a stored result never holds another
+// kind's metric set. Runs are collected in parallel; each kind's collector
+// bounds the queries in flight. The status is for the error, if any.
+func (s *Server) collectGroup(ctx context.Context, tenantID string, accountID int32, runIDs []string, snaps []*dag.Snapshot, explicit *metrics.TimeRange) ([]*metrics.RunMetrics, int, error) {
+	if explicit == nil {
+		for i, id := range runIDs {
+			if _, ok := workloadWindow(snaps[i]); !ok {
+				return nil, http.StatusBadRequest, fmt.Errorf("%s: not found or has no timestamps and no explicit time range provided", id)
+			}
+		}
+	}
+	collectors := make(map[string]*metrics.Collector)
+	for _, snap := range snaps {
+		if kind := extractDBKind(snap); collectors[kind] == nil {
+			collectors[kind] = s.metricsCollector(ctx, tenantID, accountID, kind)
+		}
+	}
+
+	out := make([]*metrics.RunMetrics, len(runIDs))
+	errs := make([]error, len(runIDs))
+	var wg sync.WaitGroup
+	for i, id := range runIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector := collectors[extractDBKind(snaps[i])]
+			if explicit == nil {
+				out[i], errs[i] = s.runResult(ctx, tenantID, id, snaps[i], collector)
+			} else {
+				out[i], errs[i] = collector.Collect(ctx, id, *explicit)
+			}
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("%s: %w", runIDs[i], err)
+		}
+	}
+	return out, http.StatusOK, nil
+}
+
+// workloadWindow is the range a run's metrics are collected over: the
+// run_stroppy node's own timings, so setup phases stay out of the averages.
+// Runs without recorded node timings fall back to the whole run, padded to
+// catch samples at the boundaries. An unfinished window ends now.
+func workloadWindow(snap *dag.Snapshot) (metrics.TimeRange, bool) {
+	if snap == nil {
+		return metrics.TimeRange{}, false
+	}
+	if ns, ok := snap.Node(string(types.PhaseRunStroppy)); ok && ns.StartedAt != nil {
+		end := time.Now()
+		if ns.FinishedAt != nil {
+			end = *ns.FinishedAt
+		}
+		return metrics.TimeRange{Start: *ns.StartedAt, End: end}, true
+	}
+	if snap.StartedAt.IsZero() {
+		return metrics.TimeRange{}, false
+	}
+	end := snap.FinishedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return metrics.TimeRange{Start: snap.StartedAt.Add(-30 * time.Second), End: end.Add(30 * time.Second)}, true
 }
 
 func parseTimeRange(r *http.Request) (metrics.TimeRange, error) {
@@ -28,22 +28,28 @@ func (s *Server) createShareLink(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Collect metrics.
-	accountID, _ := s.tenantAccountID(r.Context(), tenantID)
-	dbKind := extractDBKind(snap)
-	collector := s.metricsCollector(accountID, dbKind)
-
-	start := snap.StartedAt.Add(-30 * time.Second)
-	end := snap.FinishedAt.Add(30 * time.Second)
-	if end.Before(start) || end.IsZero() {
-		end = time.Now()
+	// Use the stored result of a finished run; collect live otherwise.
+	runMetrics, err := s.storedRunResult(r.Context(), tenantID, runID)
+	if err != nil {
+		s.logger.Warn("share: failed to load run result", zap.Error(err))
 	}
+	if runMetrics == nil {
+		accountID, _ := s.tenantAccountID(r.Context(), tenantID)
+		dbKind := extractDBKind(snap)
+		collector := s.metricsCollector(r.Context(), tenantID, accountID, dbKind)
 
-	runMetrics, err := collector.Collect(r.Context(), runID, metrics.TimeRange{Start: start, End: end})
-	if err != nil {
-		s.logger.Warn("share: failed to collect metrics", zap.Error(err))
-		// Continue with empty metrics — snapshot is still valuable.
-		runMetrics = &metrics.RunMetrics{}
+		start := snap.StartedAt.Add(-30 * time.Second)
+		end := snap.FinishedAt.Add(30 * time.Second)
+		if end.Before(start) || end.IsZero() {
+			end = time.Now()
+		}
+
+		runMetrics, err = collector.Collect(r.Context(), runID, metrics.TimeRange{Start: start, End: end})
+		if err != nil {
+			s.logger.Warn("share: failed to collect metrics", zap.Error(err))
+			// Continue with empty metrics — snapshot is still valuable.
+			runMetrics = &metrics.RunMetrics{}
+		}
 	}
 
 	metricsJSON, _ := json.Marshal(runMetrics)
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/metrics"
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+const (
+	defaultTrendLimit = 50
+	maxTrendLimit     = 500
+)
+
+// trendResponse is the body of GET /api/v1/trends.
+type trendResponse struct {
+	Tag      string `json:"tag,omitempty"`
+	Baseline string `json:"baseline,omitempty"`
+	// BaselineRun is the run the baseline names; its ConfigHash selected
+	// the runs.
+	BaselineRun string           `json:"baseline_run,omitempty"`
+	ConfigHash  string           `json:"config_hash,omitempty"`
+	Runs        int              `json:"runs"`
+	Trends      []*metrics.Trend `json:"trends"`
+}
+
+// getTrends handles GET /api/v1/trends?metric=stroppy_ops,db_tps&tag=nightly
+// &baseline=postgres-16&db_kind=postgres&limit=50: the stored results of
+// the latest runs, oldest first, one trend per metric. A tag selects the
+// runs carrying it; a baseline selects the runs configured like the
+// baseline's run.
+func (s *Server) getTrends(w http.ResponseWriter, r *http.Request) {
+	if s.pool == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "trends need a database"})
+		return
+	}
+	tenantID := auth.TenantID(r.Context())
+	q := r.URL.Query()
+
+	keys := runIDList(q["metric"])
+	if len(keys) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "query param 'metric' is required"})
+		return
+	}
+	limit := defaultTrendLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxTrendLimit {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be 1 to " + strconv.Itoa(maxTrendLimit)})
+			return
+		}
+		limit = n
+	}
+
+	resp := trendResponse{Tag: q.Get("tag"), Baseline: q.Get("baseline"), Trends: []*metrics.Trend{}}
+	if resp.Baseline != "" {
+		runID, err := s.app.Storage().GetBaseline(r.Context(), tenantID, resp.Baseline)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if runID == "" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "baseline not found"})
+			return
+		}
+		resp.BaselineRun = runID
+		row, err := pgdb.New(s.pool).GetRunResult(r.Context(), pgdb.GetRunResultParams{RunID: runID, TenantID: tenantID})
+		switch {
+		case err == nil:
+			resp.ConfigHash = row.ConfigHash
+		case errors.Is(err, pgx.ErrNoRows):
+			// No stored result yet: hash the baseline's recorded config.
+			snap, _ := s.app.storage.Load(r.Context(), tenantID, runID)
+			_, resp.ConfigHash = runLabels(snap)
+		default:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if resp.ConfigHash == "" {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "baseline run " + runID + " has no recorded config"})
+			return
+		}
+	}
+
+	rows, err := pgdb.New(s.pool).ListRunResults(r.Context(), pgdb.ListRunResultsParams{
+		TenantID:   tenantID,
+		Tag:        resp.Tag,
+		ConfigHash: resp.ConfigHash,
+		DbKind:     q.Get("db_kind"),
+		MaxRows:    int32(limit),
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	slices.Reverse(rows) // oldest first
+
+	for _, key := range keys {
+		resp.Trends = append(resp.Trends, metrics.NewTrend(key))
+	}
+	for _, row := range rows {
+		var rm metrics.RunMetrics
+		if err := json.Unmarshal([]byte(row.Metrics), &rm); err != nil {
+			s.logger.Warn("trends: skip run result", zap.String("run", row.RunID), zap.Error(err))
+			continue
+		}
+		resp.Runs++
+		for _, t := range resp.Trends {
+			t.Add(&rm, row.WindowStart.Time, row.ConfigHash, row.Tags)
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
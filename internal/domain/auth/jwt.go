@@ -13,6 +13,8 @@ type Claims struct {
 	TenantID string `json:"tenant_id,omitempty"`
 	Role     string `json:"role,omitempty"`
 	IsRoot   bool   `json:"is_root,omitempty"`
+	// Scope, when set, limits the token to requests for this one path.
+	Scope string `json:"scope,omitempty"`
 }
 
 type jwtClaims struct {
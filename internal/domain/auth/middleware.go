@@ -35,6 +35,10 @@ func NewAuthMiddleware(jwt *JWTIssuer, pool *pgxpool.Pool) func(http.Handler) ht
 
 			// 1. Try JWT.
 			if claims, err := jwt.Parse(token); err == nil {
+				if claims.Scope != "" && claims.Scope != r.URL.Path {
+					http.Error(w, "token not valid for this path", http.StatusForbidden)
+					return
+				}
 				ctx := WithClaims(r.Context(), claims)
 				next.ServeHTTP(w, r.WithContext(ctx))
 				return
@@ -122,6 +126,10 @@ func isPublicPath(path string) bool {
 	case "/api/v1/auth/login", "/api/v1/auth/refresh", "/api/v1/auth/logout":
 		return true
 	}
+	// JSON Schemas describe the config format, nothing tenant-specific.
+	if path == "/api/v1/schema" || strings.HasPrefix(path, "/api/v1/schema/") {
+		return true
+	}
 	// Public share links.
 	if strings.HasPrefix(path, "/api/share/") {
 		return true
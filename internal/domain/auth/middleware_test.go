@@ -34,6 +34,24 @@ func TestAuthMiddleware_ValidJWT(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_ScopedJWT(t *testing.T) {
+	issuer := issuerForTest()
+	mw := NewAuthMiddleware(issuer, nil)
+
+	scope := "/api/v1/packages/p1/builds/abc/deb"
+	token, _ := issuer.Issue(Claims{UserID: "d1", TenantID: "t1", Role: "viewer", Scope: scope}, 15*time.Minute)
+
+	for path, want := range map[string]int{scope: http.StatusOK, "/api/v1/runs": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, path+"?recipe=r", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		mw(okHandler()).ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+}
+
 func TestAuthMiddleware_NoAuth(t *testing.T) {
 	issuer := issuerForTest()
 	mw := NewAuthMiddleware(issuer, nil)
@@ -51,7 +69,7 @@ func TestAuthMiddleware_PublicPaths(t *testing.T) {
 	issuer := issuerForTest()
 	mw := NewAuthMiddleware(issuer, nil)
 
-	paths := []string{"/health", "/api/v1/auth/login", "/api/v1/auth/refresh"}
+	paths := []string{"/health", "/api/v1/auth/login", "/api/v1/auth/refresh", "/api/v1/schema/run-config"}
 	for _, p := range paths {
 		req := httptest.NewRequest(http.MethodGet, p, nil)
 		rec := httptest.NewRecorder()
@@ -0,0 +1,256 @@
+package dbconfig
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// DiskClass is the storage speed tier auto-tuning plans I/O settings for.
+type DiskClass string
+
+const (
+	DiskHDD  DiskClass = "hdd"
+	DiskSSD  DiskClass = "ssd"
+	DiskNVMe DiskClass = "nvme"
+)
+
+// DiskClassOf maps a cloud disk type (MachineSpec.DiskType) to a DiskClass.
+// Unknown and empty types are treated as network SSD, the provisioner default.
+func DiskClassOf(diskType string) DiskClass {
+	t := strings.ToLower(diskType)
+	switch {
+	case strings.Contains(t, "hdd"):
+		return DiskHDD
+	case strings.Contains(t, "nonreplicated"), strings.Contains(t, "io-m3"), strings.Contains(t, "nvme"):
+		return DiskNVMe
+	default:
+		return DiskSSD
+	}
+}
+
+// Hardware is what auto-tuning sizes settings against. Zero CPUs are treated
+// as one; MemoryMB must be positive for memory settings to be produced.
+type Hardware struct {
+	CPUs     int
+	MemoryMB int
+	Disk     DiskClass
+}
+
+func (hw Hardware) cpus() int {
+	return max(hw.CPUs, 1)
+}
+
+// TunePostgres returns postgresql.conf settings for profile on hw, following
+// the pgtune formulas. maxConnections sizes work_mem; pass the effective
+// max_connections so a user override is honoured. Returns nil for an empty
+// or unknown profile.
+func TunePostgres(profile string, hw Hardware, maxConnections int) map[string]string {
+	if !types.IsAutoTuneProfile(profile) || hw.MemoryMB <= 0 {
+		return nil
+	}
+	memMB := hw.MemoryMB
+	cpus := hw.cpus()
+	if maxConnections <= 0 {
+		maxConnections = 100
+	}
+
+	sharedMB := memMB / 4
+	m := map[string]string{
+		"shared_buffers":       memSize(sharedMB),
+		"effective_cache_size": memSize(memMB * 3 / 4),
+		"wal_buffers":          memSize(clamp(sharedMB*3/100, 1, 16)),
+		"random_page_cost":     "1.1",
+	}
+
+	maintDiv, statsTarget := 16, 100
+	minWAL, maxWAL := 2048, 8192
+	switch profile {
+	case types.AutoTuneAnalytic:
+		maintDiv, statsTarget = 8, 500
+		minWAL, maxWAL = 4096, 16384
+	case types.AutoTuneMixed:
+		minWAL, maxWAL = 1024, 4096
+	}
+	m["maintenance_work_mem"] = memSize(min(memMB/maintDiv, 2048))
+	m["default_statistics_target"] = strconv.Itoa(statsTarget)
+	m["min_wal_size"] = memSize(minWAL)
+	m["max_wal_size"] = memSize(maxWAL)
+
+	switch hw.Disk {
+	case DiskHDD:
+		m["random_page_cost"] = "4"
+		m["effective_io_concurrency"] = "2"
+	case DiskNVMe:
+		m["effective_io_concurrency"] = "300"
+	default:
+		m["effective_io_concurrency"] = "200"
+	}
+
+	workersPerGather := 1
+	if cpus >= 4 {
+		workersPerGather = (cpus + 1) / 2
+		if profile != types.AutoTuneAnalytic {
+			workersPerGather = min(workersPerGather, 4)
+		}
+		m["max_worker_processes"] = strconv.Itoa(cpus)
+		m["max_parallel_workers"] = strconv.Itoa(cpus)
+		m["max_parallel_workers_per_gather"] = strconv.Itoa(workersPerGather)
+		m["max_parallel_maintenance_workers"] = strconv.Itoa(min((cpus+1)/2, 4))
+	}
+
+	// Each connection may run a few sort/hash nodes, each with its own
+	// parallel workers; analytic and mixed plans run more of them at once.
+	workKB := (memMB - sharedMB) * 1024 / (maxConnections * 3) / workersPerGather
+	if profile != types.AutoTuneOLTP {
+		workKB /= 2
+	}
+	m["work_mem"] = fmt.Sprintf("%dkB", max(workKB, 64))
+
+	if memMB >= 32*1024 {
+		m["huge_pages"] = "try"
+	}
+	return m
+}
+
+// TuneMySQL returns my.cnf settings for profile on hw. Returns nil for an
+// empty or unknown profile.
+func TuneMySQL(profile string, hw Hardware) map[string]string {
+	if !types.IsAutoTuneProfile(profile) || hw.MemoryMB <= 0 {
+		return nil
+	}
+	memMB := hw.MemoryMB
+	cpus := hw.cpus()
+
+	// Small hosts keep more room for per-connection buffers and the OS.
+	poolMB := memMB / 2
+	if memMB >= 8192 {
+		poolMB = memMB * 3 / 4
+	}
+	instances := clamp(poolMB/1024, 1, 64)
+
+	m := map[string]string{
+		"innodb_buffer_pool_size":      mysqlSize(poolMB),
+		"innodb_buffer_pool_instances": strconv.Itoa(instances),
+		"innodb_page_cleaners":         strconv.Itoa(min(instances, cpus)),
+		"innodb_read_io_threads":       strconv.Itoa(clamp(cpus, 4, 64)),
+		"innodb_write_io_threads":      strconv.Itoa(clamp(cpus/2, 4, 64)),
+	}
+
+	// Redo log sized for roughly an hour of writes on the buffer pool: an
+	// eighth for OLTP, a quarter when large loads and rebuilds dominate.
+	logDiv := 8
+	if profile == types.AutoTuneAnalytic {
+		logDiv = 4
+	}
+	m["innodb_log_file_size"] = mysqlSize(clamp(poolMB/logDiv, 256, 4096))
+
+	switch hw.Disk {
+	case DiskHDD:
+		m["innodb_io_capacity"] = "200"
+		m["innodb_io_capacity_max"] = "400"
+		m["innodb_flush_neighbors"] = "1"
+	case DiskNVMe:
+		m["innodb_io_capacity"] = "10000"
+		m["innodb_io_capacity_max"] = "20000"
+		m["innodb_flush_neighbors"] = "0"
+	default:
+		m["innodb_io_capacity"] = "2000"
+		m["innodb_io_capacity_max"] = "4000"
+		m["innodb_flush_neighbors"] = "0"
+	}
+
+	tmpMB := 64
+	switch profile {
+	case types.AutoTuneAnalytic:
+		tmpMB = clamp(memMB/32, 256, 2048)
+		m["sort_buffer_size"] = "4M"
+		m["join_buffer_size"] = "4M"
+	case types.AutoTuneMixed:
+		tmpMB = 128
+	}
+	m["tmp_table_size"] = mysqlSize(tmpMB)
+	m["max_heap_table_size"] = mysqlSize(tmpMB)
+	return m
+}
+
+// TunePicodata returns picodata settings for profile on hw. Picodata keeps
+// its data in the memtx arena, so the arena takes most of the RAM; analytic
+// queries need more headroom for SQL temporary spaces. Returns nil for an
+// empty or unknown profile.
+func TunePicodata(profile string, hw Hardware) map[string]string {
+	if !types.IsAutoTuneProfile(profile) || hw.MemoryMB <= 0 {
+		return nil
+	}
+	pct := 60
+	switch profile {
+	case types.AutoTuneAnalytic:
+		pct = 50
+	case types.AutoTuneMixed:
+		pct = 55
+	}
+	// Always "<N>MB": picodataMemtxBytes only understands megabytes.
+	return map[string]string{
+		"memtx_memory": fmt.Sprintf("%dMB", max(hw.MemoryMB*pct/100, 256)),
+	}
+}
+
+// YDBTuning holds the ydb.yaml settings auto-tuning controls.
+type YDBTuning struct {
+	CPUCount  int    // actor_system_config.cpu_count
+	DriveType string // host_configs drive type: ROT, SSD or NVME
+	// memory_controller_config shares of the hard limit.
+	SharedCacheMinPercent      int
+	SharedCacheMaxPercent      int
+	QueryExecutionLimitPercent int
+}
+
+// TuneYDB returns ydb.yaml settings for profile on hw. One core is left to
+// the OS and agent on hosts with 8 or more CPUs. OLTP favours the shared
+// page cache, analytic favours query execution memory. ok is false for an
+// empty or unknown profile.
+func TuneYDB(profile string, hw Hardware) (t YDBTuning, ok bool) {
+	if !types.IsAutoTuneProfile(profile) {
+		return YDBTuning{}, false
+	}
+	t.CPUCount = hw.CPUs
+	if hw.CPUs >= 8 {
+		t.CPUCount = hw.CPUs - 1
+	}
+	switch hw.Disk {
+	case DiskHDD:
+		t.DriveType = "ROT"
+	case DiskNVMe:
+		t.DriveType = "NVME"
+	default:
+		t.DriveType = "SSD"
+	}
+	switch profile {
+	case types.AutoTuneOLTP:
+		t.SharedCacheMinPercent, t.SharedCacheMaxPercent, t.QueryExecutionLimitPercent = 30, 60, 15
+	case types.AutoTuneAnalytic:
+		t.SharedCacheMinPercent, t.SharedCacheMaxPercent, t.QueryExecutionLimitPercent = 10, 30, 40
+	default:
+		t.SharedCacheMinPercent, t.SharedCacheMaxPercent, t.QueryExecutionLimitPercent = 20, 50, 25
+	}
+	return t, true
+}
+
+// memSize formats megabytes as "<N>GB" when whole, otherwise "<N>MB".
+func memSize(mb int) string {
+	if mb >= 1024 && mb%1024 == 0 {
+		return fmt.Sprintf("%dGB", mb/1024)
+	}
+	return fmt.Sprintf("%dMB", mb)
+}
+
+// mysqlSize is memSize with my.cnf's "M"/"G" suffixes.
+func mysqlSize(mb int) string {
+	return strings.TrimSuffix(memSize(mb), "B")
+}
+
+func clamp(v, lo, hi int) int {
+	return min(max(v, lo), hi)
+}
@@ -0,0 +1,154 @@
+package dbconfig
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/dbparams"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+func TestDiskClassOf(t *testing.T) {
+	for diskType, want := range map[string]DiskClass{
+		"":                          DiskSSD,
+		"network-hdd":               DiskHDD,
+		"network-ssd":               DiskSSD,
+		"network-ssd-nonreplicated": DiskNVMe,
+		"network-ssd-io-m3":         DiskNVMe,
+	} {
+		if got := DiskClassOf(diskType); got != want {
+			t.Errorf("DiskClassOf(%q) = %s, want %s", diskType, got, want)
+		}
+	}
+}
+
+func TestRenderPostgresConf_AutoTuneLargeHost(t *testing.T) {
+	opts := RenderPostgresConfOpts{
+		Version:       "16",
+		Role:          "master",
+		TotalMemoryMB: 65536,
+		AutoTune:      types.AutoTuneOLTP,
+		CPUs:          16,
+		DiskType:      "network-ssd-nonreplicated",
+		Options:       map[string]string{"work_mem": "32MB"},
+	}
+	out := RenderPostgresConf(opts)
+	for _, want := range []string{
+		"shared_buffers = 16GB\n", // not capped at 2 GB like "25%"
+		"effective_cache_size = 48GB\n",
+		"maintenance_work_mem = 2GB\n",
+		"effective_io_concurrency = 300\n",
+		"max_parallel_workers_per_gather = 4\n",
+		"huge_pages = try\n",
+		"work_mem = 32MB\n", // user option wins
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+
+	opts.AutoTune = ""
+	if out := RenderPostgresConf(opts); !strings.Contains(out, "shared_buffers = 2048MB\n") {
+		t.Errorf("without auto_tune the static defaults apply:\n%s", out)
+	}
+}
+
+func TestRenderPostgresConf_AutoTuneWorkMemFollowsMaxConnections(t *testing.T) {
+	render := func(maxConn string) string {
+		return RenderPostgresConf(RenderPostgresConfOpts{
+			Version: "16", Role: "master", TotalMemoryMB: 8192, CPUs: 2,
+			AutoTune: types.AutoTuneOLTP, Options: map[string]string{"max_connections": maxConn},
+		})
+	}
+	// (8192 - 2048) MB / (connections * 3), in kB.
+	if out := render("100"); !strings.Contains(out, "work_mem = 20971kB\n") {
+		t.Errorf("100 connections:\n%s", out)
+	}
+	if out := render("1000"); !strings.Contains(out, "work_mem = 2097kB\n") {
+		t.Errorf("1000 connections:\n%s", out)
+	}
+}
+
+func TestRenderMySQLConf_AutoTuneHDD(t *testing.T) {
+	out := RenderMySQLConf(RenderMySQLConfOpts{
+		Version:       "8.0",
+		Role:          "primary",
+		TotalMemoryMB: 32768,
+		AutoTune:      types.AutoTuneAnalytic,
+		CPUs:          8,
+		DiskType:      "network-hdd",
+	})
+	for _, want := range []string{
+		"innodb_buffer_pool_size = 24G\n",
+		"innodb_buffer_pool_instances = 24\n",
+		"innodb_page_cleaners = 8\n",
+		"innodb_io_capacity = 200\n",
+		"innodb_flush_neighbors = 1\n",
+		"tmp_table_size = 1G\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestPicodataSettings_AutoTune(t *testing.T) {
+	opts := RenderPicodataConfOpts{TotalMemoryMB: 16384, AutoTune: types.AutoTuneOLTP}
+	if got := PicodataSettings(opts)["memtx_memory"]; got != "9830MB" {
+		t.Errorf("memtx_memory = %s", got)
+	}
+	if out := RenderPicodataConf(opts); !strings.Contains(out, "    memory: 10307502080\n") {
+		t.Errorf("rendered memtx does not match settings:\n%s", out)
+	}
+
+	opts.Options = map[string]string{"memtx_memory": "4096MB"}
+	if got := PicodataSettings(opts)["memtx_memory"]; got != "4096MB" {
+		t.Errorf("user memtx_memory not honoured: %s", got)
+	}
+}
+
+func TestRenderYDBDatabaseConf_AutoTune(t *testing.T) {
+	opts := RenderYDBDatabaseConfOpts{HostCount: 1, CPUs: 16, MemoryMB: 32768, DiskType: "network-ssd-nonreplicated"}
+	plain := RenderYDBDatabaseConf(opts)
+	if !strings.Contains(plain, "  cpu_count: 16\n") || strings.Contains(plain, "query_execution_limit_percent") {
+		t.Errorf("without auto_tune the config must be unchanged:\n%s", plain)
+	}
+
+	opts.AutoTune = types.AutoTuneAnalytic
+	out := RenderYDBDatabaseConf(opts)
+	for _, want := range []string{
+		"  cpu_count: 15\n",
+		"    type: NVME\n",
+		"pdisk_category: NVME\n",
+		"  query_execution_limit_percent: 40\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+// TestAutoTune_InCatalog keeps every tuned value acceptable to the
+// parameter catalog across hardware sizes.
+func TestAutoTune_InCatalog(t *testing.T) {
+	pg, _ := dbparams.Get("postgres", "")
+	my, _ := dbparams.Get("mysql", "8.0")
+	pico, _ := dbparams.Get("picodata", "")
+	for _, profile := range types.AutoTuneProfiles() {
+		for _, hw := range []Hardware{
+			{CPUs: 1, MemoryMB: 512, Disk: DiskHDD},
+			{CPUs: 4, MemoryMB: 8192, Disk: DiskSSD},
+			{CPUs: 64, MemoryMB: 524288, Disk: DiskNVMe},
+		} {
+			for engine, issues := range map[string][]types.ConfigIssue{
+				"postgres": pg.Check(TunePostgres(profile, hw, 200)),
+				"mysql":    my.Check(TuneMySQL(profile, hw)),
+				"picodata": pico.Check(TunePicodata(profile, hw)),
+			} {
+				if len(issues) > 0 {
+					t.Errorf("%s %s %+v: %v", engine, profile, hw, issues)
+				}
+			}
+		}
+	}
+}
@@ -25,6 +25,9 @@ type RenderMySQLConfOpts struct {
 	GroupRepl     bool              // Group Replication
 	Options       map[string]string // user overrides; "server-id" is dropped
 	TotalMemoryMB int               // memory budget for "25%"-style defaults
+	AutoTune      string            // types.AutoTune* profile; empty keeps the static defaults
+	CPUs          int               // vCPUs for auto-tuning I/O threads
+	DiskType      string            // cloud disk type for auto-tuning I/O capacity
 }
 
 // RenderMySQLConf returns the my.cnf body the agent writes into
@@ -36,6 +39,10 @@ func RenderMySQLConf(opts RenderMySQLConfOpts) string {
 		version = "8.0"
 	}
 	defaults := types.MySQLDefaults(version)
+	hw := Hardware{CPUs: opts.CPUs, MemoryMB: opts.TotalMemoryMB, Disk: DiskClassOf(opts.DiskType)}
+	for k, v := range TuneMySQL(opts.AutoTune, hw) {
+		defaults[k] = v
+	}
 
 	defaults["server-id"] = MySQLServerIDPlaceholder
 	defaults["gtid_mode"] = "ON"
@@ -17,16 +17,13 @@ type RenderPicodataConfOpts struct {
 	Replication   int               // replication factor for the default tier
 	Options       map[string]string // user overrides (e.g. memtx_memory: "4GB")
 	TotalMemoryMB int               // memory budget for "25%"-style defaults
+	AutoTune      string            // types.AutoTune* profile; empty keeps the static defaults
 }
 
 // RenderPicodataConf returns the picodata.yaml body the agent writes into
 // /etc/picodata/picodata.yaml. AdvertiseHost is left as a placeholder.
 func RenderPicodataConf(opts RenderPicodataConfOpts) string {
-	defaults := types.PicodataDefaults("25.3")
-	ResolveMemoryPercents(defaults, opts.TotalMemoryMB)
-	for k, v := range opts.Options {
-		defaults[k] = v
-	}
+	defaults := PicodataSettings(opts)
 
 	replication := opts.Replication
 	if replication < 1 {
@@ -61,6 +58,21 @@ func RenderPicodataConf(opts RenderPicodataConfOpts) string {
 	return b.String()
 }
 
+// PicodataSettings returns the effective picodata settings: defaults, then
+// auto-tuned values, with "25%"-style values resolved, then user options.
+// The agent uses it to pass the same memtx size to the process environment.
+func PicodataSettings(opts RenderPicodataConfOpts) map[string]string {
+	settings := types.PicodataDefaults("25.3")
+	for k, v := range TunePicodata(opts.AutoTune, Hardware{MemoryMB: opts.TotalMemoryMB}) {
+		settings[k] = v
+	}
+	ResolveMemoryPercents(settings, opts.TotalMemoryMB)
+	for k, v := range opts.Options {
+		settings[k] = v
+	}
+	return settings
+}
+
 // SubstitutePicodataPlaceholders fills the per-node advertise host into a
 // rendered or override picodata.yaml just before the agent writes it.
 func SubstitutePicodataPlaceholders(body, advertiseHost string) string {
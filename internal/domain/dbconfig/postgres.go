@@ -10,6 +10,7 @@ package dbconfig
 import (
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
@@ -24,6 +25,9 @@ type RenderPostgresConfOpts struct {
 	Options       map[string]string // user overrides merged on top of defaults
 	Patroni       bool              // when true, Patroni manages replication settings
 	TotalMemoryMB int               // memory budget used to resolve "25%"-style defaults
+	AutoTune      string            // types.AutoTune* profile; empty keeps the static defaults
+	CPUs          int               // vCPUs for auto-tuning parallelism
+	DiskType      string            // cloud disk type for auto-tuning I/O costs
 }
 
 // RenderPostgresConf returns the postgresql.conf body that the agent writes
@@ -37,6 +41,18 @@ func RenderPostgresConf(opts RenderPostgresConfOpts) string {
 
 	defaults := types.PostgresDefaults(version)
 
+	// Hardware-aware values replace the static defaults; user options below
+	// still win over both.
+	maxConn := defaults["max_connections"]
+	if v, ok := opts.Options["max_connections"]; ok {
+		maxConn = v
+	}
+	conns, _ := strconv.Atoi(maxConn)
+	hw := Hardware{CPUs: opts.CPUs, MemoryMB: opts.TotalMemoryMB, Disk: DiskClassOf(opts.DiskType)}
+	for k, v := range TunePostgres(opts.AutoTune, hw, conns) {
+		defaults[k] = v
+	}
+
 	// Mandatory streaming-replication knobs — only when Patroni isn't managing
 	// replication itself.
 	if !opts.Patroni {
@@ -0,0 +1,150 @@
+package dbconfig
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YDBSettingsSections are the ydb.yaml sections captured and compared as
+// settings; the rest describes cluster layout, not tuning.
+var YDBSettingsSections = []string{
+	"static_erasure",
+	"actor_system_config",
+	"memory_controller_config",
+	"table_service_config",
+}
+
+// ParseRenderedSettings returns the settings a rendered config file sets,
+// named the way the running server reports them: postgresql.conf and my.cnf
+// keys as-is (my.cnf dashes become underscores), picodata's memtx arena as
+// memtx_memory in bytes, patroni.yml's postgresql parameters, and ydb.yaml
+// flattened by FlattenYDBConfig. key is a rendered config key such as
+// "postgresql.conf:master". Values the agent resolves on the host (agent
+// placeholders, "25%"-style memory shares) are skipped, as are keys of other
+// files.
+func ParseRenderedSettings(key, body string) (map[string]string, error) {
+	file, _, _ := strings.Cut(key, ":")
+	var (
+		out map[string]string
+		err error
+	)
+	switch file {
+	case "postgresql.conf":
+		out = parseConfLines(body, false)
+	case "my.cnf":
+		out = parseConfLines(body, true)
+	case "patroni.yml":
+		out, err = parsePatroniParameters(body)
+	case "picodata.yaml":
+		out, err = parsePicodataSettings(body)
+	case "ydb.yaml":
+		out, err = FlattenYDBConfig(body)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", key, err)
+	}
+	for k, v := range out {
+		if strings.Contains(v, "__") || strings.HasSuffix(v, "%") {
+			delete(out, k)
+		}
+	}
+	return out, nil
+}
+
+// parseConfLines reads "key = value" lines, skipping comments and sections.
+// Repeated keys keep the last value, as both servers do.
+func parseConfLines(body string, dashes bool) map[string]string {
+	out := map[string]string{}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' || line[0] == '[' {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		k = strings.TrimSpace(k)
+		if dashes {
+			k = strings.ReplaceAll(strings.ToLower(k), "-", "_")
+		}
+		out[k] = strings.Trim(strings.TrimSpace(v), "'\"")
+	}
+	return out
+}
+
+func parsePatroniParameters(body string) (map[string]string, error) {
+	var doc struct {
+		Bootstrap struct {
+			DCS struct {
+				PostgreSQL struct {
+					Parameters map[string]any `yaml:"parameters"`
+				} `yaml:"postgresql"`
+			} `yaml:"dcs"`
+		} `yaml:"bootstrap"`
+	}
+	if err := yaml.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for k, v := range doc.Bootstrap.DCS.PostgreSQL.Parameters {
+		out[k] = fmt.Sprint(v)
+	}
+	return out, nil
+}
+
+func parsePicodataSettings(body string) (map[string]string, error) {
+	var doc struct {
+		Instance struct {
+			Memtx struct {
+				Memory any `yaml:"memory"`
+			} `yaml:"memtx"`
+		} `yaml:"instance"`
+	}
+	if err := yaml.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	if m := doc.Instance.Memtx.Memory; m != nil {
+		out["memtx_memory"] = fmt.Sprint(m)
+	}
+	return out, nil
+}
+
+// FlattenYDBConfig flattens the YDBSettingsSections of a YDB config.yaml
+// into dotted keys, e.g. "actor_system_config.cpu_count". List items are
+// indexed: "table_service_config.resource_manager.queues[0].name".
+func FlattenYDBConfig(body string) (map[string]string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal([]byte(body), &doc); err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	for _, section := range YDBSettingsSections {
+		if v, ok := doc[section]; ok {
+			flatten(out, section, v)
+		}
+	}
+	return out, nil
+}
+
+func flatten(out map[string]string, prefix string, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			flatten(out, prefix+"."+k, item)
+		}
+	case []any:
+		for i, item := range v {
+			flatten(out, fmt.Sprintf("%s[%d]", prefix, i), item)
+		}
+	case nil:
+		out[prefix] = ""
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,69 @@
+package dbconfig
+
+import "testing"
+
+func TestParseRenderedSettings(t *testing.T) {
+	cases := []struct {
+		key, body string
+		want      map[string]string
+	}{
+		{
+			key:  "postgresql.conf:master",
+			body: "# Generated\nshared_buffers = 1024MB\nlisten_addresses = '*'\nshared_buffers = 2GB\nwork_mem = 25%\n",
+			want: map[string]string{"shared_buffers": "2GB", "listen_addresses": "*"},
+		},
+		{
+			key:  "my.cnf:primary",
+			body: "[mysqld]\ninnodb-buffer-pool-size = 1G\nserver-id = " + MySQLServerIDPlaceholder + "\nskip-name-resolve\n",
+			want: map[string]string{"innodb_buffer_pool_size": "1G"},
+		},
+		{
+			key:  "patroni.yml",
+			body: "bootstrap:\n  dcs:\n    postgresql:\n      parameters:\n        max_connections: 200\n        wal_level: replica\n",
+			want: map[string]string{"max_connections": "200", "wal_level": "replica"},
+		},
+		{
+			key:  "picodata.yaml",
+			body: "instance:\n  memtx:\n    memory: 1073741824\n",
+			want: map[string]string{"memtx_memory": "1073741824"},
+		},
+		{key: "pg_hba.conf", body: "local all all trust\n", want: map[string]string{}},
+	}
+	for _, tc := range cases {
+		got, err := ParseRenderedSettings(tc.key, tc.body)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.key, err)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.key, got, tc.want)
+			continue
+		}
+		for k, v := range tc.want {
+			if got[k] != v {
+				t.Errorf("%s: %s = %q, want %q", tc.key, k, got[k], v)
+			}
+		}
+	}
+}
+
+func TestFlattenYDBConfig(t *testing.T) {
+	body := "hosts:\n  - host: a\nactor_system_config:\n  cpu_count: 4\n  use_auto_config: true\n" +
+		"table_service_config:\n  resource_manager:\n    queues:\n      - name: q0\n"
+	got, err := FlattenYDBConfig(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"actor_system_config.cpu_count":                        "4",
+		"actor_system_config.use_auto_config":                  "true",
+		"table_service_config.resource_manager.queues[0].name": "q0",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
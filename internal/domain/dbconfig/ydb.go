@@ -22,6 +22,8 @@ type RenderYDBConfOpts struct {
 	CPUs            int    // vCPUs hint for actor_system_config
 	MemoryMB        int    // total RAM for memory_controller_config (85% mark)
 	FaultTolerance  string // "none" | "block-4-2" | "mirror-3-dc"
+	AutoTune        string // types.AutoTune* profile; empty keeps YDB's own auto config only
+	DiskType        string // cloud disk type; picks the pdisk drive type when auto-tuning
 }
 
 // RenderYDBDatabaseConfOpts collects the inputs RenderYDBDatabaseConf needs.
@@ -34,6 +36,8 @@ type RenderYDBDatabaseConfOpts struct {
 	CPUs            int    // vCPUs hint for actor_system_config (COMPUTE)
 	MemoryMB        int    // total RAM for memory_controller_config (85% mark)
 	FaultTolerance  string // mirrored from cluster setting
+	AutoTune        string // types.AutoTune* profile; empty keeps YDB's own auto config only
+	DiskType        string // cloud disk type; picks the pdisk drive type when auto-tuning
 }
 
 // RenderYDBStorageConf returns the YDB static-node config.yaml body with
@@ -49,6 +53,8 @@ func RenderYDBStorageConf(opts RenderYDBConfOpts) string {
 		cpus:            opts.CPUs,
 		memoryMB:        opts.MemoryMB,
 		faultTolerance:  opts.FaultTolerance,
+		autoTune:        opts.AutoTune,
+		diskType:        opts.DiskType,
 	})
 }
 
@@ -66,6 +72,8 @@ func RenderYDBDatabaseConf(opts RenderYDBDatabaseConfOpts) string {
 		cpus:            opts.CPUs,
 		memoryMB:        opts.MemoryMB,
 		faultTolerance:  opts.FaultTolerance,
+		autoTune:        opts.AutoTune,
+		diskType:        opts.DiskType,
 	})
 }
 
@@ -77,6 +85,8 @@ type yDBYAMLOpts struct {
 	cpus            int
 	memoryMB        int
 	faultTolerance  string
+	autoTune        string
+	diskType        string
 }
 
 func renderYDBYAML(opts yDBYAMLOpts) string {
@@ -116,6 +126,14 @@ func renderYDBYAML(opts yDBYAMLOpts) string {
 		nodeType = "STORAGE"
 	}
 
+	cpus := opts.cpus
+	driveType := "SSD"
+	tuning, tuned := TuneYDB(opts.autoTune, Hardware{CPUs: opts.cpus, MemoryMB: memMB, Disk: DiskClassOf(opts.diskType)})
+	if tuned {
+		cpus = tuning.CPUCount
+		driveType = tuning.DriveType
+	}
+
 	var b strings.Builder
 	b.WriteString("# Generated by stroppy-agent\n")
 	fmt.Fprintf(&b, "static_erasure: %s\n", erasure)
@@ -124,7 +142,7 @@ func renderYDBYAML(opts yDBYAMLOpts) string {
 	b.WriteString("host_configs:\n")
 	b.WriteString("- drive:\n")
 	fmt.Fprintf(&b, "  - path: %s\n", pdiskPath)
-	b.WriteString("    type: SSD\n")
+	fmt.Fprintf(&b, "    type: %s\n", driveType)
 	b.WriteString("  host_config_id: 1\n")
 
 	// hosts
@@ -157,7 +175,7 @@ func renderYDBYAML(opts yDBYAMLOpts) string {
 	}
 	b.WriteString("        pdisk_filter:\n")
 	b.WriteString("        - property:\n")
-	b.WriteString("          - type: SSD\n")
+	fmt.Fprintf(&b, "          - type: %s\n", driveType)
 	b.WriteString("        vdisk_kind: Default\n")
 	b.WriteString("  state_storage:\n")
 	b.WriteString("  - ring:\n")
@@ -179,14 +197,20 @@ func renderYDBYAML(opts yDBYAMLOpts) string {
 	b.WriteString("actor_system_config:\n")
 	b.WriteString("  use_auto_config: true\n")
 	fmt.Fprintf(&b, "  node_type: %s\n", nodeType)
-	if opts.cpus > 0 {
-		fmt.Fprintf(&b, "  cpu_count: %d\n", opts.cpus)
+	if cpus > 0 {
+		fmt.Fprintf(&b, "  cpu_count: %d\n", cpus)
 	}
 
-	// memory_controller_config — 85% of total RAM as the hard limit.
+	// memory_controller_config — 85% of total RAM as the hard limit, with
+	// the cache/query split from the auto-tuning profile.
 	if hardMB > 0 {
 		b.WriteString("memory_controller_config:\n")
 		fmt.Fprintf(&b, "  hard_limit_bytes: %d\n", int64(hardMB)*1024*1024)
+		if tuned {
+			fmt.Fprintf(&b, "  shared_cache_min_percent: %d\n", tuning.SharedCacheMinPercent)
+			fmt.Fprintf(&b, "  shared_cache_max_percent: %d\n", tuning.SharedCacheMaxPercent)
+			fmt.Fprintf(&b, "  query_execution_limit_percent: %d\n", tuning.QueryExecutionLimitPercent)
+		}
 	}
 
 	// blob_storage_config
@@ -200,7 +224,7 @@ func renderYDBYAML(opts yDBYAMLOpts) string {
 			b.WriteString("      - fail_domains:\n")
 			b.WriteString("        - vdisk_locations:\n")
 			fmt.Fprintf(&b, "          - node_id: %d\n", i+1)
-			b.WriteString("            pdisk_category: SSD\n")
+			fmt.Fprintf(&b, "            pdisk_category: %s\n", driveType)
 			fmt.Fprintf(&b, "            path: %s\n", pdiskPath)
 		}
 	} else {
@@ -208,7 +232,7 @@ func renderYDBYAML(opts yDBYAMLOpts) string {
 		for i := 0; i < hostCount; i++ {
 			b.WriteString("        - vdisk_locations:\n")
 			fmt.Fprintf(&b, "          - node_id: %d\n", i+1)
-			b.WriteString("            pdisk_category: SSD\n")
+			fmt.Fprintf(&b, "            pdisk_category: %s\n", driveType)
 			fmt.Fprintf(&b, "            path: %s\n", pdiskPath)
 		}
 	}
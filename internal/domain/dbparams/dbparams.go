@@ -0,0 +1,482 @@
+// Package dbparams is the catalog of database configuration parameters the
+// option maps of a topology (master_options, primary_options, ...) may set:
+// name, value type, unit, range and whether a change needs a restart.
+//
+// Option maps are written verbatim into postgresql.conf, my.cnf or
+// picodata.yaml, where a misspelled name stops the server from starting, so
+// run validation checks them against the catalog first. The catalog is also
+// served to the UI for autocomplete.
+package dbparams
+
+import (
+	"embed"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// Type is the value type of a parameter.
+type Type string
+
+const (
+	TypeBool     Type = "bool"
+	TypeInt      Type = "int"
+	TypeReal     Type = "real"
+	TypeString   Type = "string"
+	TypeEnum     Type = "enum"
+	TypeMemory   Type = "memory"   // size: a number in Unit, a number with a size suffix, or "25%" of host RAM
+	TypeDuration Type = "duration" // a number in Unit or a number with a time suffix
+)
+
+// Param describes one configuration parameter.
+type Param struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        Type     `json:"type" yaml:"type"`
+	Unit        string   `json:"unit,omitempty" yaml:"unit,omitempty"` // unit of a bare number
+	Min         *float64 `json:"min,omitempty" yaml:"min,omitempty"`   // in Unit
+	Max         *float64 `json:"max,omitempty" yaml:"max,omitempty"`   // in Unit
+	Values      []string `json:"values,omitempty" yaml:"values,omitempty"`
+	Restart     bool     `json:"restart" yaml:"restart"`                   // a change needs a server restart
+	Since       string   `json:"since,omitempty" yaml:"since,omitempty"`   // first version that has it
+	Until       string   `json:"until,omitempty" yaml:"until,omitempty"`   // first version without it
+	Description string   `json:"description,omitempty" yaml:"description"` // one line, for autocomplete
+}
+
+// Catalog is the parameter list of one engine, filtered to one version.
+type Catalog struct {
+	Engine         string   `json:"engine" yaml:"engine"`
+	File           string   `json:"file" yaml:"file"` // config file the options are written to
+	Version        string   `json:"version" yaml:"-"`
+	DefaultVersion string   `json:"default_version" yaml:"default_version"`
+	Versions       []string `json:"versions" yaml:"versions"`
+	Params         []Param  `json:"params" yaml:"params"`
+
+	// Custom matches names the catalog does not check: extension
+	// parameters, or options the server is told to ignore when unknown.
+	Custom string `json:"-" yaml:"custom"`
+	// Dashes: '-' and '_' are interchangeable in names (my.cnf).
+	Dashes bool `json:"-" yaml:"dashes"`
+	// MemoryUnits and DurationUnits are the suffixes values may carry;
+	// UnitsIgnoreCase accepts them in any case.
+	MemoryUnits     []string `json:"-" yaml:"memory_units"`
+	DurationUnits   []string `json:"-" yaml:"duration_units"`
+	UnitsIgnoreCase bool     `json:"-" yaml:"units_ignore_case"`
+
+	custom  *regexp.Regexp
+	byName  map[string]Param
+	missing map[string]Param // known to the engine but not in this version
+}
+
+//go:embed catalog/*.yaml
+var catalogFS embed.FS
+
+// engines holds the parsed catalogs, unfiltered, keyed by engine.
+var engines = mustLoad()
+
+func mustLoad() map[string]*Catalog {
+	entries, err := catalogFS.ReadDir("catalog")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]*Catalog, len(entries))
+	for _, e := range entries {
+		data, err := catalogFS.ReadFile("catalog/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var c Catalog
+		if err := yaml.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("dbparams: %s: %v", e.Name(), err))
+		}
+		out[c.Engine] = &c
+	}
+	return out
+}
+
+// Engines lists the engines that have a catalog, sorted.
+func Engines() []string {
+	out := make([]string, 0, len(engines))
+	for name := range engines {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Get returns the catalog of engine for version; an empty version means the
+// engine's default, the one the config renderers assume.
+func Get(engine, version string) (*Catalog, bool) {
+	base, ok := engines[engine]
+	if !ok {
+		return nil, false
+	}
+	if version == "" {
+		version = base.DefaultVersion
+	}
+	c := *base
+	c.Version = version
+	c.Params = nil
+	c.byName = make(map[string]Param, len(base.Params))
+	c.missing = make(map[string]Param)
+	if c.Custom != "" {
+		c.custom = regexp.MustCompile(c.Custom)
+	}
+	for _, p := range base.Params {
+		if p.available(version) {
+			c.Params = append(c.Params, p)
+			c.byName[p.Name] = p
+		} else {
+			c.missing[p.Name] = p
+		}
+	}
+	return &c, true
+}
+
+func (p Param) available(version string) bool {
+	if p.Since != "" && compareVersions(version, p.Since) < 0 {
+		return false
+	}
+	return p.Until == "" || compareVersions(version, p.Until) < 0
+}
+
+// compareVersions compares dotted numeric versions ("8.0" < "8.4" < "16").
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// normalize maps a name to its catalog spelling; names are case-insensitive.
+func (c *Catalog) normalize(name string) string {
+	name = strings.ToLower(name)
+	if c.Dashes {
+		name = strings.ReplaceAll(name, "-", "_")
+	}
+	return name
+}
+
+// Lookup returns the parameter called name.
+func (c *Catalog) Lookup(name string) (Param, bool) {
+	p, ok := c.byName[c.normalize(name)]
+	return p, ok
+}
+
+// Check checks an option map against the catalog. Issue paths are the option
+// names. A name close to a known parameter is an error, being almost surely
+// a typo; other unknown names are warnings, since the catalog lists common
+// parameters rather than every one the server has.
+func (c *Catalog) Check(options map[string]string) []types.ConfigIssue {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var issues []types.ConfigIssue
+	add := func(name string, sev types.Severity, format string, args ...any) {
+		issues = append(issues, types.ConfigIssue{Path: name, Message: fmt.Sprintf(format, args...), Severity: sev})
+	}
+	for _, name := range names {
+		if c.custom != nil && c.custom.MatchString(name) {
+			continue
+		}
+		key := c.normalize(name)
+		p, ok := c.byName[key]
+		if !ok {
+			if p, known := c.missing[key]; known {
+				if p.Since != "" && compareVersions(c.Version, p.Since) < 0 {
+					add(name, types.SeverityError, "not available before %s %s", c.Engine, p.Since)
+				} else {
+					add(name, types.SeverityError, "removed in %s %s", c.Engine, p.Until)
+				}
+				continue
+			}
+			near, typo := c.suggest(name)
+			switch {
+			case typo:
+				add(name, types.SeverityError, "unknown %s parameter; did you mean %s?", c.Engine, strings.Join(near, " or "))
+			case len(near) > 0:
+				// Sharing a prefix is no sign of a typo: ssl_ciphers is a
+				// real parameter next to ssl.
+				add(name, types.SeverityWarning, "not in the %s %s catalog; written to %s unchecked (did you mean %s?)", c.Engine, c.Version, c.File, strings.Join(near, " or "))
+			default:
+				add(name, types.SeverityWarning, "not in the %s %s catalog; written to %s unchecked", c.Engine, c.Version, c.File)
+			}
+			continue
+		}
+		if msg := c.checkValue(p, options[name]); msg != "" {
+			add(name, types.SeverityError, "%s", msg)
+		}
+	}
+	return issues
+}
+
+// maxSuggestions caps the close matches Suggest returns.
+const maxSuggestions = 3
+
+// minPrefixMatch is the shortest catalog name matched by prefix, so short
+// names like ssl or jit do not match every parameter starting with them.
+const minPrefixMatch = 4
+
+// Suggest returns the known parameters closest to a misspelled name, best
+// first: those within a few edits, or sharing a prefix of at least
+// minPrefixMatch characters with it.
+func (c *Catalog) Suggest(name string) []string {
+	near, _ := c.suggest(name)
+	return near
+}
+
+// suggest is Suggest, also reporting whether the best match is within a
+// few edits, i.e. the name is likely a typo rather than a parameter the
+// catalog lacks.
+func (c *Catalog) suggest(name string) ([]string, bool) {
+	key := c.normalize(name)
+	type match struct {
+		name string
+		dist int
+		typo bool
+	}
+	var matches []match
+	for _, p := range c.Params {
+		d := editDistance(key, p.Name)
+		typo := d <= max(2, len(p.Name)/4)
+		prefix := len(key) >= minPrefixMatch && len(p.Name) >= minPrefixMatch &&
+			(strings.HasPrefix(p.Name, key) || strings.HasPrefix(key, p.Name))
+		if !typo && !prefix {
+			continue
+		}
+		matches = append(matches, match{p.Name, d, typo})
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].typo != matches[j].typo {
+			return matches[i].typo
+		}
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+	out := make([]string, 0, maxSuggestions)
+	for _, m := range matches {
+		if len(out) == maxSuggestions {
+			break
+		}
+		out = append(out, m.name)
+	}
+	return out, len(matches) > 0 && matches[0].typo
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+var boolWords = []string{"on", "off", "true", "false", "yes", "no", "1", "0"}
+
+// memoryFactors and durationFactors convert unit suffixes to bytes and
+// microseconds. Both postgres (kB, MB) and MySQL (K, M) spellings appear.
+var (
+	memoryFactors = map[string]float64{
+		"B": 1, "kB": 1 << 10, "8kB": 8 << 10, "MB": 1 << 20, "GB": 1 << 30, "TB": 1 << 40,
+		"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40,
+	}
+	durationFactors = map[string]float64{
+		"us": 1, "ms": 1e3, "s": 1e6, "min": 60e6, "h": 3600e6, "d": 86400e6,
+	}
+)
+
+// checkValue returns why value is not valid for p, or "".
+func (c *Catalog) checkValue(p Param, value string) string {
+	v := strings.TrimSpace(value)
+	if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+		v = v[1 : len(v)-1]
+	}
+	switch p.Type {
+	case TypeBool:
+		if !slices.Contains(boolWords, strings.ToLower(v)) {
+			return fmt.Sprintf("%q is not a boolean (on or off)", value)
+		}
+	case TypeEnum:
+		if !slices.ContainsFunc(p.Values, func(e string) bool { return strings.EqualFold(e, v) }) {
+			return fmt.Sprintf("%q is not one of %s", value, strings.Join(p.Values, ", "))
+		}
+	case TypeInt, TypeReal:
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil || (p.Type == TypeInt && n != float64(int64(n))) {
+			return fmt.Sprintf("%q is not %s", value, map[Type]string{TypeInt: "an integer", TypeReal: "a number"}[p.Type])
+		}
+		return p.checkRange(value, n)
+	case TypeMemory:
+		if pct, ok := strings.CutSuffix(v, "%"); ok {
+			// Resolved against host RAM by the renderer.
+			if n, err := strconv.Atoi(pct); err != nil || n < 1 || n > 100 {
+				return fmt.Sprintf("%q is not a percentage of RAM between 1%% and 100%%", value)
+			}
+			return ""
+		}
+		return c.checkQuantity(p, value, v, c.MemoryUnits, memoryFactors)
+	case TypeDuration:
+		return c.checkQuantity(p, value, v, c.DurationUnits, durationFactors)
+	}
+	return ""
+}
+
+// checkQuantity checks a number with an optional unit suffix and its range,
+// converted to the parameter's unit.
+func (c *Catalog) checkQuantity(p Param, value, v string, units []string, factors map[string]float64) string {
+	n, unit, err := splitQuantity(v)
+	if err != nil {
+		return fmt.Sprintf("%q is not a %s value", value, p.Type)
+	}
+	if unit == "" || p.Unit == "" {
+		if unit != "" {
+			return fmt.Sprintf("%q: %s takes no unit", value, p.Name)
+		}
+		return p.checkRange(value, n)
+	}
+	factor, ok := c.unitFactor(unit, units, factors)
+	if !ok {
+		return fmt.Sprintf("%q: unit must be one of %s", value, strings.Join(units, ", "))
+	}
+	return p.checkRange(value, n*factor/factors[p.Unit])
+}
+
+// splitQuantity splits "128MB" into 128 and "MB".
+func splitQuantity(v string) (float64, string, error) {
+	i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' && r != '-' })
+	num, unit := v, ""
+	if i >= 0 {
+		num, unit = v[:i], strings.TrimSpace(v[i:])
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	return n, unit, err
+}
+
+// unitFactor returns the factor of one of the catalog's unit suffixes.
+func (c *Catalog) unitFactor(unit string, units []string, factors map[string]float64) (float64, bool) {
+	idx := slices.IndexFunc(units, func(u string) bool {
+		return u == unit || (c.UnitsIgnoreCase && strings.EqualFold(u, unit))
+	})
+	if idx < 0 {
+		return 0, false
+	}
+	return factors[units[idx]], true
+}
+
+// Same reports whether a and b are the same value of parameter name, as
+// written in a config file and as reported by the running server. Sizes and
+// durations compare after unit conversion, booleans by meaning (on, true,
+// 1), anything else as numbers or case-insensitive strings.
+func (c *Catalog) Same(name, a, b string) bool {
+	a, b = unquote(a), unquote(b)
+	if strings.EqualFold(a, b) {
+		return true
+	}
+	p, known := c.Lookup(name)
+	if known {
+		switch p.Type {
+		case TypeBool:
+			x, okx := boolValue(a)
+			y, oky := boolValue(b)
+			return okx && oky && x == y
+		case TypeMemory:
+			x, okx := c.quantity(p, a, c.MemoryUnits, memoryFactors)
+			y, oky := c.quantity(p, b, c.MemoryUnits, memoryFactors)
+			return okx && oky && x == y
+		case TypeDuration:
+			x, okx := c.quantity(p, a, c.DurationUnits, durationFactors)
+			y, oky := c.quantity(p, b, c.DurationUnits, durationFactors)
+			return okx && oky && x == y
+		}
+	}
+	x, errx := strconv.ParseFloat(a, 64)
+	y, erry := strconv.ParseFloat(b, 64)
+	return errx == nil && erry == nil && x == y
+}
+
+// quantity converts a size or duration to bytes or microseconds; a bare
+// number is in the parameter's unit.
+func (c *Catalog) quantity(p Param, v string, units []string, factors map[string]float64) (float64, bool) {
+	n, unit, err := splitQuantity(v)
+	if err != nil {
+		return 0, false
+	}
+	if unit == "" {
+		if f, ok := factors[p.Unit]; ok {
+			return n * f, true
+		}
+		return n, true
+	}
+	f, ok := c.unitFactor(unit, units, factors)
+	return n * f, ok
+}
+
+func unquote(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && (v[0] == '\'' || v[0] == '"') && v[len(v)-1] == v[0] {
+		v = v[1 : len(v)-1]
+	}
+	return v
+}
+
+func boolValue(v string) (bool, bool) {
+	switch strings.ToLower(v) {
+	case "on", "true", "yes", "1":
+		return true, true
+	case "off", "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+func (p Param) checkRange(value string, n float64) string {
+	if p.Min != nil && n < *p.Min {
+		return fmt.Sprintf("%q is below the minimum of %s", value, p.bound(*p.Min))
+	}
+	if p.Max != nil && n > *p.Max {
+		return fmt.Sprintf("%q is above the maximum of %s", value, p.bound(*p.Max))
+	}
+	return ""
+}
+
+func (p Param) bound(n float64) string {
+	s := strconv.FormatFloat(n, 'f', -1, 64)
+	if p.Unit != "" {
+		s += " " + p.Unit
+	}
+	return s
+}
@@ -0,0 +1,170 @@
+package dbparams
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+func TestCatalogs_Load(t *testing.T) {
+	for _, engine := range []string{"postgres", "mysql", "picodata"} {
+		c, ok := Get(engine, "")
+		if !ok {
+			t.Fatalf("%s: no catalog", engine)
+		}
+		if c.Version != c.DefaultVersion || len(c.Params) == 0 {
+			t.Errorf("%s: version %q, %d params", engine, c.Version, len(c.Params))
+		}
+		seen := map[string]bool{}
+		for _, p := range c.Params {
+			if seen[p.Name] {
+				t.Errorf("%s: duplicate %s", engine, p.Name)
+			}
+			seen[p.Name] = true
+			if p.Type == TypeEnum && len(p.Values) == 0 {
+				t.Errorf("%s: enum %s has no values", engine, p.Name)
+			}
+		}
+	}
+	if _, ok := Get("oracle", ""); ok {
+		t.Error("unknown engine must not have a catalog")
+	}
+}
+
+// TestDefaults_InCatalog keeps the renderer defaults and the catalog in step.
+func TestDefaults_InCatalog(t *testing.T) {
+	for engine, defaults := range map[string]map[string]string{
+		"postgres": types.PostgresDefaults("17"),
+		"mysql":    types.MySQLDefaults("8.0"),
+		"picodata": types.PicodataDefaults("25.3"),
+	} {
+		c, _ := Get(engine, "")
+		if engine == "postgres" {
+			c, _ = Get(engine, "17")
+		}
+		if err := types.IssuesError(c.Check(defaults)); err != nil {
+			t.Errorf("%s defaults: %v", engine, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	pg, _ := Get("postgres", "16")
+	issues := pg.Check(map[string]string{
+		"shared_buffer":            "1GB",    // typo
+		"work_mem":                 "32",     // below 64 kB
+		"max_wal_size":             "8GB",    // fine
+		"synchronous_commit":       "maybe",  // not an enum value
+		"shared_buffers":           "25%",    // resolved against RAM
+		"wal_keep_segments":        "32",     // removed in 13
+		"summarize_wal":            "on",     // added in 17
+		"pg_stat_statements.track": "all",    // extension parameter
+		"zz_custom_knob":           "1",      // unknown, nothing close
+		"checkpoint_timeout":       "10min",  // fine
+		"statement_timeout":        "5 days", // bad unit
+	})
+	want := map[string]string{
+		"shared_buffer":      "did you mean shared_buffers",
+		"work_mem":           "below the minimum",
+		"synchronous_commit": "not one of",
+		"wal_keep_segments":  "removed in postgres 13",
+		"summarize_wal":      "not available before postgres 17",
+		"zz_custom_knob":     "unchecked",
+		"statement_timeout":  "unit must be",
+	}
+	if len(issues) != len(want) {
+		t.Errorf("got %d issues, want %d: %v", len(issues), len(want), issues)
+	}
+	for _, is := range issues {
+		if !strings.Contains(is.Message, want[is.Path]) || want[is.Path] == "" {
+			t.Errorf("%s: %q", is.Path, is.Message)
+		}
+		wantSev := types.SeverityError
+		if is.Path == "zz_custom_knob" {
+			wantSev = types.SeverityWarning // unknown, but no close match
+		}
+		if is.Severity != wantSev {
+			t.Errorf("%v: severity %s, want %s", is, is.Severity, wantSev)
+		}
+	}
+}
+
+func TestCheck_MySQL(t *testing.T) {
+	my, _ := Get("mysql", "8.4")
+	issues := my.Check(map[string]string{
+		"innodb-buffer-pool-size":        "2g",
+		"innodb_flush_log_at_trx_commit": "2",
+		"loose-group_replication_foo":    "x",
+		"default_authentication_plugin":  "mysql_native_password",
+		"query_cache_size":               "0",
+		"innodb_buffer_pool_instance":    "8",
+	})
+	var paths []string
+	for _, is := range issues {
+		paths = append(paths, is.Path)
+	}
+	if got := strings.Join(paths, ","); got != "default_authentication_plugin,innodb_buffer_pool_instance,query_cache_size" {
+		t.Errorf("issues at %s: %v", got, issues)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	pg, _ := Get("postgres", "")
+	if got := pg.Suggest("efective_cache_size"); len(got) == 0 || got[0] != "effective_cache_size" {
+		t.Errorf("Suggest = %v", got)
+	}
+	if got := pg.Suggest("completely_unrelated_thing"); len(got) != 0 {
+		t.Errorf("Suggest = %v", got)
+	}
+	// Short names match only by edit distance, not by prefix.
+	if got := pg.Suggest("ssl_foo_bar_baz"); len(got) != 0 {
+		t.Errorf("Suggest(ssl_foo_bar_baz) = %v, want none", got)
+	}
+}
+
+func TestCheck_PrefixIsNoTypo(t *testing.T) {
+	pg, _ := Get("postgres", "16")
+	issues := pg.Check(map[string]string{
+		"ssl_ciphers":              "'HIGH:!aNULL'",
+		"ssl_cert_file":            "'server.crt'",
+		"ssl_key_file":             "'server.key'",
+		"ssl_min_protocol_version": "TLSv1.2",
+		"jit_provider":             "llvmjit",
+		"geqo_seed":                "0.5",
+		"geqo_effort":              "7",
+		"geqo_threshold_extra":     "1", // only a prefix of geqo_threshold
+	})
+	if len(issues) != 1 || issues[0].Path != "geqo_threshold_extra" {
+		t.Fatalf("issues = %v, want one for geqo_threshold_extra", issues)
+	}
+	if is := issues[0]; is.Severity != types.SeverityWarning || !strings.Contains(is.Message, "geqo_threshold") {
+		t.Errorf("prefix match: %v, want a warning naming geqo_threshold", is)
+	}
+}
+
+func TestSame(t *testing.T) {
+	pg, _ := Get("postgres", "16")
+	my, _ := Get("mysql", "8.4")
+	cases := []struct {
+		c          *Catalog
+		name, a, b string
+		want       bool
+	}{
+		{pg, "shared_buffers", "1024MB", "1GB", true},
+		{pg, "work_mem", "4096", "4MB", true}, // bare number is in kB
+		{pg, "checkpoint_timeout", "300s", "5min", true},
+		{pg, "fsync", "'off'", "false", true},
+		{pg, "max_connections", "200", "100", false},
+		{pg, "listen_addresses", "'*'", "*", true},
+		{pg, "random_page_cost", "1.1", "1.10", true},
+		{my, "innodb_buffer_pool_size", "1G", "1073741824", true},
+		{my, "innodb_buffer_pool_size", "512M", "1073741824", false},
+		{my, "unknown_thing", "ON", "on", true},
+	}
+	for _, tc := range cases {
+		if got := tc.c.Same(tc.name, tc.a, tc.b); got != tc.want {
+			t.Errorf("Same(%s, %q, %q) = %v, want %v", tc.name, tc.a, tc.b, got, tc.want)
+		}
+	}
+}
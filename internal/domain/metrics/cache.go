@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"container/list"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CacheSettle is how long after a range ends its samples are taken as
+// final: vmagent pushes in intervals, so the last ones may still arrive.
+// Only settled ranges are cached.
+const CacheSettle = time.Minute
+
+func settled(tr TimeRange) bool {
+	return !tr.End.IsZero() && time.Since(tr.End) > CacheSettle
+}
+
+// ResultCache keeps the most recently used collected results, so a finished
+// run is queried once however often it is compared. It is safe for
+// concurrent use.
+type ResultCache struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key string
+	rm  *RunMetrics
+}
+
+// NewResultCache creates a cache of up to maxEntries results.
+func NewResultCache(maxEntries int) *ResultCache {
+	return &ResultCache{
+		max:     max(maxEntries, 1),
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Len returns the number of cached results.
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// get returns a copy of the result under key, so callers may change it.
+func (c *ResultCache) get(key string) (*RunMetrics, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).rm.clone(), true
+}
+
+// put stores a copy of rm under key, evicting the least recently used
+// result when full.
+func (c *ResultCache) put(key string, rm *RunMetrics) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cacheEntry).rm = rm.clone()
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, rm: rm.clone()})
+	for c.order.Len() > c.max {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// clone copies rm down to its slices of metrics and series, which callers
+// replace but never change in place.
+func (rm *RunMetrics) clone() *RunMetrics {
+	out := *rm
+	out.Metrics = slices.Clone(rm.Metrics)
+	out.Series = maps.Clone(rm.Series)
+	if rm.Steady != nil {
+		steady := *rm.Steady
+		out.Steady = &steady
+	}
+	return &out
+}
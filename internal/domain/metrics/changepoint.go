@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Change-point defaults; see ChangePointOptions.
+const (
+	DefaultMinSegment   = 5
+	DefaultMaxPValue    = 0.01
+	DefaultMinChangePct = 5.0
+)
+
+// ChangePointOptions tunes DetectChangePoints. Zero fields take the defaults.
+type ChangePointOptions struct {
+	// MinSegment is the fewest runs on either side of a change. A single
+	// outlier never forms a segment, so one-off noise is not a change.
+	MinSegment int
+	// MaxPValue is the Mann-Whitney U p-value a split must reach.
+	MaxPValue float64
+	// MinChangePct is the smallest shift of the mean, in percent, worth
+	// reporting; statistically clear but tiny shifts are left out.
+	MinChangePct float64
+}
+
+func (o ChangePointOptions) withDefaults() ChangePointOptions {
+	if o.MinSegment < 2 {
+		o.MinSegment = DefaultMinSegment
+	}
+	if o.MaxPValue <= 0 {
+		o.MaxPValue = DefaultMaxPValue
+	}
+	if o.MinChangePct <= 0 {
+		o.MinChangePct = DefaultMinChangePct
+	}
+	return o
+}
+
+// ChangePoint is a shift in the level of a series: the values from Index on
+// differ from those before it.
+type ChangePoint struct {
+	Index int `json:"index"`
+	// Before and After are the means of the segments either side of the
+	// change, bounded by the neighbouring changes.
+	Before    float64 `json:"before"`
+	After     float64 `json:"after"`
+	ChangePct float64 `json:"change_pct"`
+	PValue    float64 `json:"p_value"`
+}
+
+// DetectChangePoints finds shifts in the level of xs by binary
+// segmentation: each segment is split where its CUSUM peaks, and the split
+// is kept when a Mann-Whitney U test tells the halves apart and their means
+// differ by MinChangePct or more; both halves are then searched again.
+// Changes come back in index order.
+func DetectChangePoints(xs []float64, opts ChangePointOptions) []ChangePoint {
+	opts = opts.withDefaults()
+	var found []ChangePoint
+	var split func(lo, hi int)
+	split = func(lo, hi int) {
+		if hi-lo < 2*opts.MinSegment {
+			return
+		}
+		at := cusumPeak(xs[lo:hi], opts.MinSegment) + lo
+		before, after := xs[lo:at], xs[at:hi]
+		_, p := MannWhitneyU(before, after)
+		change := pctDiff(mean(before), mean(after))
+		if p > opts.MaxPValue || math.Abs(change) < opts.MinChangePct {
+			return
+		}
+		found = append(found, ChangePoint{Index: at, PValue: p})
+		split(lo, at)
+		split(at, hi)
+	}
+	split(0, len(xs))
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Index < found[j].Index })
+	for i := range found {
+		lo, hi := 0, len(xs)
+		if i > 0 {
+			lo = found[i-1].Index
+		}
+		if i < len(found)-1 {
+			hi = found[i+1].Index
+		}
+		found[i].Before = mean(xs[lo:found[i].Index])
+		found[i].After = mean(xs[found[i].Index:hi])
+		found[i].ChangePct = pctDiff(found[i].Before, found[i].After)
+	}
+	return found
+}
+
+// cusumPeak returns the split of xs, at least minSegment from either end,
+// where the cumulative sum of deviations from the mean is largest.
+func cusumPeak(xs []float64, minSegment int) int {
+	m := mean(xs)
+	var sum, best float64
+	at := minSegment
+	for i, x := range xs[:len(xs)-minSegment] {
+		sum += x - m
+		if i+1 >= minSegment && math.Abs(sum) > best {
+			best, at = math.Abs(sum), i+1
+		}
+	}
+	return at
+}
+
+func mean(xs []float64) float64 {
+	if len(xs) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// Kinds of a TrendChange.
+const (
+	ChangeRegression  = "regression"
+	ChangeImprovement = "improvement"
+)
+
+// TrendChange is a change point of a Trend, tied to the runs around it.
+// For a regression FirstAfterRun is the first bad run and LastBeforeRun the
+// last good one.
+type TrendChange struct {
+	ChangePoint
+	Key           string    `json:"key"`
+	Kind          string    `json:"kind"`
+	LastBeforeRun string    `json:"last_before_run"`
+	FirstAfterRun string    `json:"first_after_run"`
+	Time          time.Time `json:"time"`
+}
+
+// ChangePoints detects shifts in the trend's averages and judges each by
+// the metric's direction. Runs are searched per ConfigHash, in time order
+// within each config: a step where the configuration changed is explained
+// by the change, not a regression of the code under test, and runs of
+// configs benchmarked in alternation are never compared with each other.
+// Changes come back in time order; Index points into Points.
+func (t *Trend) ChangePoints(opts ChangePointOptions) []TrendChange {
+	var hashes []string
+	groups := map[string][]int{}
+	for i, p := range t.Points {
+		if _, ok := groups[p.ConfigHash]; !ok {
+			hashes = append(hashes, p.ConfigHash)
+		}
+		groups[p.ConfigHash] = append(groups[p.ConfigHash], i)
+	}
+	var out []TrendChange
+	for _, h := range hashes {
+		out = append(out, t.changePoints(groups[h], opts)...)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out
+}
+
+// changePoints detects the changes within the Points at idx, which are in
+// time order.
+func (t *Trend) changePoints(idx []int, opts ChangePointOptions) []TrendChange {
+	avgs := make([]float64, len(idx))
+	for i, at := range idx {
+		avgs[i] = t.Points[at].Avg
+	}
+	var out []TrendChange
+	for _, cp := range DetectChangePoints(avgs, opts) {
+		before, first := t.Points[idx[cp.Index-1]], t.Points[idx[cp.Index]]
+		cp.Index = idx[cp.Index]
+		kind := ChangeRegression
+		if isBetter(t.Direction, cp.After, cp.Before) {
+			kind = ChangeImprovement
+		}
+		out = append(out, TrendChange{
+			ChangePoint:   cp,
+			Key:           t.Key,
+			Kind:          kind,
+			LastBeforeRun: before.RunID,
+			FirstAfterRun: first.RunID,
+			Time:          first.Time,
+		})
+	}
+	return out
+}
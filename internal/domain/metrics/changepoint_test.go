@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// level returns n values around v with ±1% deterministic noise.
+func level(n int, v float64) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = v * (1 + 0.01*math.Sin(float64(i)*1.7))
+	}
+	return out
+}
+
+func concat(parts ...[]float64) []float64 {
+	var out []float64
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func TestDetectChangePoints(t *testing.T) {
+	t.Run("step", func(t *testing.T) {
+		got := DetectChangePoints(concat(level(20, 1000), level(20, 900)), ChangePointOptions{})
+		if len(got) != 1 || got[0].Index != 20 {
+			t.Fatalf("got %+v, want one change at 20", got)
+		}
+		if math.Abs(got[0].ChangePct+10) > 0.5 || got[0].PValue > DefaultMaxPValue {
+			t.Errorf("change = %+v", got[0])
+		}
+	})
+
+	t.Run("two steps", func(t *testing.T) {
+		got := DetectChangePoints(concat(level(15, 1000), level(15, 1200), level(15, 1000)), ChangePointOptions{})
+		if len(got) != 2 || got[0].Index != 15 || got[1].Index != 30 {
+			t.Fatalf("got %+v, want changes at 15 and 30", got)
+		}
+		if math.Abs(got[0].ChangePct-20) > 0.5 || math.Abs(got[1].After-1000) > 5 {
+			t.Errorf("segments = %+v", got)
+		}
+	})
+
+	t.Run("one-off outlier", func(t *testing.T) {
+		xs := level(30, 1000)
+		xs[17] = 400
+		if got := DetectChangePoints(xs, ChangePointOptions{}); len(got) != 0 {
+			t.Fatalf("outlier reported as %+v", got)
+		}
+	})
+
+	t.Run("small shift", func(t *testing.T) {
+		if got := DetectChangePoints(concat(level(20, 1000), level(20, 1020)), ChangePointOptions{}); len(got) != 0 {
+			t.Fatalf("2%% shift reported as %+v", got)
+		}
+		if got := DetectChangePoints(concat(level(20, 1000), level(20, 1020)), ChangePointOptions{MinChangePct: 1}); len(got) != 1 {
+			t.Fatalf("2%% shift with a 1%% floor: %+v", got)
+		}
+	})
+
+	t.Run("slow drift", func(t *testing.T) {
+		xs := make([]float64, 40)
+		for i := range xs {
+			xs[i] = 1000 - 5*float64(i)
+		}
+		got := DetectChangePoints(xs, ChangePointOptions{})
+		if len(got) == 0 || got[0].ChangePct >= 0 {
+			t.Fatalf("drift not found: %+v", got)
+		}
+	})
+
+	t.Run("short series", func(t *testing.T) {
+		if got := DetectChangePoints(concat(level(4, 1000), level(4, 500)), ChangePointOptions{}); len(got) != 0 {
+			t.Fatalf("segments under MinSegment: %+v", got)
+		}
+	})
+}
+
+func TestTrendChangePoints(t *testing.T) {
+	day := time.Date(2026, 5, 1, 2, 0, 0, 0, time.UTC)
+	trend := NewTrend("db_latency_p99")
+	for i, v := range concat(level(10, 0.010), level(10, 0.015)) {
+		rm := &RunMetrics{RunID: fmt.Sprintf("nightly-%02d", i), Metrics: []MetricSummary{{Key: "db_latency_p99", Avg: v, Samples: 6}}}
+		trend.Add(rm, day.AddDate(0, 0, i), "abc", nil)
+	}
+
+	got := trend.ChangePoints(ChangePointOptions{})
+	if len(got) != 1 {
+		t.Fatalf("got %+v", got)
+	}
+	c := got[0]
+	if c.Kind != ChangeRegression || c.LastBeforeRun != "nightly-09" || c.FirstAfterRun != "nightly-10" || !c.Time.Equal(day.AddDate(0, 0, 10)) {
+		t.Errorf("change = %+v", c)
+	}
+}
+
+func TestTrendChangePoints_ConfigChange(t *testing.T) {
+	day := time.Date(2026, 5, 1, 2, 0, 0, 0, time.UTC)
+	trend := NewTrend("db_latency_p99")
+	for i, v := range concat(level(10, 0.010), level(10, 0.015)) {
+		hash := "abc"
+		if i >= 10 {
+			hash = "def" // e.g. shared_buffers changed
+		}
+		rm := &RunMetrics{RunID: fmt.Sprintf("nightly-%02d", i), Metrics: []MetricSummary{{Key: "db_latency_p99", Avg: v, Samples: 6}}}
+		trend.Add(rm, day.AddDate(0, 0, i), hash, nil)
+	}
+	if got := trend.ChangePoints(ChangePointOptions{}); len(got) != 0 {
+		t.Errorf("step at a config change reported: %+v", got)
+	}
+
+	// A step within a stretch of one config is found at its run.
+	for i, v := range level(10, 0.020) {
+		rm := &RunMetrics{RunID: fmt.Sprintf("nightly-%02d", 20+i), Metrics: []MetricSummary{{Key: "db_latency_p99", Avg: v, Samples: 6}}}
+		trend.Add(rm, day.AddDate(0, 0, 20+i), "def", nil)
+	}
+	got := trend.ChangePoints(ChangePointOptions{})
+	if len(got) != 1 || got[0].Index != 20 || got[0].FirstAfterRun != "nightly-20" {
+		t.Errorf("changes = %+v, want one at nightly-20", got)
+	}
+}
+
+func TestTrendChangePoints_InterleavedConfigs(t *testing.T) {
+	// Two configs benchmarked on alternate nights at different levels; only
+	// "def" regresses, from its 11th run (nightly-21) on.
+	day := time.Date(2026, 5, 1, 2, 0, 0, 0, time.UTC)
+	trend := NewTrend("db_latency_p99")
+	abc := level(20, 0.010)
+	def := concat(level(10, 0.030), level(10, 0.045))
+	for i := range 40 {
+		hash, v := "abc", abc[i/2]
+		if i%2 == 1 {
+			hash, v = "def", def[i/2]
+		}
+		rm := &RunMetrics{RunID: fmt.Sprintf("nightly-%02d", i), Metrics: []MetricSummary{{Key: "db_latency_p99", Avg: v, Samples: 6}}}
+		trend.Add(rm, day.AddDate(0, 0, i), hash, nil)
+	}
+
+	got := trend.ChangePoints(ChangePointOptions{})
+	if len(got) != 1 {
+		t.Fatalf("changes = %+v, want one in config def", got)
+	}
+	c := got[0]
+	if c.Kind != ChangeRegression || c.Index != 21 || c.LastBeforeRun != "nightly-19" || c.FirstAfterRun != "nightly-21" {
+		t.Errorf("change = %+v, want a regression from nightly-19 to nightly-21", c)
+	}
+	if math.Abs(c.Before-0.030) > 0.001 || math.Abs(c.After-0.045) > 0.001 {
+		t.Errorf("levels = %v -> %v, want def's 0.030 -> 0.045", c.Before, c.After)
+	}
+}
@@ -2,10 +2,15 @@ package metrics
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"sort"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/victoria"
@@ -17,15 +22,47 @@ type TimeRange struct {
 	End   time.Time
 }
 
-// MetricSummary holds aggregated values for a single metric over a run.
+// MetricSummary holds aggregated values for a single metric over a run, or
+// over its steady state when one was detected.
 type MetricSummary struct {
-	Key  string  `json:"key"`
-	Name string  `json:"name"`
-	Unit string  `json:"unit"`
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	Unit string `json:"unit"`
+	// Avg, Min, Max, StdDev and CV leave out the lowest and highest 5% of
+	// samples, which catch counter reset spikes.
 	Avg  float64 `json:"avg"`
 	Min  float64 `json:"min"`
 	Max  float64 `json:"max"`
 	Last float64 `json:"last"`
+	// Percentiles are over all samples.
+	P50    float64 `json:"p50"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+	StdDev float64 `json:"stddev"`
+	// CV is the coefficient of variation, StdDev / Avg.
+	CV      float64 `json:"cv"`
+	Samples int     `json:"samples"`
+	// Stable reports CV <= MaxStableCV over at least minStableSamples
+	// samples. An unstable run makes a poor baseline.
+	Stable bool `json:"stable"`
+
+	// Direction and Threshold come from the metric's definition, so a
+	// comparison judges custom metrics the way their owner asked.
+	Direction string  `json:"direction,omitempty"`
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+// MaxStableCV is the highest coefficient of variation a stable metric has.
+const MaxStableCV = 0.1
+
+// minStableSamples is the fewest samples stability is judged on.
+const minStableSamples = 5
+
+// SeriesPoint is one sample of an aligned series: T is seconds since the
+// start of the collected range, so runs from different days overlay.
+type SeriesPoint struct {
+	T float64 `json:"t"`
+	V float64 `json:"v"`
 }
 
 // RunMetrics is the full metrics snapshot for a single run.
@@ -33,63 +70,192 @@ type RunMetrics struct {
 	RunID   string          `json:"run_id"`
 	Range   TimeRange       `json:"range"`
 	Metrics []MetricSummary `json:"metrics"`
+	// Series holds each metric's samples aligned to Range.Start, keyed by
+	// metric key. Series of a multi-series query are averaged per step.
+	Series map[string][]SeriesPoint `json:"series,omitempty"`
+	// Steady is the steady-state part of Range, without warm-up and
+	// ramp-down, detected from the SteadyKey throughput series. Metrics are
+	// summarized over it; nil when none was found and Range was used.
+	Steady    *TimeRange `json:"steady,omitempty"`
+	SteadyKey string     `json:"steady_key,omitempty"`
+	// Partial reports that a custom metric's query failed and its summary
+	// has no samples. A partial result is neither cached nor stored.
+	Partial bool `json:"partial,omitempty"`
 }
 
+// MaxParallelQueries bounds the queries a Collector has in flight, across
+// all its Collect calls.
+const MaxParallelQueries = 8
+
 // Collector fetches and aggregates metrics for runs.
 type Collector struct {
 	client *victoria.Client
 	defs   []MetricDef
+	slots  chan struct{}
+
+	// cache, when set, keeps results of settled ranges under scope.
+	cache *ResultCache
+	scope string
 }
 
 // NewCollector creates a metrics collector.
 func NewCollector(client *victoria.Client) *Collector {
-	return &Collector{
-		client: client,
-		defs:   DefaultMetrics(),
-	}
+	return NewCollectorWithDefs(client, DefaultMetrics())
 }
 
 // NewCollectorForDB creates a metrics collector with DB-specific queries.
 func NewCollectorForDB(client *victoria.Client, dbKind string) *Collector {
+	return NewCollectorWithDefs(client, MetricsForDB(dbKind))
+}
+
+// NewCollectorWithDefs creates a metrics collector for the given
+// definitions, e.g. MergeDefs of the built-in and a tenant's custom metrics.
+func NewCollectorWithDefs(client *victoria.Client, defs []MetricDef) *Collector {
 	return &Collector{
 		client: client,
-		defs:   MetricsForDB(dbKind),
+		defs:   defs,
+		slots:  make(chan struct{}, MaxParallelQueries),
 	}
 }
 
+// WithCache makes c keep its results in cache. scope must tell apart
+// metric stores that share run IDs, e.g. tenants.
+func (c *Collector) WithCache(cache *ResultCache, scope string) *Collector {
+	c.cache, c.scope = cache, scope
+	return c
+}
+
 // Collect fetches all defined metrics for a run within the given time range.
+// The queries run in parallel, at most MaxParallelQueries at a time. With a
+// cache, a settled range is collected once per set of definitions.
 func (c *Collector) Collect(ctx context.Context, runID string, tr TimeRange) (*RunMetrics, error) {
+	var key string
+	if c.cache != nil && settled(tr) {
+		key = c.cacheKey(runID, tr)
+		if rm, ok := c.cache.get(key); ok {
+			return rm, nil
+		}
+	}
+
 	step := inferStep(tr)
 	result := &RunMetrics{
 		RunID:   runID,
 		Range:   tr,
 		Metrics: make([]MetricSummary, 0, len(c.defs)),
+		Series:  make(map[string][]SeriesPoint, len(c.defs)),
 	}
 
-	for _, def := range c.defs {
-		query := RenderQuery(def, runID)
-		qr, err := c.client.QueryRange(ctx, query, tr.Start, tr.End, step)
-		if err != nil {
-			return nil, fmt.Errorf("metrics: query %q: %w", def.Key, err)
+	results, complete, err := c.queryAll(ctx, runID, tr, step)
+	if err != nil {
+		return nil, err
+	}
+	for i, def := range c.defs {
+		if points := alignSeries(results[i], tr.Start); len(points) > 0 {
+			result.Series[def.Key] = points
 		}
+	}
 
-		summary := summarize(def, qr)
-		result.Metrics = append(result.Metrics, summary)
+	if key, from, to, ok := detectSteadyState(result.Series); ok {
+		result.SteadyKey = key
+		result.Steady = &TimeRange{
+			Start: tr.Start.Add(time.Duration(from * float64(time.Second))),
+			End:   tr.Start.Add(time.Duration(to * float64(time.Second))),
+		}
+	}
+	for i, def := range c.defs {
+		result.Metrics = append(result.Metrics, summarize(def, results[i], result.Steady))
 	}
 
+	result.Partial = !complete
+	if key != "" && complete {
+		c.cache.put(key, result)
+	}
 	return result, nil
 }
 
-func summarize(def MetricDef, qr *victoria.QueryResult) MetricSummary {
+// queryAll runs every definition's range query, in the order of c.defs.
+// The first error of a built-in query cancels the queries still running. A
+// custom query that fails leaves its metric without samples instead, so one
+// broken definition does not break every collection; complete is false then.
+func (c *Collector) queryAll(ctx context.Context, runID string, tr TimeRange, step time.Duration) (results []*victoria.QueryResult, complete bool, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results = make([]*victoria.QueryResult, len(c.defs))
+	errs := make([]error, len(c.defs))
+	var wg sync.WaitGroup
+	for i, def := range c.defs {
+		custom := !IsBuiltinKey(def.Key)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case c.slots <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-c.slots }()
+			qr, err := c.client.QueryRange(ctx, RenderQuery(def, runID), tr.Start, tr.End, step)
+			if err != nil {
+				errs[i] = fmt.Errorf("metrics: query %q: %w", def.Key, err)
+				if !custom {
+					cancel()
+				}
+				return
+			}
+			results[i] = qr
+		}()
+	}
+	wg.Wait()
+
+	// Report the query that failed, not one cancelled because of it.
+	complete = true
+	for i, e := range errs {
+		if e == nil {
+			continue
+		}
+		if !IsBuiltinKey(c.defs[i].Key) {
+			results[i] = &victoria.QueryResult{}
+			complete = false
+			continue
+		}
+		if err == nil || (errors.Is(err, context.Canceled) && !errors.Is(e, context.Canceled)) {
+			err = e
+		}
+	}
+	return results, complete, err
+}
+
+// cacheKey identifies a result by scope, run, range and the definitions
+// it was collected with, so editing a custom metric misses the cache.
+func (c *Collector) cacheKey(runID string, tr TimeRange) string {
+	data, _ := json.Marshal(c.defs)
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%s\x00%s\x00%d\x00%d\x00%s",
+		c.scope, runID, tr.Start.UnixMilli(), tr.End.UnixMilli(), hex.EncodeToString(sum[:8]))
+}
+
+// summarize aggregates the samples of qr, only those within window when it
+// is set.
+func summarize(def MetricDef, qr *victoria.QueryResult, window *TimeRange) MetricSummary {
 	s := MetricSummary{
-		Key:  def.Key,
-		Name: def.Name,
-		Unit: def.Unit,
+		Key:       def.Key,
+		Name:      def.Name,
+		Unit:      def.Unit,
+		Direction: def.Direction,
+		Threshold: def.Threshold,
+	}
+	if s.Direction == "" {
+		s.Direction = builtinDirection(def.Key)
 	}
 
 	var vals []float64
 	for _, series := range qr.Data.Result {
 		for _, pair := range series.Values {
+			if window != nil && !window.contains(pair.Timestamp()) {
+				continue
+			}
 			if v, err := strconv.ParseFloat(pair.Val(), 64); err == nil && !math.IsInf(v, 0) && !math.IsNaN(v) {
 				vals = append(vals, v)
 			}
@@ -102,6 +268,10 @@ func summarize(def MetricDef, qr *victoria.QueryResult) MetricSummary {
 
 	// Sort to compute percentiles and filter outliers.
 	sort.Float64s(vals)
+	s.Samples = len(vals)
+	s.P50 = percentile(vals, 50)
+	s.P95 = percentile(vals, 95)
+	s.P99 = percentile(vals, 99)
 
 	// Use p5-p95 range to exclude counter reset spikes.
 	lo := len(vals) * 5 / 100
@@ -125,9 +295,85 @@ func summarize(def MetricDef, qr *victoria.QueryResult) MetricSummary {
 	s.Avg = sum / float64(len(trimmed))
 	s.Last = vals[len(vals)-1]
 
+	var ss float64
+	for _, v := range trimmed {
+		ss += (v - s.Avg) * (v - s.Avg)
+	}
+	if len(trimmed) > 1 {
+		s.StdDev = math.Sqrt(ss / float64(len(trimmed)-1))
+	}
+	if s.Avg != 0 {
+		s.CV = s.StdDev / math.Abs(s.Avg)
+	}
+	s.Stable = s.Samples >= minStableSamples && s.CV <= MaxStableCV
+
 	return s
 }
 
+// percentile returns the nearest-rank p-th percentile of sorted.
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank-1, 0), len(sorted)-1)]
+}
+
+func (tr TimeRange) contains(unixSec float64) bool {
+	ts := time.UnixMilli(int64(math.Round(unixSec * 1000)))
+	return !ts.Before(tr.Start) && !ts.After(tr.End)
+}
+
+// alignSeries averages the series of qr per timestamp and shifts the result
+// so that start is t=0.
+func alignSeries(qr *victoria.QueryResult, start time.Time) []SeriesPoint {
+	type acc struct {
+		sum float64
+		n   int
+	}
+	byTS := make(map[float64]*acc)
+	for _, series := range qr.Data.Result {
+		for _, pair := range series.Values {
+			v, err := strconv.ParseFloat(pair.Val(), 64)
+			if err != nil || math.IsInf(v, 0) || math.IsNaN(v) {
+				continue
+			}
+			ts := pair.Timestamp()
+			a := byTS[ts]
+			if a == nil {
+				a = &acc{}
+				byTS[ts] = a
+			}
+			a.sum += v
+			a.n++
+		}
+	}
+	points := make([]SeriesPoint, 0, len(byTS))
+	origin := float64(start.UnixMilli()) / 1000
+	for ts, a := range byTS {
+		points = append(points, SeriesPoint{T: ts - origin, V: a.sum / float64(a.n)})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].T < points[j].T })
+	return points
+}
+
+// Downsample averages consecutive points into at most maxPoints buckets of
+// equal size, each placed at its mean T. Shorter series are returned as is.
+func Downsample(points []SeriesPoint, maxPoints int) []SeriesPoint {
+	if maxPoints <= 0 || len(points) <= maxPoints {
+		return points
+	}
+	out := make([]SeriesPoint, 0, maxPoints)
+	for b := range maxPoints {
+		lo, hi := b*len(points)/maxPoints, (b+1)*len(points)/maxPoints
+		var t, v float64
+		for _, p := range points[lo:hi] {
+			t += p.T
+			v += p.V
+		}
+		n := float64(hi - lo)
+		out = append(out, SeriesPoint{T: t / n, V: v / n})
+	}
+	return out
+}
+
 func inferStep(tr TimeRange) time.Duration {
 	d := tr.End.Sub(tr.Start)
 	switch {
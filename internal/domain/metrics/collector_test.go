@@ -0,0 +1,243 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/victoria"
+)
+
+// rampSeries is a workload that warms up for 10 steps, holds at 1000 ops/s
+// with a little jitter for 60 and winds down over 10.
+func rampSeries() []SeriesPoint {
+	var points []SeriesPoint
+	for i := range 80 {
+		v := 1000 + float64(i%3-1)*20
+		switch {
+		case i < 10:
+			v = float64(i) * 100
+		case i >= 70:
+			v = float64(80-i) * 80
+		}
+		points = append(points, SeriesPoint{T: float64(i * 5), V: v})
+	}
+	return points
+}
+
+func TestDetectSteadyState(t *testing.T) {
+	key, from, to, ok := detectSteadyState(map[string][]SeriesPoint{
+		"db_qps":      rampSeries(),
+		"stroppy_ops": {{T: 0, V: 1}}, // too short, skipped
+	})
+	if !ok || key != "db_qps" {
+		t.Fatalf("got %q, %v; want db_qps", key, ok)
+	}
+	// Smoothing blurs the edges by a step or two.
+	if from < 35 || from > 55 || to < 335 || to > 355 {
+		t.Errorf("window = %.0fs..%.0fs, want about 50s..345s", from, to)
+	}
+
+	if _, _, _, ok := detectSteadyState(map[string][]SeriesPoint{"db_qps": make([]SeriesPoint, 20)}); ok {
+		t.Error("idle series has no steady state")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	qr := &victoria.QueryResult{}
+	var values []victoria.SamplePair
+	for _, p := range rampSeries() {
+		values = append(values, victoria.SamplePair{float64(start.Unix()) + p.T, fmt.Sprint(p.V)})
+	}
+	qr.Data.Result = []victoria.Series{{Values: values}}
+	def := MetricDef{Key: "db_qps", Name: "DB QPS", Unit: "ops/s"}
+
+	whole := summarize(def, qr, nil)
+	steady := summarize(def, qr, &TimeRange{Start: start.Add(50 * time.Second), End: start.Add(345 * time.Second)})
+
+	if whole.Samples != 80 || steady.Samples != 60 {
+		t.Fatalf("samples = %d, %d; want 80, 60", whole.Samples, steady.Samples)
+	}
+	if whole.Stable {
+		t.Errorf("whole run with ramps marked stable (cv %.3f)", whole.CV)
+	}
+	if !steady.Stable || math.Abs(steady.Avg-1000) > 1 {
+		t.Errorf("steady = avg %.1f, cv %.3f, stable %v; want 1000, stable", steady.Avg, steady.CV, steady.Stable)
+	}
+	if steady.P50 != 1000 || steady.P99 != 1020 {
+		t.Errorf("p50 = %v, p99 = %v", steady.P50, steady.P99)
+	}
+	if whole.P50 >= whole.P95 || whole.P95 > whole.P99 {
+		t.Errorf("percentiles out of order: %v %v %v", whole.P50, whole.P95, whole.P99)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	var points []SeriesPoint
+	for i := range 10 {
+		points = append(points, SeriesPoint{T: float64(i), V: float64(i * 10)})
+	}
+
+	if got := Downsample(points, 20); len(got) != 10 {
+		t.Fatalf("short series changed: %d points", len(got))
+	}
+	got := Downsample(points, 4)
+	want := []SeriesPoint{{0.5, 5}, {3, 30}, {5.5, 55}, {8, 80}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d points, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// fakeVictoria answers every range query with a flat series of value 10,
+// counting queries and the most it had in flight at once.
+type fakeVictoria struct {
+	mu                    sync.Mutex
+	queries, inFlight, hi int
+}
+
+func (f *fakeVictoria) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.queries++
+	f.inFlight++
+	f.hi = max(f.hi, f.inFlight)
+	f.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	f.mu.Lock()
+	f.inFlight--
+	f.mu.Unlock()
+
+	start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+	fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[%d,"10"],[%d,"10"]]}]}}`, start, start+15)
+}
+
+func TestCollectParallelAndCached(t *testing.T) {
+	fake := &fakeVictoria{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	var defs []MetricDef
+	for i := range 3 * MaxParallelQueries {
+		defs = append(defs, MetricDef{Key: fmt.Sprintf("m%d", i), Name: "M", Query: "up"})
+	}
+	cache := NewResultCache(4)
+	collector := func() *Collector {
+		return NewCollectorWithDefs(victoria.NewClient(srv.URL, ""), defs).WithCache(cache, "tenant-1")
+	}
+	end := time.Now().Add(-time.Hour)
+	tr := TimeRange{Start: end.Add(-10 * time.Minute), End: end}
+
+	rm, err := collector().Collect(context.Background(), "run-1", tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.Metrics) != len(defs) || rm.Metrics[5].Key != "m5" || rm.Metrics[5].Avg != 10 {
+		t.Fatalf("metrics = %+v, want every def in order", rm.Metrics)
+	}
+	if fake.queries != len(defs) || fake.hi < 2 || fake.hi > MaxParallelQueries {
+		t.Errorf("%d queries, %d at once; want %d, 2 to %d at once", fake.queries, fake.hi, len(defs), MaxParallelQueries)
+	}
+
+	// A result changed by its caller does not change the cached one.
+	rm.Series["m0"] = nil
+	again, err := collector().Collect(context.Background(), "run-1", tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.queries != len(defs) {
+		t.Errorf("settled range queried again: %d queries", fake.queries)
+	}
+	if len(again.Series["m0"]) == 0 {
+		t.Error("cached result was changed through a returned copy")
+	}
+
+	// Other scopes, definitions and unsettled ranges are collected afresh.
+	NewCollectorWithDefs(victoria.NewClient(srv.URL, ""), defs).WithCache(cache, "tenant-2").Collect(context.Background(), "run-1", tr)
+	NewCollectorWithDefs(victoria.NewClient(srv.URL, ""), defs[:1]).WithCache(cache, "tenant-1").Collect(context.Background(), "run-1", tr)
+	collector().Collect(context.Background(), "run-2", TimeRange{Start: time.Now().Add(-time.Minute), End: time.Now()})
+	collector().Collect(context.Background(), "run-2", TimeRange{Start: time.Now().Add(-time.Minute), End: time.Now()})
+	if want := 4*len(defs) + 1; fake.queries != want {
+		t.Errorf("%d queries, want %d", fake.queries, want)
+	}
+	if cache.Len() != 3 {
+		t.Errorf("cache holds %d results, want 3", cache.Len())
+	}
+}
+
+// brokenVictoria rejects every query containing "broken(" and answers the
+// others with a flat series.
+func brokenVictoria(w http.ResponseWriter, r *http.Request) {
+	if strings.Contains(r.URL.Query().Get("query"), "broken(") {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprint(w, `{"status":"error","error":"unknown func \"broken\""}`)
+		return
+	}
+	start, _ := strconv.ParseInt(r.URL.Query().Get("start"), 10, 64)
+	fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{},"values":[[%d,"10"]]}]}}`, start)
+}
+
+func TestCollectSkipsBrokenCustomQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(brokenVictoria))
+	defer srv.Close()
+	client := victoria.NewClient(srv.URL, "")
+
+	good := MetricDef{Key: "good_metric", Name: "Good", Query: "up{%s}", Direction: DirectionHigher}
+	broken := MetricDef{Key: "broken_metric", Name: "Broken", Query: "broken(up{%s})", Direction: DirectionHigher}
+	if err := good.CheckQuery(context.Background(), client); err != nil {
+		t.Errorf("CheckQuery(good) = %v", err)
+	}
+	if err := broken.CheckQuery(context.Background(), client); err == nil {
+		t.Error("CheckQuery(broken) = nil, want the store's error")
+	}
+
+	end := time.Now().Add(-time.Hour)
+	tr := TimeRange{Start: end.Add(-10 * time.Minute), End: end}
+	cache := NewResultCache(4)
+	rm, err := NewCollectorWithDefs(client, []MetricDef{good, broken}).WithCache(cache, "t").Collect(context.Background(), "run-1", tr)
+	if err != nil {
+		t.Fatalf("Collect = %v, want the broken custom metric left empty", err)
+	}
+	if rm.Metrics[0].Samples == 0 || rm.Metrics[1].Samples != 0 {
+		t.Errorf("samples = %d, %d; want some, none", rm.Metrics[0].Samples, rm.Metrics[1].Samples)
+	}
+	if !rm.Partial {
+		t.Error("a result missing a failed query is not marked partial")
+	}
+	if cache.Len() != 0 {
+		t.Error("a result missing a failed query was cached")
+	}
+
+	// A broken built-in query still fails the collection.
+	builtin := MetricDef{Key: "db_qps", Name: "QPS", Query: "broken(up{%s})"}
+	if _, err := NewCollectorWithDefs(client, []MetricDef{good, builtin}).Collect(context.Background(), "run-1", tr); err == nil {
+		t.Error("Collect with a broken built-in query succeeded")
+	}
+}
+
+func TestResultCacheEvicts(t *testing.T) {
+	c := NewResultCache(2)
+	c.put("a", &RunMetrics{RunID: "a"})
+	c.put("b", &RunMetrics{RunID: "b"})
+	c.get("a")
+	c.put("c", &RunMetrics{RunID: "c"})
+	if _, ok := c.get("b"); ok {
+		t.Error("least recently used result was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if rm, ok := c.get(key); !ok || rm.RunID != key {
+			t.Errorf("get(%q) = %v, %v", key, rm, ok)
+		}
+	}
+}
@@ -7,12 +7,32 @@ import (
 
 // Comparison holds two runs side by side with per-metric diffs.
 type Comparison struct {
-	RunA    string            `json:"run_a"`
-	RunB    string            `json:"run_b"`
-	Start   time.Time         `json:"start"`
-	End     time.Time         `json:"end"`
+	RunA string `json:"run_a"`
+	RunB string `json:"run_b"`
+	// RunsA and RunsB list every run of each group when repetitions are
+	// compared; RunA and RunB are then the first of each.
+	RunsA []string `json:"runs_a,omitempty"`
+	RunsB []string `json:"runs_b,omitempty"`
+	// Start and End span both windows.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// WindowA and WindowB are the ranges each run was collected over.
+	WindowA TimeRange         `json:"window_a"`
+	WindowB TimeRange         `json:"window_b"`
 	Metrics []MetricDiff      `json:"metrics"`
 	Summary ComparisonVerdict `json:"summary"`
+	// Series pairs each metric's aligned series for overlay charts.
+	Series []SeriesPair `json:"series,omitempty"`
+}
+
+// SeriesPair is one metric's series from both runs, each aligned to the
+// start of its own window.
+type SeriesPair struct {
+	Key  string        `json:"key"`
+	Name string        `json:"name"`
+	Unit string        `json:"unit"`
+	A    []SeriesPoint `json:"a"`
+	B    []SeriesPoint `json:"b"`
 }
 
 // MetricDiff compares a single metric between two runs.
@@ -31,10 +51,30 @@ type MetricDiff struct {
 	DiffAvgPct float64 `json:"diff_avg_pct"`
 	DiffMaxPct float64 `json:"diff_max_pct"`
 
-	// Verdict per metric: "better", "worse", "same".
+	// Verdict per metric: "better", "worse", "same". For groups, "same"
+	// means no significant change.
 	Verdict string `json:"verdict"`
+
+	// Stats is set when groups of repeated runs are compared.
+	Stats *GroupStats `json:"stats,omitempty"`
+}
+
+// GroupStats is the statistical comparison of one metric between two groups
+// of repeated runs.
+type GroupStats struct {
+	A SampleStats `json:"a"`
+	B SampleStats `json:"b"`
+	// U and PValue are the two-sided Mann-Whitney U test of A against B.
+	U      float64 `json:"u"`
+	PValue float64 `json:"p_value"`
+	// Significant is PValue < SignificanceLevel.
+	Significant bool `json:"significant"`
 }
 
+// SignificanceLevel is the p-value below which a group difference counts as
+// significant. With no ties, reaching it takes at least four runs per group.
+const SignificanceLevel = 0.05
+
 // ComparisonVerdict is the overall comparison result.
 type ComparisonVerdict struct {
 	Better int `json:"better"` // count of metrics that improved
@@ -49,24 +89,21 @@ func Compare(a, b *RunMetrics, threshold float64) *Comparison {
 	indexB := indexByKey(b)
 
 	// Union of all keys, preserving order from A then B.
-	seen := map[string]bool{}
-	var keys []string
-	for _, m := range a.Metrics {
-		if !seen[m.Key] {
-			keys = append(keys, m.Key)
-			seen[m.Key] = true
-		}
-	}
-	for _, m := range b.Metrics {
-		if !seen[m.Key] {
-			keys = append(keys, m.Key)
-			seen[m.Key] = true
-		}
-	}
+	keys := unionMetricKeys([]*RunMetrics{a, b})
 
 	comp := &Comparison{
-		RunA: a.RunID,
-		RunB: b.RunID,
+		RunA:    a.RunID,
+		RunB:    b.RunID,
+		WindowA: a.Range,
+		WindowB: b.Range,
+		Start:   a.Range.Start,
+		End:     a.Range.End,
+	}
+	if !b.Range.Start.IsZero() && (comp.Start.IsZero() || b.Range.Start.Before(comp.Start)) {
+		comp.Start = b.Range.Start
+	}
+	if b.Range.End.After(comp.End) {
+		comp.End = b.Range.End
 	}
 
 	for _, key := range keys {
@@ -87,12 +124,24 @@ func Compare(a, b *RunMetrics, threshold float64) *Comparison {
 			diff.Unit = mb.Unit
 		}
 
-		diff.DiffAvgPct = pctDiff(ma.Avg, mb.Avg)
-		diff.DiffMaxPct = pctDiff(ma.Max, mb.Max)
-		diff.Verdict = verdict(key, diff.DiffAvgPct, threshold)
+		if ma.Samples == 0 || mb.Samples == 0 {
+			// Missing on a side, or its query failed: nothing to compare.
+			diff.Verdict = "same"
+		} else {
+			diff.DiffAvgPct = pctDiff(ma.Avg, mb.Avg)
+			diff.DiffMaxPct = pctDiff(ma.Max, mb.Max)
+			diff.Verdict = verdict(metricDirection(key, ma, mb), diff.DiffAvgPct, metricThreshold(threshold, ma, mb))
+		}
 
 		comp.Metrics = append(comp.Metrics, diff)
 
+		if sa, sb := a.Series[key], b.Series[key]; len(sa) > 0 || len(sb) > 0 {
+			comp.Series = append(comp.Series, SeriesPair{
+				Key: key, Name: diff.Name, Unit: diff.Unit,
+				A: orEmpty(sa), B: orEmpty(sb),
+			})
+		}
+
 		switch diff.Verdict {
 		case "better":
 			comp.Summary.Better++
@@ -106,6 +155,122 @@ func Compare(a, b *RunMetrics, threshold float64) *Comparison {
 	return comp
 }
 
+// CompareGroups compares two groups of repeated runs, each run contributing
+// its average of every metric as one sample. A metric is "better" or "worse"
+// only if the Mann-Whitney U test finds the groups significantly different
+// and the means differ by at least threshold percent; otherwise "same".
+// One run per side is the plain Compare. Windows and series come from the
+// first run of each group.
+func CompareGroups(a, b []*RunMetrics, threshold float64) *Comparison {
+	if len(a) == 0 || len(b) == 0 {
+		return &Comparison{}
+	}
+	if len(a) == 1 && len(b) == 1 {
+		return Compare(a[0], b[0], threshold)
+	}
+
+	all := append(append([]*RunMetrics{}, a...), b...)
+	comp := Compare(a[0], b[0], threshold)
+	comp.RunsA, comp.RunsB = runIDs(a), runIDs(b)
+	for _, rm := range all {
+		if !rm.Range.Start.IsZero() && rm.Range.Start.Before(comp.Start) {
+			comp.Start = rm.Range.Start
+		}
+		if rm.Range.End.After(comp.End) {
+			comp.End = rm.Range.End
+		}
+	}
+
+	comp.Metrics = comp.Metrics[:0]
+	comp.Summary = ComparisonVerdict{}
+	for _, key := range unionMetricKeys(all) {
+		samplesA, maxA, ref := groupSamples(a, key)
+		samplesB, maxB, refB := groupSamples(b, key)
+		dir, th := metricDirection(key, ref, refB), metricThreshold(threshold, ref, refB)
+		if ref.Name == "" {
+			ref = refB
+		}
+
+		st := &GroupStats{A: Describe(samplesA), B: Describe(samplesB)}
+		st.U, st.PValue = MannWhitneyU(samplesA, samplesB)
+		st.Significant = st.PValue < SignificanceLevel
+
+		diff := MetricDiff{
+			Key:        key,
+			Name:       ref.Name,
+			Unit:       ref.Unit,
+			AvgA:       st.A.Mean,
+			AvgB:       st.B.Mean,
+			MaxA:       maxA,
+			MaxB:       maxB,
+			DiffAvgPct: pctDiff(st.A.Mean, st.B.Mean),
+			DiffMaxPct: pctDiff(maxA, maxB),
+			Stats:      st,
+		}
+		diff.Verdict = "same"
+		if st.Significant {
+			diff.Verdict = verdict(dir, diff.DiffAvgPct, th)
+		}
+		comp.Metrics = append(comp.Metrics, diff)
+
+		switch diff.Verdict {
+		case "better":
+			comp.Summary.Better++
+		case "worse":
+			comp.Summary.Worse++
+		default:
+			comp.Summary.Same++
+		}
+	}
+	return comp
+}
+
+// groupSamples collects each run's average of key, the highest max among
+// them, and a summary carrying the metric's name and unit. Runs without
+// samples of key are left out.
+func groupSamples(group []*RunMetrics, key string) ([]float64, float64, MetricSummary) {
+	var samples []float64
+	var maxV float64
+	var ref MetricSummary
+	for _, rm := range group {
+		m, ok := indexByKey(rm)[key]
+		if !ok || m.Samples == 0 {
+			continue
+		}
+		if ref.Name == "" {
+			ref = m
+		}
+		if len(samples) == 0 || m.Max > maxV {
+			maxV = m.Max
+		}
+		samples = append(samples, m.Avg)
+	}
+	return samples, maxV, ref
+}
+
+// unionMetricKeys lists the metric keys of runs in first-seen order.
+func unionMetricKeys(runs []*RunMetrics) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, rm := range runs {
+		for _, m := range rm.Metrics {
+			if !seen[m.Key] {
+				keys = append(keys, m.Key)
+				seen[m.Key] = true
+			}
+		}
+	}
+	return keys
+}
+
+func runIDs(runs []*RunMetrics) []string {
+	ids := make([]string, len(runs))
+	for i, rm := range runs {
+		ids[i] = rm.RunID
+	}
+	return ids
+}
+
 // higherIsBetter lists metric keys where an increase is positive.
 var higherIsBetter = map[string]bool{
 	"db_qps":      true,
@@ -122,24 +287,42 @@ var lowerIsBetter = map[string]bool{
 	"stroppy_errors":      true,
 }
 
-func verdict(key string, diffPct, threshold float64) string {
+// metricDirection takes the direction recorded with either summary, then
+// the built-in one.
+func metricDirection(key string, a, b MetricSummary) string {
+	switch {
+	case a.Direction != "":
+		return a.Direction
+	case b.Direction != "":
+		return b.Direction
+	}
+	return builtinDirection(key)
+}
+
+// metricThreshold is the metric's own threshold if it has one.
+func metricThreshold(threshold float64, a, b MetricSummary) float64 {
+	switch {
+	case a.Threshold > 0:
+		return a.Threshold
+	case b.Threshold > 0:
+		return b.Threshold
+	}
+	return threshold
+}
+
+func verdict(direction string, diffPct, threshold float64) string {
 	if math.Abs(diffPct) < threshold {
 		return "same"
 	}
 	increased := diffPct > 0
-	if higherIsBetter[key] {
+	if direction == DirectionHigher {
 		if increased {
 			return "better"
 		}
 		return "worse"
 	}
-	if lowerIsBetter[key] {
-		if increased {
-			return "worse"
-		}
-		return "better"
-	}
-	// Unknown metrics: just report direction.
+	// DirectionLower; metrics without a direction on record are treated
+	// the same way.
 	if increased {
 		return "worse"
 	}
@@ -156,6 +339,13 @@ func pctDiff(a, b float64) float64 {
 	return (b - a) / a * 100
 }
 
+func orEmpty(points []SeriesPoint) []SeriesPoint {
+	if points == nil {
+		return []SeriesPoint{}
+	}
+	return points
+}
+
 func indexByKey(rm *RunMetrics) map[string]MetricSummary {
 	idx := make(map[string]MetricSummary, len(rm.Metrics))
 	for _, m := range rm.Metrics {
@@ -1,22 +1,29 @@
 package metrics
 
-import "testing"
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/victoria"
+)
 
 func TestCompare(t *testing.T) {
 	a := &RunMetrics{
 		RunID: "run-a",
 		Metrics: []MetricSummary{
-			{Key: "db_qps", Name: "DB QPS", Unit: "ops/s", Avg: 1000, Min: 800, Max: 1200, Last: 1100},
-			{Key: "db_latency_p99", Name: "Latency", Unit: "s", Avg: 0.05, Min: 0.01, Max: 0.1, Last: 0.04},
-			{Key: "stroppy_ops", Name: "Ops", Unit: "ops/s", Avg: 500, Min: 400, Max: 600, Last: 550},
+			{Key: "db_qps", Name: "DB QPS", Unit: "ops/s", Avg: 1000, Min: 800, Max: 1200, Last: 1100, Samples: 6},
+			{Key: "db_latency_p99", Name: "Latency", Unit: "s", Avg: 0.05, Min: 0.01, Max: 0.1, Last: 0.04, Samples: 6},
+			{Key: "stroppy_ops", Name: "Ops", Unit: "ops/s", Avg: 500, Min: 400, Max: 600, Last: 550, Samples: 6},
 		},
 	}
 	b := &RunMetrics{
 		RunID: "run-b",
 		Metrics: []MetricSummary{
-			{Key: "db_qps", Avg: 1100, Max: 1300},         // 10% better
-			{Key: "db_latency_p99", Avg: 0.04, Max: 0.08}, // 20% better (lower is better)
-			{Key: "stroppy_ops", Avg: 500, Max: 600},      // same (within 5%)
+			{Key: "db_qps", Avg: 1100, Max: 1300, Samples: 6},         // 10% better
+			{Key: "db_latency_p99", Avg: 0.04, Max: 0.08, Samples: 6}, // 20% better (lower is better)
+			{Key: "stroppy_ops", Avg: 500, Max: 600, Samples: 6},      // same (within 5%)
 		},
 	}
 
@@ -48,3 +55,238 @@ func TestCompare(t *testing.T) {
 		}
 	}
 }
+
+func TestCompare_AlignedSeries(t *testing.T) {
+	dayA := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	dayB := time.Date(2026, 3, 8, 22, 30, 0, 0, time.UTC)
+	a := &RunMetrics{
+		RunID:   "run-a",
+		Range:   TimeRange{Start: dayA, End: dayA.Add(10 * time.Minute)},
+		Metrics: []MetricSummary{{Key: "db_qps", Name: "DB QPS", Unit: "ops/s", Avg: 100}},
+		Series:  map[string][]SeriesPoint{"db_qps": {{T: 0, V: 90}, {T: 15, V: 110}}},
+	}
+	b := &RunMetrics{
+		RunID:   "run-b",
+		Range:   TimeRange{Start: dayB, End: dayB.Add(5 * time.Minute)},
+		Metrics: []MetricSummary{{Key: "db_qps", Avg: 100}, {Key: "cpu_usage", Avg: 40}},
+	}
+
+	comp := Compare(a, b, 5.0)
+	if comp.WindowA != a.Range || comp.WindowB != b.Range {
+		t.Errorf("windows = %v, %v; want each run's own range", comp.WindowA, comp.WindowB)
+	}
+	if !comp.Start.Equal(dayA) || !comp.End.Equal(dayB.Add(5*time.Minute)) {
+		t.Errorf("span = %v..%v, want both windows", comp.Start, comp.End)
+	}
+	if len(comp.Series) != 1 {
+		t.Fatalf("series = %+v, want only db_qps", comp.Series)
+	}
+	if s := comp.Series[0]; s.Key != "db_qps" || len(s.A) != 2 || s.B == nil || len(s.B) != 0 {
+		t.Errorf("series = %+v, want A's two points and an empty B", s)
+	}
+}
+
+func TestAlignSeries(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	ts := float64(start.Unix())
+	qr := &victoria.QueryResult{}
+	qr.Data.Result = []victoria.Series{
+		{Values: []victoria.SamplePair{{ts + 15, "10"}, {ts, "2"}}},
+		{Values: []victoria.SamplePair{{ts + 15, "20"}, {ts + 30, "NaN"}}},
+	}
+
+	got := alignSeries(qr, start)
+	want := []SeriesPoint{{T: 0, V: 2}, {T: 15, V: 15}}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func groupOf(id string, qps ...float64) []*RunMetrics {
+	out := make([]*RunMetrics, len(qps))
+	for i, v := range qps {
+		out[i] = &RunMetrics{
+			RunID:   fmt.Sprintf("%s-%d", id, i),
+			Metrics: []MetricSummary{{Key: "db_qps", Name: "DB QPS", Unit: "ops/s", Avg: v, Max: v * 1.2, Samples: 6}},
+		}
+	}
+	return out
+}
+
+func TestCompareGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    []*RunMetrics
+		verdict string
+		sig     bool
+	}{
+		// Clearly separated groups, well above the threshold.
+		{"improved", groupOf("a", 1000, 1010, 990, 1005), groupOf("b", 1200, 1190, 1210, 1205), "better", true},
+		// Same shift in the means, but the groups overlap: noise.
+		{"noisy", groupOf("a", 1000, 1400, 800, 1200), groupOf("b", 1300, 900, 1500, 1100), "same", false},
+		// Significant but smaller than the 5% threshold.
+		{"below threshold", groupOf("a", 1000, 1001, 1002, 1003), groupOf("b", 1010, 1011, 1012, 1013), "same", true},
+		// Three runs per side can never reach p < 0.05.
+		{"too few runs", groupOf("a", 1000, 1001, 1002), groupOf("b", 2000, 2001, 2002), "same", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comp := CompareGroups(tt.a, tt.b, 5.0)
+			if len(comp.Metrics) != 1 {
+				t.Fatalf("metrics = %+v", comp.Metrics)
+			}
+			m := comp.Metrics[0]
+			if m.Stats == nil {
+				t.Fatal("stats missing for a group comparison")
+			}
+			if m.Verdict != tt.verdict || m.Stats.Significant != tt.sig {
+				t.Errorf("verdict = %s, significant = %v (p=%.4f); want %s, %v",
+					m.Verdict, m.Stats.Significant, m.Stats.PValue, tt.verdict, tt.sig)
+			}
+			if len(comp.RunsA) != len(tt.a) || comp.RunA != tt.a[0].RunID {
+				t.Errorf("runs_a = %v, run_a = %s", comp.RunsA, comp.RunA)
+			}
+		})
+	}
+
+	single := CompareGroups(groupOf("a", 1000), groupOf("b", 1200), 5.0)
+	if single.Metrics[0].Stats != nil || single.Metrics[0].Verdict != "better" {
+		t.Errorf("single runs = %+v, want the plain comparison", single.Metrics[0])
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	s := Describe([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if s.N != 8 || s.Mean != 5 {
+		t.Fatalf("got %+v", s)
+	}
+	if math.Abs(s.StdDev-2.138) > 0.001 || math.Abs(s.CV-0.4276) > 0.001 {
+		t.Errorf("stddev = %.4f, cv = %.4f", s.StdDev, s.CV)
+	}
+	// t(7) = 2.365: half width 2.365 * 2.138 / sqrt(8) = 1.788.
+	if math.Abs(s.CILow-3.212) > 0.001 || math.Abs(s.CIHigh-6.788) > 0.001 {
+		t.Errorf("ci = [%.3f, %.3f]", s.CILow, s.CIHigh)
+	}
+
+	one := Describe([]float64{3})
+	if one.StdDev != 0 || one.CILow != 3 || one.CIHigh != 3 {
+		t.Errorf("single sample = %+v", one)
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	// Complete separation, 4 vs 4: exact p = 2/70.
+	u, p := MannWhitneyU([]float64{1, 2, 3, 4}, []float64{5, 6, 7, 8})
+	if u != 0 || math.Abs(p-2.0/70) > 1e-12 {
+		t.Errorf("separated: u = %v, p = %v", u, p)
+	}
+	// Interleaved: no evidence of a difference.
+	if _, p := MannWhitneyU([]float64{1, 4, 5, 8}, []float64{2, 3, 6, 7}); p < 0.5 {
+		t.Errorf("interleaved: p = %v", p)
+	}
+	// Ties fall back to the normal approximation.
+	if _, p := MannWhitneyU([]float64{1, 1, 2, 2, 3}, []float64{3, 4, 4, 5, 5}); p > 0.05 {
+		t.Errorf("tied but separated: p = %v", p)
+	}
+	if _, p := MannWhitneyU([]float64{5, 5}, []float64{5, 5}); p != 1 {
+		t.Errorf("identical: p = %v", p)
+	}
+	if _, p := MannWhitneyU(nil, []float64{1}); p != 1 {
+		t.Errorf("empty: p = %v", p)
+	}
+}
+
+func TestCompareMany(t *testing.T) {
+	run := func(id string, qps, lat float64) *RunMetrics {
+		rm := &RunMetrics{RunID: id, Metrics: []MetricSummary{{Key: "db_qps", Name: "DB QPS", Avg: qps, Samples: 6}}}
+		if lat > 0 {
+			rm.Metrics = append(rm.Metrics, MetricSummary{Key: "db_latency_p99", Avg: lat, Samples: 6})
+		}
+		return rm
+	}
+	runs := []*RunMetrics{
+		run("pg15", 900, 0.05),
+		run("pg16", 1000, 0.04), // reference
+		run("pg17", 1200, 0),    // no latency metric
+	}
+
+	m := CompareMany(runs, 1, 5)
+	if m.Reference != "pg16" || len(m.Runs) != 3 || len(m.Metrics) != 2 {
+		t.Fatalf("matrix = %+v", m)
+	}
+
+	qps := m.Metrics[0]
+	if qps.Best != "pg17" || qps.Worst != "pg15" {
+		t.Errorf("qps best/worst = %s/%s, want pg17/pg15", qps.Best, qps.Worst)
+	}
+	want := []string{"worse", "reference", "better"}
+	for i, c := range qps.Cells {
+		if c.Verdict != want[i] {
+			t.Errorf("qps cell %s: verdict %s, want %s", c.Run, c.Verdict, want[i])
+		}
+	}
+	if d := qps.Cells[2].DiffPct; d != 20 {
+		t.Errorf("pg17 diff = %v, want 20", d)
+	}
+
+	lat := m.Metrics[1]
+	if lat.Best != "pg16" || lat.Worst != "pg15" {
+		t.Errorf("latency best/worst = %s/%s, want pg16/pg15 (lower is better, pg17 missing)", lat.Best, lat.Worst)
+	}
+	if !lat.Cells[2].Missing {
+		t.Error("pg17 latency should be missing")
+	}
+}
+
+func TestTrend(t *testing.T) {
+	day := time.Date(2026, 5, 1, 2, 0, 0, 0, time.UTC)
+	trend := NewTrend("stroppy_ops")
+	for i, avg := range []float64{1000, 1100, 0, 990, -1} {
+		rm := &RunMetrics{RunID: fmt.Sprintf("nightly-%d", i)}
+		switch {
+		case avg > 0:
+			rm.Metrics = []MetricSummary{{Key: "stroppy_ops", Name: "Ops", Unit: "ops/s", Avg: avg, Samples: 6, Stable: true}}
+		case avg < 0: // nightly-4 has the metric but no samples of it
+			rm.Metrics = []MetricSummary{{Key: "stroppy_ops", Name: "Ops", Unit: "ops/s"}}
+		} // nightly-2 has no such metric
+		trend.Add(rm, day.AddDate(0, 0, i), "abc", nil)
+	}
+
+	if trend.Name != "Ops" || trend.Direction != DirectionHigher || len(trend.Points) != 3 {
+		t.Fatalf("trend = %+v", trend)
+	}
+	last := trend.Points[2]
+	if last.RunID != "nightly-3" || !last.Time.Equal(day.AddDate(0, 0, 3)) || last.Tags == nil {
+		t.Errorf("last point = %+v", last)
+	}
+	if trend.Points[0].ChangePct != 0 || trend.Points[1].ChangePct != 10 || last.ChangePct != -10 {
+		t.Errorf("changes = %v, %v, %v", trend.Points[0].ChangePct, trend.Points[1].ChangePct, last.ChangePct)
+	}
+}
+
+func TestCompare_NoSamples(t *testing.T) {
+	// A custom metric whose query failed in run-b comes back without samples.
+	a := &RunMetrics{RunID: "run-a", Metrics: []MetricSummary{{Key: "wal_bytes", Avg: 100, Samples: 6}}}
+	b := &RunMetrics{RunID: "run-b", Metrics: []MetricSummary{{Key: "wal_bytes"}}}
+
+	comp := Compare(a, b, 5)
+	if d := comp.Metrics[0]; d.Verdict != "same" || d.DiffAvgPct != 0 {
+		t.Errorf("diff = %+v, want same with no change", d)
+	}
+	if comp.Summary.Better != 0 || comp.Summary.Worse != 0 {
+		t.Errorf("summary = %+v", comp.Summary)
+	}
+
+	m := CompareMany([]*RunMetrics{a, b}, 0, 5)
+	if c := m.Metrics[0].Cells[1]; !c.Missing || c.Verdict != "same" {
+		t.Errorf("run-b cell = %+v, want missing", c)
+	}
+	if row := m.Metrics[0]; row.Best != "" || row.Worst != "" {
+		t.Errorf("best/worst = %s/%s, want none", row.Best, row.Worst)
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/victoria"
+)
+
+// Directions of a MetricDef.
+const (
+	DirectionHigher = "higher" // an increase is an improvement
+	DirectionLower  = "lower"  // a decrease is an improvement
+)
+
+// dbKinds are the database kinds with built-in metric sets.
+var dbKinds = []string{"postgres", "mysql", "picodata", "ydb"}
+
+var metricKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// BuiltinDefs lists every built-in metric with its direction filled in and
+// the database metrics tagged with their kind. A key can appear once per
+// kind with a different query.
+func BuiltinDefs() []MetricDef {
+	var out []MetricDef
+	for _, kind := range dbKinds {
+		for _, def := range dbMetricsFor(kind) {
+			def.DBKinds = []string{kind}
+			def.Direction = builtinDirection(def.Key)
+			out = append(out, def)
+		}
+	}
+	for _, def := range systemMetrics() {
+		def.Direction = builtinDirection(def.Key)
+		out = append(out, def)
+	}
+	return out
+}
+
+// IsBuiltinKey reports whether key names a built-in metric of any kind.
+func IsBuiltinKey(key string) bool {
+	return slices.ContainsFunc(BuiltinDefs(), func(d MetricDef) bool { return d.Key == key })
+}
+
+// Validate checks a custom metric definition: a lowercase key that no
+// built-in uses, a name, a query scoped to the run with %s or %p, an
+// explicit direction, a non-negative threshold and known database kinds.
+func (d MetricDef) Validate() error {
+	switch {
+	case !metricKeyPattern.MatchString(d.Key):
+		return fmt.Errorf("key %q: want lowercase letters, digits and underscores, starting with a letter", d.Key)
+	case IsBuiltinKey(d.Key):
+		return fmt.Errorf("key %q is a built-in metric", d.Key)
+	case strings.TrimSpace(d.Name) == "":
+		return fmt.Errorf("name is required")
+	case !strings.Contains(d.Query, "%s") && !strings.Contains(d.Query, "%p"):
+		return fmt.Errorf("query must select the run with %%s (run label filter) or %%p (metric prefix)")
+	case d.Direction != DirectionHigher && d.Direction != DirectionLower:
+		return fmt.Errorf("direction must be %q or %q", DirectionHigher, DirectionLower)
+	case d.Threshold < 0:
+		return fmt.Errorf("threshold must not be negative")
+	}
+	for _, kind := range d.DBKinds {
+		if !slices.Contains(dbKinds, kind) {
+			return fmt.Errorf("db kind %q: want one of %s", kind, strings.Join(dbKinds, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckQuery runs the query once, as an instant query for a placeholder
+// run, so a query the metrics store rejects is caught when the definition
+// is saved rather than on every collection.
+func (d MetricDef) CheckQuery(ctx context.Context, client *victoria.Client) error {
+	if _, err := client.QueryInstant(ctx, RenderQuery(d, "query-check"), time.Now()); err != nil {
+		return fmt.Errorf("query: %w", err)
+	}
+	return nil
+}
+
+// AppliesTo reports whether the metric is collected for runs of dbKind.
+func (d MetricDef) AppliesTo(dbKind string) bool {
+	return len(d.DBKinds) == 0 || slices.Contains(d.DBKinds, dbKind)
+}
+
+// MergeDefs returns the built-in metrics for dbKind followed by the custom
+// ones that apply to it.
+func MergeDefs(dbKind string, custom []MetricDef) []MetricDef {
+	defs := MetricsForDB(dbKind)
+	for _, def := range custom {
+		if def.AppliesTo(dbKind) {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+// builtinDirection is the direction of a built-in metric, "" if it has
+// none on record.
+func builtinDirection(key string) string {
+	switch {
+	case higherIsBetter[key]:
+		return DirectionHigher
+	case lowerIsBetter[key]:
+		return DirectionLower
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetricDefValidate(t *testing.T) {
+	valid := MetricDef{
+		Key:       "wal_bytes",
+		Name:      "WAL bytes/s",
+		Query:     `sum(rate(pg_stat_wal_bytes{%s}[1m]))`,
+		Unit:      "bytes/s",
+		Direction: DirectionLower,
+		DBKinds:   []string{"postgres"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("valid def: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(*MetricDef)
+		want   string
+	}{
+		{"bad key", func(d *MetricDef) { d.Key = "WAL-bytes" }, "lowercase"},
+		{"built-in key", func(d *MetricDef) { d.Key = "db_qps" }, "built-in"},
+		{"no name", func(d *MetricDef) { d.Name = " " }, "name"},
+		{"unscoped query", func(d *MetricDef) { d.Query = `sum(rate(pg_stat_wal_bytes[1m]))` }, "%s"},
+		{"no direction", func(d *MetricDef) { d.Direction = "" }, "direction"},
+		{"negative threshold", func(d *MetricDef) { d.Threshold = -1 }, "threshold"},
+		{"unknown kind", func(d *MetricDef) { d.DBKinds = []string{"oracle"} }, "oracle"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := valid
+			tt.mutate(&d)
+			if err := d.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() = %v, want an error mentioning %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDefs(t *testing.T) {
+	custom := []MetricDef{
+		{Key: "wal_bytes", DBKinds: []string{"postgres"}},
+		{Key: "app_latency"},
+	}
+	keys := func(defs []MetricDef) map[string]bool {
+		out := map[string]bool{}
+		for _, d := range defs {
+			out[d.Key] = true
+		}
+		return out
+	}
+	pg, my := keys(MergeDefs("postgres", custom)), keys(MergeDefs("mysql", custom))
+	if !pg["wal_bytes"] || !pg["app_latency"] || !pg["cpu_usage"] {
+		t.Errorf("postgres defs = %v", pg)
+	}
+	if my["wal_bytes"] || !my["app_latency"] {
+		t.Errorf("mysql defs = %v, want app_latency but not the postgres-only metric", my)
+	}
+}
+
+func TestCompare_CustomDirectionAndThreshold(t *testing.T) {
+	a := &RunMetrics{Metrics: []MetricSummary{
+		{Key: "cache_hit", Avg: 90, Samples: 6, Direction: DirectionHigher},
+		{Key: "wal_bytes", Avg: 100, Samples: 6, Direction: DirectionLower, Threshold: 30},
+		{Key: "db_qps", Avg: 1000, Samples: 6, Direction: DirectionHigher},
+	}}
+	b := &RunMetrics{Metrics: []MetricSummary{
+		{Key: "cache_hit", Avg: 99, Samples: 6},
+		{Key: "wal_bytes", Avg: 120, Samples: 6}, // +20%, inside its own 30% threshold
+		{Key: "db_qps", Avg: 800, Samples: 6},
+	}}
+	want := map[string]string{"cache_hit": "better", "wal_bytes": "same", "db_qps": "worse"}
+	for _, m := range Compare(a, b, 5).Metrics {
+		if m.Verdict != want[m.Key] {
+			t.Errorf("%s: verdict %s, want %s", m.Key, m.Verdict, want[m.Key])
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// GateDefaultKey in GatePolicy.MaxDegradationPct limits every metric not
+// listed by key.
+const GateDefaultKey = "*"
+
+// GatePolicy decides whether a run passes against its baseline run.
+type GatePolicy struct {
+	// MaxDegradationPct is how much worse each metric may get, in percent
+	// of the baseline, by metric key. Metrics without a limit, here or
+	// under GateDefaultKey, are not gated.
+	MaxDegradationPct map[string]float64 `json:"max_degradation_pct"`
+	// MaxPValue, when set, fails a degradation only if a Mann-Whitney U
+	// test of the two runs' steady-state samples gives a p-value at or
+	// below it, so noise over the limit does not fail the gate.
+	MaxPValue float64 `json:"max_p_value,omitempty"`
+	// Ignore lists metric keys the gate skips, even under GateDefaultKey.
+	Ignore []string `json:"ignore,omitempty"`
+}
+
+// Validate reports the first problem with the policy.
+func (p GatePolicy) Validate() error {
+	if len(p.MaxDegradationPct) == 0 {
+		return fmt.Errorf("max_degradation_pct must limit at least one metric")
+	}
+	for key, limit := range p.MaxDegradationPct {
+		if limit < 0 || math.IsNaN(limit) || math.IsInf(limit, 0) {
+			return fmt.Errorf("max_degradation_pct[%s]: must be 0 or more", key)
+		}
+	}
+	if p.MaxPValue < 0 || p.MaxPValue > 1 {
+		return fmt.Errorf("max_p_value must be between 0 and 1")
+	}
+	return nil
+}
+
+func (p GatePolicy) limit(key string) (float64, bool) {
+	if slices.Contains(p.Ignore, key) {
+		return 0, false
+	}
+	if limit, ok := p.MaxDegradationPct[key]; ok {
+		return limit, true
+	}
+	limit, ok := p.MaxDegradationPct[GateDefaultKey]
+	return limit, ok
+}
+
+// GateCheck is the gate's judgement of one metric.
+type GateCheck struct {
+	Key       string  `json:"key"`
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Baseline  float64 `json:"baseline"`
+	Candidate float64 `json:"candidate"`
+	// DegradationPct is how much worse the candidate is, in percent of the
+	// baseline; negative when it is better.
+	DegradationPct    float64 `json:"degradation_pct"`
+	MaxDegradationPct float64 `json:"max_degradation_pct"`
+	// PValue is set when the policy requires significance and both runs
+	// have samples to test.
+	PValue *float64 `json:"p_value,omitempty"`
+	Passed bool     `json:"passed"`
+	Reason string   `json:"reason,omitempty"`
+}
+
+// GateResult is a run's outcome against a gate policy.
+type GateResult struct {
+	BaselineRun  string      `json:"baseline_run"`
+	CandidateRun string      `json:"candidate_run"`
+	Passed       bool        `json:"passed"`
+	Checks       []GateCheck `json:"checks"`
+}
+
+// EvaluateGate checks each gated metric of candidate against baseline. The
+// gate passes when no check fails; a gated metric the baseline has but the
+// candidate lacks fails its check. A metric without baseline samples (e.g.
+// replication lag of a single-node run) has nothing to compare with and
+// passes with a reason.
+func EvaluateGate(policy GatePolicy, baseline, candidate *RunMetrics) *GateResult {
+	res := &GateResult{BaselineRun: baseline.RunID, CandidateRun: candidate.RunID, Passed: true, Checks: []GateCheck{}}
+	indexB := indexByKey(candidate)
+	for _, a := range baseline.Metrics {
+		limit, ok := policy.limit(a.Key)
+		if !ok {
+			continue
+		}
+		check := GateCheck{Key: a.Key, Name: a.Name, Unit: a.Unit, Baseline: a.Avg, MaxDegradationPct: limit, Passed: true}
+		b, ok := indexB[a.Key]
+		if a.Samples == 0 {
+			check.Reason = "no baseline data"
+			if ok {
+				check.Candidate = b.Avg
+			}
+		} else if !ok || b.Samples == 0 {
+			check.Passed = false
+			check.Reason = "no data in the candidate run"
+		} else {
+			check.Candidate = b.Avg
+			check.DegradationPct = pctDiff(a.Avg, b.Avg)
+			if metricDirection(a.Key, a, b) == DirectionHigher {
+				check.DegradationPct = -check.DegradationPct
+			}
+			if check.DegradationPct > limit {
+				check.Passed = false
+				check.Reason = fmt.Sprintf("%.1f%% worse, limit %.1f%%", check.DegradationPct, limit)
+				if policy.MaxPValue > 0 {
+					check.significance(policy.MaxPValue, steadySamples(baseline, a.Key), steadySamples(candidate, a.Key))
+				}
+			}
+		}
+		if !check.Passed {
+			res.Passed = false
+		}
+		res.Checks = append(res.Checks, check)
+	}
+	return res
+}
+
+// significance lets a failing check pass when the samples cannot be told
+// apart. Without enough samples to test, the failure stands.
+func (c *GateCheck) significance(maxPValue float64, a, b []float64) {
+	if len(a) < 2 || len(b) < 2 {
+		c.Reason += "; too few samples to test significance"
+		return
+	}
+	_, p := MannWhitneyU(a, b)
+	c.PValue = &p
+	if p > maxPValue {
+		c.Passed = true
+		c.Reason = fmt.Sprintf("%.1f%% worse but not significant (p=%.3f)", c.DegradationPct, p)
+		return
+	}
+	c.Reason += fmt.Sprintf(" (p=%.3f)", p)
+}
+
+// steadySamples returns the values of rm's series of key within its steady
+// state, or the whole series when none was found.
+func steadySamples(rm *RunMetrics, key string) []float64 {
+	var from, to float64 = math.Inf(-1), math.Inf(1)
+	if rm.Steady != nil {
+		from = rm.Steady.Start.Sub(rm.Range.Start).Seconds()
+		to = rm.Steady.End.Sub(rm.Range.Start).Seconds()
+	}
+	var xs []float64
+	for _, p := range rm.Series[key] {
+		if p.T >= from && p.T <= to {
+			xs = append(xs, p.V)
+		}
+	}
+	return xs
+}
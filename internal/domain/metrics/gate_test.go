@@ -0,0 +1,113 @@
+package metrics
+
+import "testing"
+
+func TestGatePolicyValidate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		policy GatePolicy
+		ok     bool
+	}{
+		"valid":       {GatePolicy{MaxDegradationPct: map[string]float64{"*": 5, "db_qps": 2}, MaxPValue: 0.05}, true},
+		"no limits":   {GatePolicy{}, false},
+		"negative":    {GatePolicy{MaxDegradationPct: map[string]float64{"db_qps": -1}}, false},
+		"p-value > 1": {GatePolicy{MaxDegradationPct: map[string]float64{"*": 5}, MaxPValue: 2}, false},
+	} {
+		if err := tc.policy.Validate(); (err == nil) != tc.ok {
+			t.Errorf("%s: Validate() = %v, want ok=%v", name, err, tc.ok)
+		}
+	}
+}
+
+func gateSeries(vals ...float64) []SeriesPoint {
+	points := make([]SeriesPoint, len(vals))
+	for i, v := range vals {
+		points[i] = SeriesPoint{T: float64(i * 10), V: v}
+	}
+	return points
+}
+
+func TestEvaluateGate(t *testing.T) {
+	base := &RunMetrics{
+		RunID: "base",
+		Metrics: []MetricSummary{
+			{Key: "stroppy_ops", Name: "Ops", Avg: 1000, Samples: 6, Direction: DirectionHigher},
+			{Key: "db_latency_p99", Name: "Latency", Avg: 0.010, Samples: 6, Direction: DirectionLower},
+			{Key: "cpu_usage", Name: "CPU", Avg: 50, Samples: 6, Direction: DirectionLower},
+			{Key: "memory_usage", Name: "Memory", Avg: 100, Samples: 6, Direction: DirectionLower},
+		},
+		Series: map[string][]SeriesPoint{
+			"stroppy_ops": gateSeries(1000, 1010, 990, 1005, 995, 1000),
+		},
+	}
+	cand := &RunMetrics{
+		RunID: "cand",
+		Metrics: []MetricSummary{
+			{Key: "stroppy_ops", Avg: 900, Samples: 6},       // 10% worse
+			{Key: "db_latency_p99", Avg: 0.0104, Samples: 6}, // 4% worse
+			{Key: "cpu_usage", Avg: 90, Samples: 6},          // ignored
+		},
+		Series: map[string][]SeriesPoint{
+			"stroppy_ops": gateSeries(900, 905, 895, 910, 890, 900),
+		},
+	}
+	policy := GatePolicy{
+		MaxDegradationPct: map[string]float64{"*": 5, "stroppy_ops": 8},
+		Ignore:            []string{"cpu_usage"},
+	}
+
+	res := EvaluateGate(policy, base, cand)
+	if res.Passed {
+		t.Fatal("gate passed, want failed")
+	}
+	want := map[string]bool{"stroppy_ops": false, "db_latency_p99": true, "memory_usage": false}
+	if len(res.Checks) != len(want) {
+		t.Fatalf("checks = %+v, want %d", res.Checks, len(want))
+	}
+	for _, c := range res.Checks {
+		if c.Passed != want[c.Key] {
+			t.Errorf("%s: passed = %v (%s), want %v", c.Key, c.Passed, c.Reason, want[c.Key])
+		}
+	}
+	if c := res.Checks[0]; c.DegradationPct < 9.9 || c.DegradationPct > 10.1 || c.MaxDegradationPct != 8 {
+		t.Errorf("stroppy_ops check = %+v, want 10%% degradation against an 8%% limit", c)
+	}
+
+	// A clear shift stays failed when significance is required.
+	policy.MaxPValue = 0.05
+	policy.Ignore = append(policy.Ignore, "memory_usage")
+	res = EvaluateGate(policy, base, cand)
+	if res.Passed || res.Checks[0].PValue == nil {
+		t.Errorf("significant shift: passed = %v, p = %v; want failed with a p-value", res.Passed, res.Checks[0].PValue)
+	}
+
+	// Overlapping samples are noise, so the gate passes.
+	cand.Series["stroppy_ops"] = gateSeries(1100, 700, 1000, 800, 990, 810)
+	res = EvaluateGate(policy, base, cand)
+	if !res.Passed {
+		t.Errorf("noisy shift: checks = %+v, want passed", res.Checks)
+	}
+}
+
+func TestEvaluateGate_NoBaselineData(t *testing.T) {
+	base := &RunMetrics{RunID: "base", Metrics: []MetricSummary{
+		{Key: "db_repl_lag", Name: "Replication lag"},
+		{Key: "stroppy_ops", Avg: 1000, Samples: 6, Direction: DirectionHigher},
+	}}
+	cand := &RunMetrics{RunID: "cand", Metrics: []MetricSummary{
+		{Key: "db_repl_lag", Name: "Replication lag"},
+		{Key: "stroppy_ops", Avg: 1000, Samples: 6},
+	}}
+	res := EvaluateGate(GatePolicy{MaxDegradationPct: map[string]float64{"*": 5}}, base, cand)
+	if !res.Passed {
+		t.Fatalf("checks = %+v, want passed", res.Checks)
+	}
+	if c := res.Checks[0]; c.Key != "db_repl_lag" || !c.Passed || c.Reason != "no baseline data" {
+		t.Errorf("db_repl_lag check = %+v, want passed with no baseline data", c)
+	}
+
+	// A candidate with samples where the baseline had none passes too.
+	cand.Metrics[0] = MetricSummary{Key: "db_repl_lag", Avg: 0.5, Samples: 6}
+	if res := EvaluateGate(GatePolicy{MaxDegradationPct: map[string]float64{"*": 5}}, base, cand); !res.Passed {
+		t.Errorf("checks = %+v, want passed", res.Checks)
+	}
+}
@@ -0,0 +1,133 @@
+package metrics
+
+import "time"
+
+// Matrix compares any number of runs against one reference run.
+type Matrix struct {
+	Runs      []string `json:"runs"`
+	Reference string   `json:"reference"`
+	// Start and End span every run's window; Windows holds each run's own,
+	// in Runs order.
+	Start   time.Time   `json:"start"`
+	End     time.Time   `json:"end"`
+	Windows []TimeRange `json:"windows"`
+	Metrics []MatrixRow `json:"metrics"`
+}
+
+// MatrixRow is one metric across all runs.
+type MatrixRow struct {
+	Key       string `json:"key"`
+	Name      string `json:"name"`
+	Unit      string `json:"unit"`
+	Direction string `json:"direction,omitempty"`
+	// Cells holds one entry per run, in Matrix.Runs order.
+	Cells []MatrixCell `json:"cells"`
+	// Best and Worst name the runs with the best and worst average, by the
+	// metric's direction; empty when fewer than two runs differ.
+	Best  string `json:"best,omitempty"`
+	Worst string `json:"worst,omitempty"`
+}
+
+// MatrixCell is one run's value of a metric and how it compares with the
+// reference run's.
+type MatrixCell struct {
+	Run string  `json:"run"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	// DiffPct is (Avg - reference Avg) / reference Avg * 100.
+	DiffPct float64 `json:"diff_pct"`
+	// Verdict against the reference: "better", "worse", "same", or
+	// "reference" for the reference run itself.
+	Verdict string `json:"verdict"`
+	// Missing is set when the run has no such metric.
+	Missing bool `json:"missing,omitempty"`
+}
+
+// CompareMany compares every run with runs[ref], metric by metric, in the
+// order metrics first appear across runs. Verdicts use the same direction
+// and threshold rules as Compare.
+func CompareMany(runs []*RunMetrics, ref int, threshold float64) *Matrix {
+	m := &Matrix{
+		Runs:    runIDs(runs),
+		Windows: make([]TimeRange, len(runs)),
+		Metrics: []MatrixRow{},
+	}
+	if ref < 0 || ref >= len(runs) {
+		return m
+	}
+	m.Reference = runs[ref].RunID
+	for i, rm := range runs {
+		m.Windows[i] = rm.Range
+		if !rm.Range.Start.IsZero() && (m.Start.IsZero() || rm.Range.Start.Before(m.Start)) {
+			m.Start = rm.Range.Start
+		}
+		if rm.Range.End.After(m.End) {
+			m.End = rm.Range.End
+		}
+	}
+
+	indexes := make([]map[string]MetricSummary, len(runs))
+	for i, rm := range runs {
+		indexes[i] = indexByKey(rm)
+	}
+
+	for _, key := range unionMetricKeys(runs) {
+		refSummary, refOK := indexes[ref][key]
+		refOK = refOK && refSummary.Samples > 0
+		row := MatrixRow{Key: key, Cells: make([]MatrixCell, len(runs))}
+		best, worst := -1, -1
+		for i, rm := range runs {
+			// No samples means the metric is missing: its query failed or
+			// matched no series in that run's window.
+			s, ok := indexes[i][key]
+			ok = ok && s.Samples > 0
+			cell := MatrixCell{Run: rm.RunID, Avg: s.Avg, Max: s.Max, Missing: !ok}
+			if ok && row.Name == "" {
+				row.Name, row.Unit = s.Name, s.Unit
+			}
+			switch {
+			case i == ref:
+				cell.Verdict = "reference"
+			case !ok || !refOK:
+				cell.Verdict = "same"
+			default:
+				cell.DiffPct = pctDiff(refSummary.Avg, s.Avg)
+				cell.Verdict = verdict(metricDirection(key, refSummary, s), cell.DiffPct, metricThreshold(threshold, refSummary, s))
+			}
+			row.Cells[i] = cell
+		}
+
+		row.Direction = builtinDirection(key)
+		for i := range runs {
+			if s, ok := indexes[i][key]; ok && s.Direction != "" {
+				row.Direction = s.Direction
+				break
+			}
+		}
+		for i, cell := range row.Cells {
+			if cell.Missing {
+				continue
+			}
+			if best < 0 || isBetter(row.Direction, cell.Avg, row.Cells[best].Avg) {
+				best = i
+			}
+			if worst < 0 || isBetter(row.Direction, row.Cells[worst].Avg, cell.Avg) {
+				worst = i
+			}
+		}
+		if best >= 0 && row.Cells[best].Avg != row.Cells[worst].Avg {
+			row.Best, row.Worst = runs[best].RunID, runs[worst].RunID
+		}
+		m.Metrics = append(m.Metrics, row)
+	}
+	return m
+}
+
+// isBetter reports whether a beats b for a metric going in direction;
+// without a direction lower wins, as in verdict.
+func isBetter(direction string, a, b float64) bool {
+	if direction == DirectionHigher {
+		return a > b
+	}
+	return a < b
+}
@@ -11,10 +11,20 @@ const RunLabel = "stroppy_run_id"
 // MetricDef defines a named PromQL query template.
 // Templates use %s for run_id label filter and %p for metric prefix (runID with dashes→underscores).
 type MetricDef struct {
-	Name  string // human-readable name
-	Key   string // stable key for comparison
-	Query string // PromQL template
-	Unit  string // e.g. "ops/s", "ms", "bytes", "%"
+	Name  string `json:"name"`  // human-readable name
+	Key   string `json:"key"`   // stable key for comparison
+	Query string `json:"query"` // PromQL template
+	Unit  string `json:"unit"`  // e.g. "ops/s", "ms", "bytes", "%"
+
+	// Direction is DirectionHigher or DirectionLower. Built-ins leave it
+	// empty; theirs is fixed in higherIsBetter and lowerIsBetter.
+	Direction string `json:"direction,omitempty"`
+	// Threshold, in percent, replaces the comparison's threshold for this
+	// metric when set.
+	Threshold float64 `json:"threshold,omitempty"`
+	// DBKinds limits the metric to runs of these database kinds; empty
+	// means every kind.
+	DBKinds []string `json:"db_kinds,omitempty"`
 }
 
 func runFilter(runID string) string {
@@ -28,18 +38,20 @@ func metricPrefix(runID string) string {
 
 // MetricsForDB returns metrics with DB-specific queries based on database kind.
 func MetricsForDB(dbKind string) []MetricDef {
-	var dbMetrics []MetricDef
+	return append(dbMetricsFor(dbKind), systemMetrics()...)
+}
+
+func dbMetricsFor(dbKind string) []MetricDef {
 	switch dbKind {
 	case "mysql":
-		dbMetrics = mysqlMetrics()
+		return mysqlMetrics()
 	case "picodata":
-		dbMetrics = picodataMetrics()
+		return picodataMetrics()
 	case "ydb":
-		dbMetrics = ydbMetrics()
+		return ydbMetrics()
 	default: // postgres
-		dbMetrics = postgresMetrics()
+		return postgresMetrics()
 	}
-	return append(dbMetrics, systemMetrics()...)
 }
 
 // DefaultMetrics returns postgres metrics (backward compat).
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// SampleStats describes one metric across a group of repeated runs, one
+// sample (the run's average) per run.
+type SampleStats struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	// CV is the coefficient of variation, StdDev / Mean; 0 when Mean is 0.
+	CV float64 `json:"cv"`
+	// CILow and CIHigh bound the 95% confidence interval of the mean
+	// (Student's t). A single sample has no spread, so both equal Mean.
+	CILow  float64 `json:"ci_low"`
+	CIHigh float64 `json:"ci_high"`
+}
+
+// Describe computes SampleStats over xs.
+func Describe(xs []float64) SampleStats {
+	s := SampleStats{N: len(xs)}
+	if s.N == 0 {
+		return s
+	}
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	s.Mean = sum / float64(s.N)
+	s.CILow, s.CIHigh = s.Mean, s.Mean
+	if s.N < 2 {
+		return s
+	}
+	var ss float64
+	for _, x := range xs {
+		ss += (x - s.Mean) * (x - s.Mean)
+	}
+	s.StdDev = math.Sqrt(ss / float64(s.N-1))
+	if s.Mean != 0 {
+		s.CV = s.StdDev / math.Abs(s.Mean)
+	}
+	half := tCritical95(s.N-1) * s.StdDev / math.Sqrt(float64(s.N))
+	s.CILow, s.CIHigh = s.Mean-half, s.Mean+half
+	return s
+}
+
+// t95 holds two-sided 95% critical values of Student's t for 1..30 degrees
+// of freedom.
+var t95 = [...]float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical95(df int) float64 {
+	if df < 1 {
+		return math.Inf(1)
+	}
+	if df <= len(t95) {
+		return t95[df-1]
+	}
+	// First Cornish-Fisher term; within 0.001 of the table beyond 30.
+	const z = 1.959964
+	return z + (z*z*z+z)/(4*float64(df))
+}
+
+// MannWhitneyU runs a two-sided Mann-Whitney U test on two independent
+// samples and returns U for a and the p-value. Small samples without ties
+// use the exact distribution; otherwise the normal approximation with tie
+// and continuity corrections. Empty samples give p = 1.
+func MannWhitneyU(a, b []float64) (u, p float64) {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 0, 1
+	}
+
+	type obs struct {
+		v     float64
+		fromA bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range a {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range b {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// Average ranks over ties; tieTerm accumulates sum(t^3 - t).
+	var rankSumA, tieTerm float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2 // ranks i+1..j
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankSumA += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			tieTerm += t*t*t - t
+		}
+		i = j
+	}
+	u = rankSumA - float64(n1*(n1+1))/2
+
+	if tieTerm == 0 && n1 <= exactMaxN && n2 <= exactMaxN {
+		return u, exactTwoSidedP(n1, n2, u)
+	}
+
+	n := float64(n1 + n2)
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieTerm/(n*(n-1)))
+	if variance <= 0 {
+		return u, 1
+	}
+	z := (math.Abs(u-mean) - 0.5) / math.Sqrt(variance)
+	if z <= 0 {
+		return u, 1
+	}
+	return u, math.Min(1, math.Erfc(z/math.Sqrt2))
+}
+
+// exactMaxN caps the group size for the exact U distribution.
+const exactMaxN = 20
+
+// exactTwoSidedP is 2 * P(U <= min(u, n1*n2-u)) under the null hypothesis,
+// counting rank arrangements with the usual recurrence
+// c(n1, n2, k) = c(n1-1, n2, k-n2) + c(n1, n2-1, k).
+func exactTwoSidedP(n1, n2 int, u float64) float64 {
+	maxU := n1 * n2
+	k := int(math.Floor(math.Min(u, float64(maxU)-u)))
+
+	// counts[j][x]: arrangements of i A-values and j B-values with U = x,
+	// built up row by row over i.
+	counts := make([][]float64, n2+1)
+	for j := range counts {
+		counts[j] = make([]float64, maxU+1)
+		counts[j][0] = 1 // i = 0
+	}
+	for i := 1; i <= n1; i++ {
+		next := make([][]float64, n2+1)
+		for j := 0; j <= n2; j++ {
+			next[j] = make([]float64, maxU+1)
+			for x := 0; x <= i*j; x++ {
+				var c float64
+				if x >= j {
+					c += counts[j][x-j] // largest value is from A: beats all j B-values
+				}
+				if j > 0 {
+					c += next[j-1][x]
+				}
+				next[j][x] = c
+			}
+		}
+		counts = next
+	}
+
+	var below, total float64
+	for x, c := range counts[n2] {
+		total += c
+		if x <= k {
+			below += c
+		}
+	}
+	return math.Min(1, 2*below/total)
+}
@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// throughputKeys are the series steady state is detected from, in order of
+// preference: the workload's own rate first, then the database's.
+var throughputKeys = []string{"stroppy_ops", "db_qps", "db_tps"}
+
+const (
+	// steadyTolerance is how far, as a fraction of the steady level, the
+	// smoothed throughput may stray and still count as steady.
+	steadyTolerance = 0.2
+	// minSteadyPoints is the shortest steady state worth reporting.
+	minSteadyPoints = 5
+)
+
+// detectSteadyState finds the steady state in the first throughput series
+// that has enough samples. It returns the series key and the window as
+// offsets, in the series' own time base.
+func detectSteadyState(series map[string][]SeriesPoint) (key string, from, to float64, ok bool) {
+	for _, k := range throughputKeys {
+		points := series[k]
+		if len(points) < minSteadyPoints {
+			continue
+		}
+		if from, to, ok := steadyWindow(points); ok {
+			return k, from, to, true
+		}
+	}
+	return "", 0, 0, false
+}
+
+// steadyWindow smooths the series with a moving average, takes the median
+// of the active part (at least a tenth of the peak) as the steady level, and
+// returns the span from the first to the last point within steadyTolerance
+// of it. Warm-up below the level and ramp-down at the end fall outside.
+func steadyWindow(points []SeriesPoint) (from, to float64, ok bool) {
+	n := len(points)
+	w := max(1, n/20)
+	smoothed := make([]float64, n)
+	var peak float64
+	for i := range points {
+		lo, hi := max(0, i-w), min(n-1, i+w)
+		var sum float64
+		for j := lo; j <= hi; j++ {
+			sum += points[j].V
+		}
+		smoothed[i] = sum / float64(hi-lo+1)
+		peak = math.Max(peak, smoothed[i])
+	}
+	if peak <= 0 {
+		return 0, 0, false
+	}
+
+	var active []float64
+	for _, v := range smoothed {
+		if v >= peak/10 {
+			active = append(active, v)
+		}
+	}
+	sort.Float64s(active)
+	level := active[len(active)/2]
+	lo, hi := level*(1-steadyTolerance), level*(1+steadyTolerance)
+
+	first, last := -1, -1
+	for i, v := range smoothed {
+		if v >= lo && v <= hi {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 || last-first+1 < minSteadyPoints {
+		return 0, 0, false
+	}
+	return points[first].T, points[last].T, true
+}
@@ -0,0 +1,70 @@
+package metrics
+
+import "time"
+
+// Trend is one metric across a series of runs, oldest first.
+type Trend struct {
+	Key       string       `json:"key"`
+	Name      string       `json:"name"`
+	Unit      string       `json:"unit"`
+	Direction string       `json:"direction,omitempty"`
+	Threshold float64      `json:"threshold,omitempty"`
+	Points    []TrendPoint `json:"points"`
+}
+
+// TrendPoint is one run's summary of a trended metric.
+type TrendPoint struct {
+	RunID string    `json:"run_id"`
+	Time  time.Time `json:"time"`
+	// ConfigHash changes whenever the run's configuration does, so a step in
+	// the trend can be told apart from a config change.
+	ConfigHash string   `json:"config_hash"`
+	Tags       []string `json:"tags"`
+	Avg        float64  `json:"avg"`
+	Min        float64  `json:"min"`
+	Max        float64  `json:"max"`
+	P50        float64  `json:"p50"`
+	P95        float64  `json:"p95"`
+	P99        float64  `json:"p99"`
+	CV         float64  `json:"cv"`
+	Stable     bool     `json:"stable"`
+	// ChangePct is the change of Avg from the previous point, in percent;
+	// 0 for the first point.
+	ChangePct float64 `json:"change_pct"`
+}
+
+// NewTrend starts an empty trend of the metric key.
+func NewTrend(key string) *Trend {
+	return &Trend{Key: key, Direction: builtinDirection(key), Points: []TrendPoint{}}
+}
+
+// Add appends rm's summary of the trend's metric, taken at t; runs without
+// the metric, or without samples of it, are skipped. Runs must be added
+// oldest first.
+func (t *Trend) Add(rm *RunMetrics, at time.Time, configHash string, tags []string) {
+	s, ok := indexByKey(rm)[t.Key]
+	if !ok || s.Samples == 0 {
+		return
+	}
+	if t.Name == "" {
+		t.Name, t.Unit = s.Name, s.Unit
+	}
+	if s.Direction != "" {
+		t.Direction = s.Direction
+	}
+	if s.Threshold > 0 {
+		t.Threshold = s.Threshold
+	}
+	if tags == nil {
+		tags = []string{}
+	}
+	p := TrendPoint{
+		RunID: rm.RunID, Time: at, ConfigHash: configHash, Tags: tags,
+		Avg: s.Avg, Min: s.Min, Max: s.Max, P50: s.P50, P95: s.P95, P99: s.P99,
+		CV: s.CV, Stable: s.Stable,
+	}
+	if n := len(t.Points); n > 0 {
+		p.ChangePct = pctDiff(t.Points[n-1].Avg, s.Avg)
+	}
+	t.Points = append(t.Points, p)
+}
@@ -0,0 +1,208 @@
+package pkgmeta
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// maxControlSize bounds the control archive read into memory.
+const maxControlSize = 16 << 20
+
+// ParseDeb reads the control file of a .deb: an ar archive holding
+// debian-binary, control.tar[.gz|.xz|.zst] and data.tar.*.
+func ParseDeb(r io.Reader) (*Metadata, error) {
+	magic := make([]byte, len(debMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, debMagic) {
+		return nil, fmt.Errorf("deb: not an ar archive")
+	}
+
+	sawBinary := false
+	for {
+		var hdr [60]byte
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, fmt.Errorf("deb: no control archive")
+			}
+			return nil, fmt.Errorf("deb: %w", err)
+		}
+		if string(hdr[58:60]) != "`\n" {
+			return nil, fmt.Errorf("deb: corrupt ar member header")
+		}
+		name := strings.TrimSuffix(strings.TrimSpace(string(hdr[0:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("deb: bad size for member %q", name)
+		}
+		// Members are padded to an even length.
+		padded := size + size%2
+
+		switch {
+		case name == "debian-binary":
+			ver := make([]byte, size)
+			if _, err := io.ReadFull(r, ver); err != nil {
+				return nil, fmt.Errorf("deb: read debian-binary: %w", err)
+			}
+			if !strings.HasPrefix(string(ver), "2.") {
+				return nil, fmt.Errorf("deb: unsupported format version %q", strings.TrimSpace(string(ver)))
+			}
+			sawBinary = true
+			if _, err := io.CopyN(io.Discard, r, padded-size); err != nil {
+				return nil, fmt.Errorf("deb: %w", err)
+			}
+		case strings.HasPrefix(name, "control.tar"):
+			if !sawBinary {
+				return nil, fmt.Errorf("deb: control archive before debian-binary")
+			}
+			if size > maxControlSize {
+				return nil, fmt.Errorf("deb: control archive too large (%d bytes)", size)
+			}
+			data := make([]byte, size)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, fmt.Errorf("deb: read %s: %w", name, err)
+			}
+			control, err := readControl(name, data)
+			if err != nil {
+				return nil, fmt.Errorf("deb: %w", err)
+			}
+			return parseControl(control)
+		default:
+			if _, err := io.CopyN(io.Discard, r, padded); err != nil {
+				return nil, fmt.Errorf("deb: skip %s: %w", name, err)
+			}
+		}
+	}
+}
+
+// readControl extracts ./control from the control archive.
+func readControl(member string, data []byte) ([]byte, error) {
+	tarData, err := decompress(path.Ext(member), data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", member, err)
+	}
+	tr := tar.NewReader(bytes.NewReader(tarData))
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s: no control file", member)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", member, err)
+		}
+		if path.Clean(h.Name) == "control" {
+			return io.ReadAll(io.LimitReader(tr, maxControlSize))
+		}
+	}
+}
+
+// decompress unpacks the control archive. gzip and plain tar are handled in
+// process; xz and zstd (the dpkg-deb defaults on Debian and Ubuntu) go
+// through the xz/zstd tools.
+func decompress(ext string, data []byte) ([]byte, error) {
+	switch ext {
+	case ".tar":
+		return data, nil
+	case ".gz":
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(io.LimitReader(zr, maxControlSize))
+	case ".xz":
+		return decompressTool("xz", data)
+	case ".zst":
+		return decompressTool("zstd", data)
+	}
+	return nil, fmt.Errorf("unsupported compression %q", ext)
+}
+
+// decompressTool runs tool -dc over data, reading at most maxControlSize of
+// its output like the gzip path; a tool still writing past that is stopped.
+func decompressTool(tool string, data []byte) ([]byte, error) {
+	if _, err := exec.LookPath(tool); err != nil {
+		return nil, fmt.Errorf("%s is not installed on the server", tool)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(tool, "-dc")
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%s -dc: %w", tool, err)
+	}
+	out, readErr := io.ReadAll(io.LimitReader(stdout, maxControlSize))
+	capped := len(out) == maxControlSize
+	if capped {
+		_ = cmd.Process.Kill()
+	}
+	if err := cmd.Wait(); err != nil && !capped {
+		return nil, fmt.Errorf("%s -dc: %w: %s", tool, err, strings.TrimSpace(stderr.String()))
+	}
+	if readErr != nil {
+		return nil, fmt.Errorf("%s -dc: %w", tool, readErr)
+	}
+	return out, nil
+}
+
+// parseControl reads the deb822 control paragraph.
+func parseControl(control []byte) (*Metadata, error) {
+	fields := map[string]string{}
+	var last string
+	sc := bufio.NewScanner(bytes.NewReader(control))
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			break
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			if last != "" {
+				fields[last] += "\n" + strings.TrimSpace(line)
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("deb: malformed control line %q", line)
+		}
+		last = strings.ToLower(strings.TrimSpace(key))
+		fields[last] = strings.TrimSpace(value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("deb: %w", err)
+	}
+
+	m := &Metadata{
+		Format:       FormatDeb,
+		Name:         fields["package"],
+		Version:      fields["version"],
+		Architecture: fields["architecture"],
+		Depends:      append(splitList(fields["pre-depends"]), splitList(fields["depends"])...),
+		Provides:     splitList(fields["provides"]),
+		Description:  strings.SplitN(fields["description"], "\n", 2)[0],
+	}
+	if m.Name == "" || m.Version == "" || m.Architecture == "" {
+		return nil, fmt.Errorf("deb: control file lacks Package, Version or Architecture")
+	}
+	return m, nil
+}
+
+// splitList splits a comma-separated relationship field.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.Join(strings.Fields(item), " "); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
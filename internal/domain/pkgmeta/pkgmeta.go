@@ -0,0 +1,127 @@
+// Package pkgmeta reads control metadata out of .deb and .rpm files so that
+// uploads can be checked before a run tries to install them.
+package pkgmeta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// Format is the package file format.
+type Format string
+
+const (
+	FormatDeb Format = "deb"
+	FormatRPM Format = "rpm"
+)
+
+// Metadata is what a package declares about itself.
+type Metadata struct {
+	Format       Format   `json:"format"`
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Architecture string   `json:"architecture"`
+	Depends      []string `json:"depends,omitempty"`
+	Provides     []string `json:"provides,omitempty"`
+	Description  string   `json:"description,omitempty"`
+}
+
+// ErrUnknownFormat is returned for files that are neither .deb nor .rpm.
+var ErrUnknownFormat = errors.New("not a .deb or .rpm package")
+
+var (
+	debMagic = []byte("!<arch>\n")
+	rpmMagic = []byte{0xed, 0xab, 0xee, 0xdb}
+)
+
+// Parse detects the package format from its magic bytes and reads its metadata.
+func Parse(r io.Reader) (*Metadata, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(debMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, debMagic):
+		return ParseDeb(br)
+	case bytes.HasPrefix(head, rpmMagic):
+		return ParseRPM(br)
+	}
+	return nil, ErrUnknownFormat
+}
+
+// Installable reports whether agents can install a package of this format.
+// Agent images and cloud VMs run Debian/Ubuntu and install packages with apt.
+func (m *Metadata) Installable() error {
+	if m.Format != FormatDeb {
+		return fmt.Errorf("%s packages cannot be installed on agents (Debian/Ubuntu, apt); upload a .deb", m.Format)
+	}
+	return nil
+}
+
+// archAliases maps rpm architecture names to their Debian equivalents.
+var archAliases = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+	"noarch":  "all",
+}
+
+// CheckArch rejects packages built for a different architecture than want
+// (a Debian architecture name such as "amd64").
+func (m *Metadata) CheckArch(want string) error {
+	arch := m.Architecture
+	if alias, ok := archAliases[arch]; ok {
+		arch = alias
+	}
+	if arch == "all" || arch == want {
+		return nil
+	}
+	return fmt.Errorf("package %s is built for %s, agents run %s", m.Name, m.Architecture, want)
+}
+
+var (
+	// postgresql-17, postgresql17-server, stroppy-postgres-17-src
+	postgresName = regexp.MustCompile(`^(?:postgresql|stroppy-postgres)-?(\d+)(?:-|$)`)
+	// mysql-server, mysql-community-server, percona-server-server, mysql-server-core-8.0
+	mysqlName    = regexp.MustCompile(`^(?:mysql|percona-server|stroppy-mysql)(?:-|$)`)
+	mysqlVersion = regexp.MustCompile(`-(\d+\.\d+)$`)
+	picoName     = regexp.MustCompile(`^(?:picodata|stroppy-picodata)(?:-|$)`)
+	leadVersion  = regexp.MustCompile(`^(?:\d+:)?(\d+)(?:\.(\d+))?`)
+)
+
+// Database guesses the database kind and major version a package installs
+// from its name and provides. Returns an empty kind when unsure.
+func (m *Metadata) Database() (types.DatabaseKind, string) {
+	names := append([]string{m.Name}, m.Provides...)
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(strings.SplitN(name, " ", 2)[0]))
+		switch {
+		case postgresName.MatchString(name):
+			return types.DatabasePostgres, postgresName.FindStringSubmatch(name)[1]
+		case mysqlName.MatchString(name):
+			if v := mysqlVersion.FindStringSubmatch(name); v != nil {
+				return types.DatabaseMySQL, v[1]
+			}
+			return types.DatabaseMySQL, m.majorMinor()
+		case picoName.MatchString(name):
+			return types.DatabasePicodata, m.majorMinor()
+		}
+	}
+	return "", ""
+}
+
+// majorMinor trims the package version to "major.minor", e.g. "8.0.36-1" → "8.0".
+func (m *Metadata) majorMinor() string {
+	v := leadVersion.FindStringSubmatch(m.Version)
+	if v == nil || v[2] == "" {
+		return ""
+	}
+	return v[1] + "." + v[2]
+}
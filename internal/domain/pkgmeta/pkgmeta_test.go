@@ -0,0 +1,233 @@
+package pkgmeta
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+const testControl = `Package: postgresql-17
+Version: 17.2-1.pgdg24.04+1
+Architecture: amd64
+Maintainer: Debian PostgreSQL Maintainers <team+postgresql@tracker.debian.org>
+Pre-Depends: postgresql-common (>= 252)
+Depends: libc6 (>= 2.38),
+ libssl3t64 (>= 3.0.0),  tzdata
+Provides: postgresql-contrib-17
+Description: The World's Most Advanced Open Source Relational Database
+ PostgreSQL is a fully featured object-relational database.
+`
+
+// buildDeb assembles a minimal .deb: debian-binary, control.tar.gz, data.tar.
+func buildDeb(t *testing.T, control string) []byte {
+	t.Helper()
+	var ctl bytes.Buffer
+	zw := gzip.NewWriter(&ctl)
+	tw := tar.NewWriter(zw)
+	tw.WriteHeader(&tar.Header{Name: "./control", Mode: 0o644, Size: int64(len(control))})
+	tw.Write([]byte(control))
+	tw.Close()
+	zw.Close()
+
+	var b bytes.Buffer
+	b.WriteString("!<arch>\n")
+	member := func(name string, data []byte) {
+		fmt.Fprintf(&b, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, 0, 0, 0, "100644", len(data))
+		b.Write(data)
+		if len(data)%2 == 1 {
+			b.WriteByte('\n')
+		}
+	}
+	member("debian-binary", []byte("2.0\n"))
+	member("control.tar.gz", ctl.Bytes())
+	member("data.tar", make([]byte, 1024))
+	return b.Bytes()
+}
+
+func TestParse_Deb(t *testing.T) {
+	m, err := Parse(bytes.NewReader(buildDeb(t, testControl)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Format != FormatDeb || m.Name != "postgresql-17" || m.Version != "17.2-1.pgdg24.04+1" || m.Architecture != "amd64" {
+		t.Errorf("unexpected metadata: %+v", m)
+	}
+	wantDeps := []string{"postgresql-common (>= 252)", "libc6 (>= 2.38)", "libssl3t64 (>= 3.0.0)", "tzdata"}
+	if strings.Join(m.Depends, "|") != strings.Join(wantDeps, "|") {
+		t.Errorf("depends: got %q", m.Depends)
+	}
+	if m.Description != "The World's Most Advanced Open Source Relational Database" {
+		t.Errorf("description: got %q", m.Description)
+	}
+	if kind, ver := m.Database(); kind != types.DatabasePostgres || ver != "17" {
+		t.Errorf("database: got %s %s", kind, ver)
+	}
+	if err := m.CheckArch("amd64"); err != nil {
+		t.Error(err)
+	}
+	if err := m.CheckArch("arm64"); err == nil {
+		t.Error("expected arch mismatch")
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	if _, err := Parse(strings.NewReader("#!/bin/sh\necho not a package\n")); err != ErrUnknownFormat {
+		t.Errorf("script: got %v", err)
+	}
+	deb := buildDeb(t, testControl)
+	if _, err := Parse(bytes.NewReader(deb[:80])); err == nil {
+		t.Error("truncated deb should fail")
+	}
+	if _, err := Parse(bytes.NewReader(buildDeb(t, "Package: foo\n"))); err == nil {
+		t.Error("control without version/arch should fail")
+	}
+}
+
+// buildRPM assembles a minimal .rpm: lead, empty signature, main header.
+func buildRPM(t *testing.T, entries map[uint32][]string) []byte {
+	t.Helper()
+	header := func(entries map[uint32][]string) []byte {
+		var index, store bytes.Buffer
+		for _, tag := range []uint32{rpmTagName, rpmTagVersion, rpmTagRelease, rpmTagSummary, rpmTagArch, rpmTagProvideName, rpmTagRequireName} {
+			vals, ok := entries[tag]
+			if !ok {
+				continue
+			}
+			typ := uint32(rpmTypeStringArray)
+			if len(vals) == 1 && tag != rpmTagProvideName && tag != rpmTagRequireName {
+				typ = rpmTypeString
+			}
+			binary.Write(&index, binary.BigEndian, []uint32{tag, typ, uint32(store.Len()), uint32(len(vals))})
+			for _, v := range vals {
+				store.WriteString(v)
+				store.WriteByte(0)
+			}
+		}
+		var h bytes.Buffer
+		h.Write(rpmHeaderMagic)
+		h.Write([]byte{0, 0, 0, 0})
+		binary.Write(&h, binary.BigEndian, []uint32{uint32(index.Len() / 16), uint32(store.Len())})
+		h.Write(index.Bytes())
+		h.Write(store.Bytes())
+		return h.Bytes()
+	}
+
+	var b bytes.Buffer
+	lead := make([]byte, rpmLeadSize)
+	copy(lead, rpmMagic)
+	b.Write(lead)
+	sig := header(map[uint32][]string{rpmTagName: {"sig"}}) // 4-byte store, needs padding
+	b.Write(sig)
+	b.Write(make([]byte, (8-(len(sig)-16)%8)%8))
+	b.Write(header(entries))
+	return b.Bytes()
+}
+
+func TestParse_RPM(t *testing.T) {
+	data := buildRPM(t, map[uint32][]string{
+		rpmTagName:        {"mysql-community-server"},
+		rpmTagVersion:     {"8.4.3"},
+		rpmTagRelease:     {"1.el9"},
+		rpmTagSummary:     {"A very fast and reliable SQL database server"},
+		rpmTagArch:        {"x86_64"},
+		rpmTagProvideName: {"mysql-server", "mysqld"},
+		rpmTagRequireName: {"/bin/sh", "rpmlib(CompressedFileNames)", "libc.so.6()(64bit)"},
+	})
+	m, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Format != FormatRPM || m.Name != "mysql-community-server" || m.Version != "8.4.3-1.el9" {
+		t.Errorf("unexpected metadata: %+v", m)
+	}
+	if len(m.Depends) != 1 || m.Depends[0] != "libc.so.6()(64bit)" {
+		t.Errorf("depends: got %q", m.Depends)
+	}
+	if kind, ver := m.Database(); kind != types.DatabaseMySQL || ver != "8.4" {
+		t.Errorf("database: got %s %s", kind, ver)
+	}
+	if err := m.CheckArch("amd64"); err != nil {
+		t.Errorf("x86_64 should match amd64: %v", err)
+	}
+	if err := m.Installable(); err == nil {
+		t.Error("rpm should not be installable on apt-based agents")
+	}
+}
+
+func TestDatabase(t *testing.T) {
+	cases := []struct {
+		name, version string
+		kind          types.DatabaseKind
+		want          string
+	}{
+		{"postgresql17-server", "17.2-1PGDG", types.DatabasePostgres, "17"},
+		{"stroppy-postgres-16-src", "0.0.0+gabc", types.DatabasePostgres, "16"},
+		{"mysql-server-core-8.0", "8.0.40-0ubuntu0.24.04.1", types.DatabaseMySQL, "8.0"},
+		{"percona-server-server", "8.0.39-30-1.noble", types.DatabaseMySQL, "8.0"},
+		{"picodata", "25.3.1-1", types.DatabasePicodata, "25.3"},
+		{"postgresql-common", "262", "", ""},
+		{"nginx", "1.24.0", "", ""},
+	}
+	for _, c := range cases {
+		m := &Metadata{Name: c.name, Version: c.version}
+		if kind, ver := m.Database(); kind != c.kind || ver != c.want {
+			t.Errorf("%s %s: got %q %q, want %q %q", c.name, c.version, kind, ver, c.kind, c.want)
+		}
+	}
+}
+
+func TestTarballCompression(t *testing.T) {
+	var plain bytes.Buffer
+	tw := tar.NewWriter(&plain)
+	tw.WriteHeader(&tar.Header{Name: "bin/postgres", Mode: 0o755, Size: 2})
+	tw.Write([]byte("#!"))
+	tw.Close()
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(plain.Bytes())
+	zw.Close()
+
+	for name, tc := range map[string]struct {
+		data []byte
+		want string
+	}{
+		"tar":    {plain.Bytes(), ""},
+		"tar.gz": {gz.Bytes(), "gzip"},
+		"tar.xz": {[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00, 0x00}, "xz"},
+	} {
+		got, err := TarballCompression(bytes.NewReader(tc.data))
+		if err != nil || got != tc.want {
+			t.Errorf("%s: got %q, %v; want %q", name, got, err, tc.want)
+		}
+	}
+	if _, err := TarballCompression(bytes.NewReader(buildDeb(t, testControl))); err != ErrNotTarball {
+		t.Errorf("a .deb is not a tarball, got %v", err)
+	}
+}
+
+func TestDecompressToolCapsOutput(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("zstd is not installed")
+	}
+	// A small archive that expands well past the cap.
+	cmd := exec.Command("zstd", "-c")
+	cmd.Stdin = bytes.NewReader(make([]byte, 4*maxControlSize))
+	bomb, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := decompress(".zst", bomb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != maxControlSize {
+		t.Errorf("decompressed %d bytes, want the %d cap", len(out), maxControlSize)
+	}
+}
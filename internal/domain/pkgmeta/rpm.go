@@ -0,0 +1,171 @@
+package pkgmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	rpmLeadSize   = 96
+	rpmMaxEntries = 1 << 16
+	rpmMaxStore   = 64 << 20
+)
+
+var rpmHeaderMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+
+// Header tags read from the main rpm header.
+const (
+	rpmTagName        = 1000
+	rpmTagVersion     = 1001
+	rpmTagRelease     = 1002
+	rpmTagSummary     = 1004
+	rpmTagArch        = 1022
+	rpmTagProvideName = 1047
+	rpmTagRequireName = 1049
+)
+
+// Header entry types that carry strings.
+const (
+	rpmTypeString      = 6
+	rpmTypeStringArray = 8
+	rpmTypeI18NString  = 9
+)
+
+// ParseRPM reads the main header of an .rpm: a 96-byte lead, the signature
+// header (padded to 8 bytes) and the header holding name, version and deps.
+func ParseRPM(r io.Reader) (*Metadata, error) {
+	lead := make([]byte, rpmLeadSize)
+	if _, err := io.ReadFull(r, lead); err != nil || !bytes.Equal(lead[:4], rpmMagic) {
+		return nil, fmt.Errorf("rpm: bad lead")
+	}
+
+	sigSize, err := skipRPMHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("rpm: signature: %w", err)
+	}
+	if pad := (8 - sigSize%8) % 8; pad > 0 {
+		if _, err := io.CopyN(io.Discard, r, pad); err != nil {
+			return nil, fmt.Errorf("rpm: signature padding: %w", err)
+		}
+	}
+
+	tags, err := readRPMHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("rpm: header: %w", err)
+	}
+	first := func(tag uint32) string {
+		if v := tags[tag]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	m := &Metadata{
+		Format:       FormatRPM,
+		Name:         first(rpmTagName),
+		Version:      first(rpmTagVersion),
+		Architecture: first(rpmTagArch),
+		Description:  first(rpmTagSummary),
+	}
+	if rel := first(rpmTagRelease); rel != "" && m.Version != "" {
+		m.Version += "-" + rel
+	}
+	for _, dep := range tags[rpmTagRequireName] {
+		// rpmlib(...) and file requirements are satisfied by rpm itself.
+		if !strings.HasPrefix(dep, "rpmlib(") && !strings.HasPrefix(dep, "/") {
+			m.Depends = append(m.Depends, dep)
+		}
+	}
+	m.Provides = tags[rpmTagProvideName]
+	if m.Name == "" || m.Version == "" || m.Architecture == "" {
+		return nil, fmt.Errorf("rpm: header lacks name, version or arch")
+	}
+	return m, nil
+}
+
+// rpmHeaderIntro reads a header's magic and sizes: entry count and store size.
+func rpmHeaderIntro(r io.Reader) (nindex, hsize uint32, err error) {
+	var intro [16]byte
+	if _, err := io.ReadFull(r, intro[:]); err != nil {
+		return 0, 0, err
+	}
+	if !bytes.Equal(intro[:4], rpmHeaderMagic) {
+		return 0, 0, fmt.Errorf("bad magic")
+	}
+	nindex = binary.BigEndian.Uint32(intro[8:12])
+	hsize = binary.BigEndian.Uint32(intro[12:16])
+	if nindex > rpmMaxEntries || hsize > rpmMaxStore {
+		return 0, 0, fmt.Errorf("header too large")
+	}
+	return nindex, hsize, nil
+}
+
+// skipRPMHeader discards a header and returns its index+store size.
+func skipRPMHeader(r io.Reader) (int64, error) {
+	nindex, hsize, err := rpmHeaderIntro(r)
+	if err != nil {
+		return 0, err
+	}
+	size := int64(nindex)*16 + int64(hsize)
+	if _, err := io.CopyN(io.Discard, r, size); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// readRPMHeader returns the string-typed entries of a header by tag.
+func readRPMHeader(r io.Reader) (map[uint32][]string, error) {
+	nindex, hsize, err := rpmHeaderIntro(r)
+	if err != nil {
+		return nil, err
+	}
+	index := make([]byte, int(nindex)*16)
+	if _, err := io.ReadFull(r, index); err != nil {
+		return nil, err
+	}
+	store := make([]byte, hsize)
+	if _, err := io.ReadFull(r, store); err != nil {
+		return nil, err
+	}
+
+	tags := map[uint32][]string{}
+	for i := 0; i < int(nindex); i++ {
+		e := index[i*16 : i*16+16]
+		tag := binary.BigEndian.Uint32(e[0:4])
+		typ := binary.BigEndian.Uint32(e[4:8])
+		off := binary.BigEndian.Uint32(e[8:12])
+		count := binary.BigEndian.Uint32(e[12:16])
+		if typ != rpmTypeString && typ != rpmTypeStringArray && typ != rpmTypeI18NString {
+			continue
+		}
+		if off >= hsize {
+			return nil, fmt.Errorf("tag %d: offset out of range", tag)
+		}
+		if typ == rpmTypeString {
+			count = 1
+		}
+		vals, err := rpmStrings(store[off:], count)
+		if err != nil {
+			return nil, fmt.Errorf("tag %d: %w", tag, err)
+		}
+		tags[tag] = vals
+	}
+	return tags, nil
+}
+
+// rpmStrings reads count NUL-terminated strings from the start of b.
+func rpmStrings(b []byte, count uint32) ([]string, error) {
+	var out []string
+	for ; count > 0; count-- {
+		end := bytes.IndexByte(b, 0)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		out = append(out, string(b[:end]))
+		b = b[end+1:]
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package pkgmeta
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ErrNotTarball is returned for uploads to tarball packages that are not a
+// (possibly compressed) tar archive.
+var ErrNotTarball = errors.New("not a .tar, .tar.gz, .tar.xz, .tar.zst or .tar.bz2 archive")
+
+var tarballMagic = []struct {
+	compression string
+	magic       []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b}},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"bzip2", []byte("BZh")},
+}
+
+// ustarOffset is where a tar header carries its "ustar" magic.
+const ustarOffset = 257
+
+// TarballCompression detects a tar archive from its magic bytes and returns
+// its compression ("gzip", "xz", "zstd", "bzip2", or "" for a plain tar).
+// Agents unpack it with tar, which detects the compression the same way.
+func TarballCompression(r io.Reader) (string, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(ustarOffset + 5)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	for _, m := range tarballMagic {
+		if bytes.HasPrefix(head, m.magic) {
+			return m.compression, nil
+		}
+	}
+	if len(head) >= ustarOffset+5 && string(head[ustarOffset:ustarOffset+5]) == "ustar" {
+		return "", nil
+	}
+	return "", ErrNotTarball
+}
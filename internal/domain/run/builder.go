@@ -29,6 +29,8 @@ type Deps struct {
 	JWTIssuer *auth.JWTIssuer
 	// TenantID is the tenant running this job.
 	TenantID string
+	// PackageBuilds is the cache of source package artifacts. Nil disables reuse.
+	PackageBuilds PackageBuildCache
 }
 
 // Build constructs a dag.Graph and dag.Registry from a RunConfig.
@@ -82,6 +84,14 @@ func (b *builder) build() error {
 	b.addMustComplete(b.ph(types.PhaseMachines), []string{b.ph(types.PhaseNetwork)},
 		&machinesTask{runCfg: b.cfg, state: b.deps.State, deployer: b.deps.Deployer, serverAddr: b.deps.ServerAddr, settings: b.deps.Settings, jwtIssuer: b.deps.JWTIssuer, tenantID: b.deps.TenantID})
 
+	// --- OS tuning (before any database binaries land on the machines) ---
+	profiles, err := b.tuningProfiles()
+	if err != nil {
+		return fmt.Errorf("run: %w", err)
+	}
+	b.add(b.ph(types.PhaseTuneOS), afterMachines,
+		&tuningTask{client: b.deps.Client, state: b.deps.State, provider: b.cfg.Provider, profiles: profiles})
+
 	// --- etcd (if Postgres HA with etcd) ---
 	configDBDeps := []string{b.ph(types.PhaseInstallDB)}
 	if b.needsEtcd() {
@@ -94,7 +104,22 @@ func (b *builder) build() error {
 	if err != nil {
 		return fmt.Errorf("run: %w", err)
 	}
-	b.add(b.ph(types.PhaseInstallDB), afterMachines, installDB)
+	installDBDeps := []string{b.ph(types.PhaseTuneOS)}
+	if pkg := b.cfg.ResolvedPackage; pkg.NeedsBuild() {
+		b.add(b.ph(types.PhaseBuildPackage), afterMachines,
+			&buildPackageTask{
+				client:     b.deps.Client,
+				state:      b.deps.State,
+				cache:      b.deps.PackageBuilds,
+				jwtIssuer:  b.deps.JWTIssuer,
+				serverAddr: b.deps.ServerAddr,
+				tenantID:   b.deps.TenantID,
+				runID:      b.cfg.ID,
+				pkg:        pkg,
+			})
+		installDBDeps = append(installDBDeps, b.ph(types.PhaseBuildPackage))
+	}
+	b.add(b.ph(types.PhaseInstallDB), installDBDeps, installDB)
 
 	// --- Patroni (if Postgres HA with Patroni) ---
 	if b.needsPatroni() {
@@ -144,6 +169,15 @@ func (b *builder) build() error {
 		b.addYDBPhases()
 	}
 
+	// --- capture live DB settings (once every DB process is up) ---
+	captureDeps := []string{b.ph(types.PhaseConfigureDB)}
+	if b.needsYDBInit() {
+		captureDeps = append(captureDeps, b.ph(types.PhaseStartYDBDatabase))
+	}
+	b.add(b.ph(types.PhaseCaptureSettings), captureDeps,
+		&captureSettingsTask{client: b.deps.Client, state: b.deps.State, db: b.cfg.Database})
+	b.runStroppyDeps = append(b.runStroppyDeps, b.ph(types.PhaseCaptureSettings))
+
 	// --- stroppy ---
 	b.add(b.ph(types.PhaseInstallStroppy), afterMachines,
 		&stroppyInstallTask{client: b.deps.Client, state: b.deps.State, stroppy: b.cfg.Stroppy})
@@ -261,6 +295,20 @@ func (b *builder) addProxy(afterMachines []string) {
 	b.runStroppyDeps = append(b.runStroppyDeps, b.ph(types.PhaseConfigureProxy))
 }
 
+// tuningProfiles resolves the OS tuning profile requested for each machine role.
+func (b *builder) tuningProfiles() (map[types.MachineRole]types.TuningProfile, error) {
+	profiles := make(map[types.MachineRole]types.TuningProfile)
+	for _, role := range []types.MachineRole{types.RoleDatabase, types.RoleMonitor, types.RoleProxy, types.RoleStroppy} {
+		name := types.TuningProfileForRole(&b.cfg, role)
+		p, ok := types.ResolveTuningProfile(&b.cfg, name)
+		if !ok {
+			return nil, fmt.Errorf("unknown tuning profile %q for role %s", name, role)
+		}
+		profiles[role] = p
+	}
+	return profiles, nil
+}
+
 // --- DB task factory ---
 
 func (b *builder) dbTasks() (install dag.Task, config dag.Task, err error) {
@@ -1,8 +1,10 @@
 package run
 
 import (
+	"slices"
 	"testing"
 
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 )
 
@@ -169,3 +171,110 @@ func TestBuild_PicodataSingle(t *testing.T) {
 		t.Errorf("expected at least 9 nodes, got %d", len(graph.Nodes))
 	}
 }
+
+func TestBuild_TuneOSPrecedesInstallDB(t *testing.T) {
+	cfg := postgresSingleCfg()
+	graph, _, err := Build(cfg, baseDeps())
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	for _, n := range graph.Nodes {
+		if n.ID != "install_db" {
+			continue
+		}
+		if len(n.Deps) != 1 || n.Deps[0] != "tune_os" {
+			t.Errorf("install_db deps = %v, want [tune_os]", n.Deps)
+		}
+		return
+	}
+	t.Fatal("install_db node not found")
+}
+
+func TestBuild_CaptureSettingsGatesRunStroppy(t *testing.T) {
+	cfg := postgresSingleCfg()
+	graph, _, err := Build(cfg, baseDeps())
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	deps := make(map[string][]string)
+	for _, n := range graph.Nodes {
+		deps[n.ID] = n.Deps
+	}
+	if got := deps["capture_settings"]; !slices.Equal(got, []string{"configure_db"}) {
+		t.Errorf("capture_settings deps = %v, want [configure_db]", got)
+	}
+	if !slices.Contains(deps["run_stroppy"], "capture_settings") {
+		t.Errorf("run_stroppy deps = %v, want capture_settings among them", deps["run_stroppy"])
+	}
+}
+
+func TestBuild_UnknownTuningProfileErrors(t *testing.T) {
+	cfg := postgresSingleCfg()
+	cfg.Machines = []types.MachineSpec{{Role: types.RoleDatabase, Count: 1, TuningProfile: "nope"}}
+	if _, _, err := Build(cfg, baseDeps()); err == nil {
+		t.Fatal("expected error for unknown tuning profile")
+	}
+}
+
+func sourcePackageCfg() types.RunConfig {
+	cfg := postgresSingleCfg()
+	cfg.ResolvedPackage = &types.Package{
+		ID: "pkg-1", DbKind: "postgres", DbVersion: "16",
+		Source: &types.SourceBuild{GitURL: "https://example.com/postgres.git", Ref: "master"},
+	}
+	return cfg
+}
+
+func TestBuild_SourcePackageAddsBuildPhase(t *testing.T) {
+	graph, _, err := Build(sourcePackageCfg(), baseDeps())
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	var build, install *dag.Node
+	for _, n := range graph.Nodes {
+		switch n.ID {
+		case "build_package":
+			build = n
+		case "install_db":
+			install = n
+		}
+	}
+	if build == nil {
+		t.Fatal("build_package node not found")
+	}
+	if len(build.Deps) != 1 || build.Deps[0] != "machines" {
+		t.Errorf("build_package deps = %v, want [machines]", build.Deps)
+	}
+	if install == nil || !slices.Contains(install.Deps, "build_package") {
+		t.Errorf("install_db must depend on build_package, got %v", install.Deps)
+	}
+}
+
+func TestBuild_CachedSourcePackageSkipsBuildPhase(t *testing.T) {
+	cfg := sourcePackageCfg()
+	cfg.ResolvedPackage.DebFilename = "http://server/api/v1/packages/pkg-1/builds/sha/deb"
+	graph, _, err := Build(cfg, baseDeps())
+	if err != nil {
+		t.Fatalf("Build() error: %v", err)
+	}
+	for _, n := range graph.Nodes {
+		if n.ID == "build_package" {
+			t.Fatal("cached source package must not be rebuilt")
+		}
+	}
+}
+
+func TestFillMachinesFromTopology_AddsBuilder(t *testing.T) {
+	cfg := sourcePackageCfg()
+	FillMachinesFromTopology(&cfg)
+	FillMachinesFromTopology(&cfg) // idempotent
+	builders := 0
+	for _, m := range cfg.Machines {
+		if m.Role == types.RoleBuilder {
+			builders++
+		}
+	}
+	if builders != 1 {
+		t.Errorf("got %d builder machines, want 1", builders)
+	}
+}
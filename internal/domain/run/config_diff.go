@@ -0,0 +1,302 @@
+package run
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+)
+
+// Sections of a ConfigChange, in the order DiffRuns reports them.
+const (
+	DiffSectionConfig     = "config"      // RunConfig fields not covered below
+	DiffSectionOptions    = "options"     // topology option maps and config file overrides
+	DiffSectionPackage    = "package"     // database package and the artifact it resolved to
+	DiffSectionStroppy    = "stroppy"     // workload settings
+	DiffSectionDBSettings = "db_settings" // settings captured from the running database
+	DiffSectionMachine    = "machine"     // OS settings and hardware facts per machine role
+)
+
+var diffSectionOrder = map[string]int{
+	DiffSectionConfig:     0,
+	DiffSectionOptions:    1,
+	DiffSectionPackage:    2,
+	DiffSectionStroppy:    3,
+	DiffSectionDBSettings: 4,
+	DiffSectionMachine:    5,
+}
+
+// ConfigChange is one setting that differs between two runs. A is the value
+// in the first (baseline) run, B in the second; "" means unset.
+type ConfigChange struct {
+	Section string `json:"section"`
+	Path    string `json:"path"`
+	A       string `json:"a"`
+	B       string `json:"b"`
+}
+
+// maxDiffValue caps reported values; config file overrides can be pages long.
+const maxDiffValue = 120
+
+// optionsPath matches topology option maps ("database.postgres.master_options.x")
+// and rendered config overrides.
+var optionsPath = regexp.MustCompile(`^database\.(\w+\.\w*options\b|rendered_config_overrides\b)`)
+
+// hostSpecificSettings are captured settings that name the node itself and
+// so always differ between runs.
+var hostSpecificSettings = map[string]bool{
+	"hostname":                        true,
+	"server_uuid":                     true,
+	"server_id":                       true,
+	"report_host":                     true,
+	"bind_address":                    true,
+	"gtid_executed":                   true,
+	"gtid_purged":                     true,
+	"pid_file":                        true,
+	"group_replication_local_address": true,
+	"group_replication_group_seeds":   true,
+	"primary_conninfo":                true,
+	"instance_uuid":                   true,
+	"replicaset_uuid":                 true,
+	"listen":                          true,
+	"sysinfo.MemoryUsed":              true,
+}
+
+// DiffRuns lists what differs between the recorded state of two runs: the
+// RunConfig, the package, the settings captured from the database (per node
+// role, first node standing for the role) and the OS settings and hardware
+// facts of each machine role. Changes are ordered by section, then path.
+func DiffRuns(a, b *dag.RunState) ([]ConfigChange, error) {
+	if a == nil {
+		a = &dag.RunState{}
+	}
+	if b == nil {
+		b = &dag.RunState{}
+	}
+	var changes []ConfigChange
+
+	cfgA, err := flattenJSON(a.RunConfig)
+	if err != nil {
+		return nil, fmt.Errorf("run config A: %w", err)
+	}
+	cfgB, err := flattenJSON(b.RunConfig)
+	if err != nil {
+		return nil, fmt.Errorf("run config B: %w", err)
+	}
+	dropRunLabels(cfgA)
+	dropRunLabels(cfgB)
+	for _, c := range diffMaps(cfgA, cfgB) {
+		c.Section = configSection(c.Path)
+		changes = append(changes, c)
+	}
+
+	for _, c := range diffMaps(a.EffectiveConfigs[PackageConfigComponent], b.EffectiveConfigs[PackageConfigComponent]) {
+		c.Section = DiffSectionPackage
+		changes = append(changes, c)
+	}
+
+	changes = append(changes, diffDBSettings(a.DBSettings, b.DBSettings)...)
+
+	tuningA, tuningB := osTuning(a), osTuning(b)
+	for _, role := range unionKeys(tuningA, tuningB) {
+		for _, c := range diffMaps(tuningA[role], tuningB[role]) {
+			c.Section = DiffSectionMachine
+			c.Path = role + "." + c.Path
+			changes = append(changes, c)
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if si, sj := diffSectionOrder[changes[i].Section], diffSectionOrder[changes[j].Section]; si != sj {
+			return si < sj
+		}
+		return changes[i].Path < changes[j].Path
+	})
+	for i := range changes {
+		changes[i].A = truncateValue(changes[i].A)
+		changes[i].B = truncateValue(changes[i].B)
+	}
+	return changes, nil
+}
+
+// ConfigHash fingerprints a run's config: runs with equal hashes differ in
+// nothing DiffRuns reports in the config sections. The run ID, tags and gate
+// are left out, as they label the run rather than configure it.
+func ConfigHash(runConfig json.RawMessage) (string, error) {
+	cfg, err := flattenJSON(runConfig)
+	if err != nil {
+		return "", err
+	}
+	dropRunLabels(cfg)
+	keys := make([]string, 0, len(cfg))
+	for k := range cfg {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, cfg[k])
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// dropRunLabels removes the flattened paths that label a run rather than
+// configure it: its ID, tags and gate.
+func dropRunLabels(cfg map[string]string) {
+	for path := range cfg {
+		if path == "id" || path == "gate" || strings.HasPrefix(path, "tags[") {
+			delete(cfg, path)
+		}
+	}
+}
+
+func configSection(path string) string {
+	switch {
+	case strings.HasPrefix(path, "stroppy."):
+		return DiffSectionStroppy
+	case strings.HasPrefix(path, "package_"):
+		return DiffSectionPackage
+	case optionsPath.MatchString(path):
+		return DiffSectionOptions
+	}
+	return DiffSectionConfig
+}
+
+// diffDBSettings compares the first captured node of each role. A role only
+// one run captured is reported once, not setting by setting.
+func diffDBSettings(a, b map[string]dag.DBSettings) []ConfigChange {
+	rolesA, rolesB := settingsByRole(a), settingsByRole(b)
+	var changes []ConfigChange
+	for _, role := range unionKeys(rolesA, rolesB) {
+		sa, okA := rolesA[role]
+		sb, okB := rolesB[role]
+		if !okA || !okB || sa.Error != "" || sb.Error != "" {
+			if da, db := captureSummary(sa, okA), captureSummary(sb, okB); da != db {
+				changes = append(changes, ConfigChange{Section: DiffSectionDBSettings, Path: role, A: da, B: db})
+			}
+			continue
+		}
+		for _, c := range diffMaps(sa.Values, sb.Values) {
+			if hostSpecificSettings[c.Path] {
+				continue
+			}
+			c.Section = DiffSectionDBSettings
+			c.Path = role + "." + c.Path
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+func settingsByRole(captured map[string]dag.DBSettings) map[string]dag.DBSettings {
+	ids := make([]string, 0, len(captured))
+	for id := range captured {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	out := make(map[string]dag.DBSettings)
+	for _, id := range ids {
+		s := captured[id]
+		if _, ok := out[s.Role]; !ok {
+			out[s.Role] = s
+		}
+	}
+	return out
+}
+
+func captureSummary(s dag.DBSettings, ok bool) string {
+	switch {
+	case !ok:
+		return "not captured"
+	case s.Error != "":
+		return "capture failed"
+	}
+	return fmt.Sprintf("%d settings", len(s.Values))
+}
+
+func osTuning(rs *dag.RunState) map[string]map[string]string {
+	out := make(map[string]map[string]string)
+	for component, values := range rs.EffectiveConfigs {
+		if role, ok := strings.CutPrefix(component, TuningConfigPrefix); ok {
+			out[role] = values
+		}
+	}
+	return out
+}
+
+// diffMaps returns a change per key whose value differs, Section unset.
+func diffMaps(a, b map[string]string) []ConfigChange {
+	var out []ConfigChange
+	for _, k := range unionKeys(a, b) {
+		if a[k] != b[k] {
+			out = append(out, ConfigChange{Path: k, A: a[k], B: b[k]})
+		}
+	}
+	return out
+}
+
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// flattenJSON flattens a JSON document into dotted paths with "[i]" list
+// indexes, e.g. "database.postgres.replicas[0].cpus".
+func flattenJSON(data json.RawMessage) (map[string]string, error) {
+	out := make(map[string]string)
+	if len(data) == 0 {
+		return out, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	flattenValue(out, "", doc)
+	return out, nil
+}
+
+func flattenValue(out map[string]string, prefix string, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, item := range v {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			flattenValue(out, p, item)
+		}
+	case []any:
+		for i, item := range v {
+			flattenValue(out, fmt.Sprintf("%s[%d]", prefix, i), item)
+		}
+	case nil:
+		// Absent and null read the same.
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+func truncateValue(v string) string {
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	if r := []rune(v); len(r) > maxDiffValue {
+		return string(r[:maxDiffValue]) + fmt.Sprintf("… (%d bytes)", len(v))
+	}
+	return v
+}
@@ -0,0 +1,115 @@
+package run
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+func TestDiffRuns(t *testing.T) {
+	cfgA := postgresSingleCfg()
+	cfgB := postgresSingleCfg()
+	cfgB.ID = "other-run"
+	cfgB.Tags = []string{"nightly"}
+	topo := *cfgB.Database.Postgres
+	topo.MasterOptions = map[string]string{"shared_buffers": "2GB"}
+	cfgB.Database.Postgres = &topo
+	cfgB.Stroppy.Workers = 8
+	cfgB.Database.RenderedConfigOverrides = map[string]string{"pg_hba.conf": strings.Repeat("x", 500)}
+
+	state := func(cfg types.RunConfig, sharedBuffers, kernel, sha string) *dag.RunState {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &dag.RunState{
+			RunConfig: data,
+			EffectiveConfigs: map[string]map[string]string{
+				PackageConfigComponent:          {"name": "PostgreSQL 16", "deb_sha256": sha},
+				TuningConfigPrefix + "database": {"machine.kernel": kernel, "machine.cpus": "4"},
+			},
+			DBSettings: map[string]dag.DBSettings{
+				cfg.ID + "-database-0": {Role: "master", Values: map[string]string{
+					"shared_buffers": sharedBuffers,
+					"hostname":       cfg.ID,
+				}},
+			},
+		}
+	}
+	a := state(cfgA, "1GB", "6.1", "aaa")
+	b := state(cfgB, "2GB", "6.8", "bbb")
+
+	got, err := DiffRuns(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ConfigChange{
+		{DiffSectionOptions, "database.postgres.master_options.shared_buffers", "", "2GB"},
+		{DiffSectionOptions, "database.rendered_config_overrides.pg_hba.conf", "", strings.Repeat("x", maxDiffValue) + "… (500 bytes)"},
+		{DiffSectionPackage, "deb_sha256", "aaa", "bbb"},
+		{DiffSectionStroppy, "stroppy.workers", "4", "8"},
+		{DiffSectionDBSettings, "master.shared_buffers", "1GB", "2GB"},
+		{DiffSectionMachine, "database.machine.kernel", "6.1", "6.8"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes, want %d:\n%+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("change %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	same, err := DiffRuns(a, a)
+	if err != nil || len(same) != 0 {
+		t.Errorf("DiffRuns(a, a) = %+v, %v; want no changes", same, err)
+	}
+}
+
+func TestDiffRuns_CaptureMissing(t *testing.T) {
+	a := &dag.RunState{DBSettings: map[string]dag.DBSettings{
+		"db-0": {Role: "primary", Values: map[string]string{"max_connections": "151"}},
+	}}
+	b := &dag.RunState{DBSettings: map[string]dag.DBSettings{
+		"db-0": {Role: "primary", Error: "timeout"},
+	}}
+	got, err := DiffRuns(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ConfigChange{DiffSectionDBSettings, "primary", "1 settings", "capture failed"}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("got %+v, want [%+v]", got, want)
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	hash := func(cfg types.RunConfig) string {
+		data, err := json.Marshal(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := ConfigHash(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	base := postgresSingleCfg()
+	relabelled := postgresSingleCfg()
+	relabelled.ID = "other-run"
+	relabelled.Tags = []string{"nightly", "postgres-16"}
+	relabelled.Gate = "postgres-16"
+	changed := postgresSingleCfg()
+	changed.Stroppy.VUs++
+
+	if h := hash(base); len(h) != 12 || h != hash(relabelled) {
+		t.Errorf("ID, tags and gate changed the hash: %s vs %s", h, hash(relabelled))
+	}
+	if hash(base) == hash(changed) {
+		t.Error("a workload change kept the hash")
+	}
+}
@@ -2,6 +2,7 @@ package run
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 )
@@ -176,3 +177,38 @@ func ComputeEffectiveConfigs(cfg *types.RunConfig) map[string]map[string]string
 
 	return out
 }
+
+// PackageConfigComponent is the EffectiveConfigs component describing the
+// database package a run installed.
+const PackageConfigComponent = "package"
+
+// PackageFacts describes pkg for EffectiveConfigs: what it is and the exact
+// artifact it resolved to (commit, checksum). Empty fields are left out.
+func PackageFacts(pkg *types.Package) map[string]string {
+	if pkg == nil {
+		return nil
+	}
+	out := make(map[string]string)
+	put := func(k, v string) {
+		if v != "" {
+			out[k] = v
+		}
+	}
+	put("id", pkg.ID)
+	put("name", pkg.Name)
+	put("db_version", pkg.DbVersion)
+	put("apt_packages", strings.Join(pkg.AptPackages, ","))
+	put("custom_repo", pkg.CustomRepo)
+	put("deb_sha256", pkg.DebSHA256)
+	if src := pkg.Source; src != nil {
+		put("source.git_url", src.GitURL)
+		put("source.ref", src.Ref)
+		put("source.commit_sha", src.CommitSHA)
+		put("source.recipe", src.RecipeHash())
+	}
+	if tb := pkg.Tarball; tb != nil {
+		put("tarball.url", tb.URL)
+		put("tarball.sha256", tb.SHA256)
+	}
+	return out
+}
@@ -12,6 +12,14 @@ func applyOverride(orig int, override *types.MachineSpec, field func(*types.Mach
 	return orig
 }
 
+// tuningOverride returns the override's tuning profile if set, otherwise the original.
+func tuningOverride(override *types.MachineSpec, orig string) string {
+	if override != nil && override.TuningProfile != "" {
+		return override.TuningProfile
+	}
+	return orig
+}
+
 // BakeMachineOverrideIntoTopology folds cfg.MachineOverride into the database
 // topology's per-component MachineSpec fields and clears MachineOverride.
 // After this runs, the topology alone reflects the final database-node sizing,
@@ -35,6 +43,9 @@ func BakeMachineOverrideIntoTopology(cfg *types.RunConfig) {
 		if ov.DiskType != "" {
 			m.DiskType = ov.DiskType
 		}
+		if ov.TuningProfile != "" {
+			m.TuningProfile = ov.TuningProfile
+		}
 	}
 	db := &cfg.Database
 	switch db.Kind {
@@ -74,6 +85,9 @@ func BakeMachineOverrideIntoTopology(cfg *types.RunConfig) {
 // If MachineOverride is set, its CPU/memory/disk values override the preset's
 // database node specs (non-database roles like HAProxy are not affected).
 func FillMachinesFromTopology(cfg *types.RunConfig) {
+	// The builder is added even when machines are explicit: it is derived
+	// from the package, not from the topology.
+	defer addBuilderMachine(cfg)
 	if len(cfg.Machines) > 0 {
 		return // user specified machines explicitly
 	}
@@ -95,6 +109,7 @@ func FillMachinesFromTopology(cfg *types.RunConfig) {
 				Role: types.RoleDatabase, Count: dbCount,
 				CPUs: ovCPU(db.Postgres.Master.CPUs), MemoryMB: ovMem(db.Postgres.Master.MemoryMB), DiskGB: ovDisk(db.Postgres.Master.DiskGB),
 				DiskType: db.Postgres.Master.DiskType, SecondaryDisks: db.Postgres.Master.SecondaryDisks,
+				TuningProfile: tuningOverride(ov, db.Postgres.Master.TuningProfile),
 			})
 			if db.Postgres.HAProxy != nil {
 				cfg.Machines = append(cfg.Machines, *db.Postgres.HAProxy)
@@ -110,6 +125,7 @@ func FillMachinesFromTopology(cfg *types.RunConfig) {
 				Role: types.RoleDatabase, Count: dbCount,
 				CPUs: ovCPU(db.MySQL.Primary.CPUs), MemoryMB: ovMem(db.MySQL.Primary.MemoryMB), DiskGB: ovDisk(db.MySQL.Primary.DiskGB),
 				DiskType: db.MySQL.Primary.DiskType, SecondaryDisks: db.MySQL.Primary.SecondaryDisks,
+				TuningProfile: tuningOverride(ov, db.MySQL.Primary.TuningProfile),
 			})
 			if db.MySQL.ProxySQL != nil {
 				cfg.Machines = append(cfg.Machines, *db.MySQL.ProxySQL)
@@ -122,6 +138,7 @@ func FillMachinesFromTopology(cfg *types.RunConfig) {
 					Role: types.RoleDatabase, Count: inst.Count,
 					CPUs: ovCPU(inst.CPUs), MemoryMB: ovMem(inst.MemoryMB), DiskGB: ovDisk(inst.DiskGB),
 					DiskType: inst.DiskType, SecondaryDisks: inst.SecondaryDisks,
+					TuningProfile: tuningOverride(ov, inst.TuningProfile),
 				})
 			}
 			if db.Picodata.HAProxy != nil {
@@ -152,6 +169,7 @@ func FillMachinesFromTopology(cfg *types.RunConfig) {
 				Role: types.RoleDatabase, Count: count,
 				CPUs: ovCPU(cpus), MemoryMB: ovMem(mem), DiskGB: ovDisk(disk),
 				DiskType: db.YDB.Storage.DiskType, SecondaryDisks: db.YDB.Storage.SecondaryDisks,
+				TuningProfile: tuningOverride(ov, db.YDB.Storage.TuningProfile),
 			})
 			if db.YDB.HAProxy != nil {
 				cfg.Machines = append(cfg.Machines, *db.YDB.HAProxy)
@@ -168,3 +186,23 @@ func FillMachinesFromTopology(cfg *types.RunConfig) {
 	}
 	cfg.Machines = append(cfg.Machines, stroppySpec)
 }
+
+// addBuilderMachine appends a builder machine when the resolved package must
+// be compiled from source. Idempotent: an existing builder spec is kept.
+func addBuilderMachine(cfg *types.RunConfig) {
+	if !cfg.ResolvedPackage.NeedsBuild() {
+		return
+	}
+	for _, m := range cfg.Machines {
+		if m.Role == types.RoleBuilder {
+			return
+		}
+	}
+	spec := types.DefaultBuilderMachine()
+	if m := cfg.ResolvedPackage.Source.Machine; m != nil {
+		spec = *m
+		spec.Role = types.RoleBuilder
+		spec.Count = 1
+	}
+	cfg.Machines = append(cfg.Machines, spec)
+}
@@ -39,6 +39,9 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 			Options:       db.Postgres.MasterOptions,
 			Patroni:       db.Postgres.Patroni,
 			TotalMemoryMB: db.Postgres.Master.MemoryMB,
+			AutoTune:      db.Postgres.AutoTune,
+			CPUs:          db.Postgres.Master.CPUs,
+			DiskType:      db.Postgres.Master.DiskType,
 		}))
 		if len(db.Postgres.Replicas) > 0 {
 			r := db.Postgres.Replicas[0]
@@ -48,6 +51,9 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 				Options:       db.Postgres.ReplicaOptions,
 				Patroni:       db.Postgres.Patroni,
 				TotalMemoryMB: r.MemoryMB,
+				AutoTune:      db.Postgres.AutoTune,
+				CPUs:          r.CPUs,
+				DiskType:      r.DiskType,
 			}))
 		}
 		put("pg_hba.conf", dbconfig.PostgresPgHbaConf())
@@ -93,6 +99,9 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 			GroupRepl:     db.MySQL.GroupRepl,
 			Options:       db.MySQL.PrimaryOptions,
 			TotalMemoryMB: db.MySQL.Primary.MemoryMB,
+			AutoTune:      db.MySQL.AutoTune,
+			CPUs:          db.MySQL.Primary.CPUs,
+			DiskType:      db.MySQL.Primary.DiskType,
 		}))
 		if len(db.MySQL.Replicas) > 0 {
 			r := db.MySQL.Replicas[0]
@@ -103,6 +112,9 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 				GroupRepl:     db.MySQL.GroupRepl,
 				Options:       db.MySQL.ReplicaOptions,
 				TotalMemoryMB: r.MemoryMB,
+				AutoTune:      db.MySQL.AutoTune,
+				CPUs:          r.CPUs,
+				DiskType:      r.DiskType,
 			}))
 		}
 
@@ -118,6 +130,7 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 			Replication:   db.Picodata.Replication,
 			Options:       db.Picodata.InstanceOptions,
 			TotalMemoryMB: spec.MemoryMB,
+			AutoTune:      db.Picodata.AutoTune,
 		}))
 		if db.Picodata.HAProxy != nil {
 			put("haproxy.cfg", dbconfig.RenderHAProxyConf(dbconfig.RenderHAProxyConfOpts{
@@ -147,6 +160,8 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 			CPUs:            db.YDB.Storage.CPUs,
 			MemoryMB:        db.YDB.Storage.MemoryMB,
 			FaultTolerance:  db.YDB.FaultTolerance,
+			AutoTune:        db.YDB.AutoTune,
+			DiskType:        db.YDB.Storage.DiskType,
 		}))
 		// Database (dynamic) node config: separate file the agent writes to
 		// /opt/ydb/cfg/database.yaml. Same cluster topology as the storage
@@ -165,6 +180,8 @@ func BuildRenderedConfigs(cfg *types.RunConfig) map[string]string {
 			CPUs:            dbCPUs,
 			MemoryMB:        dbMem,
 			FaultTolerance:  db.YDB.FaultTolerance,
+			AutoTune:        db.YDB.AutoTune,
+			DiskType:        db.YDB.Storage.DiskType,
 		}))
 		if db.YDB.HAProxy != nil {
 			put("haproxy.cfg", dbconfig.RenderHAProxyConf(dbconfig.RenderHAProxyConfOpts{
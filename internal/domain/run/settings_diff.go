@@ -0,0 +1,122 @@
+package run
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/dbconfig"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/dbparams"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// SettingDiff is one setting whose live value differs from the rendered one.
+type SettingDiff struct {
+	Name     string `json:"name"`
+	Rendered string `json:"rendered"`
+	Live     string `json:"live"`
+}
+
+// NodeSettingsDiff compares what one database node runs with against the
+// config files it was rendered.
+type NodeSettingsDiff struct {
+	Node    string   `json:"node"`
+	Role    string   `json:"role"`
+	Sources []string `json:"sources,omitempty"`
+	Error   string   `json:"error,omitempty"` // capture failed; nothing compared
+	// Compared counts the rendered settings the node reported a value for.
+	Compared int           `json:"compared"`
+	Diffs    []SettingDiff `json:"diffs"`
+	// NotReported lists rendered settings the server does not know about.
+	NotReported []string `json:"not_reported,omitempty"`
+	// Values is the full live settings map, only when asked for.
+	Values map[string]string `json:"values,omitempty"`
+}
+
+// DiffDBSettings compares the settings captured from each database node
+// against the configs BuildRenderedConfigs produces for cfg, user overrides
+// included. Sizes, durations and booleans compare by meaning, so "4096kB"
+// matches a live "4MB". Nodes are ordered by ID.
+func DiffDBSettings(cfg *types.RunConfig, captured map[string]dag.DBSettings, withValues bool) []NodeSettingsDiff {
+	rendered := BuildRenderedConfigs(cfg)
+	catalog, _ := dbparams.Get(string(cfg.Database.Kind), cfg.Database.Version)
+
+	ids := make([]string, 0, len(captured))
+	for id := range captured {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	out := make([]NodeSettingsDiff, 0, len(ids))
+	for _, id := range ids {
+		node := captured[id]
+		d := NodeSettingsDiff{
+			Node:    id,
+			Role:    node.Role,
+			Sources: node.Sources,
+			Error:   node.Error,
+			Diffs:   []SettingDiff{},
+		}
+		if withValues {
+			d.Values = node.Values
+		}
+		if node.Error != "" {
+			out = append(out, d)
+			continue
+		}
+
+		expected := expectedSettings(rendered, node.Sources)
+		names := make([]string, 0, len(expected))
+		for name := range expected {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			live, ok := node.Values[name]
+			if !ok {
+				d.NotReported = append(d.NotReported, name)
+				continue
+			}
+			d.Compared++
+			if !sameSetting(catalog, name, expected[name], live) {
+				d.Diffs = append(d.Diffs, SettingDiff{Name: name, Rendered: expected[name], Live: live})
+			}
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// expectedSettings merges the settings of a node's rendered config files.
+// The YDB files share key names, so their settings are prefixed with the
+// process they configure ("storage.", "database."), as the agent reports them.
+func expectedSettings(rendered map[string]string, sources []string) map[string]string {
+	out := make(map[string]string)
+	for _, key := range sources {
+		body, ok := rendered[key]
+		if !ok {
+			continue
+		}
+		// Rendering ran when the run was submitted; a parse failure here
+		// means a hand-edited override, which the node reports as-is.
+		values, err := dbconfig.ParseRenderedSettings(key, body)
+		if err != nil {
+			continue
+		}
+		prefix := ""
+		if file, part, ok := strings.Cut(key, ":"); ok && file == "ydb.yaml" {
+			prefix = part + "."
+		}
+		for k, v := range values {
+			out[prefix+k] = v
+		}
+	}
+	return out
+}
+
+func sameSetting(catalog *dbparams.Catalog, name, rendered, live string) bool {
+	if catalog != nil {
+		return catalog.Same(name, rendered, live)
+	}
+	return strings.EqualFold(strings.TrimSpace(rendered), strings.TrimSpace(live))
+}
@@ -0,0 +1,82 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+func TestDiffDBSettings_Postgres(t *testing.T) {
+	cfg := postgresSingleCfg()
+	captured := map[string]dag.DBSettings{
+		"db-1": {Role: "replica", Sources: []string{"postgresql.conf:replica"}, Error: "connection refused"},
+		"db-0": {
+			Role:    "master",
+			Sources: []string{"postgresql.conf:master"},
+			Values: map[string]string{
+				"shared_buffers":     "1GB", // rendered 1024MB
+				"work_mem":           "65536kB",
+				"max_connections":    "100", // rendered 200
+				"listen_addresses":   "*",
+				"wal_level":          "replica",
+				"checkpoint_timeout": "5min",
+			},
+		},
+	}
+
+	got := DiffDBSettings(&cfg, captured, false)
+	if len(got) != 2 || got[0].Node != "db-0" || got[1].Node != "db-1" {
+		t.Fatalf("nodes = %+v, want db-0 then db-1", got)
+	}
+
+	master := got[0]
+	if len(master.Diffs) != 1 || master.Diffs[0] != (SettingDiff{Name: "max_connections", Rendered: "200", Live: "100"}) {
+		t.Errorf("diffs = %+v, want only max_connections 200 -> 100", master.Diffs)
+	}
+	if master.Compared != 5 {
+		t.Errorf("compared = %d, want 5", master.Compared)
+	}
+	if len(master.NotReported) == 0 {
+		t.Error("expected rendered settings missing from the capture to be listed")
+	}
+	if master.Values != nil {
+		t.Error("values included without being asked for")
+	}
+
+	if replica := got[1]; replica.Error == "" || replica.Compared != 0 {
+		t.Errorf("replica = %+v, want the capture error and nothing compared", replica)
+	}
+}
+
+func TestDiffDBSettings_YDBPrefixesProcess(t *testing.T) {
+	cfg := types.RunConfig{Database: types.DatabaseConfig{
+		Kind: types.DatabaseYDB,
+		YDB:  &types.YDBTopology{Storage: types.MachineSpec{Count: 1, CPUs: 4, MemoryMB: 8192}},
+		RenderedConfigOverrides: map[string]string{
+			"ydb.yaml:storage":  "actor_system_config:\n  cpu_count: 4\n",
+			"ydb.yaml:database": "actor_system_config:\n  cpu_count: 2\n",
+		},
+	}}
+	captured := map[string]dag.DBSettings{"ydb-0": {
+		Role:    "node",
+		Sources: []string{"ydb.yaml:storage", "ydb.yaml:database"},
+		Values: map[string]string{
+			"storage.actor_system_config.cpu_count":  "4",
+			"database.actor_system_config.cpu_count": "3",
+			"sysinfo.NumberOfCpus":                   "4",
+		},
+	}}
+
+	got := DiffDBSettings(&cfg, captured, true)
+	if len(got) != 1 {
+		t.Fatalf("got %d nodes, want 1", len(got))
+	}
+	want := SettingDiff{Name: "database.actor_system_config.cpu_count", Rendered: "2", Live: "3"}
+	if len(got[0].Diffs) != 1 || got[0].Diffs[0] != want {
+		t.Errorf("diffs = %+v, want [%+v]", got[0].Diffs, want)
+	}
+	if got[0].Values["sysinfo.NumberOfCpus"] != "4" {
+		t.Errorf("values = %v, want the full capture", got[0].Values)
+	}
+}
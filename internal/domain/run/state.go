@@ -5,6 +5,7 @@ import (
 
 	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/agent"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 	"github.com/stroppy-io/stroppy-cloud/internal/infrastructure/terraform"
 )
 
@@ -19,6 +20,11 @@ type State struct {
 	monitorTargets []agent.Target
 	proxyTargets   []agent.Target
 	stroppyTarget  *agent.Target
+	builderTarget  *agent.Target
+
+	// Populated by the "build_package" phase: the source package with its
+	// DebFilename pointing at the built artifact.
+	builtPackage *types.Package
 
 	// Populated by the "configure_db" phase.
 	dbHost string
@@ -34,6 +40,9 @@ type State struct {
 
 	// Effective configs per component, set by config tasks at runtime.
 	effectiveConfigs map[string]map[string]string
+
+	// Populated by the "capture_settings" phase, keyed by target ID.
+	dbSettings map[string]dag.DBSettings
 }
 
 func NewState() *State { return &State{} }
@@ -64,6 +73,18 @@ func (s *State) SetStroppyTarget(target agent.Target) {
 	s.stroppyTarget = &target
 }
 
+func (s *State) SetBuilderTarget(target agent.Target) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.builderTarget = &target
+}
+
+func (s *State) SetBuiltPackage(pkg *types.Package) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.builtPackage = pkg
+}
+
 func (s *State) SetDBEndpoint(host string, port int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -97,6 +118,23 @@ func (s *State) StroppyTarget() *agent.Target {
 	return s.stroppyTarget
 }
 
+func (s *State) BuilderTarget() *agent.Target {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.builderTarget
+}
+
+// Package returns the package install tasks should use: the built artifact
+// once build_package has run, otherwise pkg itself.
+func (s *State) Package(pkg *types.Package) *types.Package {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.builtPackage != nil {
+		return s.builtPackage
+	}
+	return pkg
+}
+
 func (s *State) DBEndpoint() (string, int) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -160,7 +198,18 @@ func (s *State) SetEffectiveConfig(component string, cfg map[string]string) {
 	s.effectiveConfigs[component] = cfg
 }
 
-// AllTargets returns all known agent targets across all roles.
+// SetDBSettings records the live settings captured from a database node.
+func (s *State) SetDBSettings(targetID string, settings dag.DBSettings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dbSettings == nil {
+		s.dbSettings = make(map[string]dag.DBSettings)
+	}
+	s.dbSettings[targetID] = settings
+}
+
+// AllTargets returns all known agent targets across all roles except the
+// builder, which only lives for the build_package phase.
 func (s *State) AllTargets() []agent.Target {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -185,6 +234,7 @@ func (s *State) ExportRunState() *dag.RunState {
 		DBHost:           s.dbHost,
 		DBPort:           s.dbPort,
 		EffectiveConfigs: s.effectiveConfigs,
+		DBSettings:       s.dbSettings,
 	}
 
 	for _, t := range s.dbTargets {
@@ -199,6 +249,9 @@ func (s *State) ExportRunState() *dag.RunState {
 	if s.stroppyTarget != nil {
 		rs.Targets = append(rs.Targets, dag.TargetInfo{ID: s.stroppyTarget.ID, Host: s.stroppyTarget.Host, InternalHost: s.stroppyTarget.InternalHost, AgentPort: s.stroppyTarget.AgentPort, Role: "stroppy"})
 	}
+	if s.builderTarget != nil {
+		rs.Targets = append(rs.Targets, dag.TargetInfo{ID: s.builderTarget.ID, Host: s.builderTarget.Host, InternalHost: s.builderTarget.InternalHost, AgentPort: s.builderTarget.AgentPort, Role: "builder"})
+	}
 
 	return rs
 }
@@ -213,11 +266,13 @@ func (s *State) ImportRunState(rs *dag.RunState) {
 	s.dbHost = rs.DBHost
 	s.dbPort = rs.DBPort
 	s.effectiveConfigs = rs.EffectiveConfigs
+	s.dbSettings = rs.DBSettings
 
 	s.dbTargets = nil
 	s.monitorTargets = nil
 	s.proxyTargets = nil
 	s.stroppyTarget = nil
+	s.builderTarget = nil
 
 	for _, t := range rs.Targets {
 		target := agent.Target{ID: t.ID, Host: t.Host, InternalHost: t.InternalHost, AgentPort: t.AgentPort}
@@ -230,6 +285,8 @@ func (s *State) ImportRunState(rs *dag.RunState) {
 			s.proxyTargets = append(s.proxyTargets, target)
 		case "stroppy":
 			s.stroppyTarget = &target
+		case "builder":
+			s.builderTarget = &target
 		}
 	}
 }
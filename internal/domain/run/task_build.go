@@ -0,0 +1,148 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/agent"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/auth"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// PackageBuildCache looks up source package artifacts built by earlier runs.
+type PackageBuildCache interface {
+	PackageBuildExists(ctx context.Context, tenantID, packageID, commitSHA, recipeHash string) (bool, error)
+}
+
+// buildTokenTTL bounds how long the builder may take to compile and upload;
+// debTokenTTL how long after the build the DB nodes may download the result.
+const (
+	buildTokenTTL = 6 * time.Hour
+	debTokenTTL   = 2 * time.Hour
+)
+
+// buildPackageTask compiles a source package on the builder machine, unless
+// an artifact for the same commit and recipe is already cached, and points
+// the install phase at the result.
+type buildPackageTask struct {
+	client     agent.Client
+	state      *State
+	cache      PackageBuildCache
+	jwtIssuer  *auth.JWTIssuer
+	serverAddr string
+	tenantID   string
+	runID      string
+	pkg        *types.Package
+}
+
+func (t *buildPackageTask) Execute(nc *dag.NodeContext) error {
+	builder := t.state.BuilderTarget()
+	if builder == nil {
+		return fmt.Errorf("build package: no builder machine")
+	}
+	src := *t.pkg.Source
+
+	if src.CommitSHA == "" {
+		report, err := t.client.Call(nc, *builder, agent.Command{
+			Action: agent.ActionResolveGitRef,
+			Config: agent.ResolveGitRefConfig{GitURL: src.GitURL, Ref: src.Ref},
+		})
+		if err != nil {
+			return fmt.Errorf("resolve %s: %w", src.Ref, err)
+		}
+		src.CommitSHA = strings.TrimSpace(report.Output)
+	}
+	recipe := src.RecipeHash()
+	nc.Log().Info("source package resolved",
+		zap.String("git_url", src.GitURL),
+		zap.String("ref", src.Ref),
+		zap.String("commit", src.CommitSHA),
+		zap.String("recipe", recipe),
+	)
+
+	buildPath := t.buildPath(src.CommitSHA)
+	uploadToken, err := t.token("package-build:"+t.runID, "operator", buildPath, buildTokenTTL)
+	if err != nil {
+		return err
+	}
+	debName := buildDebName(t.pkg)
+	buildURL := t.buildURL(buildPath, recipe, agent.BuildDebFilename(debName, src.CommitSHA))
+
+	cached := false
+	if t.cache != nil {
+		cached, err = t.cache.PackageBuildExists(nc, t.tenantID, t.pkg.ID, src.CommitSHA, recipe)
+		if err != nil {
+			return fmt.Errorf("check build cache: %w", err)
+		}
+	}
+	if cached {
+		nc.Log().Info("reusing cached build", zap.String("commit", src.CommitSHA))
+	} else {
+		nc.Log().Info("building package from source", zap.String("builder", builder.ID))
+		if _, err := t.client.Call(nc, *builder, agent.Command{
+			Action: agent.ActionBuildPackage,
+			Config: agent.BuildPackageConfig{
+				DebName:     debName,
+				Source:      src,
+				UploadURL:   buildURL,
+				UploadToken: uploadToken,
+			},
+		}); err != nil {
+			return fmt.Errorf("build package: %w", err)
+		}
+	}
+
+	// The DB nodes get their own token: read-only, and only for this build.
+	downloadToken, err := t.token("deb-download:"+t.runID, "viewer", buildPath, debTokenTTL)
+	if err != nil {
+		return err
+	}
+	built := *t.pkg
+	built.Source = &src
+	built.DebFilename = buildURL
+	built.DebToken = downloadToken
+	t.state.SetBuiltPackage(&built)
+	t.state.SetEffectiveConfig(PackageConfigComponent, PackageFacts(&built))
+	return nil
+}
+
+// token issues a bearer token valid only for requests to path.
+func (t *buildPackageTask) token(userID, role, path string, ttl time.Duration) (string, error) {
+	if t.jwtIssuer == nil {
+		return "", nil
+	}
+	token, err := t.jwtIssuer.Issue(auth.Claims{
+		UserID: userID, TenantID: t.tenantID, Role: role, Scope: path,
+	}, ttl)
+	if err != nil {
+		return "", fmt.Errorf("issue %s token: %w", role, err)
+	}
+	return token, nil
+}
+
+// buildPath is the server path a build artifact is uploaded to and served from.
+func (t *buildPackageTask) buildPath(commitSHA string) string {
+	return fmt.Sprintf("/api/v1/packages/%s/builds/%s/deb", t.pkg.ID, commitSHA)
+}
+
+// buildURL is the absolute URL of buildPath for the recipe and file name.
+func (t *buildPackageTask) buildURL(path, recipe, filename string) string {
+	q := url.Values{"recipe": {recipe}, "filename": {filename}, "run_id": {t.runID}}
+	return strings.TrimRight(t.serverAddr, "/") + path + "?" + q.Encode()
+}
+
+// buildDebName derives the Debian package name of a source build, e.g.
+// "stroppy-postgres-17-src".
+func buildDebName(pkg *types.Package) string {
+	name := "stroppy-" + pkg.DbKind
+	if pkg.DbVersion != "" {
+		name += "-" + pkg.DbVersion
+	}
+	return strings.ToLower(name) + "-src"
+}
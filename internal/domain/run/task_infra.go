@@ -177,6 +177,8 @@ func (t *machinesTask) dockerMachines(nc *dag.NodeContext) error {
 				proxyTargets = append(proxyTargets, target)
 			case types.RoleStroppy:
 				t.state.SetStroppyTarget(target)
+			case types.RoleBuilder:
+				t.state.SetBuilderTarget(target)
 			}
 
 			nc.Log().Info("container deployed",
@@ -510,6 +512,8 @@ func (t *machinesTask) yandexMachines(nc *dag.NodeContext) error {
 			proxyTargets = append(proxyTargets, target)
 		case types.RoleStroppy:
 			t.state.SetStroppyTarget(target)
+		case types.RoleBuilder:
+			t.state.SetBuilderTarget(target)
 		}
 	}
 
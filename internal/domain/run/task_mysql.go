@@ -24,7 +24,7 @@ func (t *mysqlInstallTask) Execute(nc *dag.NodeContext) error {
 		Config: agent.MySQLInstallConfig{
 			Version: t.version,
 			DataDir: "/var/lib/mysql",
-			Package: t.pkg,
+			Package: t.state.Package(t.pkg),
 		},
 	})
 }
@@ -86,6 +86,9 @@ func (t *mysqlConfigTask) Execute(nc *dag.NodeContext) error {
 			GroupSeeds:   groupSeeds,
 			GroupName:    groupName,
 			MemoryMB:     spec.MemoryMB,
+			CPUs:         spec.CPUs,
+			DiskType:     spec.DiskType,
+			AutoTune:     t.topology.AutoTune,
 			Options:      opts,
 			ConfOverride: t.overrides["my.cnf:"+role],
 		}
@@ -109,6 +112,9 @@ func (t *mysqlConfigTask) Execute(nc *dag.NodeContext) error {
 	} else if t.topology.SemiSync {
 		ec["replication"] = "semi-sync"
 	}
+	if t.topology.AutoTune != "" {
+		ec["auto_tune"] = t.topology.AutoTune
+	}
 	for k, v := range t.topology.PrimaryOptions {
 		ec[k] = v
 	}
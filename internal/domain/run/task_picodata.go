@@ -68,6 +68,7 @@ func (t *picoConfigTask) Execute(nc *dag.NodeContext) error {
 			Replication:   t.topology.Replication,
 			Shards:        t.topology.Shards,
 			MemoryMB:      spec.MemoryMB,
+			AutoTune:      t.topology.AutoTune,
 			Options:       t.topology.InstanceOptions,
 			ConfOverride:  t.overrides["picodata.yaml"],
 		}
@@ -87,6 +88,9 @@ func (t *picoConfigTask) Execute(nc *dag.NodeContext) error {
 		inst := t.topology.Instances[0]
 		ec["per_node"] = fmt.Sprintf("%d vCPU / %d MB / %d GB", inst.CPUs, inst.MemoryMB, inst.DiskGB)
 	}
+	if t.topology.AutoTune != "" {
+		ec["auto_tune"] = t.topology.AutoTune
+	}
 	for k, v := range t.topology.InstanceOptions {
 		ec[k] = v
 	}
@@ -24,7 +24,7 @@ func (t *pgInstallTask) Execute(nc *dag.NodeContext) error {
 		Config: agent.PostgresInstallConfig{
 			Version: t.version,
 			DataDir: "/var/lib/postgresql/data",
-			Package: t.pkg,
+			Package: t.state.Package(t.pkg),
 		},
 	})
 }
@@ -66,6 +66,9 @@ func (t *pgConfigTask) Execute(nc *dag.NodeContext) error {
 			Patroni:      t.topology.Patroni,
 			SyncReplicas: t.topology.SyncReplicas,
 			MemoryMB:     spec.MemoryMB,
+			CPUs:         spec.CPUs,
+			DiskType:     spec.DiskType,
+			AutoTune:     t.topology.AutoTune,
 			Options:      opts,
 			ConfOverride: t.overrides["postgresql.conf:"+role],
 			HBAOverride:  t.overrides["pg_hba.conf"],
@@ -91,6 +94,9 @@ func (t *pgConfigTask) Execute(nc *dag.NodeContext) error {
 	if t.topology.PgBouncer {
 		ec["pooler"] = "pgbouncer"
 	}
+	if t.topology.AutoTune != "" {
+		ec["auto_tune"] = t.topology.AutoTune
+	}
 	for k, v := range t.topology.MasterOptions {
 		ec[k] = v
 	}
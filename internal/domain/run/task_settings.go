@@ -0,0 +1,90 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/agent"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// captureSettingsTask reads the settings each database node actually runs
+// with and stores them per node. Capturing is best effort: a node that cannot
+// report is recorded with its error and the run goes on.
+type captureSettingsTask struct {
+	client agent.Client
+	state  *State
+	db     types.DatabaseConfig
+}
+
+func (t *captureSettingsTask) Execute(nc *dag.NodeContext) error {
+	targets := t.state.DBTargets()
+	nc.Log().Info("capturing database settings", zap.Int("targets", len(targets)))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target agent.Target) {
+			defer wg.Done()
+			role, sources := settingsSources(t.db, i)
+			settings := dag.DBSettings{Role: role, Sources: sources}
+			values, err := t.capture(nc, target)
+			if err != nil {
+				nc.Log().Warn("capture database settings failed",
+					zap.String("target", target.ID), zap.Error(err))
+				settings.Error = err.Error()
+			} else {
+				settings.Values = values
+			}
+			t.state.SetDBSettings(target.ID, settings)
+		}(i, target)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (t *captureSettingsTask) capture(nc *dag.NodeContext, target agent.Target) (map[string]string, error) {
+	report, err := t.client.Call(nc, target, agent.Command{
+		Action: agent.ActionCaptureSettings,
+		Config: agent.CaptureSettingsConfig{Kind: string(t.db.Kind)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	if err := json.Unmarshal([]byte(report.Output), &values); err != nil {
+		return nil, fmt.Errorf("decode settings: %w", err)
+	}
+	return values, nil
+}
+
+// settingsSources returns the role of the i-th database node and the
+// rendered config keys (see BuildRenderedConfigs) it was configured from.
+func settingsSources(db types.DatabaseConfig, i int) (string, []string) {
+	switch db.Kind {
+	case types.DatabasePostgres:
+		if db.Postgres != nil && db.Postgres.Patroni {
+			// Patroni elects the leader; every member gets the same parameters.
+			return "member", []string{"patroni.yml"}
+		}
+		if i == 0 {
+			return "master", []string{"postgresql.conf:master"}
+		}
+		return "replica", []string{"postgresql.conf:replica"}
+	case types.DatabaseMySQL:
+		if i == 0 {
+			return "primary", []string{"my.cnf:primary"}
+		}
+		return "replica", []string{"my.cnf:replica"}
+	case types.DatabasePicodata:
+		return "instance", []string{"picodata.yaml"}
+	case types.DatabaseYDB:
+		// Every node runs a storage and a database process.
+		return "node", []string{"ydb.yaml:storage", "ydb.yaml:database"}
+	}
+	return "", nil
+}
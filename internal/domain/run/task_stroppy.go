@@ -166,11 +166,9 @@ func dbDriverURL(dbKind types.DatabaseKind, host, port string) (string, string)
 // Exported so dry-run can show users what config will be applied.
 // When dbHost=="" and dbPort==0, the generated config embeds sentinel tokens
 // (DBHostPlaceholder/DBPortPlaceholder) that stroppyRunTask substitutes at run time.
+// s comes from an upgraded RunConfig, so the deprecated v1 fields are not consulted.
 func BuildStroppyConfigJSON(s types.StroppyConfig, dbKind types.DatabaseKind, dbHost string, dbPort int, settings types.StroppySettings, runID string) ([]byte, error) {
 	script := s.Script
-	if script == "" {
-		script = s.Workload
-	}
 	if script == "" {
 		script = "tpcc/procs"
 	}
@@ -182,12 +180,6 @@ func BuildStroppyConfigJSON(s types.StroppyConfig, dbKind types.DatabaseKind, db
 	driverURL, driverType := dbDriverURL(dbKind, hostTok, portTok)
 
 	vus := s.VUs
-	if vus == 0 && s.VUSScale > 0 {
-		vus = int(s.VUSScale)
-	}
-	if vus == 0 && s.Workers > 0 {
-		vus = s.Workers
-	}
 	if vus == 0 {
 		vus = 1
 	}
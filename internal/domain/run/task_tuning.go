@@ -0,0 +1,132 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/agent"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// TuningConfigPrefix prefixes the EffectiveConfigs component holding the
+// effective OS settings of a machine: "os_tuning:database" for a role's only
+// machine, "os_tuning:database/0", "os_tuning:database/1", ... when the role
+// has several.
+const TuningConfigPrefix = "os_tuning:"
+
+// tuningComponent is the EffectiveConfigs component of the i-th of n
+// machines of role.
+func tuningComponent(role types.MachineRole, i, n int) string {
+	if n == 1 {
+		return TuningConfigPrefix + string(role)
+	}
+	return TuningConfigPrefix + string(role) + "/" + strconv.Itoa(i)
+}
+
+// tuningTask applies each role's OS tuning profile to its machines and
+// records the effective values reported back by the agents.
+type tuningTask struct {
+	client   agent.Client
+	state    *State
+	provider types.Provider
+	profiles map[types.MachineRole]types.TuningProfile
+}
+
+func (t *tuningTask) Execute(nc *dag.NodeContext) error {
+	if t.provider == types.ProviderDocker {
+		// Containers share the host's kernel: sysctls and /sys writes
+		// would tune the host, not the run.
+		nc.Log().Warn("skipping OS tuning: docker machines share the host kernel")
+		return nil
+	}
+	groups := map[types.MachineRole][]agent.Target{
+		types.RoleDatabase: t.state.DBTargets(),
+		types.RoleMonitor:  t.state.MonitorTargets(),
+		types.RoleProxy:    t.state.ProxyTargets(),
+	}
+	if st := t.state.StroppyTarget(); st != nil {
+		groups[types.RoleStroppy] = []agent.Target{*st}
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	for role, targets := range groups {
+		if len(targets) == 0 {
+			continue
+		}
+		profile := t.profiles[role]
+		if profile.Name == "" {
+			profile, _ = types.ResolveTuningProfile(nil, types.TuningDefault)
+		}
+		nc.Log().Info("applying OS tuning profile",
+			zap.String("role", string(role)),
+			zap.String("profile", profile.Name),
+			zap.Int("targets", len(targets)),
+		)
+
+		// Every machine of a role gets the same profile, but kernels and
+		// hardware may still differ, so each one's readback is recorded.
+		wg.Add(1)
+		go func(role types.MachineRole, targets []agent.Target, profile types.TuningProfile) {
+			defer wg.Done()
+			effective, err := t.apply(nc, targets, profile)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("tune %s: %w", role, err)
+				}
+				return
+			}
+			for i, values := range effective {
+				values["profile"] = profile.Name
+				t.state.SetEffectiveConfig(tuningComponent(role, i, len(effective)), values)
+			}
+		}(role, targets, profile)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// apply runs the profile on all targets and returns each target's
+// effective values, in the order of targets.
+func (t *tuningTask) apply(nc *dag.NodeContext, targets []agent.Target, profile types.TuningProfile) ([]map[string]string, error) {
+	reports := make([]agent.Report, len(targets))
+	errs := make([]error, len(targets))
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target agent.Target) {
+			defer wg.Done()
+			reports[i], errs[i] = t.client.Call(nc, target, agent.Command{
+				Action: agent.ActionApplyTuning,
+				Config: agent.TuningConfig{Profile: profile},
+			})
+		}(i, target)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	effective := make([]map[string]string, len(targets))
+	for i, report := range reports {
+		effective[i] = make(map[string]string)
+		if out := report.Output; out != "" {
+			if err := json.Unmarshal([]byte(out), &effective[i]); err != nil {
+				return nil, fmt.Errorf("decode effective tuning from %s: %w", targets[i].ID, err)
+			}
+		}
+	}
+	return effective, nil
+}
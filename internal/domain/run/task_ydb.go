@@ -22,7 +22,7 @@ func (t *ydbInstallTask) Execute(nc *dag.NodeContext) error {
 	nc.Log().Info("installing YDB on targets")
 	return t.client.SendAll(nc, targets, agent.Command{
 		Action: agent.ActionInstallYDB,
-		Config: agent.YDBInstallConfig{Version: t.version},
+		Config: agent.YDBInstallConfig{Version: t.version, Package: t.state.Package(t.pkg)},
 	})
 }
 
@@ -73,6 +73,8 @@ func (t *ydbConfigTask) Execute(nc *dag.NodeContext) error {
 			DiskGB:          t.topology.Storage.DiskGB,
 			MemoryMB:        t.topology.Storage.MemoryMB,
 			CPUs:            t.topology.Storage.CPUs,
+			DiskType:        t.topology.Storage.DiskType,
+			AutoTune:        t.topology.AutoTune,
 			FaultTolerance:  ft,
 			Options:         t.topology.StorageOptions,
 			ConfOverride:    t.overrides["ydb.yaml:storage"],
@@ -215,6 +217,8 @@ func (t *ydbStartDBTask) Execute(nc *dag.NodeContext) error {
 			DatabasePath:    dbPath,
 			MemoryMB:        memMB,
 			CPUs:            cpus,
+			DiskType:        t.topology.Storage.DiskType,
+			AutoTune:        t.topology.AutoTune,
 			FaultTolerance:  ft,
 			StorageHosts:    staticHosts,
 			BlockDevicePath: firstBlockDevicePath(t.topology.Storage.SecondaryDisks),
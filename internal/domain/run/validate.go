@@ -2,8 +2,12 @@ package run
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/dbparams"
 	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 )
 
@@ -15,100 +19,344 @@ var scriptDBSupport = map[string][]types.DatabaseKind{
 	"tpcb/tx":    {types.DatabasePostgres, types.DatabaseMySQL, types.DatabasePicodata, types.DatabaseYDB},
 }
 
-// ValidateConfig checks RunConfig semantics before building the DAG.
+// Scripts lists the built-in stroppy scripts, sorted.
+func Scripts() []string {
+	out := make([]string, 0, len(scriptDBSupport))
+	for s := range scriptDBSupport {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ValidateConfig checks RunConfig semantics before building the DAG and
+// returns the errors CheckConfig finds as one error.
 func ValidateConfig(cfg types.RunConfig) error {
-	// Database kind must be set.
-	if cfg.Database.Kind == "" {
-		return fmt.Errorf("database.kind is required")
+	return types.IssuesError(CheckConfig(cfg))
+}
+
+// CheckConfig checks RunConfig semantics and returns every problem found:
+// errors that stop the run and warnings about legal but dubious choices. cfg
+// must already be upgraded to the current schema (types.UpgradeRunConfig).
+func CheckConfig(cfg types.RunConfig) []types.ConfigIssue {
+	c := &checker{}
+	checkDatabase(c, cfg)
+	checkStroppy(c, cfg)
+	for i, m := range cfg.Machines {
+		path := fmt.Sprintf("machines[%d]", i)
+		if !knownRole(m.Role) {
+			c.errorf(path+".role", "unknown role %q", m.Role)
+		}
+		checkMachine(c, path, m)
+	}
+	if cfg.MachineOverride != nil {
+		checkMachine(c, "machine_override", *cfg.MachineOverride)
+	}
+	checkTuning(c, cfg)
+	checkTags(c, cfg.Tags)
+	return c.issues
+}
+
+// checker collects the issues found in a config.
+type checker struct {
+	issues []types.ConfigIssue
+}
+
+func (c *checker) errorf(path, format string, args ...any) {
+	c.issues = append(c.issues, types.ConfigIssue{Path: path, Message: fmt.Sprintf(format, args...), Severity: types.SeverityError})
+}
+
+func (c *checker) warnf(path, format string, args ...any) {
+	c.issues = append(c.issues, types.ConfigIssue{Path: path, Message: fmt.Sprintf(format, args...), Severity: types.SeverityWarning})
+}
+
+func checkDatabase(c *checker, cfg types.RunConfig) {
+	db := cfg.Database
+	if db.Kind == "" {
+		c.errorf("database.kind", "required")
 	}
 
 	// At least one topology must be set (or preset_id).
-	if cfg.Database.Postgres == nil && cfg.Database.MySQL == nil && cfg.Database.Picodata == nil && cfg.Database.YDB == nil && cfg.PresetID == "" {
-		return fmt.Errorf("database topology or preset_id is required")
+	if db.Postgres == nil && db.MySQL == nil && db.Picodata == nil && db.YDB == nil && cfg.PresetID == "" {
+		c.errorf("database", "a topology or preset_id is required")
+	}
+
+	// Only the topology matching the kind may be set.
+	set := map[types.DatabaseKind]bool{
+		types.DatabasePostgres: db.Postgres != nil,
+		types.DatabaseMySQL:    db.MySQL != nil,
+		types.DatabasePicodata: db.Picodata != nil,
+		types.DatabaseYDB:      db.YDB != nil,
+	}
+	if _, known := set[db.Kind]; db.Kind != "" && !known {
+		c.errorf("database.kind", "unsupported database %q", db.Kind)
+	}
+	for _, kind := range []types.DatabaseKind{types.DatabasePostgres, types.DatabaseMySQL, types.DatabasePicodata, types.DatabaseYDB} {
+		if set[kind] && kind != db.Kind && db.Kind != "" {
+			c.errorf("database."+string(kind), "database.kind is %s but a %s topology is set", db.Kind, kind)
+		}
 	}
 
-	// Topology must match database kind.
-	switch cfg.Database.Kind {
+	switch db.Kind {
 	case types.DatabasePostgres:
-		if cfg.Database.MySQL != nil || cfg.Database.Picodata != nil {
-			return fmt.Errorf("database.kind is postgres but non-postgres topology is set")
+		if db.Postgres != nil {
+			checkPostgres(c, "database.postgres", db.Postgres)
+			checkAutoTune(c, "database.postgres.auto_tune", db.Postgres.AutoTune)
+			checkOptions(c, "database.postgres.master_options", db.Kind, db.Version, db.Postgres.MasterOptions)
+			checkOptions(c, "database.postgres.replica_options", db.Kind, db.Version, db.Postgres.ReplicaOptions)
 		}
 	case types.DatabaseMySQL:
-		if cfg.Database.Postgres != nil || cfg.Database.Picodata != nil {
-			return fmt.Errorf("database.kind is mysql but non-mysql topology is set")
+		if db.MySQL != nil {
+			checkMySQL(c, "database.mysql", db.MySQL)
+			checkAutoTune(c, "database.mysql.auto_tune", db.MySQL.AutoTune)
+			checkOptions(c, "database.mysql.primary_options", db.Kind, db.Version, db.MySQL.PrimaryOptions)
+			checkOptions(c, "database.mysql.replica_options", db.Kind, db.Version, db.MySQL.ReplicaOptions)
 		}
 	case types.DatabasePicodata:
-		if cfg.Database.Postgres != nil || cfg.Database.MySQL != nil {
-			return fmt.Errorf("database.kind is picodata but non-picodata topology is set")
+		if db.Picodata != nil {
+			checkPicodata(c, "database.picodata", db.Picodata)
+			checkAutoTune(c, "database.picodata.auto_tune", db.Picodata.AutoTune)
+			checkOptions(c, "database.picodata.instance_options", db.Kind, db.Version, db.Picodata.InstanceOptions)
 		}
 	case types.DatabaseYDB:
-		if cfg.Database.Postgres != nil || cfg.Database.MySQL != nil || cfg.Database.Picodata != nil {
-			return fmt.Errorf("database.kind is ydb but non-ydb topology is set")
+		if db.YDB != nil {
+			checkYDB(c, "database.ydb", db.YDB)
+			checkAutoTune(c, "database.ydb.auto_tune", db.YDB.AutoTune)
 		}
 	}
+}
 
-	// Script vs DB kind compatibility.
-	script := cfg.Stroppy.Script
-	if script == "" {
-		script = cfg.Stroppy.Workload // backward compat
+// checkAutoTune rejects unknown auto-tuning profile names.
+func checkAutoTune(c *checker, path, profile string) {
+	if profile != "" && !types.IsAutoTuneProfile(profile) {
+		c.errorf(path, "unknown profile %q (supported: %s)", profile, strings.Join(types.AutoTuneProfiles(), ", "))
 	}
-	if script != "" {
-		supported, known := scriptDBSupport[script]
-		if known {
-			found := false
-			for _, k := range supported {
-				if k == cfg.Database.Kind {
-					found = true
-					break
-				}
-			}
-			if !found {
-				supportedNames := make([]string, len(supported))
-				for i, k := range supported {
-					supportedNames[i] = string(k)
-				}
-				return fmt.Errorf("script %q is not compatible with database %q (supported: %s)",
-					script, cfg.Database.Kind, strings.Join(supportedNames, ", "))
+}
+
+// checkOptions checks a database option map against the parameter catalog
+// of its engine and version.
+func checkOptions(c *checker, path string, kind types.DatabaseKind, version string, options map[string]string) {
+	if len(options) == 0 {
+		return
+	}
+	catalog, ok := dbparams.Get(string(kind), version)
+	if !ok {
+		return
+	}
+	for _, is := range catalog.Check(options) {
+		is.Path = path + "." + is.Path
+		c.issues = append(c.issues, is)
+	}
+}
+
+func checkStroppy(c *checker, cfg types.RunConfig) {
+	s := cfg.Stroppy
+
+	// Script vs DB kind compatibility. Deprecated fields were converted by
+	// types.UpgradeRunConfig before we get here.
+	if supported, known := scriptDBSupport[s.Script]; known && cfg.Database.Kind != "" {
+		if !slices.Contains(supported, cfg.Database.Kind) {
+			names := make([]string, len(supported))
+			for i, k := range supported {
+				names[i] = string(k)
 			}
+			c.errorf("stroppy.script", "script %q is not compatible with database %q (supported: %s)",
+				s.Script, cfg.Database.Kind, strings.Join(names, ", "))
 		}
 	}
 
-	// Stroppy basic validation.
-	if cfg.Stroppy.Duration != "" {
-		d := cfg.Stroppy.Duration
-		// Must end with s, m, or h.
+	if d := s.Duration; d != "" {
 		if len(d) < 2 {
-			return fmt.Errorf("invalid duration %q", d)
-		}
-		suffix := d[len(d)-1]
-		if suffix != 's' && suffix != 'm' && suffix != 'h' {
-			return fmt.Errorf("duration %q must end with s, m, or h", d)
+			c.errorf("stroppy.duration", "invalid duration %q", d)
+		} else if suffix := d[len(d)-1]; suffix != 's' && suffix != 'm' && suffix != 'h' {
+			c.errorf("stroppy.duration", "duration %q must end with s, m, or h", d)
 		}
 	}
 
-	if cfg.Stroppy.VUs < 0 {
-		return fmt.Errorf("vus must be >= 0")
+	if s.VUs < 0 {
+		c.errorf("stroppy.vus", "must be >= 0")
+	}
+	if s.PoolSize < 0 {
+		c.errorf("stroppy.pool_size", "must be >= 0")
 	}
-	if cfg.Stroppy.PoolSize < 0 {
-		return fmt.Errorf("pool_size must be >= 0")
+	if s.ScaleFactor < 0 {
+		c.errorf("stroppy.scale_factor", "must be >= 0")
 	}
-	if cfg.Stroppy.ScaleFactor < 0 {
-		return fmt.Errorf("scale_factor must be >= 0")
+	if s.VUs > 0 && s.PoolSize > 0 && s.VUs > s.PoolSize {
+		c.warnf("stroppy.vus", "%d VUs share a pool of %d connections; VUs will wait for connections", s.VUs, s.PoolSize)
 	}
 
-	// Machine specs basic checks.
-	if cfg.Stroppy.Machine != nil {
-		m := cfg.Stroppy.Machine
+	if m := s.Machine; m != nil {
 		if m.CPUs < 1 {
-			return fmt.Errorf("stroppy machine cpus must be >= 1")
+			c.errorf("stroppy.machine.cpus", "must be >= 1")
 		}
 		if m.MemoryMB < 512 {
-			return fmt.Errorf("stroppy machine memory must be >= 512 MB")
+			c.errorf("stroppy.machine.memory_mb", "must be >= 512")
 		}
 		if m.DiskGB < 10 {
-			return fmt.Errorf("stroppy machine disk must be >= 10 GB")
+			c.errorf("stroppy.machine.disk_gb", "must be >= 10")
 		}
+		checkSecondaryDisks(c, "stroppy.machine", m.SecondaryDisks)
+	}
+}
+
+func knownRole(r types.MachineRole) bool {
+	switch r {
+	case types.RoleDatabase, types.RoleMonitor, types.RoleStroppy, types.RoleEtcd,
+		types.RoleProxy, types.RolePgBouncer, types.RoleBuilder:
+		return true
 	}
+	return false
+}
+
+var (
+	sysctlKeyRe  = regexp.MustCompile(`^[a-z0-9_\-]+(\.[a-z0-9_\-]+)+$`)
+	tuningWordRe = regexp.MustCompile(`^[a-z0-9\-+]+$`)
+	// ulimitValueRe accepts a number or "unlimited".
+	ulimitValueRe = regexp.MustCompile(`^([0-9]+|unlimited)$`)
+
+	thpModes    = map[string]bool{"always": true, "madvise": true, "never": true}
+	ulimitItems = map[string]bool{"nofile": true, "nproc": true, "memlock": true, "core": true, "stack": true}
+)
 
+// checkTuning checks custom tuning profiles and that every referenced profile exists.
+func checkTuning(c *checker, cfg types.RunConfig) {
+	seen := make(map[string]bool)
+	for i, p := range cfg.TuningProfiles {
+		path := fmt.Sprintf("tuning_profiles[%d]", i)
+		if p.Name == "" {
+			c.errorf(path+".name", "required")
+			continue
+		}
+		if seen[p.Name] {
+			c.errorf(path+".name", "duplicate profile %q", p.Name)
+		}
+		seen[p.Name] = true
+		if err := validateTuningProfile(p); err != nil {
+			c.errorf(path, "%v", err)
+		}
+	}
+
+	// Machines of one role are tuned alike, so their specs must agree.
+	byRole := make(map[types.MachineRole]pathSpec)
+	for _, m := range machineSpecs(cfg) {
+		if m.spec.TuningProfile == "" {
+			continue
+		}
+		if m.spec.Role != "" {
+			if first, ok := byRole[m.spec.Role]; !ok {
+				byRole[m.spec.Role] = m
+			} else if first.spec.TuningProfile != m.spec.TuningProfile {
+				c.errorf(m.path+".tuning_profile", "%q conflicts with %q of %s: machines of role %s share one tuning profile",
+					m.spec.TuningProfile, first.spec.TuningProfile, first.path, m.spec.Role)
+			}
+		}
+		if _, ok := types.ResolveTuningProfile(&cfg, m.spec.TuningProfile); !ok {
+			c.errorf(m.path+".tuning_profile", "unknown tuning profile %q (built-in: %s)",
+				m.spec.TuningProfile, strings.Join(types.TuningProfileNames(), ", "))
+		} else if cfg.Provider == types.ProviderDocker {
+			c.warnf(m.path+".tuning_profile", "ignored on docker: containers share the host kernel, so OS tuning is skipped")
+		}
+	}
+}
+
+// maxTagLen caps a run tag.
+const maxTagLen = 64
+
+// checkTags checks run tags: non-empty, unique, and free of commas and
+// whitespace so they can be listed on a command line.
+func checkTags(c *checker, tags []string) {
+	seen := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		path := fmt.Sprintf("tags[%d]", i)
+		switch {
+		case tag == "":
+			c.errorf(path, "empty tag")
+		case len(tag) > maxTagLen:
+			c.errorf(path, "longer than %d characters", maxTagLen)
+		case strings.ContainsAny(tag, ", \t\n"):
+			c.errorf(path, "tag %q contains a comma or whitespace", tag)
+		case seen[tag]:
+			c.errorf(path, "duplicate tag %q", tag)
+		}
+		seen[tag] = true
+	}
+}
+
+type pathSpec struct {
+	path string
+	spec types.MachineSpec
+}
+
+// machineSpecs lists every machine spec in the config with its path:
+// explicit machines, the override, the stroppy machine and all topology
+// components.
+func machineSpecs(cfg types.RunConfig) []pathSpec {
+	var specs []pathSpec
+	add := func(path string, m *types.MachineSpec) {
+		if m != nil {
+			specs = append(specs, pathSpec{path, *m})
+		}
+	}
+	list := func(path string, ms []types.MachineSpec) {
+		for i := range ms {
+			add(fmt.Sprintf("%s[%d]", path, i), &ms[i])
+		}
+	}
+	list("machines", cfg.Machines)
+	add("machine_override", cfg.MachineOverride)
+	add("stroppy.machine", cfg.Stroppy.Machine)
+	db := cfg.Database
+	if db.Postgres != nil {
+		add("database.postgres.master", &db.Postgres.Master)
+		list("database.postgres.replicas", db.Postgres.Replicas)
+		add("database.postgres.haproxy", db.Postgres.HAProxy)
+	}
+	if db.MySQL != nil {
+		add("database.mysql.primary", &db.MySQL.Primary)
+		list("database.mysql.replicas", db.MySQL.Replicas)
+		add("database.mysql.proxysql", db.MySQL.ProxySQL)
+	}
+	if db.Picodata != nil {
+		list("database.picodata.instances", db.Picodata.Instances)
+		add("database.picodata.haproxy", db.Picodata.HAProxy)
+	}
+	if db.YDB != nil {
+		add("database.ydb.storage", &db.YDB.Storage)
+		add("database.ydb.database", db.YDB.Database)
+		add("database.ydb.haproxy", db.YDB.HAProxy)
+	}
+	return specs
+}
+
+func validateTuningProfile(p types.TuningProfile) error {
+	for k, v := range p.Sysctls {
+		if !sysctlKeyRe.MatchString(k) {
+			return fmt.Errorf("invalid sysctl key %q", k)
+		}
+		if v == "" || strings.ContainsAny(v, "\n'") {
+			return fmt.Errorf("invalid value %q for sysctl %s", v, k)
+		}
+	}
+	if p.TransparentHugepages != "" && !thpModes[p.TransparentHugepages] {
+		return fmt.Errorf("transparent_hugepages must be always, madvise or never, got %q", p.TransparentHugepages)
+	}
+	if p.HugePagesPercent < 0 || p.HugePagesPercent > 90 {
+		return fmt.Errorf("huge_pages_percent must be between 0 and 90")
+	}
+	if p.IOScheduler != "" && !tuningWordRe.MatchString(p.IOScheduler) {
+		return fmt.Errorf("invalid io_scheduler %q", p.IOScheduler)
+	}
+	if p.CPUGovernor != "" && !tuningWordRe.MatchString(p.CPUGovernor) {
+		return fmt.Errorf("invalid cpu_governor %q", p.CPUGovernor)
+	}
+	for k, v := range p.Ulimits {
+		if !ulimitItems[k] {
+			return fmt.Errorf("unsupported ulimit %q (supported: core, memlock, nofile, nproc, stack)", k)
+		}
+		if !ulimitValueRe.MatchString(v) {
+			return fmt.Errorf("invalid value %q for ulimit %s", v, k)
+		}
+	}
 	return nil
 }
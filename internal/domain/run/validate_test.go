@@ -0,0 +1,250 @@
+package run
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// issuesAt returns the severities reported at path.
+func issuesAt(issues []types.ConfigIssue, path string) []types.Severity {
+	var out []types.Severity
+	for _, is := range issues {
+		if is.Path == path {
+			out = append(out, is.Severity)
+		}
+	}
+	return out
+}
+
+func presetCfg(p types.Preset) types.RunConfig {
+	return types.RunConfig{
+		Database: types.DatabaseConfig{
+			Kind: types.DatabaseKind(p.DbKind), Postgres: p.Postgres, MySQL: p.MySQL, Picodata: p.Picodata, YDB: p.YDB,
+		},
+		Stroppy: types.StroppyConfig{Script: "tpcc/tx", Duration: "60s", VUs: 8},
+	}
+}
+
+func TestCheckConfig_BuiltinPresetsHaveNoErrors(t *testing.T) {
+	for _, p := range types.BuiltinPresets() {
+		if err := types.IssuesError(CheckConfig(presetCfg(p))); err != nil {
+			t.Errorf("%s: %v", p.Name, err)
+		}
+	}
+}
+
+func TestCheckConfig_ReportsAllProblems(t *testing.T) {
+	cfg := postgresSingleCfg()
+	cfg.Stroppy = types.StroppyConfig{Script: "tpcc/tx", Duration: "5x", VUs: -1}
+	cfg.Machines = []types.MachineSpec{{Role: "wizard", Count: 1, CPUs: -2}}
+
+	issues := CheckConfig(cfg)
+	for _, path := range []string{"stroppy.duration", "stroppy.vus", "machines[0].role", "machines[0].cpus"} {
+		if got := issuesAt(issues, path); len(got) != 1 || got[0] != types.SeverityError {
+			t.Errorf("%s: got %v in %v", path, got, issues)
+		}
+	}
+	if err := ValidateConfig(cfg); err == nil || !strings.HasPrefix(err.Error(), "4 problems") {
+		t.Errorf("ValidateConfig() = %v", err)
+	}
+}
+
+func TestCheckConfig_Tags(t *testing.T) {
+	cfg := postgresSingleCfg()
+	cfg.Tags = []string{"nightly", "", "a,b", "nightly", "postgres-16"}
+
+	issues := CheckConfig(cfg)
+	for _, path := range []string{"tags[1]", "tags[2]", "tags[3]"} {
+		if got := issuesAt(issues, path); len(got) != 1 || got[0] != types.SeverityError {
+			t.Errorf("%s: got %v in %v", path, got, issues)
+		}
+	}
+	for _, path := range []string{"tags[0]", "tags[4]"} {
+		if got := issuesAt(issues, path); len(got) != 0 {
+			t.Errorf("%s: unexpected %v", path, got)
+		}
+	}
+}
+
+func TestCheckConfig_PostgresSyncReplicas(t *testing.T) {
+	cfg := postgresSingleCfg()
+	topo := types.PostgresPresets[types.PostgresHA]
+	topo.SyncReplicas = replicaNodes(topo.Replicas) + 1
+	cfg.Database.Postgres = &topo
+
+	got := issuesAt(CheckConfig(cfg), "database.postgres.sync_replicas")
+	if len(got) != 1 || got[0] != types.SeverityError {
+		t.Errorf("sync_replicas above the replica count: %v", got)
+	}
+
+	topo.SyncReplicas = 1
+	topo.Patroni = false
+	topo.Etcd = false
+	got = issuesAt(CheckConfig(cfg), "database.postgres.sync_replicas")
+	if len(got) != 1 || got[0] != types.SeverityWarning {
+		t.Errorf("sync_replicas without patroni: %v", got)
+	}
+}
+
+func TestCheckConfig_YDBFaultTolerance(t *testing.T) {
+	topo := types.YDBTopology{
+		Storage:        types.MachineSpec{Role: types.RoleDatabase, Count: 3, CPUs: 4, MemoryMB: 8192, DiskGB: 50},
+		FaultTolerance: "block-4-2",
+	}
+	cfg := types.RunConfig{Database: types.DatabaseConfig{Kind: types.DatabaseYDB, YDB: &topo}}
+
+	if got := issuesAt(CheckConfig(cfg), "database.ydb.fault_tolerance"); len(got) != 1 || got[0] != types.SeverityError {
+		t.Errorf("block-4-2 on 3 nodes: %v", got)
+	}
+	topo.FaultTolerance = "mirror-3-dc"
+	if err := ValidateConfig(cfg); err != nil {
+		t.Errorf("mirror-3-dc on 3 nodes: %v", err)
+	}
+	topo.FaultTolerance = "raid5"
+	if err := ValidateConfig(cfg); err == nil {
+		t.Error("expected error for unknown fault tolerance")
+	}
+}
+
+func TestCheckConfig_Picodata(t *testing.T) {
+	topo := types.PicodataTopology{
+		Instances:   []types.MachineSpec{{Role: types.RoleDatabase, Count: 3, CPUs: 2, MemoryMB: 4096, DiskGB: 50}},
+		Replication: 2,
+	}
+	cfg := types.RunConfig{Database: types.DatabaseConfig{Kind: types.DatabasePicodata, Picodata: &topo}}
+
+	issues := CheckConfig(cfg)
+	if err := types.IssuesError(issues); err != nil {
+		t.Fatalf("3 instances with rf 2 must only warn: %v", err)
+	}
+	if got := issuesAt(issues, "database.picodata.replication_factor"); len(got) != 1 || got[0] != types.SeverityWarning {
+		t.Errorf("replication_factor: %v", got)
+	}
+
+	topo.Tiers = []types.PicodataTier{{Name: "default", Count: 2, CanVote: true}, {Name: "default", Count: 2}}
+	issues = CheckConfig(cfg)
+	if got := issuesAt(issues, "database.picodata.tiers"); len(got) != 1 || got[0] != types.SeverityError {
+		t.Errorf("tier counts 4 != 3 instances: %v", got)
+	}
+	if got := issuesAt(issues, "database.picodata.tiers[1].name"); len(got) != 1 {
+		t.Errorf("duplicate tier name: %v", got)
+	}
+}
+
+func TestCheckConfig_SecondaryDisks(t *testing.T) {
+	topo := types.YDBPresets[types.YDBSingle]
+	topo.Storage.SecondaryDisks = []types.SecondaryDisk{
+		{DeviceName: "ydb-data", SizeGB: 100},
+		{DeviceName: "ydb-data", SizeGB: 100},
+		{DeviceName: "Data_1", SizeGB: 0},
+	}
+	cfg := types.RunConfig{Database: types.DatabaseConfig{Kind: types.DatabaseYDB, YDB: &topo}}
+
+	issues := CheckConfig(cfg)
+	for _, path := range []string{
+		"database.ydb.storage.secondary_disks[1].device_name",
+		"database.ydb.storage.secondary_disks[2].device_name",
+		"database.ydb.storage.secondary_disks[2].size_gb",
+	} {
+		if got := issuesAt(issues, path); len(got) != 1 || got[0] != types.SeverityError {
+			t.Errorf("%s: %v", path, got)
+		}
+	}
+	if got := issuesAt(issues, "database.ydb.storage.secondary_disks"); len(got) != 1 || got[0] != types.SeverityWarning {
+		t.Errorf("extra disks: %v", got)
+	}
+}
+
+func TestCheckConfig_OptionsAgainstCatalog(t *testing.T) {
+	cfg := postgresSingleCfg()
+	topo := *cfg.Database.Postgres
+	topo.MasterOptions = map[string]string{"shared_buffer": "1GB", "work_mem": "128MB"}
+	cfg.Database.Postgres = &topo
+
+	issues := CheckConfig(cfg)
+	if got := issuesAt(issues, "database.postgres.master_options.shared_buffer"); len(got) != 1 || got[0] != types.SeverityError {
+		t.Errorf("misspelled option: %v", issues)
+	}
+	if got := issuesAt(issues, "database.postgres.master_options.work_mem"); len(got) != 0 {
+		t.Errorf("valid option reported: %v", issues)
+	}
+}
+
+func TestCheckConfig_AutoTune(t *testing.T) {
+	cfg := postgresSingleCfg()
+	topo := *cfg.Database.Postgres
+	topo.AutoTune = "turbo"
+	cfg.Database.Postgres = &topo
+
+	if got := issuesAt(CheckConfig(cfg), "database.postgres.auto_tune"); len(got) != 1 || got[0] != types.SeverityError {
+		t.Errorf("unknown profile: %v", got)
+	}
+
+	// The dry-run preview shows the tuned values, not the capped defaults.
+	topo.AutoTune = types.AutoTuneOLTP
+	topo.Master.MemoryMB = 32768
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	conf := BuildRenderedConfigs(&cfg)["postgresql.conf:master"]
+	if !strings.Contains(conf, "shared_buffers = 8GB\n") {
+		t.Errorf("rendered config is not tuned:\n%s", conf)
+	}
+}
+
+func TestCheckConfig_TuningOnDocker(t *testing.T) {
+	cfg := postgresSingleCfg()
+	topo := *cfg.Database.Postgres
+	topo.Master.TuningProfile = types.TuningDefault
+	cfg.Database.Postgres = &topo
+
+	path := "database.postgres.master.tuning_profile"
+	if got := issuesAt(CheckConfig(cfg), path); len(got) != 1 || got[0] != types.SeverityWarning {
+		t.Errorf("docker: %v", got)
+	}
+	cfg.Provider = types.ProviderYandex
+	if got := issuesAt(CheckConfig(cfg), path); len(got) != 0 {
+		t.Errorf("yandex: %v", got)
+	}
+}
+
+func TestValidateConfig_Tuning(t *testing.T) {
+	cfg := postgresSingleCfg()
+	topo := *cfg.Database.Postgres
+	topo.Master.TuningProfile = types.TuningPostgresOLTP
+	cfg.Database.Postgres = &topo
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("built-in profile should validate: %v", err)
+	}
+
+	topo.Master.TuningProfile = "custom"
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected error for undefined profile")
+	}
+
+	cfg.TuningProfiles = []types.TuningProfile{{Name: "custom", Sysctls: map[string]string{"vm.swappiness": "10"}}}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("custom profile should validate: %v", err)
+	}
+
+	cfg.TuningProfiles[0].TransparentHugepages = "sometimes"
+	if err := ValidateConfig(cfg); err == nil {
+		t.Fatal("expected error for invalid THP mode")
+	}
+
+	// Machines of one role must agree on their profile.
+	cfg.Machines = []types.MachineSpec{
+		{Role: types.RoleDatabase, Count: 1, TuningProfile: "custom"},
+		{Role: types.RoleDatabase, Count: 2, TuningProfile: types.TuningPostgresOLTP},
+	}
+	cfg.TuningProfiles[0].TransparentHugepages = ""
+	if err := ValidateConfig(cfg); err == nil || !strings.Contains(err.Error(), "machines[1].tuning_profile") {
+		t.Fatalf("conflicting profiles of role database: err = %v, want one at machines[1]", err)
+	}
+	cfg.Machines[1].TuningProfile = "custom"
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("matching profiles should validate: %v", err)
+	}
+}
@@ -0,0 +1,270 @@
+package run
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// Topology checks: node counts that cannot form the requested cluster are
+// errors; layouts that deploy but waste nodes or silently ignore a setting
+// are warnings.
+
+// ydbMinStorageNodes is the number of storage nodes (fail domains) each YDB
+// erasure needs.
+var ydbMinStorageNodes = map[string]int{
+	"":            1,
+	"none":        1,
+	"block-4-2":   8,
+	"mirror-3-dc": 3,
+}
+
+// mysqlGroupMaxMembers is the Group Replication member limit.
+const mysqlGroupMaxMembers = 9
+
+// diskNameRe matches device names the cloud accepts; the agent finds the
+// disk in the guest as /dev/disk/by-id/virtio-<name>.
+var diskNameRe = regexp.MustCompile(`^[a-z]([-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// checkMachine checks the sizes of one machine spec.
+func checkMachine(c *checker, path string, m types.MachineSpec) {
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"count", m.Count}, {"cpus", m.CPUs}, {"memory_mb", m.MemoryMB}, {"disk_gb", m.DiskGB},
+	} {
+		if f.value < 0 {
+			c.errorf(path+"."+f.name, "must be >= 0")
+		}
+	}
+	checkSecondaryDisks(c, path, m.SecondaryDisks)
+}
+
+// checkNode checks a topology component that must have at least min nodes.
+func checkNode(c *checker, path string, m types.MachineSpec, min int) {
+	checkMachine(c, path, m)
+	if m.Count < min && m.Count >= 0 {
+		c.errorf(path+".count", "at least %d required, got %d", min, m.Count)
+	}
+}
+
+func checkSecondaryDisks(c *checker, path string, disks []types.SecondaryDisk) {
+	seen := make(map[string]bool, len(disks))
+	for i, d := range disks {
+		p := fmt.Sprintf("%s.secondary_disks[%d]", path, i)
+		switch {
+		case d.DeviceName == "":
+			c.errorf(p+".device_name", "required")
+		case !diskNameRe.MatchString(d.DeviceName):
+			c.errorf(p+".device_name", "%q must be lowercase letters, digits and dashes, starting with a letter", d.DeviceName)
+		case seen[d.DeviceName]:
+			c.errorf(p+".device_name", "duplicate device name %q; names must be unique per machine", d.DeviceName)
+		}
+		seen[d.DeviceName] = true
+		if d.SizeGB < 1 {
+			c.errorf(p+".size_gb", "must be >= 1")
+		}
+	}
+}
+
+// warnUnusedDisks flags secondary disks on engines that do not use them.
+func warnUnusedDisks(c *checker, kind types.DatabaseKind, specs ...pathSpec) {
+	for _, s := range specs {
+		if len(s.spec.SecondaryDisks) > 0 {
+			c.warnf(s.path+".secondary_disks", "attached but unused: only ydb puts data on secondary disks, not %s", kind)
+		}
+	}
+}
+
+func replicaNodes(replicas []types.MachineSpec) int {
+	n := 0
+	for _, r := range replicas {
+		n += r.Count
+	}
+	return n
+}
+
+func checkReplicas(c *checker, path string, replicas []types.MachineSpec) []pathSpec {
+	var specs []pathSpec
+	for i, r := range replicas {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		checkMachine(c, p, r)
+		if r.Count == 0 {
+			c.warnf(p+".count", "0 nodes; the entry deploys nothing")
+		}
+		specs = append(specs, pathSpec{p, r})
+	}
+	return specs
+}
+
+func checkPostgres(c *checker, path string, t *types.PostgresTopology) {
+	checkNode(c, path+".master", t.Master, 1)
+	specs := append([]pathSpec{{path + ".master", t.Master}}, checkReplicas(c, path+".replicas", t.Replicas)...)
+	replicas := replicaNodes(t.Replicas)
+	if t.HAProxy != nil {
+		checkNode(c, path+".haproxy", *t.HAProxy, 1)
+		if replicas == 0 {
+			c.warnf(path+".haproxy", "load balancer in front of a single node only adds a network hop")
+		}
+	}
+
+	switch {
+	case t.SyncReplicas < 0:
+		c.errorf(path+".sync_replicas", "must be >= 0")
+	case t.SyncReplicas > replicas:
+		c.errorf(path+".sync_replicas", "%d synchronous replicas requested but only %d replicas are deployed", t.SyncReplicas, replicas)
+	case t.SyncReplicas > 0 && !t.Patroni:
+		c.warnf(path+".sync_replicas", "ignored: synchronous replication is configured by patroni, which is off")
+	}
+	if t.Patroni && !t.Etcd {
+		c.errorf(path+".patroni", "patroni stores cluster state in etcd; set etcd: true")
+	}
+	if t.Patroni && replicas == 0 {
+		c.warnf(path+".patroni", "no replicas to fail over to")
+	}
+	if t.Etcd && !t.Patroni {
+		c.warnf(path+".etcd", "etcd is deployed but only patroni uses it")
+	}
+	warnUnusedDisks(c, types.DatabasePostgres, specs...)
+}
+
+func checkMySQL(c *checker, path string, t *types.MySQLTopology) {
+	checkNode(c, path+".primary", t.Primary, 1)
+	specs := append([]pathSpec{{path + ".primary", t.Primary}}, checkReplicas(c, path+".replicas", t.Replicas)...)
+	replicas := replicaNodes(t.Replicas)
+	if t.ProxySQL != nil {
+		checkNode(c, path+".proxysql", *t.ProxySQL, 1)
+		if replicas == 0 {
+			c.warnf(path+".proxysql", "proxy in front of a single node only adds a network hop")
+		}
+	}
+
+	if t.GroupRepl {
+		members := t.Primary.Count + replicas
+		switch {
+		case members > mysqlGroupMaxMembers:
+			c.errorf(path+".group_replication", "a group has at most %d members, got %d nodes", mysqlGroupMaxMembers, members)
+		case members < 3:
+			c.warnf(path+".group_replication", "a group of %d cannot tolerate a failure; use at least 3 nodes", members)
+		}
+		if t.SemiSync {
+			c.warnf(path+".semi_sync", "ignored with group_replication")
+		}
+	} else if t.SemiSync && replicas == 0 {
+		c.warnf(path+".semi_sync", "no replicas: every commit waits for the semi-sync timeout")
+	}
+	warnUnusedDisks(c, types.DatabaseMySQL, specs...)
+}
+
+func checkPicodata(c *checker, path string, t *types.PicodataTopology) {
+	var specs []pathSpec
+	total := 0
+	for i, inst := range t.Instances {
+		p := fmt.Sprintf("%s.instances[%d]", path, i)
+		checkMachine(c, p, inst)
+		total += inst.Count
+		specs = append(specs, pathSpec{p, inst})
+	}
+	if total < 1 {
+		c.errorf(path+".instances", "at least one instance is required")
+		return
+	}
+	if t.HAProxy != nil {
+		checkNode(c, path+".haproxy", *t.HAProxy, 1)
+	}
+	if t.Shards < 0 {
+		c.errorf(path+".shards", "must be >= 0")
+	}
+
+	// The agent renders replication_factor 2 when it is unset.
+	rf, rfPath := t.Replication, path+".replication_factor"
+	switch {
+	case rf < 0:
+		c.errorf(rfPath, "must be >= 0")
+	case rf == 0:
+		if total < 2 {
+			c.warnf(rfPath, "unset, so the default of 2 applies, but there is only %d instance", total)
+		}
+	case rf > total:
+		c.errorf(rfPath, "%d replicas per replicaset need at least %d instances, got %d", rf, rf, total)
+	case total%rf != 0:
+		c.warnf(rfPath, "%d instances do not split into replicasets of %d; the last replicaset is incomplete", total, rf)
+	}
+
+	if len(t.Tiers) > 0 {
+		checkPicodataTiers(c, path+".tiers", t.Tiers, total)
+	}
+	warnUnusedDisks(c, types.DatabasePicodata, specs...)
+}
+
+func checkPicodataTiers(c *checker, path string, tiers []types.PicodataTier, instances int) {
+	seen := make(map[string]bool, len(tiers))
+	sum, voters := 0, 0
+	for i, tier := range tiers {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case tier.Name == "":
+			c.errorf(p+".name", "required")
+		case seen[tier.Name]:
+			c.errorf(p+".name", "duplicate tier %q", tier.Name)
+		}
+		seen[tier.Name] = true
+		if tier.Count < 1 {
+			c.errorf(p+".count", "must be >= 1")
+			continue
+		}
+		sum += tier.Count
+		if tier.CanVote {
+			voters++
+		}
+		rf := tier.Replication
+		switch {
+		case rf < 0:
+			c.errorf(p+".replication_factor", "must be >= 0")
+		case rf > tier.Count:
+			c.errorf(p+".replication_factor", "%d replicas per replicaset need at least %d instances in the tier, got %d", rf, rf, tier.Count)
+		case rf > 0 && tier.Count%rf != 0:
+			c.warnf(p+".replication_factor", "%d instances do not split into replicasets of %d", tier.Count, rf)
+		}
+	}
+	if sum != instances {
+		c.errorf(path, "tier counts add up to %d but %d instances are deployed", sum, instances)
+	}
+	if voters == 0 {
+		c.warnf(path, "no tier has can_vote; the cluster has no raft voters")
+	}
+}
+
+func checkYDB(c *checker, path string, t *types.YDBTopology) {
+	checkNode(c, path+".storage", t.Storage, 1)
+	if t.Database != nil {
+		checkNode(c, path+".database", *t.Database, 1)
+	}
+	if t.HAProxy != nil {
+		checkNode(c, path+".haproxy", *t.HAProxy, 1)
+	}
+
+	ftPath := path + ".fault_tolerance"
+	if min, ok := ydbMinStorageNodes[t.FaultTolerance]; !ok {
+		c.errorf(ftPath, "unknown fault tolerance %q (supported: none, block-4-2, mirror-3-dc)", t.FaultTolerance)
+	} else if t.Storage.Count >= 1 && t.Storage.Count < min {
+		c.errorf(ftPath, "%s needs at least %d storage nodes, got %d", t.FaultTolerance, min, t.Storage.Count)
+	}
+	if t.DatabasePath != "" && !strings.HasPrefix(t.DatabasePath, "/") {
+		c.errorf(path+".database_path", "must be absolute, e.g. /Root/testdb")
+	}
+	for _, o := range []struct {
+		name string
+		opts map[string]string
+	}{{"storage_options", t.StorageOptions}, {"database_options", t.DatabaseOptions}} {
+		if len(o.opts) > 0 {
+			c.warnf(path+"."+o.name, "not applied: the ydb config is not rendered from options; use database.rendered_config_overrides")
+		}
+	}
+	if len(t.Storage.SecondaryDisks) > 1 {
+		c.warnf(path+".storage.secondary_disks", "only the first disk holds the pdisk; the others stay unused")
+	}
+}
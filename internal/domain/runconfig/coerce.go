@@ -0,0 +1,116 @@
+package runconfig
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// coerceDocument converts scalars to the kinds RunConfig expects. YAML types
+// values by their look, so `version: 17` is a number and `cpus: ${CPUS}` a
+// string; both are fixed up here. Unknown keys are left alone.
+func coerceDocument(doc map[string]any) map[string]any {
+	out, _ := coerce(doc, reflect.TypeOf(types.RunConfig{})).(map[string]any)
+	return out
+}
+
+func coerce(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if v == nil || t == rawMessageType {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		fields := jsonFields(t)
+		for k, item := range m {
+			if ft, ok := fields[k]; ok {
+				m[k] = coerce(item, ft)
+			}
+		}
+		return m
+	case reflect.Map:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for k, item := range m {
+			m[k] = coerce(item, t.Elem())
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		s, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i, item := range s {
+			s[i] = coerce(item, t.Elem())
+		}
+		return s
+	case reflect.String:
+		switch v := v.(type) {
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s, ok := v.(string); ok {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return json.Number(strings.TrimSpace(s))
+			}
+		}
+	case reflect.Bool:
+		if s, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(s); err == nil {
+				return b
+			}
+		}
+	}
+	return v
+}
+
+// jsonFields maps the JSON names of a struct's fields (including embedded
+// ones) to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	out := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for k, v := range jsonFields(ft) {
+					out[k] = v
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		out[name] = f.Type
+	}
+	return out
+}
@@ -0,0 +1,258 @@
+// Package runconfig loads RunConfig files. Files are YAML or JSON, may use
+// ${VAR} references to the environment, and compose through "extends" (a base
+// config this file overrides) and "include" (fragments merged in order).
+// --set style path=value overrides are applied last.
+package runconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// Composition keys; they are removed from the resolved document.
+const (
+	KeyExtends = "extends"
+	KeyInclude = "include"
+)
+
+// Options controls how a config file is resolved.
+type Options struct {
+	// Set holds path=value overrides applied after composition, e.g.
+	// "database.version=17" or "stroppy.vus=64". Values are parsed as YAML
+	// scalars or flow collections.
+	Set []string
+	// LookupEnv resolves ${VAR} references; os.LookupEnv when nil.
+	LookupEnv func(string) (string, bool)
+}
+
+// Load reads the config at path and decodes the resolved document.
+func Load(path string, opts Options) (types.RunConfig, error) {
+	doc, err := LoadDocument(path, opts)
+	if err != nil {
+		return types.RunConfig{}, err
+	}
+	return Decode(doc)
+}
+
+// LoadDocument reads the config at path and returns the resolved document:
+// variables substituted, extends/include merged, overrides applied, and
+// scalars converted to the types RunConfig expects.
+func LoadDocument(path string, opts Options) (map[string]any, error) {
+	l := &loader{lookup: opts.LookupEnv, loading: map[string]bool{}}
+	if l.lookup == nil {
+		l.lookup = os.LookupEnv
+	}
+	doc, err := l.load(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range opts.Set {
+		if err := ApplySet(doc, s); err != nil {
+			return nil, err
+		}
+	}
+	return coerceDocument(doc), nil
+}
+
+// Decode converts a resolved document into a RunConfig.
+func Decode(doc map[string]any) (types.RunConfig, error) {
+	var cfg types.RunConfig
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return cfg, fmt.Errorf("runconfig: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("runconfig: decode: %w", err)
+	}
+	return cfg, nil
+}
+
+type loader struct {
+	lookup  func(string) (string, bool)
+	loading map[string]bool // files on the current extends/include chain
+}
+
+func (l *loader) load(path string) (map[string]any, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if l.loading[abs] {
+		return nil, fmt.Errorf("runconfig: %s extends or includes itself", path)
+	}
+	l.loading[abs] = true
+	defer delete(l.loading, abs)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("runconfig: read %s: %w", path, err)
+	}
+	doc, err := parse(data, l.lookup)
+	if err != nil {
+		return nil, fmt.Errorf("runconfig: %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	extends, err := pathList(doc, KeyExtends)
+	if err != nil {
+		return nil, fmt.Errorf("runconfig: %s: %w", path, err)
+	}
+	if len(extends) > 1 {
+		return nil, fmt.Errorf("runconfig: %s: %s takes a single file, use %s for more", path, KeyExtends, KeyInclude)
+	}
+	includes, err := pathList(doc, KeyInclude)
+	if err != nil {
+		return nil, fmt.Errorf("runconfig: %s: %w", path, err)
+	}
+
+	// Precedence, lowest first: base, includes in order, this file.
+	out := map[string]any{}
+	for _, p := range append(extends, includes...) {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(dir, p)
+		}
+		part, err := l.load(p)
+		if err != nil {
+			return nil, err
+		}
+		out = Merge(out, part)
+	}
+	return Merge(out, doc), nil
+}
+
+// pathList removes key from doc and returns its file list (a string or a
+// list of strings).
+func pathList(doc map[string]any, key string) ([]string, error) {
+	v, ok := doc[key]
+	if !ok {
+		return nil, nil
+	}
+	delete(doc, key)
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must list file paths", key)
+			}
+			out = append(out, s)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s must be a file path or a list of them", key)
+}
+
+// Merge applies patch over base with JSON merge patch semantics (RFC 7386):
+// objects merge key by key, null deletes a key, anything else replaces.
+func Merge(base, patch map[string]any) map[string]any {
+	if base == nil {
+		base = map[string]any{}
+	}
+	for k, pv := range patch {
+		if pv == nil {
+			delete(base, k)
+			continue
+		}
+		pm, pIsMap := pv.(map[string]any)
+		bm, bIsMap := base[k].(map[string]any)
+		if pIsMap && bIsMap {
+			base[k] = Merge(bm, pm)
+		} else if pIsMap {
+			base[k] = Merge(nil, pm)
+		} else {
+			base[k] = pv
+		}
+	}
+	return base
+}
+
+// ApplySet applies one path=value override. Path segments are separated by
+// dots; a numeric segment indexes a list (the list's length appends).
+func ApplySet(doc map[string]any, set string) error {
+	path, raw, ok := strings.Cut(set, "=")
+	if !ok || path == "" {
+		return fmt.Errorf("runconfig: --set %q: want path=value", set)
+	}
+	value, err := parseValue(raw)
+	if err != nil {
+		return fmt.Errorf("runconfig: --set %s: %w", path, err)
+	}
+	keys := strings.Split(path, ".")
+	var cur any = doc
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch node := cur.(type) {
+		case map[string]any:
+			if last {
+				node[key] = value
+				return nil
+			}
+			next, ok := node[key]
+			if !ok || next == nil {
+				next = map[string]any{}
+				node[key] = next
+			}
+			cur = next
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return fmt.Errorf("runconfig: --set %s: %q is not an index of %s (length %d)",
+					path, key, strings.Join(keys[:i], "."), len(node))
+			}
+			if last {
+				node[idx] = value
+				return nil
+			}
+			cur = node[idx]
+		default:
+			return fmt.Errorf("runconfig: --set %s: %s is not an object or list", path, strings.Join(keys[:i], "."))
+		}
+	}
+	return nil
+}
+
+// parseValue reads a --set value as YAML, so "8", "true", "[a, b]" and
+// "{cpus: 4}" keep their type. Anything else is a plain string.
+func parseValue(raw string) (any, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &node); err != nil || len(node.Content) == 0 {
+		return raw, nil
+	}
+	return fromNode(node.Content[0], nil)
+}
+
+// Marshal renders a resolved document as YAML or, for format "json",
+// indented JSON.
+func Marshal(doc map[string]any, format string) ([]byte, error) {
+	if format == "json" {
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlValue(doc)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), enc.Close()
+}
+
+// FormatOf guesses the format of a config file from its extension.
+func FormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	}
+	return "json"
+}
@@ -0,0 +1,163 @@
+package runconfig
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func TestLoad_YAMLExtendsIncludeSet(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base/pg.yaml": `
+provider: docker
+network: {cidr: 10.10.0.0/24}
+database:
+  kind: postgres
+  version: 16
+  postgres:
+    master: {role: database, count: 1, cpus: 2, memory_mb: 4096, disk_gb: 20}
+    master_options:
+      max_connections: 200
+      shared_buffers: 1GB
+stroppy:
+  version: "4.1.0"
+  script: tpcc/procs
+  duration: 10m
+  vus: 16
+`,
+		"fragments/monitor.yaml": `monitor: {metrics_endpoint: "${METRICS_URL:-http://vm:8428/api/v1/write}"}`,
+		"exp.yaml": `
+extends: base/pg.yaml
+include: [fragments/monitor.yaml]
+database:
+  version: ${PG_VERSION}
+  postgres:
+    master: {cpus: "${CPUS}"}
+    master_options: {shared_buffers: null}
+stroppy: {duration: 30m}
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "exp.yaml"), Options{
+		Set:       []string{"stroppy.vus=64", "database.postgres.master.disk_gb=50"},
+		LookupEnv: env(map[string]string{"PG_VERSION": "17", "CPUS": "8"}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := cfg.Database
+	if db.Kind != "postgres" || db.Version != "17" {
+		t.Errorf("database = %s %s", db.Kind, db.Version)
+	}
+	m := db.Postgres.Master
+	if m.CPUs != 8 || m.MemoryMB != 4096 || m.DiskGB != 50 {
+		t.Errorf("master = %+v", m)
+	}
+	if got := db.Postgres.MasterOptions; got["max_connections"] != "200" || len(got) != 1 {
+		t.Errorf("master_options = %v (null must delete, numbers become strings)", got)
+	}
+	if cfg.Stroppy.Duration != "30m" || cfg.Stroppy.VUs != 64 || cfg.Stroppy.Script != "tpcc/procs" {
+		t.Errorf("stroppy = %+v", cfg.Stroppy)
+	}
+	if cfg.Monitor.MetricsEndpoint != "http://vm:8428/api/v1/write" {
+		t.Errorf("include with default: %q", cfg.Monitor.MetricsEndpoint)
+	}
+}
+
+func TestLoad_JSONStillWorks(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"run.json": `{"database": {"kind": "mysql", "version": "8.0"}, "stroppy": {"vus": 4}}`,
+	})
+	cfg, err := Load(filepath.Join(dir, "run.json"), Options{LookupEnv: env(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Version != "8.0" || cfg.Stroppy.VUs != 4 {
+		t.Errorf("got %+v", cfg)
+	}
+}
+
+func TestLoad_VersionKeepsLiteral(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"run.yaml": "database: {kind: mysql, version: 8.0}\n"})
+	cfg, err := Load(filepath.Join(dir, "run.yaml"), Options{LookupEnv: env(nil)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.Version != "8.0" {
+		t.Errorf("version = %q, want 8.0", cfg.Database.Version)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.yaml":       "extends: b.yaml\n",
+		"b.yaml":       "extends: a.yaml\n",
+		"vars.yaml":    "id: ${RUN_ID}\nprovider: ${PROVIDER}\n",
+		"escaped.yaml": "id: $${RUN_ID}\n",
+	})
+	if _, err := Load(filepath.Join(dir, "a.yaml"), Options{LookupEnv: env(nil)}); err == nil || !strings.Contains(err.Error(), "itself") {
+		t.Errorf("cycle: got %v", err)
+	}
+	_, err := Load(filepath.Join(dir, "vars.yaml"), Options{LookupEnv: env(nil)})
+	if err == nil || !strings.Contains(err.Error(), "PROVIDER, RUN_ID") {
+		t.Errorf("undefined variables must all be reported, got %v", err)
+	}
+	cfg, err := Load(filepath.Join(dir, "escaped.yaml"), Options{LookupEnv: env(nil)})
+	if err != nil || cfg.ID != "${RUN_ID}" {
+		t.Errorf("escaped reference: %q, %v", cfg.ID, err)
+	}
+}
+
+func TestApplySet(t *testing.T) {
+	doc := map[string]any{"machines": []any{map[string]any{"cpus": 2}}}
+	for _, s := range []string{"machines.0.cpus=4", "stroppy.steps=[create_schema, workload]", "id=run-1"} {
+		if err := ApplySet(doc, s); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+	}
+	cfg, err := Decode(coerceDocument(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Machines[0].CPUs != 4 || len(cfg.Stroppy.Steps) != 2 || cfg.ID != "run-1" {
+		t.Errorf("got %+v", cfg)
+	}
+	for _, bad := range []string{"novalue", "machines.5.cpus=1", "id.x=1"} {
+		if err := ApplySet(doc, bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestMarshal_PrintsNumbers(t *testing.T) {
+	out, err := Marshal(map[string]any{"stroppy": map[string]any{"vus": 8}, "database": map[string]any{"version": "17"}}, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "vus: 8\n") || !strings.Contains(string(out), `version: "17"`) {
+		t.Errorf("yaml output:\n%s", out)
+	}
+}
@@ -0,0 +1,235 @@
+package runconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parse reads a YAML or JSON document (JSON is valid YAML) into generic
+// values. Numbers keep their literal as json.Number, so "8.0" stays "8.0"
+// when it lands in a string field. ${VAR} references in string values are
+// resolved with lookup.
+func parse(data []byte, lookup func(string) (string, bool)) (map[string]any, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 {
+		return map[string]any{}, nil
+	}
+	env := &substituter{lookup: lookup}
+	v, err := fromNode(root.Content[0], env)
+	if err != nil {
+		return nil, err
+	}
+	if err := env.err(); err != nil {
+		return nil, err
+	}
+	doc, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("top level must be an object")
+	}
+	return doc, nil
+}
+
+// fromNode converts a YAML node to map[string]any / []any / scalars. env may
+// be nil to leave strings as they are.
+func fromNode(n *yaml.Node, env *substituter) (any, error) {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return fromNode(n.Content[0], env)
+	case yaml.AliasNode:
+		return fromNode(n.Alias, env)
+	case yaml.MappingNode:
+		m := make(map[string]any, len(n.Content)/2)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k, v := n.Content[i], n.Content[i+1]
+			if k.Value == "<<" && k.Tag == "!!merge" {
+				// YAML merge key: the mapping's own keys win.
+				merged, err := fromNode(v, env)
+				if err != nil {
+					return nil, err
+				}
+				for _, src := range mergeSources(merged) {
+					for mk, mv := range src {
+						if _, ok := m[mk]; !ok {
+							m[mk] = mv
+						}
+					}
+				}
+				continue
+			}
+			val, err := fromNode(v, env)
+			if err != nil {
+				return nil, err
+			}
+			m[k.Value] = val
+		}
+		return m, nil
+	case yaml.SequenceNode:
+		out := make([]any, 0, len(n.Content))
+		for _, c := range n.Content {
+			v, err := fromNode(c, env)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case yaml.ScalarNode:
+		return scalar(n, env)
+	}
+	return nil, fmt.Errorf("line %d: unsupported YAML node", n.Line)
+}
+
+func mergeSources(v any) []map[string]any {
+	switch v := v.(type) {
+	case map[string]any:
+		return []map[string]any{v}
+	case []any:
+		var out []map[string]any
+		for _, item := range v {
+			if m, ok := item.(map[string]any); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func scalar(n *yaml.Node, env *substituter) (any, error) {
+	switch n.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		return b, nil
+	case "!!int":
+		if _, err := strconv.ParseFloat(n.Value, 64); err == nil && json.Valid([]byte(n.Value)) {
+			return json.Number(n.Value), nil
+		}
+		var i int64
+		if err := n.Decode(&i); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		return json.Number(strconv.FormatInt(i, 10)), nil
+	case "!!float":
+		if json.Valid([]byte(n.Value)) {
+			return json.Number(n.Value), nil
+		}
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n.Line, err)
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return n.Value, nil
+		}
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+	}
+	if env != nil {
+		return env.expand(n.Value), nil
+	}
+	return n.Value, nil
+}
+
+// substituter expands ${VAR} and ${VAR:-default}; $${ is a literal ${.
+// Unset variables without a default are collected and reported together.
+type substituter struct {
+	lookup  func(string) (string, bool)
+	missing map[string]bool
+}
+
+func (s *substituter) expand(in string) string {
+	if !strings.Contains(in, "${") {
+		return in
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(in, "${")
+		if i < 0 {
+			b.WriteString(in)
+			return b.String()
+		}
+		if i > 0 && in[i-1] == '$' {
+			b.WriteString(in[:i-1] + "${")
+			in = in[i+2:]
+			continue
+		}
+		end := strings.IndexByte(in[i:], '}')
+		if end < 0 {
+			b.WriteString(in)
+			return b.String()
+		}
+		b.WriteString(in[:i])
+		b.WriteString(s.resolve(in[i+2 : i+end]))
+		in = in[i+end+1:]
+	}
+}
+
+func (s *substituter) resolve(ref string) string {
+	name, def, hasDef := strings.Cut(ref, ":-")
+	if v, ok := s.lookup(name); ok && (v != "" || !hasDef) {
+		return v
+	}
+	if hasDef {
+		return def
+	}
+	if s.missing == nil {
+		s.missing = map[string]bool{}
+	}
+	s.missing[name] = true
+	return ""
+}
+
+func (s *substituter) err() error {
+	if len(s.missing) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(s.missing))
+	for name := range s.missing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("undefined variables: %s (set them or use ${VAR:-default})", strings.Join(names, ", "))
+}
+
+// yamlValue replaces json.Number with int64/float64 so YAML output prints
+// numbers rather than quoted strings.
+func yamlValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, item := range v {
+			out[k] = yamlValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = yamlValue(item)
+		}
+		return out
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return v
+}
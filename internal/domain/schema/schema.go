@@ -0,0 +1,291 @@
+// Package schema generates JSON Schema documents for the configs users write
+// by hand: RunConfig, presets and the database topologies. Schemas are built
+// from the Go types by reflection, so they follow the structs the server
+// decodes; only enums and a few field annotations are listed here.
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/run"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/runconfig"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
+)
+
+// Draft is the JSON Schema dialect of the generated documents.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Names of the published schemas.
+const (
+	RunConfig        = "run-config"
+	Preset           = "preset"
+	PostgresTopology = "postgres-topology"
+	MySQLTopology    = "mysql-topology"
+	PicodataTopology = "picodata-topology"
+	YDBTopology      = "ydb-topology"
+)
+
+// roots builds each published schema.
+var roots = map[string]struct {
+	title string
+	build func(g *generator) map[string]any
+}{
+	RunConfig:        {"Stroppy run config", runConfigSchema},
+	Preset:           {"Stroppy topology preset", presetSchema},
+	PostgresTopology: {"PostgreSQL topology", structRoot(types.PostgresTopology{})},
+	MySQLTopology:    {"MySQL topology", structRoot(types.MySQLTopology{})},
+	PicodataTopology: {"Picodata topology", structRoot(types.PicodataTopology{})},
+	YDBTopology:      {"YDB topology", structRoot(types.YDBTopology{})},
+}
+
+// topologies maps each database kind to its topology type.
+var topologies = []struct {
+	kind types.DatabaseKind
+	typ  reflect.Type
+}{
+	{types.DatabasePostgres, reflect.TypeOf(types.PostgresTopology{})},
+	{types.DatabaseMySQL, reflect.TypeOf(types.MySQLTopology{})},
+	{types.DatabasePicodata, reflect.TypeOf(types.PicodataTopology{})},
+	{types.DatabaseYDB, reflect.TypeOf(types.YDBTopology{})},
+}
+
+// Names lists the published schemas, sorted.
+func Names() []string {
+	out := make([]string, 0, len(roots))
+	for name := range roots {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Get returns the schema called name.
+func Get(name string) (map[string]any, bool) {
+	root, ok := roots[name]
+	if !ok {
+		return nil, false
+	}
+	g := &generator{defs: map[string]any{}}
+	doc := root.build(g)
+	doc["$schema"] = Draft
+	doc["title"] = root.title
+	g.defs[envVarDef] = map[string]any{
+		"type":        "string",
+		"pattern":     `^\$\{[^}]+\}$`,
+		"description": "${VAR} or ${VAR:-default}, resolved when a YAML or JSON config file is loaded",
+	}
+	doc["$defs"] = g.defs
+	return doc, true
+}
+
+// envVarDef names the definition that lets numbers, booleans and enums be
+// written as ${VAR} references in config files.
+const envVarDef = "env_var"
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// enums lists the allowed values of string types.
+var enums = map[reflect.Type][]string{
+	reflect.TypeOf(types.Provider("")): {
+		string(types.ProviderYandex), string(types.ProviderDocker),
+	},
+	reflect.TypeOf(types.DatabaseKind("")): {
+		string(types.DatabasePostgres), string(types.DatabaseMySQL), string(types.DatabasePicodata), string(types.DatabaseYDB),
+	},
+	reflect.TypeOf(types.MachineRole("")): {
+		string(types.RoleDatabase), string(types.RoleMonitor), string(types.RoleStroppy), string(types.RoleEtcd),
+		string(types.RoleProxy), string(types.RolePgBouncer), string(types.RoleBuilder),
+	},
+}
+
+// annotations adjust single fields, keyed by "<Go type>.<json name>".
+var annotations = map[string]func(s map[string]any){
+	"RunConfig.api_version": func(s map[string]any) {
+		s["enum"] = types.RunConfigVersions()
+		s["default"] = types.RunConfigV1
+		s["description"] = "schema version of this config; " + types.RunConfigAPIVersion + " is current"
+	},
+	"StroppyConfig.script": func(s map[string]any) {
+		// Custom scripts are allowed, so the built-in names are offered
+		// for completion rather than enforced.
+		delete(s, "type")
+		s["anyOf"] = []any{
+			map[string]any{"enum": run.Scripts()},
+			map[string]any{"type": "string"},
+		}
+	},
+	"StroppyConfig.workload":     deprecated("use script"),
+	"StroppyConfig.vus_scale":    deprecated("use vus"),
+	"StroppyConfig.workers":      deprecated("use vus"),
+	"PostgresTopology.auto_tune": autoTune,
+	"MySQLTopology.auto_tune":    autoTune,
+	"PicodataTopology.auto_tune": autoTune,
+	"YDBTopology.auto_tune":      autoTune,
+}
+
+// autoTune offers the auto-tuning profiles for a topology's auto_tune field.
+func autoTune(s map[string]any) {
+	s["enum"] = types.AutoTuneProfiles()
+	s["description"] = "derive memory, parallelism and I/O settings from the node's CPUs, RAM and disk type; explicit options still win"
+}
+
+func deprecated(hint string) func(map[string]any) {
+	return func(s map[string]any) {
+		s["deprecated"] = true
+		s["description"] = "deprecated in api_version " + types.RunConfigV2 + ", " + hint
+	}
+}
+
+func structRoot(v any) func(g *generator) map[string]any {
+	return func(g *generator) map[string]any {
+		return g.structSchema(reflect.TypeOf(v))
+	}
+}
+
+// runConfigSchema describes a run config file: RunConfig plus the
+// extends/include keys files may use to compose.
+func runConfigSchema(g *generator) map[string]any {
+	doc := g.structSchema(reflect.TypeOf(types.RunConfig{}))
+	props := doc["properties"].(map[string]any)
+	props[runconfig.KeyExtends] = map[string]any{
+		"type":        "string",
+		"description": "base config file this one overrides, relative to this file",
+	}
+	props[runconfig.KeyInclude] = map[string]any{
+		"description": "config fragments merged in order before this file",
+		"anyOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+	}
+	return doc
+}
+
+// presetSchema describes the body of a preset create or update: the
+// topology must match db_kind.
+func presetSchema(g *generator) map[string]any {
+	kinds := make([]string, 0, len(topologies))
+	rules := make([]any, 0, len(topologies))
+	for _, t := range topologies {
+		kinds = append(kinds, string(t.kind))
+		rules = append(rules, map[string]any{
+			"if":   map[string]any{"properties": map[string]any{"db_kind": map[string]any{"const": string(t.kind)}}},
+			"then": map[string]any{"properties": map[string]any{"topology": g.typeSchema(t.typ)}},
+		})
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":        map[string]any{"type": "string"},
+			"description": map[string]any{"type": "string"},
+			"db_kind":     map[string]any{"type": "string", "enum": kinds},
+			"topology":    map[string]any{"type": "object"},
+		},
+		"required":             []string{"name", "db_kind", "topology"},
+		"additionalProperties": false,
+		"allOf":                rules,
+	}
+}
+
+type generator struct {
+	defs map[string]any
+}
+
+// typeSchema returns the schema of a field type. Named structs go to $defs
+// and are referenced, which also ends recursion.
+func (g *generator) typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == rawMessageType:
+		return map[string]any{}
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	if values, ok := enums[t]; ok {
+		return orEnvVar(map[string]any{"type": "string", "enum": values})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = map[string]any{} // placeholder for recursive types
+			g.defs[t.Name()] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return orEnvVar(map[string]any{"type": "boolean"})
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return orEnvVar(map[string]any{"type": "integer"})
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return orEnvVar(map[string]any{"type": "integer", "minimum": 0})
+	case reflect.Float32, reflect.Float64:
+		return orEnvVar(map[string]any{"type": "number"})
+	}
+	return map[string]any{}
+}
+
+// orEnvVar also accepts a ${VAR} reference where s is not a string.
+func orEnvVar(s map[string]any) map[string]any {
+	return map[string]any{"anyOf": []any{s, map[string]any{"$ref": "#/$defs/" + envVarDef}}}
+}
+
+func (g *generator) structSchema(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	g.addFields(t, props)
+	// Nothing is required: files that extend or include others, and
+	// fragments, only set part of a config. /validate checks completeness.
+	return map[string]any{
+		"type":                 "object",
+		"properties":           props,
+		"additionalProperties": false,
+	}
+}
+
+func (g *generator) addFields(t reflect.Type, props map[string]any) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.addFields(ft, props)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s := g.typeSchema(f.Type)
+		if annotate, ok := annotations[t.Name()+"."+name]; ok {
+			annotate(s)
+		}
+		props[name] = s
+	}
+}
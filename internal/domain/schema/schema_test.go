@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGet_AllSchemasResolve(t *testing.T) {
+	for _, name := range Names() {
+		doc, ok := Get(name)
+		if !ok {
+			t.Fatalf("%s: not found", name)
+		}
+		data, err := json.Marshal(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		defs := doc["$defs"].(map[string]any)
+		for _, ref := range refs(string(data)) {
+			if _, ok := defs[strings.TrimPrefix(ref, "#/$defs/")]; !ok {
+				t.Errorf("%s: dangling %s", name, ref)
+			}
+		}
+	}
+	if _, ok := Get("nope"); ok {
+		t.Error("unknown schema must not be found")
+	}
+}
+
+func TestGet_RunConfig(t *testing.T) {
+	doc, _ := Get(RunConfig)
+	data, _ := json.Marshal(doc)
+	s := string(data)
+	for _, want := range []string{`"tpcc/procs"`, `"picodata"`, `"yandex"`, `"pgbouncer"`, `"deprecated":true`, `"extends"`} {
+		if !strings.Contains(s, want) {
+			t.Errorf("run-config schema lacks %s", want)
+		}
+	}
+	props := doc["properties"].(map[string]any)
+	for _, hidden := range []string{"ResolvedPackage", "-"} {
+		if _, ok := props[hidden]; ok {
+			t.Errorf("%s must not be in the schema", hidden)
+		}
+	}
+}
+
+// TestExamplesMatchSchema checks that every key in the example configs is
+// declared, so a renamed field cannot silently break the schema or examples.
+func TestExamplesMatchSchema(t *testing.T) {
+	doc, _ := Get(RunConfig)
+	defs := doc["$defs"].(map[string]any)
+	files, _ := filepath.Glob("../../../examples/*.json")
+	yamls, _ := filepath.Glob("../../../examples/*.yaml")
+	files = append(files, yamls...)
+	if len(files) == 0 {
+		t.Skip("no examples")
+	}
+	for _, f := range files {
+		if strings.Contains(f, "settings") {
+			continue // server settings, not a run config
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			t.Fatalf("%s: %v", f, err)
+		}
+		for _, path := range unknownKeys(doc, defs, v, "") {
+			t.Errorf("%s: %s is not in the schema", filepath.Base(f), path)
+		}
+	}
+}
+
+func unknownKeys(s, defs map[string]any, v any, path string) []string {
+	if ref, ok := s["$ref"].(string); ok {
+		s = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	}
+	if alts, ok := s["anyOf"].([]any); ok {
+		s = alts[0].(map[string]any)
+	}
+	var out []string
+	switch v := v.(type) {
+	case map[string]any:
+		props, _ := s["properties"].(map[string]any)
+		extra, _ := s["additionalProperties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch {
+			case props[k] != nil:
+				out = append(out, unknownKeys(props[k].(map[string]any), defs, v[k], path+"."+k)...)
+			case extra != nil:
+				out = append(out, unknownKeys(extra, defs, v[k], path+"."+k)...)
+			default:
+				out = append(out, strings.TrimPrefix(path+"."+k, "."))
+			}
+		}
+	case []any:
+		if items, ok := s["items"].(map[string]any); ok {
+			for _, item := range v {
+				out = append(out, unknownKeys(items, defs, item, path+"[]")...)
+			}
+		}
+	}
+	return out
+}
+
+func refs(s string) []string {
+	var out []string
+	for {
+		i := strings.Index(s, `"$ref":"`)
+		if i < 0 {
+			return out
+		}
+		s = s[i+len(`"$ref":"`):]
+		end := strings.IndexByte(s, '"')
+		out = append(out, s[:end])
+		s = s[end:]
+	}
+}
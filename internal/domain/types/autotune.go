@@ -0,0 +1,25 @@
+package types
+
+// Database auto-tuning profile names. A topology's AutoTune field selects one;
+// the renderers then derive memory, parallelism and I/O settings from the
+// node's CPUs, RAM and disk class. Explicit options still override them.
+const (
+	AutoTuneOLTP     = "oltp"     // many short transactions: small work memory, large buffer pool
+	AutoTuneAnalytic = "analytic" // few large queries: big sort/hash memory, more parallel workers
+	AutoTuneMixed    = "mixed"    // between the two
+)
+
+// AutoTuneProfiles returns the accepted AutoTune values.
+func AutoTuneProfiles() []string {
+	return []string{AutoTuneOLTP, AutoTuneAnalytic, AutoTuneMixed}
+}
+
+// IsAutoTuneProfile reports whether name is a known auto-tuning profile.
+func IsAutoTuneProfile(name string) bool {
+	for _, p := range AutoTuneProfiles() {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity grades a ConfigIssue.
+type Severity string
+
+const (
+	// SeverityError marks a config the run cannot start with.
+	SeverityError Severity = "error"
+	// SeverityWarning marks a legal but dubious choice, or a deprecated
+	// field that was converted.
+	SeverityWarning Severity = "warning"
+)
+
+// ConfigIssue is one problem found in a config. Path is the JSON path of the
+// offending field, e.g. "database.postgres.replicas[0].count".
+type ConfigIssue struct {
+	Path     string   `json:"path"`
+	Message  string   `json:"message"`
+	Severity Severity `json:"severity"`
+}
+
+func (i ConfigIssue) String() string {
+	if i.Path == "" {
+		return i.Message
+	}
+	return i.Path + ": " + i.Message
+}
+
+// IssuesError joins the errors among issues into one error, or returns nil
+// when there are only warnings.
+func IssuesError(issues []ConfigIssue) error {
+	var msgs []string
+	for _, i := range issues {
+		if i.Severity == SeverityError {
+			msgs = append(msgs, i.String())
+		}
+	}
+	switch len(msgs) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New(msgs[0])
+	}
+	return fmt.Errorf("%d problems: %s", len(msgs), strings.Join(msgs, "; "))
+}
@@ -17,6 +17,14 @@ type Package struct {
 	DebFilename   string   `json:"deb_filename,omitempty"`
 	// DebToken is the auth token for downloading the .deb file. Injected at run start.
 	DebToken string `json:"deb_token,omitempty"`
+	// DebSHA256 is the hex checksum the agent verifies the downloaded .deb against.
+	DebSHA256 string `json:"deb_sha256,omitempty"`
+	// Source, when set, makes this a source package: it is compiled from git on
+	// a builder machine instead of being installed from apt or an uploaded .deb.
+	Source *SourceBuild `json:"source,omitempty"`
+	// Tarball, when set, installs prebuilt binaries from an archive: the one at
+	// Tarball.URL, or else the uploaded file (served like a .deb).
+	Tarball *Tarball `json:"tarball,omitempty"`
 	// DebData is not serialized to JSON — served via a dedicated endpoint.
 }
 
@@ -65,9 +73,10 @@ func BuiltinPackages() []Package {
 			},
 		},
 		{
-			Name: "YDB 25.3", Description: "YDB — binary downloaded directly from binaries.ydb.tech",
+			Name: "YDB 25.3", Description: "YDB — ydbd release tarball from binaries.ydb.tech",
 			DbKind: "ydb", DbVersion: "25.3", IsBuiltin: true,
-			AptPackages: []string{}, // no apt — ydbd binary downloaded in installYDB
+			AptPackages: []string{},
+			Tarball:     YDBTarball("25.3"),
 		},
 	}
 }
@@ -0,0 +1,132 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Revision pins an immutable version of a package or preset. Runs record the
+// revision they used so later edits do not change what a run means.
+type Revision struct {
+	Number int    `json:"number"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+// PackageSpec is the versioned content of a package. Every change to a
+// package records a new revision holding its spec.
+type PackageSpec struct {
+	Name          string       `json:"name"`
+	Description   string       `json:"description,omitempty"`
+	DbKind        string       `json:"db_kind"`
+	DbVersion     string       `json:"db_version,omitempty"`
+	AptPackages   []string     `json:"apt_packages,omitempty"`
+	PreInstall    []string     `json:"pre_install,omitempty"`
+	CustomRepo    string       `json:"custom_repo,omitempty"`
+	CustomRepoKey string       `json:"custom_repo_key,omitempty"`
+	DebFilename   string       `json:"deb_filename,omitempty"`
+	DebSHA256     string       `json:"deb_sha256,omitempty"`
+	Source        *SourceBuild `json:"source,omitempty"`
+	Tarball       *Tarball     `json:"tarball,omitempty"`
+}
+
+// Hash is the content hash of what the package installs. Name and
+// description are left out, so renaming keeps the hash.
+func (s PackageSpec) Hash() string {
+	s.Name, s.Description = "", ""
+	return hashJSON(s)
+}
+
+// PresetSpec is the versioned content of a preset.
+type PresetSpec struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	DbKind      string          `json:"db_kind"`
+	Topology    json.RawMessage `json:"topology"`
+}
+
+// Hash is the content hash of what the preset deploys. The topology is
+// hashed in canonical form, so key order and whitespace do not matter.
+func (s PresetSpec) Hash() string {
+	var topo any
+	_ = json.Unmarshal(s.Topology, &topo)
+	return hashJSON(struct {
+		DbKind   string `json:"db_kind"`
+		Topology any    `json:"topology"`
+	}{s.DbKind, topo})
+}
+
+func hashJSON(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// FieldChange is one difference between two revisions.
+type FieldChange struct {
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// DiffJSON compares two values through their JSON form. Objects are walked
+// key by key (paths like "topology.master.cpus"); arrays and scalars are
+// compared whole. Changes are sorted by path.
+func DiffJSON(a, b any) ([]FieldChange, error) {
+	av, err := toJSONValue(a)
+	if err != nil {
+		return nil, err
+	}
+	bv, err := toJSONValue(b)
+	if err != nil {
+		return nil, err
+	}
+	var changes []FieldChange
+	diffValues("", av, bv, &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func toJSONValue(v any) (any, error) {
+	data, ok := v.(json.RawMessage)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	var out any
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+	return out, nil
+}
+
+func diffValues(path string, a, b any, out *[]FieldChange) {
+	am, aObj := a.(map[string]any)
+	bm, bObj := b.(map[string]any)
+	if aObj && bObj {
+		for k, av := range am {
+			diffValues(joinPath(path, k), av, bm[k], out)
+		}
+		for k, bv := range bm {
+			if _, ok := am[k]; !ok {
+				diffValues(joinPath(path, k), nil, bv, out)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, FieldChange{Path: path, From: a, To: b})
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
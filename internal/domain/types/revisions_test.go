@@ -0,0 +1,52 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPackageSpec_HashIgnoresName(t *testing.T) {
+	a := PackageSpec{Name: "pg17", DbKind: "postgres", DbVersion: "17", AptPackages: []string{"postgresql-17"}}
+	b := a
+	b.Name, b.Description = "pg17 renamed", "now with a description"
+	if a.Hash() != b.Hash() {
+		t.Error("renaming must keep the content hash")
+	}
+	c := a
+	c.DebSHA256 = "abc"
+	if a.Hash() == c.Hash() {
+		t.Error("a new .deb must change the content hash")
+	}
+}
+
+func TestPresetSpec_HashCanonicalTopology(t *testing.T) {
+	a := PresetSpec{DbKind: "postgres", Topology: json.RawMessage(`{"master":{"cpus":2,"memory_mb":4096}}`)}
+	b := PresetSpec{Name: "x", DbKind: "postgres", Topology: json.RawMessage(`{ "master": { "memory_mb": 4096, "cpus": 2 } }`)}
+	if a.Hash() != b.Hash() {
+		t.Error("key order and whitespace must not change the hash")
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	from := PresetSpec{Name: "ha", DbKind: "postgres", Topology: json.RawMessage(`{"master":{"cpus":2},"replicas":[1]}`)}
+	to := PresetSpec{Name: "ha", DbKind: "postgres", Topology: json.RawMessage(`{"master":{"cpus":4,"disk_gb":50},"replicas":[1,2]}`)}
+	changes, err := DiffJSON(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"topology.master.cpus", "topology.master.disk_gb", "topology.replicas"}
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes: %+v", len(changes), changes)
+	}
+	for i, c := range changes {
+		if c.Path != want[i] {
+			t.Errorf("change %d: path %q, want %q", i, c.Path, want[i])
+		}
+	}
+	if changes[0].From != 2.0 || changes[0].To != 4.0 {
+		t.Errorf("cpus: %v -> %v", changes[0].From, changes[0].To)
+	}
+	if changes[1].From != nil || changes[1].To != 50.0 {
+		t.Errorf("added field: %v -> %v", changes[1].From, changes[1].To)
+	}
+}
@@ -24,8 +24,11 @@ type Phase string
 const (
 	PhaseNetwork            Phase = "network"           // subnet/VPC allocation
 	PhaseMachines           Phase = "machines"          // VM provisioning or Docker container creation
+	PhaseTuneOS             Phase = "tune_os"           // OS tuning profile applied before install
+	PhaseBuildPackage       Phase = "build_package"     // compile a source package on the builder
 	PhaseInstallDB          Phase = "install_db"        // database binary installation
 	PhaseConfigureDB        Phase = "configure_db"      // database cluster configuration
+	PhaseCaptureSettings    Phase = "capture_settings"  // read back the settings the database runs with
 	PhaseInstallMonitor     Phase = "install_monitor"   // monitoring stack deployment
 	PhaseConfigureMonitor   Phase = "configure_monitor" // monitoring configuration & targets
 	PhaseInstallStroppy     Phase = "install_stroppy"   // stroppy deployment on a dedicated machine
@@ -53,6 +56,7 @@ const (
 	RoleEtcd      MachineRole = "etcd"
 	RoleProxy     MachineRole = "proxy" // HAProxy / ProxySQL / LB
 	RolePgBouncer MachineRole = "pgbouncer"
+	RoleBuilder   MachineRole = "builder" // compiles source packages
 )
 
 // MachineSpec describes a single machine to provision.
@@ -67,6 +71,9 @@ type MachineSpec struct {
 	// disk. Today only consumed by YDB (storage nodes point pdisk at the raw
 	// device when one is present). Other engines ignore the field.
 	SecondaryDisks []SecondaryDisk `json:"secondary_disks,omitempty"`
+	// TuningProfile names the OS tuning profile applied before the database is
+	// installed (built-in or from RunConfig.TuningProfiles). Empty = "default".
+	TuningProfile string `json:"tuning_profile,omitempty"`
 }
 
 // SecondaryDisk is a raw block device attached to a VM in addition to its
@@ -90,6 +97,7 @@ type PostgresTopology struct {
 	Patroni      bool          `json:"patroni"`
 	Etcd         bool          `json:"etcd"` // colocated on PG nodes (up to 3)
 	SyncReplicas int           `json:"sync_replicas"`
+	AutoTune     string        `json:"auto_tune,omitempty"` // hardware-aware tuning profile; empty = static defaults
 	// Per-component configuration options.
 	MasterOptions    map[string]string `json:"master_options,omitempty"`    // postgresql.conf for master
 	ReplicaOptions   map[string]string `json:"replica_options,omitempty"`   // postgresql.conf for replicas
@@ -106,6 +114,7 @@ type MySQLTopology struct {
 	ProxySQL  *MachineSpec  `json:"proxysql,omitempty"` // dedicated ProxySQL node(s)
 	GroupRepl bool          `json:"group_replication"`
 	SemiSync  bool          `json:"semi_sync"` // semi-synchronous replication (when no GR)
+	AutoTune  string        `json:"auto_tune,omitempty"` // hardware-aware tuning profile; empty = static defaults
 	// Per-component configuration options.
 	PrimaryOptions  map[string]string `json:"primary_options,omitempty"`  // my.cnf for primary
 	ReplicaOptions  map[string]string `json:"replica_options,omitempty"`  // my.cnf for replicas
@@ -119,6 +128,7 @@ type PicodataTopology struct {
 	Replication int            `json:"replication_factor"`
 	Shards      int            `json:"shards"`
 	Tiers       []PicodataTier `json:"tiers,omitempty"` // for scale deployments
+	AutoTune    string         `json:"auto_tune,omitempty"` // hardware-aware tuning profile; empty = static defaults
 	// Per-component configuration options.
 	InstanceOptions map[string]string `json:"instance_options,omitempty"` // picodata.yaml tuning
 	HAProxyOptions  map[string]string `json:"haproxy_options,omitempty"`  // haproxy.cfg tuning
@@ -131,6 +141,7 @@ type YDBTopology struct {
 	HAProxy         *MachineSpec      `json:"haproxy,omitempty"`  // optional load balancer
 	FaultTolerance  string            `json:"fault_tolerance"`    // "none", "block-4-2", "mirror-3-dc"
 	DatabasePath    string            `json:"database_path"`      // default "/Root/testdb"
+	AutoTune        string            `json:"auto_tune,omitempty"` // hardware-aware tuning profile; empty = static defaults
 	StorageOptions  map[string]string `json:"storage_options,omitempty"`
 	DatabaseOptions map[string]string `json:"database_options,omitempty"`
 	HAProxyOptions  map[string]string `json:"haproxy_options,omitempty"`
@@ -301,7 +312,8 @@ type StroppyConfig struct {
 	ConfigOverrideJSON string `json:"config_override_json,omitempty"`
 	// Machine spec for the stroppy runner node. If nil, defaults to 2 vCPU / 4 GB / 20 GB.
 	Machine *MachineSpec `json:"machine,omitempty"`
-	// Deprecated fields kept for backward compatibility with existing runs.
+	// Deprecated api_version v1 fields, still decoded so old configs and
+	// stored runs load. UpgradeRunConfig moves them to Script and VUs.
 	Workload string  `json:"workload,omitempty"`  // Deprecated: use Script
 	VUSScale float64 `json:"vus_scale,omitempty"` // Deprecated: use VUs
 	Workers  int     `json:"workers,omitempty"`   // Deprecated: use VUs
@@ -316,24 +328,41 @@ type NetworkConfig struct {
 // RunConfig is the full specification of a test run.
 // It is used to build the execution DAG.
 type RunConfig struct {
-	ID       string         `json:"id"`
-	Provider Provider       `json:"provider"`
-	Network  NetworkConfig  `json:"network"`
-	Machines []MachineSpec  `json:"machines"`
-	Database DatabaseConfig `json:"database"`
-	Monitor  MonitorConfig  `json:"monitor"`
-	Stroppy  StroppyConfig  `json:"stroppy"`
+	// APIVersion is the schema version the config was written against
+	// (RunConfigV1 when empty). See UpgradeRunConfig.
+	APIVersion string         `json:"api_version,omitempty"`
+	ID         string         `json:"id"`
+	Provider   Provider       `json:"provider"`
+	Network    NetworkConfig  `json:"network"`
+	Machines   []MachineSpec  `json:"machines"`
+	Database   DatabaseConfig `json:"database"`
+	Monitor    MonitorConfig  `json:"monitor"`
+	Stroppy    StroppyConfig  `json:"stroppy"`
 	// PresetID references a presets row. If set and no topology is provided in Database,
 	// the preset's topology is applied. Topology in the request takes priority.
 	PresetID string `json:"preset_id,omitempty"`
 	// PackageID references a packages row. Resolved to ResolvedPackage at run start.
 	// If empty, the default built-in package for db_kind+version is used.
 	PackageID string `json:"package_id,omitempty"`
+	// PresetRevision and PackageRevision pin the revisions of the preset and
+	// package the run uses. The server records the current ones at run start;
+	// a client may set them to rerun against an older revision.
+	PresetRevision  *Revision `json:"preset_revision,omitempty"`
+	PackageRevision *Revision `json:"package_revision,omitempty"`
 	// PlatformID overrides the Yandex Cloud platform from server settings (e.g. "standard-v3").
 	PlatformID string `json:"platform_id,omitempty"`
 	// MachineOverride, when set, overrides the CPU/memory/disk of all database-role
 	// machines from the preset topology. Allows per-run sizing without editing the preset.
 	MachineOverride *MachineSpec `json:"machine_override,omitempty"`
+	// TuningProfiles defines custom OS tuning profiles referenced by name from
+	// MachineSpec.TuningProfile. A custom profile shadows a built-in of the same name.
+	TuningProfiles []TuningProfile `json:"tuning_profiles,omitempty"`
+	// Tags label the run for trend history, e.g. "nightly" or "postgres-16".
+	// They do not change what the run does.
+	Tags []string `json:"tags,omitempty"`
+	// Gate names a baseline whose gate policy the run is checked against
+	// once its workload finishes; the outcome is reported with its status.
+	Gate string `json:"gate,omitempty"`
 	// ResolvedPackage is populated by the server before building the DAG. Not sent by clients.
 	ResolvedPackage *Package `json:"-"`
 }
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// BuildSystem selects how a SourceBuild is configured and compiled.
+type BuildSystem string
+
+const (
+	BuildAutotools BuildSystem = "autotools" // ./configure && make && make install
+	BuildCMake     BuildSystem = "cmake"     // cmake -B build && cmake --build && cmake --install
+	BuildMeson     BuildSystem = "meson"     // meson setup && ninja && meson install
+)
+
+// SourceBuild describes a package compiled from a git checkout on a builder
+// machine during the build_package phase. The result is packaged as a .deb
+// and cached per commit SHA, so later runs of the same commit skip compilation.
+type SourceBuild struct {
+	GitURL string `json:"git_url"`
+	// Ref is a branch, tag or commit SHA to build.
+	Ref            string      `json:"ref"`
+	BuildSystem    BuildSystem `json:"build_system,omitempty"`
+	ConfigureFlags []string    `json:"configure_flags,omitempty"`
+	MakeFlags      []string    `json:"make_flags,omitempty"`
+	// Prefix is the install prefix baked into the binaries.
+	Prefix string `json:"prefix,omitempty"`
+	// BuildDeps are apt packages installed on the builder before compiling.
+	BuildDeps []string `json:"build_deps,omitempty"`
+	// Depends is the Depends: line of the produced .deb (runtime dependencies).
+	Depends []string `json:"depends,omitempty"`
+	// PostInstall lines become the .deb postinst script (e.g. creating a cluster).
+	PostInstall []string `json:"post_install,omitempty"`
+	// Machine overrides the builder machine size.
+	Machine *MachineSpec `json:"machine,omitempty"`
+
+	// CommitSHA is the resolved Ref. Filled in by the server at run start or
+	// by the build_package phase; never part of the recipe hash.
+	CommitSHA string `json:"commit_sha,omitempty"`
+}
+
+var commitSHARe = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// IsCommitSHA reports whether ref is a full 40-character git commit SHA.
+func IsCommitSHA(ref string) bool {
+	return commitSHARe.MatchString(ref)
+}
+
+// scpLikeRe matches the scp-like ssh form user@host:path.
+var scpLikeRe = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^:]`)
+
+// ValidateGit checks GitURL and Ref before they reach a git command line:
+// only https, ssh and git URLs are accepted, and neither may start with
+// "-", which git would read as an option (e.g. --upload-pack=<cmd>).
+func (s SourceBuild) ValidateGit() error {
+	switch {
+	case strings.HasPrefix(s.GitURL, "-"):
+		return fmt.Errorf("source.git_url must not start with \"-\"")
+	case strings.HasPrefix(s.Ref, "-"):
+		return fmt.Errorf("source.ref must not start with \"-\"")
+	case strings.HasPrefix(s.GitURL, "https://"),
+		strings.HasPrefix(s.GitURL, "ssh://"),
+		strings.HasPrefix(s.GitURL, "git://"),
+		scpLikeRe.MatchString(s.GitURL):
+		return nil
+	default:
+		return fmt.Errorf("source.git_url %q: want an https://, ssh:// or git:// URL", s.GitURL)
+	}
+}
+
+// WithDefaults fills empty fields with sensible values for the database kind.
+// Postgres builds install into the Debian layout (/usr/lib/postgresql/<ver>)
+// and create the "main" cluster so the regular configure_db phase works.
+func (s SourceBuild) WithDefaults(dbKind, dbVersion string) SourceBuild {
+	switch DatabaseKind(dbKind) {
+	case DatabasePostgres:
+		if s.BuildSystem == "" {
+			s.BuildSystem = BuildAutotools
+		}
+		if s.Prefix == "" && dbVersion != "" {
+			s.Prefix = "/usr/lib/postgresql/" + dbVersion
+		}
+		if len(s.BuildDeps) == 0 {
+			s.BuildDeps = []string{"build-essential", "bison", "flex", "pkg-config", "libreadline-dev", "zlib1g-dev", "libssl-dev", "libicu-dev"}
+		}
+		if len(s.Depends) == 0 {
+			s.Depends = []string{"postgresql-common", "libreadline8 | libreadline7", "zlib1g", "libssl3 | libssl1.1", "libicu-dev"}
+		}
+		if len(s.PostInstall) == 0 && dbVersion != "" {
+			s.PostInstall = []string{"pg_lsclusters -h | grep -q '^" + dbVersion + " main' || pg_createcluster " + dbVersion + " main"}
+		}
+	case DatabaseMySQL:
+		if s.BuildSystem == "" {
+			s.BuildSystem = BuildCMake
+		}
+		if len(s.BuildDeps) == 0 {
+			s.BuildDeps = []string{"build-essential", "cmake", "bison", "pkg-config", "libssl-dev", "libncurses-dev", "libtirpc-dev"}
+		}
+	}
+	if s.BuildSystem == "" {
+		s.BuildSystem = BuildAutotools
+	}
+	if s.Prefix == "" {
+		s.Prefix = "/usr/local"
+	}
+	return s
+}
+
+// RecipeHash identifies the build recipe (everything except the commit), so a
+// cached artifact is only reused when both the commit and the flags match.
+func (s SourceBuild) RecipeHash() string {
+	s.CommitSHA = ""
+	s.Ref = ""
+	s.Machine = nil
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// DefaultBuilderMachine is the builder size used when SourceBuild.Machine is unset.
+func DefaultBuilderMachine() MachineSpec {
+	return MachineSpec{Role: RoleBuilder, Count: 1, CPUs: 8, MemoryMB: 16384, DiskGB: 50}
+}
+
+// NeedsBuild reports whether the package must be compiled on a builder
+// machine before install (a source package without a cached artifact).
+func (p *Package) NeedsBuild() bool {
+	return p != nil && p.Source != nil && p.DebFilename == ""
+}
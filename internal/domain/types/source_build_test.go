@@ -0,0 +1,88 @@
+package types
+
+import "testing"
+
+func TestIsCommitSHA(t *testing.T) {
+	cases := map[string]bool{
+		"0123456789abcdef0123456789abcdef01234567": true,
+		"0123456789ABCDEF0123456789ABCDEF01234567": false,
+		"0123456":         false,
+		"REL_17_STABLE":   false,
+		"refs/heads/main": false,
+	}
+	for ref, want := range cases {
+		if got := IsCommitSHA(ref); got != want {
+			t.Errorf("IsCommitSHA(%q) = %v, want %v", ref, got, want)
+		}
+	}
+}
+
+func TestSourceBuild_ValidateGit(t *testing.T) {
+	cases := map[string]bool{
+		"https://git.postgresql.org/git/postgresql.git": true,
+		"ssh://git@github.com/mysql/mysql-server.git":   true,
+		"git://git.postgresql.org/git/postgresql.git":   true,
+		"git@github.com:mysql/mysql-server.git":         true,
+		"--upload-pack=touch /tmp/pwned":                false,
+		"-u":                                            false,
+		"file:///etc":                                   false,
+		"ext::sh -c touch% /tmp/pwned":                  false,
+		"/srv/repo.git":                                 false,
+	}
+	for url, ok := range cases {
+		err := SourceBuild{GitURL: url, Ref: "master"}.ValidateGit()
+		if (err == nil) != ok {
+			t.Errorf("ValidateGit(%q) = %v, want ok=%v", url, err, ok)
+		}
+	}
+	if err := (SourceBuild{GitURL: "https://example.com/x.git", Ref: "--output=/etc/passwd"}).ValidateGit(); err == nil {
+		t.Error("ValidateGit should reject a ref starting with -")
+	}
+}
+
+func TestSourceBuild_RecipeHashIgnoresCommit(t *testing.T) {
+	a := SourceBuild{GitURL: "https://git.postgresql.org/git/postgresql.git", Ref: "master", ConfigureFlags: []string{"--with-openssl"}}
+	b := a
+	b.Ref = "REL_17_STABLE"
+	b.CommitSHA = "0123456789abcdef0123456789abcdef01234567"
+	b.Machine = &MachineSpec{CPUs: 32}
+	if a.RecipeHash() != b.RecipeHash() {
+		t.Error("recipe hash must not depend on ref, commit or builder size")
+	}
+
+	c := a
+	c.ConfigureFlags = []string{"--with-openssl", "--enable-cassert"}
+	if a.RecipeHash() == c.RecipeHash() {
+		t.Error("recipe hash must change with configure flags")
+	}
+}
+
+func TestSourceBuild_WithDefaults(t *testing.T) {
+	pg := SourceBuild{GitURL: "x", Ref: "master"}.WithDefaults("postgres", "17")
+	if pg.BuildSystem != BuildAutotools || pg.Prefix != "/usr/lib/postgresql/17" {
+		t.Errorf("postgres defaults = %s %s", pg.BuildSystem, pg.Prefix)
+	}
+	if len(pg.PostInstall) == 0 || len(pg.BuildDeps) == 0 {
+		t.Error("postgres defaults should set build deps and a postinst creating the cluster")
+	}
+
+	my := SourceBuild{GitURL: "x", Ref: "trunk", Prefix: "/opt/mysql"}.WithDefaults("mysql", "8.4")
+	if my.BuildSystem != BuildCMake || my.Prefix != "/opt/mysql" {
+		t.Errorf("mysql defaults = %s %s", my.BuildSystem, my.Prefix)
+	}
+}
+
+func TestPackage_NeedsBuild(t *testing.T) {
+	var nilPkg *Package
+	if nilPkg.NeedsBuild() {
+		t.Error("nil package needs no build")
+	}
+	src := &Package{Source: &SourceBuild{GitURL: "x", Ref: "main"}}
+	if !src.NeedsBuild() {
+		t.Error("source package without artifact needs a build")
+	}
+	src.DebFilename = "http://server/api/v1/packages/p/builds/sha/deb"
+	if src.NeedsBuild() {
+		t.Error("source package with a cached artifact needs no build")
+	}
+}
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Tarball describes a package shipped as an archive of prebuilt binaries
+// instead of a .deb. The archive is fetched from URL or, when URL is empty,
+// is the file uploaded to the package. Agents unpack it under Prefix, put
+// BinDirs on PATH and LibDirs on the loader path, and install Units.
+type Tarball struct {
+	URL string `json:"url,omitempty"`
+	// SHA256 is the expected checksum of the archive at URL.
+	SHA256 string `json:"sha256,omitempty"`
+	// Prefix is the directory the archive is unpacked into.
+	Prefix string `json:"prefix,omitempty"`
+	// StripComponents drops leading path components, like tar --strip-components.
+	StripComponents int `json:"strip_components,omitempty"`
+	// BinDirs and LibDirs are relative to Prefix. Binaries are linked into
+	// /usr/local/bin and listed in PATH; libraries are registered with ldconfig
+	// and listed in LD_LIBRARY_PATH.
+	BinDirs []string `json:"bin_dirs,omitempty"`
+	LibDirs []string `json:"lib_dirs,omitempty"`
+	// Depends are apt packages the binaries need at runtime.
+	Depends []string `json:"depends,omitempty"`
+	// Units are systemd unit templates written to /etc/systemd/system.
+	Units []SystemdUnit `json:"units,omitempty"`
+	// PostInstall commands run after unpacking (e.g. creating a cluster).
+	PostInstall []string `json:"post_install,omitempty"`
+}
+
+// SystemdUnit is a systemd unit file rendered with text/template. The
+// template sees {{.Prefix}}, {{.Version}} and {{.Name}} (the unit name).
+type SystemdUnit struct {
+	Name     string `json:"name"`
+	Template string `json:"template"`
+}
+
+// WithDefaults fills empty fields with sensible values for the database kind.
+// Postgres tarballs install into the Debian layout (/usr/lib/postgresql/<ver>)
+// and create the "main" cluster through postgresql-common, so the regular
+// configure_db phase works, as for source builds.
+func (t Tarball) WithDefaults(dbKind, dbVersion string) Tarball {
+	switch DatabaseKind(dbKind) {
+	case DatabasePostgres:
+		if t.Prefix == "" && dbVersion != "" {
+			t.Prefix = "/usr/lib/postgresql/" + dbVersion
+		}
+		if len(t.Depends) == 0 {
+			t.Depends = []string{"postgresql-common"}
+		}
+		if len(t.PostInstall) == 0 && dbVersion != "" {
+			t.PostInstall = []string{"pg_lsclusters -h | grep -q '^" + dbVersion + " main' || pg_createcluster " + dbVersion + " main"}
+		}
+	case DatabaseYDB:
+		if t.Prefix == "" {
+			t.Prefix = "/opt/ydb"
+		}
+	}
+	if t.Prefix == "" {
+		t.Prefix = "/opt/" + dbKind
+	}
+	if len(t.BinDirs) == 0 {
+		t.BinDirs = []string{"bin"}
+	}
+	if len(t.LibDirs) == 0 {
+		t.LibDirs = []string{"lib"}
+	}
+	return t
+}
+
+var (
+	unitNameRe = regexp.MustCompile(`^[A-Za-z0-9@._-]+\.(service|socket|target|timer)$`)
+	sha256Re   = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// Validate checks the fields agents interpolate into paths and shell commands.
+func (t Tarball) Validate() error {
+	if t.URL != "" && !strings.HasPrefix(t.URL, "https://") && !strings.HasPrefix(t.URL, "http://") {
+		return fmt.Errorf("tarball.url must be an http(s) URL")
+	}
+	if t.SHA256 != "" && !sha256Re.MatchString(t.SHA256) {
+		return fmt.Errorf("tarball.sha256 must be 64 lowercase hex characters")
+	}
+	if t.Prefix != "" && (!path.IsAbs(t.Prefix) || path.Clean(t.Prefix) == "/") {
+		return fmt.Errorf("tarball.prefix must be an absolute path below /")
+	}
+	if t.StripComponents < 0 {
+		return fmt.Errorf("tarball.strip_components must not be negative")
+	}
+	for _, dir := range append(append([]string{}, t.BinDirs...), t.LibDirs...) {
+		if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return fmt.Errorf("tarball dir %q must be relative to the prefix", dir)
+		}
+	}
+	seen := make(map[string]bool, len(t.Units))
+	for _, u := range t.Units {
+		if !unitNameRe.MatchString(u.Name) {
+			return fmt.Errorf("invalid systemd unit name %q", u.Name)
+		}
+		if seen[u.Name] {
+			return fmt.Errorf("duplicate systemd unit %q", u.Name)
+		}
+		seen[u.Name] = true
+	}
+	return nil
+}
+
+// ydbReleases maps short version labels (from UI) to full patch versions
+// available at binaries.ydb.tech/release/. Update when new YDB releases ship.
+var ydbReleases = map[string]string{
+	"25.2": "25.2.1.24",
+	"25.1": "25.1.4.7",
+	"24.4": "24.4.4.12",
+	"24.3": "24.3.15.5",
+	"24.2": "24.2.7",
+	"24.1": "24.1.18",
+}
+
+// YDBRelease maps a short version like "25.2" to the full "25.2.1.24".
+// A full version (e.g. "25.2.1.24") is returned as is.
+func YDBRelease(v string) string {
+	if v == "" {
+		return "25.2.1.24"
+	}
+	if full, ok := ydbReleases[v]; ok {
+		return full
+	}
+	return v
+}
+
+// YDBTarball is the official ydbd release archive for a version.
+func YDBTarball(version string) *Tarball {
+	v := YDBRelease(version)
+	return &Tarball{
+		URL:             fmt.Sprintf("https://binaries.ydb.tech/release/%s/ydbd-%s-linux-amd64.tar.gz", v, v),
+		Prefix:          "/opt/ydb",
+		StripComponents: 1,
+	}
+}
@@ -0,0 +1,57 @@
+package types
+
+import "testing"
+
+func TestTarball_WithDefaults(t *testing.T) {
+	pg := Tarball{URL: "https://example.com/pg.tar.gz"}.WithDefaults("postgres", "17")
+	if pg.Prefix != "/usr/lib/postgresql/17" {
+		t.Errorf("postgres prefix = %q", pg.Prefix)
+	}
+	if len(pg.PostInstall) != 1 || len(pg.Depends) != 1 || pg.Depends[0] != "postgresql-common" {
+		t.Errorf("postgres must create its cluster through postgresql-common: %+v", pg)
+	}
+	pico := Tarball{}.WithDefaults("picodata", "25.3")
+	if pico.Prefix != "/opt/picodata" || pico.BinDirs[0] != "bin" || pico.LibDirs[0] != "lib" {
+		t.Errorf("picodata defaults: %+v", pico)
+	}
+	custom := Tarball{Prefix: "/srv/pg", BinDirs: []string{"usr/bin"}}.WithDefaults("postgres", "17")
+	if custom.Prefix != "/srv/pg" || custom.BinDirs[0] != "usr/bin" {
+		t.Errorf("explicit fields must be kept: %+v", custom)
+	}
+}
+
+func TestTarball_Validate(t *testing.T) {
+	ok := Tarball{
+		URL: "https://example.com/pg.tar.gz", Prefix: "/opt/pg", BinDirs: []string{"bin"},
+		Units: []SystemdUnit{{Name: "pg-custom.service", Template: "[Service]\nExecStart={{.Prefix}}/bin/postgres\n"}},
+	}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("valid tarball: %v", err)
+	}
+	for name, bad := range map[string]Tarball{
+		"relative prefix": {Prefix: "opt/pg"},
+		"root prefix":     {Prefix: "/"},
+		"ftp url":         {URL: "ftp://example.com/pg.tar"},
+		"short sha":       {SHA256: "abc"},
+		"escaping dir":    {LibDirs: []string{"../lib"}},
+		"unit path":       {Units: []SystemdUnit{{Name: "../../etc/passwd"}}},
+		"duplicate unit":  {Units: []SystemdUnit{{Name: "a.service"}, {Name: "a.service"}}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestYDBTarball(t *testing.T) {
+	tb := YDBTarball("25.2")
+	if tb.URL != "https://binaries.ydb.tech/release/25.2.1.24/ydbd-25.2.1.24-linux-amd64.tar.gz" {
+		t.Errorf("url = %s", tb.URL)
+	}
+	if tb.Prefix != "/opt/ydb" || tb.StripComponents != 1 {
+		t.Errorf("ydb tarball: %+v", tb)
+	}
+	if got := YDBRelease("24.4.4.12"); got != "24.4.4.12" {
+		t.Errorf("full versions pass through, got %s", got)
+	}
+}
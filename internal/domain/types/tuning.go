@@ -0,0 +1,120 @@
+package types
+
+import "sort"
+
+// Built-in OS tuning profile names.
+const (
+	TuningDefault      = "default"       // leave the OS as provisioned, only record its values
+	TuningPostgresOLTP = "postgres-oltp" // low-latency OLTP on Postgres/MySQL-style engines
+	TuningYDB          = "ydb"           // YDB storage/compute nodes
+)
+
+// TuningProfile is a set of OS-level knobs applied to a machine before the
+// database is installed. Empty fields leave the corresponding setting untouched.
+type TuningProfile struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Sysctls are kernel parameters written to /etc/sysctl.d and applied live.
+	Sysctls map[string]string `json:"sysctls,omitempty"`
+	// TransparentHugepages sets THP mode: "always", "madvise" or "never".
+	TransparentHugepages string `json:"transparent_hugepages,omitempty"`
+	// HugePagesPercent reserves this share of RAM as 2 MB huge pages (vm.nr_hugepages).
+	HugePagesPercent int `json:"huge_pages_percent,omitempty"`
+	// IOScheduler is written to every physical block device ("none", "mq-deadline", "bfq", "kyber").
+	IOScheduler string `json:"io_scheduler,omitempty"`
+	// CPUGovernor is the cpufreq scaling governor (e.g. "performance").
+	CPUGovernor string `json:"cpu_governor,omitempty"`
+	// Ulimits are process limits keyed by limits.conf item (nofile, nproc, memlock).
+	// Applied both to PAM sessions and as systemd defaults so DB services inherit them.
+	Ulimits map[string]string `json:"ulimits,omitempty"`
+}
+
+// BuiltinTuningProfiles returns the tuning profiles shipped with the server.
+func BuiltinTuningProfiles() map[string]TuningProfile {
+	return map[string]TuningProfile{
+		TuningDefault: {
+			Name:        TuningDefault,
+			Description: "Stock OS settings; values are recorded but nothing is changed",
+		},
+		TuningPostgresOLTP: {
+			Name:        TuningPostgresOLTP,
+			Description: "Low-latency OLTP: no THP, no swapping, early writeback, performance governor",
+			Sysctls: map[string]string{
+				"vm.swappiness":                  "1",
+				"vm.dirty_background_ratio":      "3",
+				"vm.dirty_ratio":                 "10",
+				"kernel.sched_autogroup_enabled": "0",
+				"net.core.somaxconn":             "4096",
+				"net.ipv4.tcp_max_syn_backlog":   "4096",
+			},
+			TransparentHugepages: "never",
+			IOScheduler:          "none",
+			CPUGovernor:          "performance",
+			Ulimits: map[string]string{
+				"nofile":  "1048576",
+				"nproc":   "unlimited",
+				"memlock": "unlimited",
+			},
+		},
+		TuningYDB: {
+			Name:        TuningYDB,
+			Description: "YDB nodes: many connections and AIO contexts, no THP, performance governor",
+			Sysctls: map[string]string{
+				"vm.swappiness":                "1",
+				"vm.max_map_count":             "1048576",
+				"fs.aio-max-nr":                "1048576",
+				"net.core.somaxconn":           "65535",
+				"net.ipv4.tcp_max_syn_backlog": "65535",
+				"net.ipv4.ip_local_port_range": "1024 65535",
+			},
+			TransparentHugepages: "never",
+			IOScheduler:          "none",
+			CPUGovernor:          "performance",
+			Ulimits: map[string]string{
+				"nofile":  "1048576",
+				"nproc":   "unlimited",
+				"memlock": "unlimited",
+			},
+		},
+	}
+}
+
+// TuningProfileNames returns the names of the built-in profiles, sorted.
+func TuningProfileNames() []string {
+	builtins := BuiltinTuningProfiles()
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResolveTuningProfile looks a profile up by name, preferring profiles defined
+// in the run config over built-ins. An empty name resolves to "default".
+func ResolveTuningProfile(cfg *RunConfig, name string) (TuningProfile, bool) {
+	if name == "" {
+		name = TuningDefault
+	}
+	if cfg != nil {
+		for _, p := range cfg.TuningProfiles {
+			if p.Name == name {
+				return p, true
+			}
+		}
+	}
+	p, ok := BuiltinTuningProfiles()[name]
+	return p, ok
+}
+
+// TuningProfileForRole returns the profile name requested for a machine role:
+// the first machine spec of that role with a TuningProfile set, or "default".
+// Config validation rejects specs of one role asking for different profiles.
+func TuningProfileForRole(cfg *RunConfig, role MachineRole) string {
+	for _, m := range cfg.Machines {
+		if m.Role == role && m.TuningProfile != "" {
+			return m.TuningProfile
+		}
+	}
+	return TuningDefault
+}
@@ -0,0 +1,158 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// RunConfig schema versions. A config names the schema it was written
+// against in api_version; configs without one predate versioning and are v1.
+const (
+	// RunConfigV1 is the original schema: stroppy.workload, stroppy.vus_scale
+	// and stroppy.workers are still accepted.
+	RunConfigV1 = "v1"
+	// RunConfigV2 replaces those fields with stroppy.script and stroppy.vus.
+	RunConfigV2 = "v2"
+
+	// RunConfigAPIVersion is the current schema. UpgradeRunConfig converts
+	// older configs to it.
+	RunConfigAPIVersion = RunConfigV2
+)
+
+// runConfigUpgrades lists the conversions from each schema version to the
+// next, oldest first.
+var runConfigUpgrades = []struct {
+	from, to string
+	apply    func(cfg *RunConfig) []ConfigIssue
+}{
+	{RunConfigV1, RunConfigV2, upgradeRunConfigV1},
+}
+
+// UpgradeRunConfig converts cfg in place to the current schema and sets its
+// APIVersion. It returns a warning issue for every deprecated field it converted
+// or dropped. Upgrading a current config is a no-op, so callers may upgrade
+// defensively. Unknown versions, and deprecated fields in a config that
+// declares the current version, are errors.
+func UpgradeRunConfig(cfg *RunConfig) ([]ConfigIssue, error) {
+	version := cfg.APIVersion
+	if version == "" {
+		version = RunConfigV1
+	}
+	if !knownRunConfigVersion(version) {
+		return nil, fmt.Errorf("api_version %q is not supported (supported: %s)",
+			cfg.APIVersion, strings.Join(RunConfigVersions(), ", "))
+	}
+	if version == RunConfigAPIVersion {
+		if fields := deprecatedStroppyFields(cfg.Stroppy); len(fields) > 0 {
+			return nil, fmt.Errorf("%s removed in api_version %s; use stroppy.script and stroppy.vus",
+				strings.Join(fields, ", "), RunConfigAPIVersion)
+		}
+	}
+
+	var warnings []ConfigIssue
+	for _, u := range runConfigUpgrades {
+		if u.from == version {
+			warnings = append(warnings, u.apply(cfg)...)
+			version = u.to
+		}
+	}
+	cfg.APIVersion = version
+	return warnings, nil
+}
+
+// RunConfigVersions lists the supported schema versions, oldest first.
+func RunConfigVersions() []string {
+	out := []string{RunConfigV1}
+	for _, u := range runConfigUpgrades {
+		out = append(out, u.to)
+	}
+	return out
+}
+
+func knownRunConfigVersion(v string) bool {
+	for _, known := range RunConfigVersions() {
+		if v == known {
+			return true
+		}
+	}
+	return false
+}
+
+func deprecatedStroppyFields(s StroppyConfig) []string {
+	var out []string
+	if s.Workload != "" {
+		out = append(out, "stroppy.workload")
+	}
+	if s.VUSScale != 0 {
+		out = append(out, "stroppy.vus_scale")
+	}
+	if s.Workers != 0 {
+		out = append(out, "stroppy.workers")
+	}
+	return out
+}
+
+func deprecation(path, msg string) ConfigIssue {
+	return ConfigIssue{Path: path, Message: msg, Severity: SeverityWarning}
+}
+
+// upgradeRunConfigV1 moves the v1 stroppy fields to script and vus. The
+// precedence matches what the runner used to apply: script wins over
+// workload, and vus over vus_scale over workers.
+func upgradeRunConfigV1(cfg *RunConfig) []ConfigIssue {
+	s := &cfg.Stroppy
+	var warnings []ConfigIssue
+	if s.Workload != "" {
+		if s.Script == "" {
+			s.Script = s.Workload
+			warnings = append(warnings, deprecation("stroppy.workload", "deprecated, converted to stroppy.script"))
+		} else if s.Script != s.Workload {
+			warnings = append(warnings, deprecation("stroppy.workload",
+				fmt.Sprintf("deprecated and ignored because stroppy.script is set (%q)", s.Script)))
+		} else {
+			warnings = append(warnings, deprecation("stroppy.workload", "deprecated, duplicates stroppy.script"))
+		}
+	}
+	for _, f := range []struct {
+		path string
+		vus  int
+		set  bool
+	}{
+		{"stroppy.vus_scale", int(s.VUSScale), s.VUSScale != 0},
+		{"stroppy.workers", s.Workers, s.Workers != 0},
+	} {
+		if !f.set {
+			continue
+		}
+		if s.VUs == 0 && f.vus > 0 {
+			s.VUs = f.vus
+			warnings = append(warnings, deprecation(f.path, "deprecated, converted to stroppy.vus"))
+		} else if s.VUs != 0 {
+			warnings = append(warnings, deprecation(f.path, "deprecated and ignored because stroppy.vus is set"))
+		} else {
+			warnings = append(warnings, deprecation(f.path, "deprecated and ignored: less than one VU"))
+		}
+	}
+	s.Workload, s.VUSScale, s.Workers = "", 0, 0
+	return warnings
+}
+
+// UpgradeRunConfigJSON upgrades a serialized RunConfig, e.g. one stored in a
+// run snapshot. Configs already at the current version are returned as they
+// are.
+func UpgradeRunConfigJSON(data []byte) ([]byte, []ConfigIssue, error) {
+	var cfg RunConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, nil, err
+	}
+	if cfg.APIVersion == RunConfigAPIVersion {
+		return data, nil, nil
+	}
+	warnings, err := UpgradeRunConfig(&cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := json.Marshal(cfg)
+	return out, warnings, err
+}
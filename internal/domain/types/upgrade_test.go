@@ -0,0 +1,84 @@
+package types
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestUpgradeRunConfig_V1(t *testing.T) {
+	cfg := RunConfig{Stroppy: StroppyConfig{Workload: "tpcb", Workers: 8, Duration: "60s"}}
+	warnings, err := UpgradeRunConfig(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := cfg.Stroppy
+	if s.Script != "tpcb" || s.VUs != 8 || s.Workload != "" || s.Workers != 0 {
+		t.Errorf("stroppy = %+v", s)
+	}
+	if cfg.APIVersion != RunConfigAPIVersion {
+		t.Errorf("api_version = %q", cfg.APIVersion)
+	}
+	if len(warnings) != 2 || warnings[0].Path != "stroppy.workload" || warnings[1].Path != "stroppy.workers" {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	again, err := UpgradeRunConfig(&cfg)
+	if err != nil || len(again) != 0 {
+		t.Errorf("upgrading a current config must be a no-op: %v, %v", again, err)
+	}
+}
+
+func TestUpgradeRunConfig_NewFieldsWin(t *testing.T) {
+	cfg := RunConfig{Stroppy: StroppyConfig{Script: "tpcc/tx", Workload: "tpcb", VUs: 4, VUSScale: 2.5, Workers: 8}}
+	warnings, err := UpgradeRunConfig(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Stroppy.Script != "tpcc/tx" || cfg.Stroppy.VUs != 4 {
+		t.Errorf("stroppy = %+v", cfg.Stroppy)
+	}
+	for _, w := range warnings {
+		if !strings.Contains(w.Message, "ignored") {
+			t.Errorf("%s: want an ignored warning", w)
+		}
+	}
+
+	cfg = RunConfig{Stroppy: StroppyConfig{VUSScale: 2.5, Workers: 8}}
+	if _, err := UpgradeRunConfig(&cfg); err != nil || cfg.Stroppy.VUs != 2 {
+		t.Errorf("vus_scale goes before workers: vus = %d, %v", cfg.Stroppy.VUs, err)
+	}
+}
+
+func TestUpgradeRunConfig_Errors(t *testing.T) {
+	cfg := RunConfig{APIVersion: "v9"}
+	if _, err := UpgradeRunConfig(&cfg); err == nil || !strings.Contains(err.Error(), "v1, v2") {
+		t.Errorf("unknown version: %v", err)
+	}
+	cfg = RunConfig{APIVersion: RunConfigV2, Stroppy: StroppyConfig{Workers: 2}}
+	if _, err := UpgradeRunConfig(&cfg); err == nil || !strings.Contains(err.Error(), "stroppy.workers") {
+		t.Errorf("deprecated field in a v2 config: %v", err)
+	}
+}
+
+func TestUpgradeRunConfigJSON(t *testing.T) {
+	out, warnings, err := UpgradeRunConfigJSON([]byte(`{"id":"r1","stroppy":{"workload":"tpcc/tx","vus_scale":3}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg RunConfig
+	if err := json.Unmarshal(out, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ID != "r1" || cfg.Stroppy.Script != "tpcc/tx" || cfg.Stroppy.VUs != 3 || len(warnings) != 2 {
+		t.Errorf("got %s, %v", out, warnings)
+	}
+	if strings.Contains(string(out), "workload") {
+		t.Errorf("deprecated field left in %s", out)
+	}
+
+	current := []byte(`{"api_version":"v2","id":"r2"}`)
+	if out, _, _ := UpgradeRunConfigJSON(current); string(out) != string(current) {
+		t.Errorf("current config rewritten: %s", out)
+	}
+}
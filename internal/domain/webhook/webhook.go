@@ -18,6 +18,15 @@ const (
 	EventRunFailed    = "run.failed"
 	EventNodeDone     = "node.done"
 	EventNodeFailed   = "node.failed"
+
+	// Shifts found by change-point detection over a tag's runs.
+	EventMetricRegression  = "metric.regression"
+	EventMetricImprovement = "metric.improvement"
+
+	// Outcome of a run's regression gate; gate.failed also covers a gate
+	// that could not be checked.
+	EventGatePassed = "gate.passed"
+	EventGateFailed = "gate.failed"
 )
 
 // Payload is the JSON body sent to webhook endpoints.
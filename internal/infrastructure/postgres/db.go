@@ -186,12 +186,17 @@ func seedBuiltins(ctx context.Context, pool *pgxpool.Pool) error {
 				if pre == nil {
 					pre = []string{}
 				}
+				tarball := ""
+				if bp.Tarball != nil {
+					data, _ := json.Marshal(bp.Tarball)
+					tarball = string(data)
+				}
 				_, _ = pool.Exec(ctx,
-					`INSERT INTO packages (id, tenant_id, name, description, db_kind, db_version, is_builtin, apt_packages, pre_install, custom_repo, custom_repo_key, deb_filename)
-					 VALUES ($1, $2, $3, $4, $5, $6, TRUE, $7, $8, $9, $10, '')
+					`INSERT INTO packages (id, tenant_id, name, description, db_kind, db_version, is_builtin, apt_packages, pre_install, custom_repo, custom_repo_key, deb_filename, tarball)
+					 VALUES ($1, $2, $3, $4, $5, $6, TRUE, $7, $8, $9, $10, '', $11)
 					 ON CONFLICT DO NOTHING`,
 					uuid.New().String(), tenantID, bp.Name, bp.Description,
-					bp.DbKind, bp.DbVersion, apt, pre, bp.CustomRepo, bp.CustomRepoKey,
+					bp.DbKind, bp.DbVersion, apt, pre, bp.CustomRepo, bp.CustomRepoKey, tarball,
 				)
 			}
 		}
@@ -14,6 +14,43 @@ type Baseline struct {
 	RunID    string
 }
 
+type GatePolicy struct {
+	Name      string
+	TenantID  string
+	Policy    string
+	UpdatedAt pgtype.Timestamptz
+}
+
+type MetricDef struct {
+	ID        string
+	TenantID  string
+	Key       string
+	Name      string
+	Query     string
+	Unit      string
+	Direction string
+	Threshold float64
+	DbKinds   []string
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type MetricEvent struct {
+	ID            string
+	TenantID      string
+	Tag           string
+	MetricKey     string
+	Kind          string
+	LastBeforeRun string
+	FirstAfterRun string
+	BeforeMean    float64
+	AfterMean     float64
+	ChangePct     float64
+	PValue        float64
+	RunTime       pgtype.Timestamptz
+	DetectedAt    pgtype.Timestamptz
+}
+
 type Package struct {
 	ID            string
 	TenantID      string
@@ -30,6 +67,36 @@ type Package struct {
 	DebFilename   string
 	CreatedAt     pgtype.Timestamptz
 	UpdatedAt     pgtype.Timestamptz
+	Source        string
+	DebKey        string
+	DebSha256     string
+	DebSize       int64
+	DebMeta       string
+	Tarball       string
+}
+
+type PackageBuild struct {
+	TenantID    string
+	PackageID   string
+	CommitSha   string
+	RecipeHash  string
+	DebData     []byte
+	DebFilename string
+	RunID       string
+	CreatedAt   pgtype.Timestamptz
+	DebKey      string
+	DebSha256   string
+	DebSize     int64
+}
+
+type PackageRevision struct {
+	TenantID    string
+	PackageID   string
+	Revision    int32
+	ContentHash string
+	Spec        string
+	CreatedBy   string
+	CreatedAt   pgtype.Timestamptz
 }
 
 type Preset struct {
@@ -44,6 +111,16 @@ type Preset struct {
 	UpdatedAt   pgtype.Timestamptz
 }
 
+type PresetRevision struct {
+	TenantID    string
+	PresetID    string
+	Revision    int32
+	ContentHash string
+	Spec        string
+	CreatedBy   string
+	CreatedAt   pgtype.Timestamptz
+}
+
 type RefreshToken struct {
 	ID        string
 	UserID    string
@@ -60,6 +137,29 @@ type Run struct {
 	UpdatedAt pgtype.Timestamptz
 }
 
+type RunGate struct {
+	RunID       string
+	TenantID    string
+	Gate        string
+	BaselineRun string
+	Status      string
+	Error       string
+	Result      string
+	EvaluatedAt pgtype.Timestamptz
+}
+
+type RunResult struct {
+	RunID       string
+	TenantID    string
+	DbKind      string
+	WindowStart pgtype.Timestamptz
+	WindowEnd   pgtype.Timestamptz
+	Metrics     string
+	CreatedAt   pgtype.Timestamptz
+	Tags        []string
+	ConfigHash  string
+}
+
 type SharedRun struct {
 	Token     string
 	RunID     string
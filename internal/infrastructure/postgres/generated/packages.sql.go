@@ -12,8 +12,8 @@ import (
 )
 
 const createPackage = `-- name: CreatePackage :exec
-INSERT INTO packages (id, tenant_id, name, description, db_kind, db_version, is_builtin, apt_packages, pre_install, custom_repo, custom_repo_key, deb_filename, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW())
+INSERT INTO packages (id, tenant_id, name, description, db_kind, db_version, is_builtin, apt_packages, pre_install, custom_repo, custom_repo_key, deb_filename, source, tarball, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, NOW(), NOW())
 `
 
 type CreatePackageParams struct {
@@ -29,6 +29,8 @@ type CreatePackageParams struct {
 	CustomRepo    string
 	CustomRepoKey string
 	DebFilename   string
+	Source        string
+	Tarball       string
 }
 
 func (q *Queries) CreatePackage(ctx context.Context, arg CreatePackageParams) error {
@@ -45,6 +47,8 @@ func (q *Queries) CreatePackage(ctx context.Context, arg CreatePackageParams) er
 		arg.CustomRepo,
 		arg.CustomRepoKey,
 		arg.DebFilename,
+		arg.Source,
+		arg.Tarball,
 	)
 	return err
 }
@@ -66,7 +70,7 @@ func (q *Queries) DeletePackage(ctx context.Context, arg DeletePackageParams) er
 const getPackage = `-- name: GetPackage :one
 SELECT id, tenant_id, name, description, db_kind, db_version, is_builtin,
        apt_packages, pre_install, custom_repo, custom_repo_key,
-       deb_filename, created_at, updated_at
+       deb_filename, source, tarball, created_at, updated_at
 FROM packages WHERE id = $1 AND tenant_id = $2
 `
 
@@ -88,6 +92,8 @@ type GetPackageRow struct {
 	CustomRepo    string
 	CustomRepoKey string
 	DebFilename   string
+	Source        string
+	Tarball       string
 	CreatedAt     pgtype.Timestamptz
 	UpdatedAt     pgtype.Timestamptz
 }
@@ -108,6 +114,8 @@ func (q *Queries) GetPackage(ctx context.Context, arg GetPackageParams) (GetPack
 		&i.CustomRepo,
 		&i.CustomRepoKey,
 		&i.DebFilename,
+		&i.Source,
+		&i.Tarball,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -115,7 +123,7 @@ func (q *Queries) GetPackage(ctx context.Context, arg GetPackageParams) (GetPack
 }
 
 const getPackageDebData = `-- name: GetPackageDebData :one
-SELECT deb_data, deb_filename FROM packages WHERE id = $1 AND tenant_id = $2
+SELECT deb_data, deb_filename, deb_key, deb_sha256 FROM packages WHERE id = $1 AND tenant_id = $2
 `
 
 type GetPackageDebDataParams struct {
@@ -126,19 +134,85 @@ type GetPackageDebDataParams struct {
 type GetPackageDebDataRow struct {
 	DebData     []byte
 	DebFilename string
+	DebKey      string
+	DebSha256   string
 }
 
 func (q *Queries) GetPackageDebData(ctx context.Context, arg GetPackageDebDataParams) (GetPackageDebDataRow, error) {
 	row := q.db.QueryRow(ctx, getPackageDebData, arg.ID, arg.TenantID)
 	var i GetPackageDebDataRow
-	err := row.Scan(&i.DebData, &i.DebFilename)
+	err := row.Scan(
+		&i.DebData,
+		&i.DebFilename,
+		&i.DebKey,
+		&i.DebSha256,
+	)
 	return i, err
 }
 
+const getPackageDebMeta = `-- name: GetPackageDebMeta :one
+SELECT deb_filename, deb_key, deb_sha256, deb_size, deb_meta FROM packages WHERE id = $1 AND tenant_id = $2
+`
+
+type GetPackageDebMetaParams struct {
+	ID       string
+	TenantID string
+}
+
+type GetPackageDebMetaRow struct {
+	DebFilename string
+	DebKey      string
+	DebSha256   string
+	DebSize     int64
+	DebMeta     string
+}
+
+func (q *Queries) GetPackageDebMeta(ctx context.Context, arg GetPackageDebMetaParams) (GetPackageDebMetaRow, error) {
+	row := q.db.QueryRow(ctx, getPackageDebMeta, arg.ID, arg.TenantID)
+	var i GetPackageDebMetaRow
+	err := row.Scan(
+		&i.DebFilename,
+		&i.DebKey,
+		&i.DebSha256,
+		&i.DebSize,
+		&i.DebMeta,
+	)
+	return i, err
+}
+
+const listInlinePackageDebs = `-- name: ListInlinePackageDebs :many
+SELECT id, tenant_id FROM packages WHERE deb_data IS NOT NULL AND deb_key = ''
+`
+
+type ListInlinePackageDebsRow struct {
+	ID       string
+	TenantID string
+}
+
+func (q *Queries) ListInlinePackageDebs(ctx context.Context) ([]ListInlinePackageDebsRow, error) {
+	rows, err := q.db.Query(ctx, listInlinePackageDebs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListInlinePackageDebsRow
+	for rows.Next() {
+		var i ListInlinePackageDebsRow
+		if err := rows.Scan(&i.ID, &i.TenantID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listPackages = `-- name: ListPackages :many
 SELECT id, tenant_id, name, description, db_kind, db_version, is_builtin,
        apt_packages, pre_install, custom_repo, custom_repo_key,
-       deb_filename, created_at, updated_at
+       deb_filename, source, tarball, created_at, updated_at
 FROM packages WHERE tenant_id = $1 ORDER BY is_builtin DESC, name
 `
 
@@ -155,6 +229,8 @@ type ListPackagesRow struct {
 	CustomRepo    string
 	CustomRepoKey string
 	DebFilename   string
+	Source        string
+	Tarball       string
 	CreatedAt     pgtype.Timestamptz
 	UpdatedAt     pgtype.Timestamptz
 }
@@ -181,6 +257,8 @@ func (q *Queries) ListPackages(ctx context.Context, tenantID string) ([]ListPack
 			&i.CustomRepo,
 			&i.CustomRepoKey,
 			&i.DebFilename,
+			&i.Source,
+			&i.Tarball,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -197,7 +275,7 @@ func (q *Queries) ListPackages(ctx context.Context, tenantID string) ([]ListPack
 const listPackagesByKind = `-- name: ListPackagesByKind :many
 SELECT id, tenant_id, name, description, db_kind, db_version, is_builtin,
        apt_packages, pre_install, custom_repo, custom_repo_key,
-       deb_filename, created_at, updated_at
+       deb_filename, source, tarball, created_at, updated_at
 FROM packages WHERE tenant_id = $1 AND db_kind = $2 ORDER BY is_builtin DESC, name
 `
 
@@ -219,6 +297,8 @@ type ListPackagesByKindRow struct {
 	CustomRepo    string
 	CustomRepoKey string
 	DebFilename   string
+	Source        string
+	Tarball       string
 	CreatedAt     pgtype.Timestamptz
 	UpdatedAt     pgtype.Timestamptz
 }
@@ -245,6 +325,8 @@ func (q *Queries) ListPackagesByKind(ctx context.Context, arg ListPackagesByKind
 			&i.CustomRepo,
 			&i.CustomRepoKey,
 			&i.DebFilename,
+			&i.Source,
+			&i.Tarball,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -261,7 +343,7 @@ func (q *Queries) ListPackagesByKind(ctx context.Context, arg ListPackagesByKind
 const listPackagesByKindVersion = `-- name: ListPackagesByKindVersion :many
 SELECT id, tenant_id, name, description, db_kind, db_version, is_builtin,
        apt_packages, pre_install, custom_repo, custom_repo_key,
-       deb_filename, created_at, updated_at
+       deb_filename, source, tarball, created_at, updated_at
 FROM packages WHERE tenant_id = $1 AND db_kind = $2 AND db_version = $3 ORDER BY is_builtin DESC, name
 `
 
@@ -284,6 +366,8 @@ type ListPackagesByKindVersionRow struct {
 	CustomRepo    string
 	CustomRepoKey string
 	DebFilename   string
+	Source        string
+	Tarball       string
 	CreatedAt     pgtype.Timestamptz
 	UpdatedAt     pgtype.Timestamptz
 }
@@ -310,6 +394,8 @@ func (q *Queries) ListPackagesByKindVersion(ctx context.Context, arg ListPackage
 			&i.CustomRepo,
 			&i.CustomRepoKey,
 			&i.DebFilename,
+			&i.Source,
+			&i.Tarball,
 			&i.CreatedAt,
 			&i.UpdatedAt,
 		); err != nil {
@@ -323,10 +409,34 @@ func (q *Queries) ListPackagesByKindVersion(ctx context.Context, arg ListPackage
 	return items, nil
 }
 
+const setPackageDebBlob = `-- name: SetPackageDebBlob :exec
+UPDATE packages SET deb_key = $3, deb_sha256 = $4, deb_size = $5, deb_data = NULL
+WHERE id = $1 AND tenant_id = $2
+`
+
+type SetPackageDebBlobParams struct {
+	ID        string
+	TenantID  string
+	DebKey    string
+	DebSha256 string
+	DebSize   int64
+}
+
+func (q *Queries) SetPackageDebBlob(ctx context.Context, arg SetPackageDebBlobParams) error {
+	_, err := q.db.Exec(ctx, setPackageDebBlob,
+		arg.ID,
+		arg.TenantID,
+		arg.DebKey,
+		arg.DebSha256,
+		arg.DebSize,
+	)
+	return err
+}
+
 const updatePackage = `-- name: UpdatePackage :exec
 UPDATE packages SET name = $3, description = $4, db_kind = $5, db_version = $6,
        apt_packages = $7, pre_install = $8, custom_repo = $9, custom_repo_key = $10,
-       deb_filename = $11, updated_at = NOW()
+       deb_filename = $11, source = $12, tarball = $13, updated_at = NOW()
 WHERE id = $1 AND tenant_id = $2 AND is_builtin = FALSE
 `
 
@@ -342,6 +452,8 @@ type UpdatePackageParams struct {
 	CustomRepo    string
 	CustomRepoKey string
 	DebFilename   string
+	Source        string
+	Tarball       string
 }
 
 func (q *Queries) UpdatePackage(ctx context.Context, arg UpdatePackageParams) error {
@@ -357,12 +469,15 @@ func (q *Queries) UpdatePackage(ctx context.Context, arg UpdatePackageParams) er
 		arg.CustomRepo,
 		arg.CustomRepoKey,
 		arg.DebFilename,
+		arg.Source,
+		arg.Tarball,
 	)
 	return err
 }
 
 const updatePackageDebData = `-- name: UpdatePackageDebData :exec
-UPDATE packages SET deb_data = $3, deb_filename = $4, updated_at = NOW()
+UPDATE packages SET deb_data = $3, deb_filename = $4, deb_key = $5, deb_sha256 = $6, deb_size = $7,
+       deb_meta = $8, db_kind = $9, db_version = $10, updated_at = NOW()
 WHERE id = $1 AND tenant_id = $2
 `
 
@@ -371,6 +486,12 @@ type UpdatePackageDebDataParams struct {
 	TenantID    string
 	DebData     []byte
 	DebFilename string
+	DebKey      string
+	DebSha256   string
+	DebSize     int64
+	DebMeta     string
+	DbKind      string
+	DbVersion   string
 }
 
 func (q *Queries) UpdatePackageDebData(ctx context.Context, arg UpdatePackageDebDataParams) error {
@@ -379,6 +500,12 @@ func (q *Queries) UpdatePackageDebData(ctx context.Context, arg UpdatePackageDeb
 		arg.TenantID,
 		arg.DebData,
 		arg.DebFilename,
+		arg.DebKey,
+		arg.DebSha256,
+		arg.DebSize,
+		arg.DebMeta,
+		arg.DbKind,
+		arg.DbVersion,
 	)
 	return err
 }
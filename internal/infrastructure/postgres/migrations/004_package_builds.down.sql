@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS package_builds;
+ALTER TABLE packages DROP COLUMN IF EXISTS source;
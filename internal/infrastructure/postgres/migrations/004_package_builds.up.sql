@@ -0,0 +1,15 @@
+-- Source packages: JSON-encoded types.SourceBuild; empty for apt/.deb packages.
+ALTER TABLE packages ADD COLUMN source TEXT NOT NULL DEFAULT '';
+
+-- Artifacts produced by the build_package phase, cached per commit and recipe.
+CREATE TABLE package_builds (
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    package_id   TEXT NOT NULL REFERENCES packages(id) ON DELETE CASCADE,
+    commit_sha   TEXT NOT NULL,
+    recipe_hash  TEXT NOT NULL,   -- types.SourceBuild.RecipeHash(): flags, prefix, deps
+    deb_data     BYTEA NOT NULL,
+    deb_filename TEXT NOT NULL,
+    run_id       TEXT NOT NULL DEFAULT '', -- run that produced the artifact
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, package_id, commit_sha, recipe_hash)
+);
@@ -0,0 +1,15 @@
+-- Blobs already moved to object storage cannot be restored inline: their
+-- builds are dropped (they are rebuilt on demand) and package debs are unset.
+DELETE FROM package_builds WHERE deb_data IS NULL;
+UPDATE packages SET deb_filename = '' WHERE deb_data IS NULL AND deb_key <> '';
+
+ALTER TABLE package_builds
+    DROP COLUMN IF EXISTS deb_size,
+    DROP COLUMN IF EXISTS deb_sha256,
+    DROP COLUMN IF EXISTS deb_key,
+    ALTER COLUMN deb_data SET NOT NULL;
+
+ALTER TABLE packages
+    DROP COLUMN IF EXISTS deb_size,
+    DROP COLUMN IF EXISTS deb_sha256,
+    DROP COLUMN IF EXISTS deb_key;
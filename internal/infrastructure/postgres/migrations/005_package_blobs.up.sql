@@ -0,0 +1,19 @@
+-- Package binaries move to S3-compatible object storage under content-addressed
+-- keys (sha256/<2>/<hex>). deb_data stays for rows not yet migrated and for
+-- servers running without object storage.
+ALTER TABLE packages
+    ADD COLUMN deb_key    TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN deb_sha256 TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN deb_size   BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE package_builds
+    ALTER COLUMN deb_data DROP NOT NULL,
+    ADD COLUMN deb_key    TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN deb_sha256 TEXT   NOT NULL DEFAULT '',
+    ADD COLUMN deb_size   BIGINT NOT NULL DEFAULT 0;
+
+-- Checksums for inline blobs, so every row carries one wherever the bytes live.
+UPDATE packages SET deb_sha256 = encode(sha256(deb_data), 'hex'), deb_size = octet_length(deb_data)
+WHERE deb_data IS NOT NULL;
+UPDATE package_builds SET deb_sha256 = encode(sha256(deb_data), 'hex'), deb_size = octet_length(deb_data)
+WHERE deb_data IS NOT NULL;
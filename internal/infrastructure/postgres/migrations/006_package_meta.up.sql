@@ -0,0 +1,3 @@
+-- Control metadata parsed from uploaded packages: JSON-encoded
+-- pkgmeta.Metadata (name, version, architecture, depends, provides).
+ALTER TABLE packages ADD COLUMN deb_meta TEXT NOT NULL DEFAULT '';
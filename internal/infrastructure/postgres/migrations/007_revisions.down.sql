@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS preset_revisions;
+DROP TABLE IF EXISTS package_revisions;
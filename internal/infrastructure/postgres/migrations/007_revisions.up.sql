@@ -0,0 +1,23 @@
+-- Immutable revisions of packages and presets. Each change appends one; runs
+-- record the revision they used. Existing rows get revision 1 on first use.
+CREATE TABLE package_revisions (
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    package_id   TEXT NOT NULL REFERENCES packages(id) ON DELETE CASCADE,
+    revision     INT  NOT NULL,
+    content_hash TEXT NOT NULL,  -- types.PackageSpec.Hash()
+    spec         TEXT NOT NULL,  -- JSON-encoded types.PackageSpec
+    created_by   TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, package_id, revision)
+);
+
+CREATE TABLE preset_revisions (
+    tenant_id    TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    preset_id    TEXT NOT NULL REFERENCES presets(id) ON DELETE CASCADE,
+    revision     INT  NOT NULL,
+    content_hash TEXT NOT NULL,  -- types.PresetSpec.Hash()
+    spec         TEXT NOT NULL,  -- JSON-encoded types.PresetSpec
+    created_by   TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tenant_id, preset_id, revision)
+);
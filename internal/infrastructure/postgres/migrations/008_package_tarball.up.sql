@@ -0,0 +1,2 @@
+-- Tarball packages: JSON-encoded types.Tarball; empty for apt/.deb/source packages.
+ALTER TABLE packages ADD COLUMN tarball TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,17 @@
+-- Tenant-defined metrics, collected and compared next to the built-in ones.
+CREATE TABLE metric_defs (
+    id          TEXT PRIMARY KEY,
+    tenant_id   TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    key         TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    query       TEXT NOT NULL,
+    unit        TEXT NOT NULL DEFAULT '',
+    direction   TEXT NOT NULL,
+    threshold   DOUBLE PRECISION NOT NULL DEFAULT 0,
+    db_kinds    TEXT[] NOT NULL DEFAULT '{}',
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(tenant_id, key)
+);
+
+CREATE INDEX idx_metric_defs_tenant ON metric_defs(tenant_id);
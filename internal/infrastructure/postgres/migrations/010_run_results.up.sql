@@ -0,0 +1,13 @@
+-- Metric summaries and downsampled series of finished runs, kept so
+-- comparisons outlive the VictoriaMetrics retention period.
+CREATE TABLE run_results (
+    run_id       TEXT NOT NULL,
+    tenant_id    TEXT NOT NULL,
+    db_kind      TEXT NOT NULL DEFAULT '',
+    window_start TIMESTAMPTZ NOT NULL,
+    window_end   TIMESTAMPTZ NOT NULL,
+    metrics      TEXT NOT NULL,       -- metrics.RunMetrics JSON
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (run_id, tenant_id),
+    FOREIGN KEY (run_id, tenant_id) REFERENCES runs(id, tenant_id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_run_results_tags;
+DROP INDEX IF EXISTS idx_run_results_tenant_window;
+ALTER TABLE run_results DROP COLUMN IF EXISTS config_hash, DROP COLUMN IF EXISTS tags;
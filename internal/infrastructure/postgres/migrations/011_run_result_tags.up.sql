@@ -0,0 +1,7 @@
+-- Tags and a config fingerprint let trends select comparable runs.
+ALTER TABLE run_results
+    ADD COLUMN tags        TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN config_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX idx_run_results_tenant_window ON run_results(tenant_id, window_start DESC);
+CREATE INDEX idx_run_results_tags ON run_results USING GIN (tags);
@@ -0,0 +1,20 @@
+-- Shifts in the level of a metric found by change-point detection over the
+-- stored results of a tag's runs.
+CREATE TABLE metric_events (
+    id              TEXT PRIMARY KEY,
+    tenant_id       TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    tag             TEXT NOT NULL,
+    metric_key      TEXT NOT NULL,
+    kind            TEXT NOT NULL,       -- regression | improvement
+    last_before_run TEXT NOT NULL,       -- last run at the old level
+    first_after_run TEXT NOT NULL,       -- first run at the new level
+    before_mean     DOUBLE PRECISION NOT NULL,
+    after_mean      DOUBLE PRECISION NOT NULL,
+    change_pct      DOUBLE PRECISION NOT NULL,
+    p_value         DOUBLE PRECISION NOT NULL,
+    run_time        TIMESTAMPTZ NOT NULL, -- when first_after_run's workload started
+    detected_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE(tenant_id, tag, metric_key, first_after_run)
+);
+
+CREATE INDEX idx_metric_events_tenant ON metric_events(tenant_id, run_time DESC);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS run_gates;
+DROP TABLE IF EXISTS gate_policies;
@@ -0,0 +1,22 @@
+-- Regression gates: a policy per baseline name, and the outcome of each run
+-- submitted with that gate against the baseline's run.
+CREATE TABLE gate_policies (
+    name       TEXT NOT NULL,          -- baseline name
+    tenant_id  TEXT NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    policy     TEXT NOT NULL,          -- metrics.GatePolicy JSON
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (name, tenant_id)
+);
+
+CREATE TABLE run_gates (
+    run_id       TEXT NOT NULL,
+    tenant_id    TEXT NOT NULL,
+    gate         TEXT NOT NULL,
+    baseline_run TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,        -- passed | failed | error
+    error        TEXT NOT NULL DEFAULT '',
+    result       TEXT NOT NULL DEFAULT '', -- metrics.GateResult JSON
+    evaluated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (run_id, tenant_id),
+    FOREIGN KEY (run_id, tenant_id) REFERENCES runs(id, tenant_id) ON DELETE CASCADE
+);
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	pgdb "github.com/stroppy-io/stroppy-cloud/internal/infrastructure/postgres/generated"
+)
+
+// PackageBuildStore reads the per-commit cache of source package builds.
+type PackageBuildStore struct {
+	pool *pgxpool.Pool
+}
+
+func NewPackageBuildStore(pool *pgxpool.Pool) *PackageBuildStore {
+	return &PackageBuildStore{pool: pool}
+}
+
+// PackageBuildExists reports whether a .deb for the commit and recipe is cached.
+func (s *PackageBuildStore) PackageBuildExists(ctx context.Context, tenantID, packageID, commitSHA, recipeHash string) (bool, error) {
+	return pgdb.New(s.pool).PackageBuildExists(ctx, pgdb.PackageBuildExistsParams{
+		TenantID: tenantID, PackageID: packageID, CommitSha: commitSHA, RecipeHash: recipeHash,
+	})
+}
@@ -0,0 +1,23 @@
+-- name: SetGatePolicy :exec
+INSERT INTO gate_policies (name, tenant_id, policy, updated_at) VALUES ($1, $2, $3, NOW())
+ON CONFLICT(name, tenant_id) DO UPDATE SET policy = excluded.policy, updated_at = NOW();
+
+-- name: GetGatePolicy :one
+SELECT name, tenant_id, policy, updated_at FROM gate_policies WHERE name = $1 AND tenant_id = $2;
+
+-- name: ListGatePolicies :many
+SELECT name, tenant_id, policy, updated_at FROM gate_policies WHERE tenant_id = $1 ORDER BY name;
+
+-- name: DeleteGatePolicy :execrows
+DELETE FROM gate_policies WHERE name = $1 AND tenant_id = $2;
+
+-- name: SaveRunGate :exec
+INSERT INTO run_gates (run_id, tenant_id, gate, baseline_run, status, error, result, evaluated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+ON CONFLICT(run_id, tenant_id) DO UPDATE SET
+    gate = excluded.gate, baseline_run = excluded.baseline_run, status = excluded.status,
+    error = excluded.error, result = excluded.result, evaluated_at = NOW();
+
+-- name: GetRunGate :one
+SELECT run_id, tenant_id, gate, baseline_run, status, error, result, evaluated_at FROM run_gates
+WHERE run_id = $1 AND tenant_id = $2;
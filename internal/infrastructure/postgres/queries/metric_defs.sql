@@ -0,0 +1,18 @@
+-- name: CreateMetricDef :exec
+INSERT INTO metric_defs (id, tenant_id, key, name, query, unit, direction, threshold, db_kinds, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW());
+
+-- name: GetMetricDef :one
+SELECT id, tenant_id, key, name, query, unit, direction, threshold, db_kinds, created_at, updated_at
+FROM metric_defs WHERE id = $1 AND tenant_id = $2;
+
+-- name: ListMetricDefs :many
+SELECT id, tenant_id, key, name, query, unit, direction, threshold, db_kinds, created_at, updated_at
+FROM metric_defs WHERE tenant_id = $1 ORDER BY key;
+
+-- name: UpdateMetricDef :exec
+UPDATE metric_defs SET name = $3, query = $4, unit = $5, direction = $6, threshold = $7, db_kinds = $8, updated_at = NOW()
+WHERE id = $1 AND tenant_id = $2;
+
+-- name: DeleteMetricDef :exec
+DELETE FROM metric_defs WHERE id = $1 AND tenant_id = $2;
@@ -0,0 +1,19 @@
+-- name: CreateMetricEvent :execrows
+INSERT INTO metric_events (id, tenant_id, tag, metric_key, kind, last_before_run, first_after_run,
+                           before_mean, after_mean, change_pct, p_value, run_time, detected_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+ON CONFLICT(tenant_id, tag, metric_key, first_after_run) DO NOTHING;
+
+-- name: ListMetricEvents :many
+-- Newest first; an empty tag matches every tag.
+SELECT id, tenant_id, tag, metric_key, kind, last_before_run, first_after_run,
+       before_mean, after_mean, change_pct, p_value, run_time, detected_at
+FROM metric_events
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.arg(tag)::text = '' OR tag = sqlc.arg(tag)::text)
+ORDER BY run_time DESC
+LIMIT sqlc.arg(max_rows);
+
+-- name: ListRunResultTags :many
+SELECT DISTINCT tenant_id, unnest(tags)::text AS tag FROM run_results
+ORDER BY tenant_id, tag;
@@ -0,0 +1,34 @@
+-- name: UpsertPackageBuild :exec
+INSERT INTO package_builds (tenant_id, package_id, commit_sha, recipe_hash, deb_data, deb_filename, run_id,
+                            deb_key, deb_sha256, deb_size, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+ON CONFLICT (tenant_id, package_id, commit_sha, recipe_hash)
+DO UPDATE SET deb_data = excluded.deb_data, deb_filename = excluded.deb_filename, run_id = excluded.run_id,
+              deb_key = excluded.deb_key, deb_sha256 = excluded.deb_sha256, deb_size = excluded.deb_size,
+              created_at = NOW();
+
+-- name: PackageBuildExists :one
+SELECT EXISTS (
+    SELECT 1 FROM package_builds
+    WHERE tenant_id = $1 AND package_id = $2 AND commit_sha = $3 AND recipe_hash = $4
+);
+
+-- name: GetPackageBuildData :one
+SELECT deb_data, deb_filename, deb_key, deb_sha256 FROM package_builds
+WHERE tenant_id = $1 AND package_id = $2 AND commit_sha = $3 AND recipe_hash = $4;
+
+-- name: GetPackageBuildMeta :one
+SELECT deb_filename, deb_key, deb_sha256, deb_size FROM package_builds
+WHERE tenant_id = $1 AND package_id = $2 AND commit_sha = $3 AND recipe_hash = $4;
+
+-- name: ListPackageBuilds :many
+SELECT commit_sha, recipe_hash, deb_filename, deb_sha256, deb_size, run_id, created_at
+FROM package_builds WHERE tenant_id = $1 AND package_id = $2 ORDER BY created_at DESC;
+
+-- name: ListInlinePackageBuilds :many
+SELECT tenant_id, package_id, commit_sha, recipe_hash FROM package_builds
+WHERE deb_data IS NOT NULL AND deb_key = '';
+
+-- name: SetPackageBuildBlob :exec
+UPDATE package_builds SET deb_key = $5, deb_sha256 = $6, deb_size = $7, deb_data = NULL
+WHERE tenant_id = $1 AND package_id = $2 AND commit_sha = $3 AND recipe_hash = $4;
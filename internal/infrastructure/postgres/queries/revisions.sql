@@ -0,0 +1,47 @@
+-- name: CreatePackageRevision :one
+INSERT INTO package_revisions (tenant_id, package_id, revision, content_hash, spec, created_by, created_at)
+SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, $4, $5, NOW()
+FROM package_revisions WHERE tenant_id = $1 AND package_id = $2
+RETURNING revision;
+
+-- name: GetLatestPackageRevision :one
+SELECT revision, content_hash, spec FROM package_revisions
+WHERE tenant_id = $1 AND package_id = $2
+ORDER BY revision DESC LIMIT 1;
+
+-- name: GetPackageRevision :one
+SELECT revision, content_hash, spec, created_by, created_at FROM package_revisions
+WHERE tenant_id = $1 AND package_id = $2 AND revision = $3;
+
+-- name: ListPackageRevisions :many
+SELECT revision, content_hash, created_by, created_at FROM package_revisions
+WHERE tenant_id = $1 AND package_id = $2
+ORDER BY revision DESC;
+
+-- name: CreatePresetRevision :one
+INSERT INTO preset_revisions (tenant_id, preset_id, revision, content_hash, spec, created_by, created_at)
+SELECT $1, $2, COALESCE(MAX(revision), 0) + 1, $3, $4, $5, NOW()
+FROM preset_revisions WHERE tenant_id = $1 AND preset_id = $2
+RETURNING revision;
+
+-- name: GetLatestPresetRevision :one
+SELECT revision, content_hash, spec FROM preset_revisions
+WHERE tenant_id = $1 AND preset_id = $2
+ORDER BY revision DESC LIMIT 1;
+
+-- name: GetPresetRevision :one
+SELECT revision, content_hash, spec, created_by, created_at FROM preset_revisions
+WHERE tenant_id = $1 AND preset_id = $2 AND revision = $3;
+
+-- name: ListPresetRevisions :many
+SELECT revision, content_hash, created_by, created_at FROM preset_revisions
+WHERE tenant_id = $1 AND preset_id = $2
+ORDER BY revision DESC;
+
+-- name: LockPackage :exec
+-- Serializes revision numbering of a package until the transaction ends.
+SELECT id FROM packages WHERE tenant_id = $1 AND id = $2 FOR UPDATE;
+
+-- name: LockPreset :exec
+-- Serializes revision numbering of a preset until the transaction ends.
+SELECT id FROM presets WHERE tenant_id = $1 AND id = $2 FOR UPDATE;
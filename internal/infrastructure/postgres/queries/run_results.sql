@@ -0,0 +1,21 @@
+-- name: SaveRunResult :exec
+INSERT INTO run_results (run_id, tenant_id, db_kind, window_start, window_end, metrics, tags, config_hash, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+ON CONFLICT(run_id, tenant_id) DO UPDATE SET
+    db_kind = excluded.db_kind, window_start = excluded.window_start,
+    window_end = excluded.window_end, metrics = excluded.metrics,
+    tags = excluded.tags, config_hash = excluded.config_hash, created_at = NOW();
+
+-- name: GetRunResult :one
+SELECT run_id, tenant_id, db_kind, window_start, window_end, metrics, created_at, tags, config_hash FROM run_results
+WHERE run_id = $1 AND tenant_id = $2;
+
+-- name: ListRunResults :many
+-- Newest first; empty filters match every run.
+SELECT run_id, tenant_id, db_kind, window_start, window_end, metrics, created_at, tags, config_hash FROM run_results
+WHERE tenant_id = sqlc.arg(tenant_id)
+  AND (sqlc.arg(tag)::text = '' OR sqlc.arg(tag)::text = ANY(tags))
+  AND (sqlc.arg(config_hash)::text = '' OR config_hash = sqlc.arg(config_hash)::text)
+  AND (sqlc.arg(db_kind)::text = '' OR db_kind = sqlc.arg(db_kind)::text)
+ORDER BY window_start DESC
+LIMIT sqlc.arg(max_rows);
@@ -9,6 +9,7 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/stroppy-io/stroppy-cloud/internal/core/dag"
+	"github.com/stroppy-io/stroppy-cloud/internal/domain/types"
 )
 
 type RunStorage struct {
@@ -49,9 +50,22 @@ func (s *RunStorage) Load(ctx context.Context, tenantID, id string) (*dag.Snapsh
 	if err := json.Unmarshal([]byte(data), &snap); err != nil {
 		return nil, fmt.Errorf("run storage: unmarshal: %w", err)
 	}
+	upgradeRunConfig(&snap)
 	return &snap, nil
 }
 
+// upgradeRunConfig converts the run config of a snapshot saved under an
+// older schema to the current one, so readers never see deprecated fields.
+// A config that cannot be upgraded is left as it was stored.
+func upgradeRunConfig(snap *dag.Snapshot) {
+	if snap.State == nil || len(snap.State.RunConfig) == 0 {
+		return
+	}
+	if data, _, err := types.UpgradeRunConfigJSON(snap.State.RunConfig); err == nil {
+		snap.State.RunConfig = data
+	}
+}
+
 func (s *RunStorage) List(ctx context.Context, tenantID string) ([]dag.RunSummary, error) {
 	rows, err := s.pool.Query(ctx,
 		"SELECT id, snapshot FROM runs WHERE tenant_id = $1 ORDER BY created_at DESC", tenantID,
@@ -75,6 +89,7 @@ func (s *RunStorage) List(ctx context.Context, tenantID string) ([]dag.RunSummar
 			ID: id, Total: len(snap.Nodes),
 			StartedAt: snap.StartedAt, FinishedAt: snap.FinishedAt,
 		}
+		upgradeRunConfig(&snap)
 		if snap.State != nil {
 			summary.Provider = snap.State.Provider
 			if len(snap.State.RunConfig) > 0 {
@@ -0,0 +1,136 @@
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"go.uber.org/zap"
+)
+
+// Blob identifies a stored object by its content.
+type Blob struct {
+	Key    string
+	SHA256 string // hex
+	Size   int64
+}
+
+// BlobStore keeps content-addressed blobs (package .debs) in a single bucket.
+// Identical uploads share one object.
+type BlobStore struct {
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+}
+
+// NewBlobStore connects to the bucket described by config, creating it if needed.
+func NewBlobStore(ctx context.Context, config *Config, lg *zap.Logger) (*BlobStore, error) {
+	client := NewS3Client(ctx, config, lg)
+	if err := CreateS3BucketIfNotExists(ctx, client, config.Bucket); err != nil {
+		return nil, fmt.Errorf("s3: ensure bucket %s: %w", config.Bucket, err)
+	}
+	presignClient := client
+	if config.PublicUrl != "" && config.PublicUrl != config.Url {
+		public := *config
+		public.Url = config.PublicUrl
+		presignClient = NewS3Client(ctx, &public, lg)
+	}
+	return &BlobStore{
+		client:  client,
+		presign: s3.NewPresignClient(presignClient),
+		bucket:  config.Bucket,
+	}, nil
+}
+
+// BlobKey is the object key of content with the given hex SHA-256.
+func BlobKey(sha string) string {
+	return "sha256/" + sha[:2] + "/" + sha
+}
+
+// Put stores r under its content address. The body is spooled to a temp file
+// first so the checksum is known before upload; existing objects are not
+// uploaded again.
+func (b *BlobStore) Put(ctx context.Context, r io.Reader) (Blob, error) {
+	f, blob, err := spool(r)
+	if err != nil {
+		return Blob{}, err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	if ok, err := b.exists(ctx, blob.Key); err != nil {
+		return Blob{}, err
+	} else if ok {
+		return blob, nil
+	}
+
+	sum, _ := hex.DecodeString(blob.SHA256)
+	_, err = b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:         aws.String(b.bucket),
+		Key:            aws.String(blob.Key),
+		Body:           f,
+		ContentLength:  aws.Int64(blob.Size),
+		ChecksumSHA256: aws.String(base64.StdEncoding.EncodeToString(sum)),
+	})
+	if err != nil {
+		return Blob{}, fmt.Errorf("s3: put %s: %w", blob.Key, err)
+	}
+	return blob, nil
+}
+
+// PresignGet returns a URL that downloads key without credentials until ttl
+// expires. filename sets the Content-Disposition of the response.
+func (b *BlobStore) PresignGet(ctx context.Context, key, filename string, ttl time.Duration) (string, error) {
+	in := &s3.GetObjectInput{Bucket: aws.String(b.bucket), Key: aws.String(key)}
+	if filename != "" {
+		in.ResponseContentDisposition = aws.String(`attachment; filename="` + filename + `"`)
+	}
+	req, err := b.presign.PresignGetObject(ctx, in, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", fmt.Errorf("s3: presign %s: %w", key, err)
+	}
+	return req.URL, nil
+}
+
+func (b *BlobStore) exists(ctx context.Context, key string) (bool, error) {
+	_, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(b.bucket), Key: aws.String(key)})
+	if err == nil {
+		return true, nil
+	}
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return false, nil
+	}
+	return false, fmt.Errorf("s3: head %s: %w", key, err)
+}
+
+// spool copies r into a temp file, hashing it on the way, and rewinds the file.
+func spool(r io.Reader) (*os.File, Blob, error) {
+	f, err := os.CreateTemp("", "stroppy-blob-*")
+	if err != nil {
+		return nil, Blob{}, fmt.Errorf("s3: spool: %w", err)
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), r)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, Blob{}, fmt.Errorf("s3: spool: %w", err)
+	}
+	sha := hex.EncodeToString(h.Sum(nil))
+	return f, Blob{Key: BlobKey(sha), SHA256: sha, Size: n}, nil
+}
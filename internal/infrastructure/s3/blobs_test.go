@@ -0,0 +1,29 @@
+package s3
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSpool(t *testing.T) {
+	f, blob, err := spool(strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	const sha = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+	if blob.SHA256 != sha || blob.Size != 5 {
+		t.Errorf("blob = %+v", blob)
+	}
+	if blob.Key != "sha256/2c/"+sha {
+		t.Errorf("key = %q", blob.Key)
+	}
+	data, _ := io.ReadAll(f)
+	if string(data) != "hello" {
+		t.Errorf("spooled file not rewound, read %q", data)
+	}
+}
@@ -10,6 +10,11 @@ type Config struct {
 	Region          string `mapstructure:"region" default:"us-east-1" validate:"required"`
 	SecretAccessKey string `mapstructure:"secret_key" validate:"required"`
 	AccessKeyId     string `mapstructure:"key_id" validate:"required"`
+	// Bucket holds package blobs.
+	Bucket string `mapstructure:"bucket" default:"stroppy-packages"`
+	// PublicUrl is the endpoint agents reach for presigned downloads when it
+	// differs from Url (e.g. MinIO on localhost vs. agents on remote VMs).
+	PublicUrl string `mapstructure:"public_url"`
 }
 
 func (c Config) Validate() error {
@@ -33,14 +38,29 @@ const (
 	s3RegionKey          = "S3_REGION"
 	s3SecretAccessKeyKey = "S3_SECRET_ACCESS_KEY"
 	s3AccessKeyIdKey     = "S3_ACCESS_KEY_ID"
+	s3BucketKey          = "S3_BUCKET"
+	s3PublicUrlKey       = "S3_PUBLIC_URL"
+
+	defaultBucket = "stroppy-packages"
 )
 
+// Enabled reports whether object storage is configured in the environment.
+// When it is not, package blobs stay in Postgres.
+func Enabled() bool {
+	return os.Getenv(s3UrlKey) != ""
+}
+
 func NewConfigFromEnv() (*Config, error) {
 	c := &Config{
 		Url:             os.Getenv(s3UrlKey),
 		Region:          os.Getenv(s3RegionKey),
 		SecretAccessKey: os.Getenv(s3SecretAccessKeyKey),
 		AccessKeyId:     os.Getenv(s3AccessKeyIdKey),
+		Bucket:          os.Getenv(s3BucketKey),
+		PublicUrl:       os.Getenv(s3PublicUrlKey),
+	}
+	if c.Bucket == "" {
+		c.Bucket = defaultBucket
 	}
 	err := c.Validate()
 	if err != nil {
@@ -1,11 +1,17 @@
 import type {
   RunConfig,
+  ConfigIssue,
+  DBParamCatalog,
   Snapshot,
+  RunSettingsResponse,
   RunSummary,
   Preset,
   ServerSettings,
   Package,
   ComparisonResponse,
+  MatrixResponse,
+  MetricDef,
+  MetricDefsResponse,
   MetricValue,
   GrafanaSettings,
   AuthUser,
@@ -15,6 +21,8 @@ import type {
   DatabaseKind,
   ProbeRequest,
   ProbeResponse,
+  RevisionInfo,
+  RevisionDiff,
 } from "./types";
 
 const API_BASE = "/api/v1";
@@ -318,7 +326,7 @@ export async function cancelRun(
 
 export async function validateRun(
   config: RunConfig
-): Promise<{ status: string; error?: string }> {
+): Promise<{ status: string; error?: string; issues?: ConfigIssue[] }> {
   return request(`${API_BASE}/validate`, {
     method: "POST",
     body: JSON.stringify(config),
@@ -336,6 +344,35 @@ export async function getRunStatus(runID: string): Promise<Snapshot> {
   return request(`${API_BASE}/run/${runID}/status`);
 }
 
+export async function getRunSettings(runID: string, values = false): Promise<RunSettingsResponse> {
+  return request(`${API_BASE}/run/${runID}/settings${values ? "?values=true" : ""}`);
+}
+
+// ---------- Metric definitions ----------
+
+export async function listMetricDefs(dbKind?: string): Promise<MetricDefsResponse> {
+  const suffix = dbKind ? `?db_kind=${encodeURIComponent(dbKind)}` : "";
+  return request(`${API_BASE}/metrics/defs${suffix}`);
+}
+
+export async function createMetricDef(def: MetricDef): Promise<{ id: string }> {
+  return request(`${API_BASE}/metrics/defs`, {
+    method: "POST",
+    body: JSON.stringify(def),
+  });
+}
+
+export async function updateMetricDef(id: string, def: MetricDef): Promise<{ status: string }> {
+  return request(`${API_BASE}/metrics/defs/${id}`, {
+    method: "PUT",
+    body: JSON.stringify(def),
+  });
+}
+
+export async function deleteMetricDef(id: string): Promise<{ status: string }> {
+  return request(`${API_BASE}/metrics/defs/${id}`, { method: "DELETE" });
+}
+
 // ---------- Presets ----------
 
 export async function listPresets(params?: {
@@ -351,6 +388,17 @@ export async function getPreset(id: string): Promise<Preset> {
   return request(`${API_BASE}/presets/${id}`);
 }
 
+export async function listPresetRevisions(id: string): Promise<RevisionInfo[]> {
+  return request(`${API_BASE}/presets/${id}/revisions`);
+}
+
+export async function diffPresetRevisions(id: string, from?: number, to?: number): Promise<RevisionDiff> {
+  const params = new URLSearchParams();
+  if (from) params.set("from", String(from));
+  if (to) params.set("to", String(to));
+  return request(`${API_BASE}/presets/${id}/revisions/diff?${params}`);
+}
+
 export async function createPreset(data: {
   name: string;
   description?: string;
@@ -448,6 +496,15 @@ export async function compareRuns(
   return request(`${API_BASE}/compare?${params.toString()}`);
 }
 
+export async function compareMany(
+  runs: string[],
+  ref?: string
+): Promise<MatrixResponse> {
+  const params = new URLSearchParams({ runs: runs.join(",") });
+  if (ref) params.set("ref", ref);
+  return request(`${API_BASE}/compare?${params.toString()}`);
+}
+
 // ---------- Settings ----------
 
 export async function getSettings(): Promise<ServerSettings> {
@@ -474,6 +531,14 @@ export async function getDBDefaults(
   return result;
 }
 
+export async function getParameterCatalog(
+  engine: string,
+  version?: string
+): Promise<DBParamCatalog> {
+  const qs = version ? `?version=${encodeURIComponent(version)}` : "";
+  return request(`${API_BASE}/parameters/${engine}${qs}`);
+}
+
 // ---------- Grafana ----------
 
 export async function getGrafanaSettings(): Promise<GrafanaSettings> {
@@ -497,6 +562,17 @@ export async function getPackage(id: string): Promise<Package> {
   return request(`${API_BASE}/packages/${id}`);
 }
 
+export async function listPackageRevisions(id: string): Promise<RevisionInfo[]> {
+  return request(`${API_BASE}/packages/${id}/revisions`);
+}
+
+export async function diffPackageRevisions(id: string, from?: number, to?: number): Promise<RevisionDiff> {
+  const params = new URLSearchParams();
+  if (from) params.set("from", String(from));
+  if (to) params.set("to", String(to));
+  return request(`${API_BASE}/packages/${id}/revisions/diff?${params}`);
+}
+
 export async function createPackage(data: {
   name: string;
   description?: string;
@@ -9,8 +9,11 @@ export const ALL_DB_KINDS: DatabaseKind[] = ["postgres", "mysql", "picodata", "y
 export type Phase =
   | "network"
   | "machines"
+  | "tune_os"
+  | "build_package"
   | "install_db"
   | "configure_db"
+  | "capture_settings"
   | "install_monitor"
   | "configure_monitor"
   | "install_stroppy"
@@ -29,7 +32,8 @@ export type MachineRole =
   | "stroppy"
   | "etcd"
   | "proxy"
-  | "pgbouncer";
+  | "pgbouncer"
+  | "builder";
 
 export type NodeStatusValue = "pending" | "running" | "done" | "failed" | "cancelled";
 
@@ -42,8 +46,12 @@ export interface MachineSpec {
   memory_mb: number;
   disk_gb: number;
   disk_type?: string;
+  tuning_profile?: string;
 }
 
+// Hardware-aware database tuning; empty keeps the static defaults.
+export type AutoTuneProfile = "oltp" | "analytic" | "mixed";
+
 export interface PostgresTopology {
   master: MachineSpec;
   replicas?: MachineSpec[];
@@ -52,6 +60,7 @@ export interface PostgresTopology {
   patroni: boolean;
   etcd: boolean;
   sync_replicas: number;
+  auto_tune?: AutoTuneProfile;
   master_options?: Record<string, string>;
   replica_options?: Record<string, string>;
   haproxy_options?: Record<string, string>;
@@ -66,6 +75,7 @@ export interface MySQLTopology {
   proxysql?: MachineSpec;
   group_replication: boolean;
   semi_sync: boolean;
+  auto_tune?: AutoTuneProfile;
   primary_options?: Record<string, string>;
   replica_options?: Record<string, string>;
   proxysql_options?: Record<string, string>;
@@ -84,6 +94,7 @@ export interface PicodataTopology {
   replication_factor: number;
   shards: number;
   tiers?: PicodataTier[];
+  auto_tune?: AutoTuneProfile;
   instance_options?: Record<string, string>;
   haproxy_options?: Record<string, string>;
 }
@@ -94,6 +105,7 @@ export interface YDBTopology {
   haproxy?: MachineSpec;
   fault_tolerance: string;
   database_path: string;
+  auto_tune?: AutoTuneProfile;
   storage_options?: Record<string, string>;
   database_options?: Record<string, string>;
   haproxy_options?: Record<string, string>;
@@ -125,7 +137,7 @@ export interface StroppyConfig {
   no_steps?: string[];          // step blocklist
   machine?: MachineSpec;        // stroppy runner machine spec
   config_override_json?: string; // raw stroppy protojson override
-  // Deprecated — kept for backward compat with existing runs.
+  // Deprecated (api_version v1) — the server converts them to script/vus.
   workload?: string;
   vus_scale?: number;
   workers?: number;
@@ -170,11 +182,130 @@ export interface Package {
   custom_repo_key?: string;
   deb_filename?: string;
   has_deb?: boolean;
+  /** checksum and size of the uploaded .deb (package detail only) */
+  deb_sha256?: string;
+  deb_size?: number;
+  /** control metadata parsed from the uploaded package */
+  deb_meta?: PackageMeta;
+  /** set for packages compiled from git on a builder machine */
+  source?: SourceBuild;
+  /** set for packages installed from a tarball of prebuilt binaries */
+  tarball?: Tarball;
+  /** cached source builds, newest first (package detail only) */
+  builds?: PackageBuild[];
   created_at?: string;
   updated_at?: string;
 }
 
+export interface PackageMeta {
+  format: "deb" | "rpm";
+  name: string;
+  version: string;
+  architecture: string;
+  depends?: string[];
+  provides?: string[];
+  description?: string;
+}
+
+export type BuildSystem = "autotools" | "cmake" | "meson";
+
+export interface SourceBuild {
+  git_url: string;
+  ref: string;
+  build_system?: BuildSystem;
+  configure_flags?: string[];
+  make_flags?: string[];
+  prefix?: string;
+  build_deps?: string[];
+  depends?: string[];
+  post_install?: string[];
+  machine?: MachineSpec;
+}
+
+export interface Tarball {
+  /** download URL; empty when the archive is uploaded to the package */
+  url?: string;
+  sha256?: string;
+  prefix?: string;
+  strip_components?: number;
+  bin_dirs?: string[];
+  lib_dirs?: string[];
+  depends?: string[];
+  units?: SystemdUnit[];
+  post_install?: string[];
+}
+
+/** systemd unit rendered with Go text/template: {{.Prefix}}, {{.Version}}, {{.Name}} */
+export interface SystemdUnit {
+  name: string;
+  template: string;
+}
+
+export interface PackageBuild {
+  commit_sha: string;
+  recipe_hash: string;
+  deb_filename: string;
+  sha256: string;
+  size_bytes: number;
+  run_id?: string;
+  created_at: string;
+}
+
+/** Immutable version of a package or preset. */
+export interface Revision {
+  number: number;
+  hash?: string;
+}
+
+export interface RevisionInfo {
+  revision: number;
+  hash: string;
+  created_by?: string;
+  created_at?: string;
+  spec?: Record<string, unknown>;
+}
+
+export interface RevisionDiff {
+  from: RevisionInfo;
+  to: RevisionInfo;
+  same_content: boolean;
+  changes: { path: string; from?: unknown; to?: unknown }[];
+}
+
+// DBParam is one entry of a database parameter catalog
+// (GET /api/v1/parameters/{engine}).
+export interface DBParam {
+  name: string;
+  type: "bool" | "int" | "real" | "string" | "enum" | "memory" | "duration";
+  unit?: string;
+  min?: number;
+  max?: number;
+  values?: string[];
+  restart: boolean;
+  since?: string;
+  until?: string;
+  description?: string;
+}
+
+export interface DBParamCatalog {
+  engine: string;
+  file: string;
+  version: string;
+  default_version: string;
+  versions: string[];
+  params: DBParam[];
+}
+
+// ConfigIssue is one problem found in a run config, addressed by field path
+// (e.g. "database.ydb.storage.count").
+export interface ConfigIssue {
+  path: string;
+  message: string;
+  severity: "error" | "warning";
+}
+
 export interface RunConfig {
+  api_version?: string; // schema version, "v2" is current; missing means v1
   id: string;
   provider: Provider;
   network: NetworkConfig;
@@ -184,6 +315,9 @@ export interface RunConfig {
   stroppy: StroppyConfig;
   preset_id?: string;
   package_id?: string;
+  /** revisions pinned at run start */
+  preset_revision?: Revision;
+  package_revision?: Revision;
   platform_id?: string;
   machine_override?: MachineSpec;
 }
@@ -194,6 +328,8 @@ export interface NodeStatus {
   id: string;
   status: NodeStatusValue;
   error?: string;
+  started_at?: string;
+  finished_at?: string;
 }
 
 export interface SnapshotTarget {
@@ -208,15 +344,87 @@ export interface Snapshot {
   nodes: NodeStatus[];
   started_at?: string;
   finished_at?: string;
+  /** Set when the run was submitted with a gate. */
+  gate?: GateStatus;
   state?: {
     provider?: string;
     run_config?: Record<string, unknown> | string; // object or JSON string
     targets?: SnapshotTarget[];
     effective_configs?: Record<string, Record<string, string>>;
+    /** target ID → settings captured from the running database */
+    db_settings?: Record<string, DBSettings>;
     [key: string]: unknown;
   };
 }
 
+// --- Regression gates ---
+
+export interface GatePolicy {
+  /** Max degradation in percent by metric key; "*" covers unlisted metrics. */
+  max_degradation_pct: Record<string, number>;
+  max_p_value?: number;
+  ignore?: string[];
+}
+
+export interface GateCheck {
+  key: string;
+  name: string;
+  unit: string;
+  baseline: number;
+  candidate: number;
+  degradation_pct: number;
+  max_degradation_pct: number;
+  p_value?: number;
+  passed: boolean;
+  reason?: string;
+}
+
+export interface GateStatus {
+  name: string;
+  status: "pending" | "passed" | "failed" | "error" | "skipped";
+  baseline_run?: string;
+  error?: string;
+  result?: {
+    baseline_run: string;
+    candidate_run: string;
+    passed: boolean;
+    checks: GateCheck[];
+  };
+  evaluated_at?: string;
+}
+
+// --- Live database settings ---
+
+export interface DBSettings {
+  role: string;
+  sources?: string[];
+  values?: Record<string, string>;
+  error?: string;
+}
+
+export interface SettingDiff {
+  name: string;
+  rendered: string;
+  live: string;
+}
+
+export interface NodeSettingsDiff {
+  node: string;
+  role: string;
+  sources?: string[];
+  error?: string;
+  compared: number;
+  diffs: SettingDiff[];
+  not_reported?: string[];
+  values?: Record<string, string>;
+}
+
+export interface RunSettingsResponse {
+  run_id: string;
+  kind: DatabaseKind;
+  nodes: NodeSettingsDiff[];
+}
+
 // --- Run Summary ---
 
 export interface RunSummary {
@@ -321,18 +529,140 @@ export interface ComparisonRow {
   max_b: number;
   diff_avg_pct: number;
   diff_max_pct: number;
+  /** for groups, "same" means no significant change */
   verdict: "better" | "worse" | "same";
+  /** set when groups of repeated runs are compared */
+  stats?: GroupStats;
+}
+
+export interface SampleStats {
+  n: number;
+  mean: number;
+  stddev: number;
+  /** coefficient of variation, stddev / mean */
+  cv: number;
+  /** 95% confidence interval of the mean */
+  ci_low: number;
+  ci_high: number;
+}
+
+export interface GroupStats {
+  a: SampleStats;
+  b: SampleStats;
+  /** two-sided Mann-Whitney U test */
+  u: number;
+  p_value: number;
+  significant: boolean;
 }
 
 export interface ComparisonResponse {
   run_a: string;
   run_b: string;
+  /** every run of each group when repetitions are compared */
+  runs_a?: string[];
+  runs_b?: string[];
+  /** span of both windows */
   start: string;
   end: string;
+  /** range each run was collected over: its run_stroppy workload, or an explicit range */
+  window_a: { Start: string; End: string };
+  window_b: { Start: string; End: string };
   metrics: ComparisonRow[];
   summary: { better: number; worse: number; same: number };
+  /** per-metric series of both runs, t in seconds since each window's start */
+  series?: SeriesPair[];
   config_a?: RunConfig;
   config_b?: RunConfig;
+  /** machine role → effective OS settings recorded by the tune_os phase */
+  os_tuning_a?: Record<string, Record<string, string>>;
+  os_tuning_b?: Record<string, Record<string, string>>;
+  /** every setting that differs between the runs, ordered by section */
+  config_diff?: ConfigChange[];
+}
+
+export interface MatrixCell {
+  run: string;
+  avg: number;
+  max: number;
+  /** percent against the reference run's average */
+  diff_pct: number;
+  verdict: "better" | "worse" | "same" | "reference";
+  missing?: boolean;
+}
+
+export interface MatrixRow {
+  key: string;
+  name: string;
+  unit: string;
+  direction?: MetricDirection;
+  /** one cell per run, in MatrixResponse.runs order */
+  cells: MatrixCell[];
+  /** runs with the best and worst average; unset when all runs tie */
+  best?: string;
+  worst?: string;
+}
+
+export interface MatrixResponse {
+  runs: string[];
+  reference: string;
+  start: string;
+  end: string;
+  /** each run's collection window, in runs order */
+  windows: { Start: string; End: string }[];
+  metrics: MatrixRow[];
+  /** run → settings that differ from the reference run */
+  config_diff: Record<string, ConfigChange[]>;
+}
+
+export type MetricDirection = "higher" | "lower";
+
+export interface MetricDef {
+  key: string;
+  name: string;
+  /** PromQL; %s is the run label filter, %p the run's metric prefix */
+  query: string;
+  unit: string;
+  /** which way is better; built-ins without one are treated as "lower" */
+  direction?: MetricDirection;
+  /** percent; replaces the comparison threshold for this metric */
+  threshold?: number;
+  /** database kinds the metric applies to; empty = all */
+  db_kinds?: DatabaseKind[];
+}
+
+export interface CustomMetricDef extends MetricDef {
+  id: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface MetricDefsResponse {
+  builtin: MetricDef[];
+  custom: CustomMetricDef[];
+}
+
+export interface SeriesPoint {
+  t: number;
+  v: number;
+}
+
+export interface SeriesPair {
+  key: string;
+  name: string;
+  unit: string;
+  a: SeriesPoint[];
+  b: SeriesPoint[];
+}
+
+export type ConfigChangeSection = "config" | "options" | "package" | "stroppy" | "db_settings" | "machine";
+
+export interface ConfigChange {
+  section: ConfigChangeSection;
+  path: string;
+  /** value in run A ("" = unset) */
+  a: string;
+  /** value in run B ("" = unset) */
+  b: string;
 }
 
 // --- Auth / Multi-tenancy ---
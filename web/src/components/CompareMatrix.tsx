@@ -0,0 +1,75 @@
+import { Link } from "react-router-dom";
+import type { MatrixResponse } from "@/api/types";
+import { formatValue } from "@/components/MetricsDiff";
+
+/** One row per metric, one column per run; diffs are against the reference run. */
+export function CompareMatrix({ matrix }: { matrix: MatrixResponse }) {
+  if (matrix.metrics.length === 0) {
+    return (
+      <div className="p-4 text-xs text-zinc-600 font-mono">
+        No metrics data found for these runs.
+      </div>
+    );
+  }
+  return (
+    <div className="overflow-x-auto">
+      <table className="w-full text-[11px] font-mono">
+        <thead>
+          <tr className="text-zinc-500 border-b border-zinc-800/60">
+            <th className="text-left font-normal px-4 py-1.5">Metric</th>
+            {matrix.runs.map((id) => (
+              <th key={id} className="text-right font-normal px-4 py-1.5 whitespace-nowrap">
+                <Link to={`/runs/${id}`} className="hover:underline">{id}</Link>
+                {id === matrix.reference && <span className="ml-1 text-primary">ref</span>}
+              </th>
+            ))}
+          </tr>
+        </thead>
+        <tbody>
+          {matrix.metrics.map((row) => (
+            <tr key={row.key} className="border-b border-zinc-900 last:border-0">
+              <td className="px-4 py-1 text-zinc-300 whitespace-nowrap">{row.name}</td>
+              {row.cells.map((cell) => {
+                const highlight =
+                  cell.run === row.best
+                    ? "bg-emerald-500/10 text-emerald-300"
+                    : cell.run === row.worst
+                      ? "bg-red-500/10 text-red-300"
+                      : "text-zinc-300";
+                return (
+                  <td key={cell.run} className={`px-4 py-1 text-right whitespace-nowrap ${highlight}`}>
+                    {cell.missing ? (
+                      <span className="text-zinc-600">–</span>
+                    ) : (
+                      <>
+                        {formatValue(cell.avg, row.unit)}
+                        {cell.verdict !== "reference" && (
+                          <span
+                            className={`ml-1.5 text-[10px] ${
+                              cell.verdict === "better"
+                                ? "text-emerald-400"
+                                : cell.verdict === "worse"
+                                  ? "text-red-400"
+                                  : "text-zinc-500"
+                            }`}
+                          >
+                            {cell.diff_pct >= 0 ? "+" : ""}
+                            {cell.diff_pct.toFixed(1)}%
+                          </span>
+                        )}
+                      </>
+                    )}
+                  </td>
+                );
+              })}
+            </tr>
+          ))}
+        </tbody>
+      </table>
+      <div className="px-4 py-2 text-[10px] font-mono text-zinc-600">
+        <span className="text-emerald-400">green</span> is the best run per metric,{" "}
+        <span className="text-red-400">red</span> the worst; diffs are against the reference run.
+      </div>
+    </div>
+  );
+}
@@ -19,8 +19,11 @@ const phaseGroups: { label: string; icon: typeof Zap; phases: string[] }[] = [
     phases: [
       "install_etcd",
       "configure_etcd",
+      "tune_os",
+      "build_package",
       "install_db",
       "configure_db",
+      "capture_settings",
       "install_pgbouncer",
       "configure_pgbouncer",
     ],
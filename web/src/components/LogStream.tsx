@@ -49,7 +49,10 @@ const ACTION_LABELS: Record<string, string> = {
   install_haproxy: "Install HAProxy", config_haproxy: "Configure HAProxy",
   install_proxysql: "Install ProxySQL", config_proxysql: "Configure ProxySQL",
   shutdown: "Shutdown", network: "Network", machines: "Machines",
+  tune_os: "Tune OS", apply_tuning: "Apply OS Tuning",
+  build_package: "Build Package", resolve_git_ref: "Resolve Git Ref",
   install_db: "Install DB", configure_db: "Configure DB",
+  capture_settings: "Capture DB Settings", capture_db_settings: "Capture DB Settings",
   configure_monitor: "Configure Monitoring",
   install_proxy: "Install Proxy", configure_proxy: "Configure Proxy",
   configure_etcd: "Configure etcd", configure_patroni: "Configure Patroni",
@@ -63,8 +66,11 @@ function phaseLabel(p: string): string {
 /* ---------- phase resolution ---------- */
 
 const PHASE_ACTIONS: Record<string, string[]> = {
+  tune_os: ["apply_tuning"],
+  build_package: ["resolve_git_ref", "build_package"],
   install_db: ["install_postgres", "install_mysql", "install_picodata", "install_ydb"],
   configure_db: ["config_postgres", "config_mysql", "config_picodata", "config_ydb"],
+  capture_settings: ["capture_db_settings"],
   install_monitor: ["install_monitor"], configure_monitor: ["config_monitor"],
   install_stroppy: ["install_stroppy"], run_stroppy: ["run_stroppy"],
   install_etcd: ["install_etcd"], configure_etcd: ["config_etcd"],
@@ -0,0 +1,202 @@
+import { useEffect, useState } from "react";
+import { listMetricDefs, createMetricDef, deleteMetricDef } from "@/api/client";
+import type { MetricDef, MetricDefsResponse, DatabaseKind, MetricDirection } from "@/api/types";
+import { Button } from "@/components/ui/button";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Card, CardHeader, CardTitle, CardContent } from "@/components/ui/card";
+import { Badge } from "@/components/ui/badge";
+import { Plus, Trash2, AlertCircle } from "lucide-react";
+
+const DB_KINDS: DatabaseKind[] = ["postgres", "mysql", "picodata", "ydb"];
+
+const emptyDef: MetricDef = { key: "", name: "", query: "", unit: "", direction: "higher", threshold: 0, db_kinds: [] };
+
+/** Tenant-defined metrics, collected and compared alongside the built-in ones. */
+export function MetricDefsPanel({ canEdit }: { canEdit: boolean }) {
+  const [defs, setDefs] = useState<MetricDefsResponse | null>(null);
+  const [draft, setDraft] = useState<MetricDef>(emptyDef);
+  const [error, setError] = useState<string | null>(null);
+  const [saving, setSaving] = useState(false);
+
+  const load = () =>
+    listMetricDefs()
+      .then(setDefs)
+      .catch((err) => setError(err instanceof Error ? err.message : "Failed to load metric definitions"));
+
+  useEffect(() => {
+    load();
+  }, []);
+
+  async function handleCreate() {
+    setSaving(true);
+    setError(null);
+    try {
+      await createMetricDef(draft);
+      setDraft(emptyDef);
+      await load();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Failed to create metric");
+    }
+    setSaving(false);
+  }
+
+  async function handleDelete(id: string) {
+    setError(null);
+    try {
+      await deleteMetricDef(id);
+      await load();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : "Failed to delete metric");
+    }
+  }
+
+  function toggleKind(kind: DatabaseKind) {
+    const kinds = draft.db_kinds || [];
+    setDraft({ ...draft, db_kinds: kinds.includes(kind) ? kinds.filter((k) => k !== kind) : [...kinds, kind] });
+  }
+
+  return (
+    <Card>
+      <CardHeader>
+        <CardTitle>Custom Metrics</CardTitle>
+      </CardHeader>
+      <CardContent className="space-y-6">
+        <p className="text-xs text-zinc-500">
+          PromQL queries collected with every run and compared next to the built-in metrics.
+          Use <code className="font-mono">%s</code> for the run label filter and{" "}
+          <code className="font-mono">%p</code> for the run's stroppy metric prefix.
+        </p>
+
+        {error && (
+          <div className="flex items-center gap-2 text-xs p-2 border border-destructive/30 text-destructive font-mono">
+            <AlertCircle className="h-3.5 w-3.5 shrink-0" />
+            {error}
+          </div>
+        )}
+
+        {defs && defs.custom.length > 0 ? (
+          <table className="w-full text-xs font-mono">
+            <thead>
+              <tr className="text-[10px] text-zinc-600 uppercase tracking-wider border-b border-zinc-800 text-left">
+                <th className="py-1.5 pr-3">Key</th>
+                <th className="py-1.5 px-3">Query</th>
+                <th className="py-1.5 px-3">Better</th>
+                <th className="py-1.5 px-3">Threshold</th>
+                <th className="py-1.5 px-3">Databases</th>
+                <th className="py-1.5 pl-3"></th>
+              </tr>
+            </thead>
+            <tbody>
+              {defs.custom.map((d) => (
+                <tr key={d.id} className="border-b border-zinc-900/50 align-top">
+                  <td className="py-1.5 pr-3">
+                    <div className="text-zinc-300">{d.key}</div>
+                    <div className="text-[10px] text-zinc-600">{d.name}{d.unit ? ` · ${d.unit}` : ""}</div>
+                  </td>
+                  <td className="py-1.5 px-3 text-zinc-400 break-all">{d.query}</td>
+                  <td className="py-1.5 px-3 text-zinc-400">{d.direction}</td>
+                  <td className="py-1.5 px-3 text-zinc-400">{d.threshold ? `${d.threshold}%` : "default"}</td>
+                  <td className="py-1.5 px-3 text-zinc-400">{d.db_kinds?.length ? d.db_kinds.join(", ") : "all"}</td>
+                  <td className="py-1.5 pl-3 text-right">
+                    {canEdit && (
+                      <Button size="sm" variant="ghost" className="h-6 px-2" onClick={() => handleDelete(d.id)}>
+                        <Trash2 className="h-3 w-3" />
+                      </Button>
+                    )}
+                  </td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        ) : (
+          <p className="text-xs text-zinc-600 font-mono">No custom metrics yet.</p>
+        )}
+
+        {canEdit && (
+          <div className="space-y-3 border-t border-zinc-800 pt-4">
+            <div className="grid grid-cols-3 gap-3">
+              <div className="space-y-1.5">
+                <Label className="text-xs">Key</Label>
+                <Input value={draft.key} onChange={(e) => setDraft({ ...draft, key: e.target.value })} placeholder="wal_bytes" className="font-mono text-xs" />
+              </div>
+              <div className="space-y-1.5">
+                <Label className="text-xs">Name</Label>
+                <Input value={draft.name} onChange={(e) => setDraft({ ...draft, name: e.target.value })} placeholder="WAL write rate" className="text-xs" />
+              </div>
+              <div className="space-y-1.5">
+                <Label className="text-xs">Unit</Label>
+                <Input value={draft.unit} onChange={(e) => setDraft({ ...draft, unit: e.target.value })} placeholder="bytes/s" className="font-mono text-xs" />
+              </div>
+            </div>
+            <div className="space-y-1.5">
+              <Label className="text-xs">PromQL</Label>
+              <textarea
+                className="w-full h-16 bg-transparent border border-input p-2 font-mono text-xs resize-y focus:outline-none focus:ring-1 focus:ring-ring"
+                value={draft.query}
+                onChange={(e) => setDraft({ ...draft, query: e.target.value })}
+                placeholder="sum(rate(pg_stat_wal_bytes_total{%s}[1m]))"
+              />
+            </div>
+            <div className="grid grid-cols-3 gap-3 items-end">
+              <div className="space-y-1.5">
+                <Label className="text-xs">Better when</Label>
+                <select
+                  value={draft.direction}
+                  onChange={(e) => setDraft({ ...draft, direction: e.target.value as MetricDirection })}
+                  className="w-full h-9 bg-transparent border border-input px-2 font-mono text-xs"
+                >
+                  <option value="higher">higher</option>
+                  <option value="lower">lower</option>
+                </select>
+              </div>
+              <div className="space-y-1.5">
+                <Label className="text-xs">Threshold %</Label>
+                <Input
+                  type="number" min={0}
+                  value={draft.threshold || ""}
+                  onChange={(e) => setDraft({ ...draft, threshold: parseFloat(e.target.value) || 0 })}
+                  placeholder="comparison default"
+                  className="font-mono text-xs"
+                />
+              </div>
+              <div className="space-y-1.5">
+                <Label className="text-xs">Databases</Label>
+                <div className="flex gap-1 flex-wrap">
+                  {DB_KINDS.map((k) => (
+                    <button key={k} type="button" onClick={() => toggleKind(k)}>
+                      <Badge variant={draft.db_kinds?.includes(k) ? "default" : "outline"} className="text-[10px]">{k}</Badge>
+                    </button>
+                  ))}
+                </div>
+              </div>
+            </div>
+            <Button size="sm" onClick={handleCreate} disabled={saving || !draft.key || !draft.query}>
+              <Plus className="h-3.5 w-3.5" />
+              {saving ? "Adding..." : "Add Metric"}
+            </Button>
+          </div>
+        )}
+
+        {defs && (
+          <details>
+            <summary className="text-[10px] uppercase tracking-widest text-zinc-600 cursor-pointer hover:text-zinc-400">
+              Built-in metrics ({defs.builtin.length})
+            </summary>
+            <table className="w-full text-xs font-mono mt-2">
+              <tbody>
+                {defs.builtin.map((d) => (
+                  <tr key={`${d.key}-${d.db_kinds?.join(",") ?? ""}`} className="border-b border-zinc-900/50">
+                    <td className="py-1 pr-3 text-zinc-400">{d.key}</td>
+                    <td className="py-1 px-3 text-zinc-600">{d.direction || "—"}</td>
+                    <td className="py-1 pl-3 text-zinc-600">{d.db_kinds?.length ? d.db_kinds.join(", ") : "all"}</td>
+                  </tr>
+                ))}
+              </tbody>
+            </table>
+          </details>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
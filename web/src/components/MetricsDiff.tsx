@@ -1,4 +1,4 @@
-import type { ComparisonRow } from "@/api/types";
+import type { ComparisonRow, SampleStats } from "@/api/types";
 import { ArrowUp, ArrowDown, Minus } from "lucide-react";
 
 interface MetricsDiffProps {
@@ -7,7 +7,7 @@ interface MetricsDiffProps {
   runB?: string;
 }
 
-function formatValue(val: number, unit: string): string {
+export function formatValue(val: number, unit: string): string {
   if (unit === "%" || unit === "percent") return `${val.toFixed(1)}%`;
   if (unit === "ms") {
     if (val >= 1000) return `${(val / 1000).toFixed(2)}s`;
@@ -31,7 +31,13 @@ function VerdictIcon({ verdict }: { verdict: string }) {
   return <Minus className="h-3 w-3 text-zinc-600" />;
 }
 
+function ciHalf(s: SampleStats): number {
+  return (s.ci_high - s.ci_low) / 2;
+}
+
 export function MetricsDiff({ rows, runA, runB }: MetricsDiffProps) {
+  const grouped = rows.some((r) => r.stats);
+
   if (rows.length === 0) {
     return (
       <div className="text-sm text-muted-foreground p-4">
@@ -53,6 +59,7 @@ export function MetricsDiff({ rows, runA, runB }: MetricsDiffProps) {
               {runB ? <span className="text-amber-400">{runB}</span> : "Run B"} avg
             </th>
             <th className="py-2.5 px-3 font-medium text-right">Diff %</th>
+            {grouped && <th className="py-2.5 px-3 font-medium text-right">p</th>}
             <th className="py-2.5 px-3 font-medium w-20"></th>
           </tr>
         </thead>
@@ -76,9 +83,19 @@ export function MetricsDiff({ rows, runA, runB }: MetricsDiffProps) {
               </td>
               <td className="py-2 px-3 text-right font-mono text-xs text-zinc-300 tabular-nums">
                 {formatValue(row.avg_a, row.unit)}
+                {row.stats && (
+                  <div className="text-[10px] text-zinc-600">
+                    ±{formatValue(ciHalf(row.stats.a), row.unit)} · cv {(row.stats.a.cv * 100).toFixed(1)}% · n={row.stats.a.n}
+                  </div>
+                )}
               </td>
               <td className="py-2 px-3 text-right font-mono text-xs text-zinc-300 tabular-nums">
                 {formatValue(row.avg_b, row.unit)}
+                {row.stats && (
+                  <div className="text-[10px] text-zinc-600">
+                    ±{formatValue(ciHalf(row.stats.b), row.unit)} · cv {(row.stats.b.cv * 100).toFixed(1)}% · n={row.stats.b.n}
+                  </div>
+                )}
               </td>
               <td className="py-2 px-3 text-right font-mono text-xs tabular-nums">
                 <span
@@ -94,6 +111,13 @@ export function MetricsDiff({ rows, runA, runB }: MetricsDiffProps) {
                   {row.diff_avg_pct.toFixed(1)}%
                 </span>
               </td>
+              {grouped && (
+                <td className="py-2 px-3 text-right font-mono text-xs tabular-nums">
+                  <span className={row.stats?.significant ? "text-zinc-300" : "text-zinc-600"}>
+                    {row.stats ? row.stats.p_value.toFixed(3) : "—"}
+                  </span>
+                </td>
+              )}
               <td className="py-2 px-3">
                 <div className="flex items-center gap-1.5">
                   <VerdictIcon verdict={row.verdict} />
@@ -14,12 +14,29 @@ interface MetricSummary {
   min: number;
   max: number;
   last: number;
+  p50: number;
+  p95: number;
+  p99: number;
+  stddev: number;
+  /** coefficient of variation, stddev / avg */
+  cv: number;
+  samples: number;
+  /** cv within 10%; an unstable run makes a poor baseline */
+  stable: boolean;
+  /** which way is better, from the metric's definition */
+  direction?: "higher" | "lower";
+  threshold?: number;
 }
 
 interface RunMetricsResponse {
   run_id: string;
   range: { Start: string; End: string };
   metrics: MetricSummary[];
+  /** steady state the metrics were summarized over, without warm-up and ramp-down */
+  steady?: { Start: string; End: string };
+  steady_key?: string;
+  /** a custom metric's query failed; its summary has no samples */
+  partial?: boolean;
 }
 
 interface MetricsPanelProps {
@@ -43,10 +60,9 @@ function formatMetricValue(value: number, unit: string): string {
   return value.toFixed(1);
 }
 
-function metricIcon(key: string, value: number) {
+function metricIcon(direction: string | undefined, value: number) {
   if (value === 0) return <Minus className="h-3 w-3 text-zinc-600" />;
-  const goodWhenHigh = ["db_qps", "stroppy_ops"];
-  if (goodWhenHigh.includes(key)) {
+  if (direction === "higher") {
     return <TrendingUp className="h-3 w-3 text-emerald-500" />;
   }
   return <TrendingDown className="h-3 w-3 text-zinc-400" />;
@@ -60,6 +76,7 @@ const metricCategories: Record<string, string[]> = {
 
 export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps) {
   const [metrics, setMetrics] = useState<MetricSummary[]>([]);
+  const [steady, setSteady] = useState<{ window: { Start: string; End: string }; key: string } | null>(null);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
   const [unavailable, setUnavailable] = useState(false);
@@ -89,6 +106,7 @@ export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps
       const endISO = new Date(end).toISOString();
       const data = (await getRunMetrics(runID, startISO, endISO)) as unknown as RunMetricsResponse;
       setMetrics(data.metrics || []);
+      setSteady(data.steady ? { window: data.steady, key: data.steady_key || "" } : null);
     } catch (err) {
       const msg = err instanceof Error ? err.message : "Failed to fetch metrics";
       if (msg.includes("503")) {
@@ -106,6 +124,10 @@ export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps
   }, [runID]);
 
   const metricsByKey = Object.fromEntries(metrics.map((m) => [m.key, m]));
+  // Tenant-defined metrics fall outside the built-in categories.
+  const knownKeys = new Set(Object.values(metricCategories).flat());
+  const customKeys = metrics.map((m) => m.key).filter((k) => !knownKeys.has(k));
+  const categories = customKeys.length > 0 ? { ...metricCategories, Custom: customKeys } : metricCategories;
 
   if (unavailable) {
     return (
@@ -155,8 +177,24 @@ export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps
         </div>
       )}
 
+      {metrics.length > 0 && (
+        <div className="text-[10px] font-mono text-zinc-500">
+          {steady ? (
+            <>
+              Summarized over the steady state{" "}
+              <span className="text-zinc-300">
+                {new Date(steady.window.Start).toLocaleTimeString()} – {new Date(steady.window.End).toLocaleTimeString()}
+              </span>{" "}
+              (detected from {steady.key}; warm-up and ramp-down excluded)
+            </>
+          ) : (
+            "No steady state detected; summarized over the whole range."
+          )}
+        </div>
+      )}
+
       {/* Metric categories */}
-      {Object.entries(metricCategories).map(([category, keys]) => {
+      {Object.entries(categories).map(([category, keys]) => {
         const categoryMetrics = keys
           .map((k) => metricsByKey[k])
           .filter(Boolean);
@@ -179,8 +217,16 @@ export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps
                       className="flex items-center justify-between py-1.5 border-b border-zinc-900 last:border-0"
                     >
                       <div className="flex items-center gap-2">
-                        {metricIcon(m.key, m.avg)}
+                        {metricIcon(m.direction, m.avg)}
                         <span className="text-xs text-zinc-400">{m.name}</span>
+                        {m.samples > 0 && !m.stable && (
+                          <span
+                            className="text-[9px] uppercase tracking-wider text-amber-500"
+                            title={`coefficient of variation ${(m.cv * 100).toFixed(1)}%`}
+                          >
+                            noisy
+                          </span>
+                        )}
                       </div>
                       <div className="flex items-baseline gap-3 font-mono">
                         <span className="text-xs text-zinc-300">
@@ -213,6 +259,10 @@ export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps
                   <th className="text-right py-1.5 px-3">Avg</th>
                   <th className="text-right py-1.5 px-3">Min</th>
                   <th className="text-right py-1.5 px-3">Max</th>
+                  <th className="text-right py-1.5 px-3">P50</th>
+                  <th className="text-right py-1.5 px-3">P95</th>
+                  <th className="text-right py-1.5 px-3">P99</th>
+                  <th className="text-right py-1.5 px-3">CV</th>
                   <th className="text-right py-1.5 pl-3">Last</th>
                 </tr>
               </thead>
@@ -229,6 +279,18 @@ export function MetricsPanel({ runID, startedAt, finishedAt }: MetricsPanelProps
                     <td className="text-right py-1.5 px-3 text-zinc-500">
                       {formatMetricValue(m.max, m.unit)}
                     </td>
+                    <td className="text-right py-1.5 px-3 text-zinc-500">
+                      {formatMetricValue(m.p50, m.unit)}
+                    </td>
+                    <td className="text-right py-1.5 px-3 text-zinc-500">
+                      {formatMetricValue(m.p95, m.unit)}
+                    </td>
+                    <td className="text-right py-1.5 px-3 text-zinc-500">
+                      {formatMetricValue(m.p99, m.unit)}
+                    </td>
+                    <td className={`text-right py-1.5 px-3 ${m.samples > 0 && !m.stable ? "text-amber-500" : "text-zinc-500"}`}>
+                      {m.samples > 0 ? `${(m.cv * 100).toFixed(1)}%` : "--"}
+                    </td>
                     <td className="text-right py-1.5 pl-3 text-zinc-300">
                       {formatMetricValue(m.last, m.unit)}
                     </td>
@@ -35,7 +35,7 @@ const phaseGroups: { label: string; icon: typeof Zap; phases: string[] }[] = [
     icon: Database,
     phases: [
       "install_etcd", "configure_etcd",
-      "install_db", "configure_db",
+      "tune_os", "build_package", "install_db", "configure_db", "capture_settings",
       "install_pgbouncer", "configure_pgbouncer",
     ],
   },
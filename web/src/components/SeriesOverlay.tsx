@@ -0,0 +1,54 @@
+import type { SeriesPair, SeriesPoint } from "@/api/types";
+import { formatValue } from "@/components/MetricsDiff";
+
+const WIDTH = 320;
+const HEIGHT = 80;
+
+function fmtOffset(sec: number): string {
+  if (sec >= 3600) return `${(sec / 3600).toFixed(1)}h`;
+  if (sec >= 60) return `${Math.round(sec / 60)}m`;
+  return `${Math.round(sec)}s`;
+}
+
+function toPath(points: SeriesPoint[], maxT: number, maxV: number): string {
+  return points
+    .map((p, i) => {
+      const x = maxT > 0 ? (p.t / maxT) * WIDTH : 0;
+      const y = HEIGHT - (maxV > 0 ? (p.v / maxV) * HEIGHT : 0);
+      return `${i === 0 ? "M" : "L"}${x.toFixed(1)},${y.toFixed(1)}`;
+    })
+    .join(" ");
+}
+
+/** One small chart per metric with both runs drawn from their workload start (t=0). */
+export function SeriesOverlay({ series }: { series: SeriesPair[] }) {
+  if (series.length === 0) {
+    return <div className="p-4 text-xs text-zinc-600 font-mono">No series data for these runs.</div>;
+  }
+
+  return (
+    <div className="grid grid-cols-1 md:grid-cols-2 xl:grid-cols-3 gap-3 p-3">
+      {series.map((s) => {
+        const all = [...s.a, ...s.b];
+        const maxT = Math.max(0, ...all.map((p) => p.t));
+        const maxV = Math.max(0, ...all.map((p) => p.v));
+        return (
+          <div key={s.key} className="border border-zinc-800/80 p-2 space-y-1">
+            <div className="flex items-center justify-between text-[10px] font-mono">
+              <span className="text-zinc-300">{s.name || s.key}</span>
+              <span className="text-zinc-600">max {formatValue(maxV, s.unit)}</span>
+            </div>
+            <svg viewBox={`0 0 ${WIDTH} ${HEIGHT}`} className="w-full h-20" preserveAspectRatio="none">
+              <path d={toPath(s.a, maxT, maxV)} fill="none" className="stroke-cyan-400" strokeWidth={1.2} vectorEffect="non-scaling-stroke" />
+              <path d={toPath(s.b, maxT, maxV)} fill="none" className="stroke-amber-400" strokeWidth={1.2} vectorEffect="non-scaling-stroke" />
+            </svg>
+            <div className="flex justify-between text-[9px] font-mono text-zinc-600">
+              <span>t=0</span>
+              <span>{fmtOffset(maxT)}</span>
+            </div>
+          </div>
+        );
+      })}
+    </div>
+  );
+}
@@ -1,17 +1,29 @@
 import { useState, useEffect } from "react";
 import { useSearchParams, useNavigate, Link } from "react-router-dom";
-import { compareRuns, getGrafanaSettings } from "@/api/client";
-import type { ComparisonResponse, GrafanaSettings } from "@/api/types";
+import { compareMany, compareRuns, getGrafanaSettings } from "@/api/client";
+import type { ComparisonResponse, GrafanaSettings, MatrixResponse } from "@/api/types";
+import { CompareMatrix } from "@/components/CompareMatrix";
 import { MetricsDiff } from "@/components/MetricsDiff";
+import { SeriesOverlay } from "@/components/SeriesOverlay";
 import { Badge } from "@/components/ui/badge";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
 import { Label } from "@/components/ui/label";
 import { Card, CardHeader, CardTitle, CardContent } from "@/components/ui/card";
-import { AlertCircle, GitCompare, ArrowLeft, ExternalLink, Loader2, Cpu } from "lucide-react";
-import type { RunConfig, MachineSpec } from "@/api/types";
+import { AlertCircle, GitCompare, ArrowLeft, ExternalLink, Loader2, Cpu, SlidersHorizontal } from "lucide-react";
+import type { RunConfig, MachineSpec, ConfigChange } from "@/api/types";
 
 export function Compare() {
+  const [searchParams] = useSearchParams();
+  // ?runs=r1,r2,r3[&ref=r2] compares many runs against one reference.
+  const runs = searchParams.get("runs") || "";
+  if (runs) {
+    return <CompareManyView runs={runs} reference={searchParams.get("ref") || ""} />;
+  }
+  return <ComparePair />;
+}
+
+function ComparePair() {
   const [searchParams] = useSearchParams();
   const navigate = useNavigate();
   const runA = searchParams.get("a") || "";
@@ -67,6 +79,7 @@ export function Compare() {
             <p className="text-xs text-zinc-500 font-mono">
               Enter two run IDs or select them from the{" "}
               <Link to="/" className="text-primary hover:underline">runs table</Link>.
+              Separate repeated runs with commas to compare them as groups.
             </p>
             <div className="grid grid-cols-2 gap-3">
               <div className="space-y-1.5">
@@ -121,7 +134,7 @@ export function Compare() {
 
         {grafana?.embed_enabled && grafana.dashboards?.compare && result && (
           <a
-            href={`${grafana.url}/d/${grafana.dashboards.compare}?var-run_a=${runA}&var-run_b=${runB}&from=${new Date(result.start).getTime()}&to=${new Date(result.end).getTime()}&theme=dark`}
+            href={`${grafana.url}/d/${grafana.dashboards.compare}?var-run_a=${result.run_a}&var-run_b=${result.run_b}&from=${new Date(result.start).getTime()}&to=${new Date(result.end).getTime()}&theme=dark`}
             target="_blank"
             rel="noopener noreferrer"
             className="flex items-center gap-1.5 text-[11px] font-mono text-primary hover:underline"
@@ -154,9 +167,9 @@ export function Compare() {
           </Button>
         )}
         <div className="flex items-center gap-2 ml-2 text-[10px] font-mono">
-          <Link to={`/runs/${runA}`} className="text-cyan-400 hover:underline">{runA}</Link>
+          <RunLinks ids={runA} className="text-cyan-400" />
           <span className="text-zinc-700">vs</span>
-          <Link to={`/runs/${runB}`} className="text-amber-400 hover:underline">{runB}</Link>
+          <RunLinks ids={runB} className="text-amber-400" />
         </div>
       </div>
 
@@ -184,6 +197,9 @@ export function Compare() {
         </div>
       )}
 
+      {/* Configuration differences */}
+      {result?.config_diff && <ConfigDiffCard changes={result.config_diff} />}
+
       {/* Results */}
       {result && (
         <Card className="border-zinc-800/80 bg-[#080808]">
@@ -219,6 +235,24 @@ export function Compare() {
         </Card>
       )}
 
+      {/* Aligned series */}
+      {result?.series && result.series.length > 0 && (
+        <Card className="border-zinc-800/80 bg-[#080808]">
+          <CardHeader className="pb-0">
+            <div className="flex items-center justify-between">
+              <CardTitle className="text-sm font-mono">Workload Overlay</CardTitle>
+              <div className="flex items-center gap-3 text-[10px] font-mono text-zinc-500">
+                <span className="text-cyan-400">A {fmtWindow(result.window_a)}</span>
+                <span className="text-amber-400">B {fmtWindow(result.window_b)}</span>
+              </div>
+            </div>
+          </CardHeader>
+          <CardContent className="p-0 pt-1">
+            <SeriesOverlay series={result.series} />
+          </CardContent>
+        </Card>
+      )}
+
       {/* Grafana embed */}
       {grafana?.embed_enabled && grafana.dashboards?.compare && result && (
         <Card className="border-zinc-800/80">
@@ -227,7 +261,7 @@ export function Compare() {
           </CardHeader>
           <CardContent className="p-0">
             <iframe
-              src={`${grafana.url}/d/${grafana.dashboards.compare}?var-run_a=${runA}&var-run_b=${runB}&from=${new Date(result.start).getTime()}&to=${new Date(result.end).getTime()}&kiosk&theme=dark`}
+              src={`${grafana.url}/d/${grafana.dashboards.compare}?var-run_a=${result.run_a}&var-run_b=${result.run_b}&from=${new Date(result.start).getTime()}&to=${new Date(result.end).getTime()}&kiosk&theme=dark`}
               className="w-full h-[600px] border-0"
               title="Compare Dashboard"
               sandbox="allow-scripts allow-same-origin allow-popups allow-forms"
@@ -239,6 +273,99 @@ export function Compare() {
   );
 }
 
+function CompareManyView({ runs, reference }: { runs: string; reference: string }) {
+  const [result, setResult] = useState<MatrixResponse | null>(null);
+  const [loading, setLoading] = useState(false);
+  const [error, setError] = useState<string | null>(null);
+
+  useEffect(() => {
+    const ids = runs.split(",").map((id) => id.trim()).filter(Boolean);
+    setLoading(true);
+    setError(null);
+    setResult(null);
+    compareMany(ids, reference || undefined)
+      .then(setResult)
+      .catch((err) => setError(err instanceof Error ? err.message : "Comparison failed"))
+      .finally(() => setLoading(false));
+  }, [runs, reference]);
+
+  const diffs = result
+    ? Object.entries(result.config_diff).filter(([, changes]) => changes.length > 0)
+    : [];
+
+  return (
+    <div className="p-5 space-y-4">
+      <div className="flex items-center gap-3">
+        <Link to="/" className="text-zinc-500 hover:text-zinc-300 transition-colors">
+          <ArrowLeft className="h-4 w-4" />
+        </Link>
+        <GitCompare className="h-4 w-4 text-primary" />
+        <h1 className="text-base font-semibold font-mono">Compare</h1>
+        {result && (
+          <span className="text-[11px] font-mono text-zinc-500">
+            {result.runs.length} runs against <span className="text-primary">{result.reference}</span>
+          </span>
+        )}
+      </div>
+
+      {loading && (
+        <div className="flex items-center gap-2 py-8 justify-center">
+          <Loader2 className="h-4 w-4 text-zinc-500 animate-spin" />
+          <span className="text-xs text-zinc-500 font-mono">Fetching metrics...</span>
+        </div>
+      )}
+
+      {error && (
+        <div className="flex items-center gap-2 text-xs p-3 border border-destructive/30 text-destructive font-mono">
+          <AlertCircle className="h-3.5 w-3.5 shrink-0" />
+          {error}
+        </div>
+      )}
+
+      {result && (
+        <Card className="border-zinc-800/80 bg-[#080808]">
+          <CardHeader className="pb-0">
+            <CardTitle className="text-sm font-mono">Metric Matrix</CardTitle>
+          </CardHeader>
+          <CardContent className="p-0 pt-3">
+            <CompareMatrix matrix={result} />
+          </CardContent>
+        </Card>
+      )}
+
+      {diffs.map(([id, changes]) => (
+        <div key={id} className="space-y-1">
+          <div className="text-[10px] font-mono text-zinc-500">
+            {id} vs {result?.reference} (Run A is the reference)
+          </div>
+          <ConfigDiffCard changes={changes} />
+        </div>
+      ))}
+    </div>
+  );
+}
+
+/** Links each run of a comma-separated group. */
+function RunLinks({ ids, className }: { ids: string; className: string }) {
+  const list = ids.split(",").map((id) => id.trim()).filter(Boolean);
+  return (
+    <span className="flex items-center gap-1">
+      {list.map((id, i) => (
+        <span key={id}>
+          <Link to={`/runs/${id}`} className={`${className} hover:underline`}>{id}</Link>
+          {i < list.length - 1 && <span className="text-zinc-700">,</span>}
+        </span>
+      ))}
+    </span>
+  );
+}
+
+function fmtWindow(w: { Start: string; End: string }): string {
+  const start = new Date(w.Start);
+  const sec = Math.max(0, (new Date(w.End).getTime() - start.getTime()) / 1000);
+  return `${start.toLocaleString()} · ${Math.round(sec / 60)}m`;
+}
+
 function fmtMem(mb: number): string {
   return mb >= 1024 ? `${(mb / 1024).toFixed(mb % 1024 ? 1 : 0)} GB` : `${mb} MB`;
 }
@@ -293,3 +420,54 @@ function HardwareCard({ label, runId, config, color }: { label: string; runId: s
     </Card>
   );
 }
+
+const SECTION_LABELS: Record<ConfigChange["section"], string> = {
+  config: "Config",
+  options: "Options",
+  package: "Package",
+  stroppy: "Workload",
+  db_settings: "DB settings",
+  machine: "Machine",
+};
+
+function ConfigDiffCard({ changes }: { changes: ConfigChange[] }) {
+  return (
+    <Card className="border-zinc-800/80 bg-[#080808]">
+      <CardHeader className="pb-0">
+        <div className="flex items-center gap-2">
+          <SlidersHorizontal className="h-3.5 w-3.5 text-zinc-400" />
+          <CardTitle className="text-sm font-mono">Configuration Differences</CardTitle>
+          <Badge variant="pending" className="text-[10px]">{changes.length}</Badge>
+        </div>
+      </CardHeader>
+      <CardContent className="p-0 pt-3">
+        {changes.length === 0 ? (
+          <div className="p-4 text-xs text-zinc-600 font-mono">
+            The runs were configured identically.
+          </div>
+        ) : (
+          <table className="w-full text-[11px] font-mono">
+            <thead>
+              <tr className="text-zinc-500 border-b border-zinc-800/60">
+                <th className="text-left font-normal px-4 py-1.5">Section</th>
+                <th className="text-left font-normal px-4 py-1.5">Setting</th>
+                <th className="text-left font-normal px-4 py-1.5 text-cyan-400">Run A</th>
+                <th className="text-left font-normal px-4 py-1.5 text-amber-400">Run B</th>
+              </tr>
+            </thead>
+            <tbody>
+              {changes.map((c) => (
+                <tr key={`${c.section}:${c.path}`} className="border-b border-zinc-900 last:border-0">
+                  <td className="px-4 py-1 text-zinc-500 whitespace-nowrap">{SECTION_LABELS[c.section] ?? c.section}</td>
+                  <td className="px-4 py-1 text-zinc-300 break-all">{c.path}</td>
+                  <td className="px-4 py-1 text-zinc-400 break-all">{c.a || <span className="text-zinc-600">unset</span>}</td>
+                  <td className="px-4 py-1 text-zinc-400 break-all">{c.b || <span className="text-zinc-600">unset</span>}</td>
+                </tr>
+              ))}
+            </tbody>
+          </table>
+        )}
+      </CardContent>
+    </Card>
+  );
+}
@@ -1036,7 +1036,7 @@ function StepStroppy({
 // Phase grouping — mirrors DAG dependency structure (same as DagGraph.tsx)
 const PHASE_GROUPS: { label: string; icon: typeof Database; phases: string[] }[] = [
   { label: "Infrastructure", icon: Server, phases: ["network", "machines"] },
-  { label: "Database", icon: Database, phases: ["install_etcd", "configure_etcd", "install_patroni", "configure_patroni", "install_db", "configure_db", "install_pgbouncer", "configure_pgbouncer"] },
+  { label: "Database", icon: Database, phases: ["install_etcd", "configure_etcd", "install_patroni", "configure_patroni", "tune_os", "build_package", "install_db", "configure_db", "capture_settings", "install_pgbouncer", "configure_pgbouncer"] },
   { label: "Proxy", icon: Server, phases: ["install_proxy", "configure_proxy"] },
   { label: "Monitoring", icon: Server, phases: ["install_monitor", "configure_monitor"] },
   { label: "Benchmark", icon: Rocket, phases: ["install_stroppy", "run_stroppy"] },
@@ -144,7 +144,7 @@ export function Packages() {
                 <span className="text-xs font-mono text-zinc-400">{pkg.db_kind}</span>
                 <span className="text-xs font-mono text-zinc-500">{pkg.db_version}</span>
                 <span className="text-[10px] font-mono text-zinc-500">{pkg.apt_packages?.length || 0} apt</span>
-                <span className="text-[10px] font-mono text-zinc-500">{pkg.has_deb ? "yes" : "—"}</span>
+                <span className="text-[10px] font-mono text-zinc-500">{pkg.tarball ? "tarball" : pkg.has_deb ? "yes" : "—"}</span>
                 <div className="flex items-center gap-1">
                   <button onClick={() => setEditing(pkg)} className="p-1 text-zinc-600 hover:text-zinc-300" title="Edit">
                     <Pencil className="w-3 h-3" />
@@ -340,16 +340,21 @@ function PackageEditor({
           <div className="border border-dashed border-zinc-800 p-3 space-y-2">
             <div className="flex items-center gap-2">
               <FileDown className="w-3.5 h-3.5 text-zinc-500" />
-              <span className="text-[11px] font-mono text-zinc-400 uppercase tracking-wider">.deb File</span>
+              <span className="text-[11px] font-mono text-zinc-400 uppercase tracking-wider">{pkg?.tarball ? "Tarball" : ".deb File"}</span>
               {pkg?.deb_filename && <span className="text-[10px] font-mono text-zinc-600">current: {pkg.deb_filename}</span>}
+              {pkg?.deb_meta && (
+                <span className="text-[10px] font-mono text-zinc-600">
+                  {pkg.deb_meta.name} {pkg.deb_meta.version} · {pkg.deb_meta.architecture}
+                </span>
+              )}
             </div>
             <label className="cursor-pointer inline-block">
-              <input type="file" accept=".deb" onChange={(e) => {
+              <input type="file" accept={pkg?.tarball ? ".tar,.tar.gz,.tgz,.tar.xz,.tar.zst,.tar.bz2" : ".deb"} onChange={(e) => {
                 const f = e.target.files?.[0];
                 if (f) setDebFile(f);
               }} className="hidden" />
               <Button asChild variant="outline" size="sm">
-                <span><Upload className="h-3 w-3" />{debFile ? debFile.name : "Choose .deb"}</span>
+                <span><Upload className="h-3 w-3" />{debFile ? debFile.name : pkg?.tarball ? "Choose tarball" : "Choose .deb"}</span>
               </Button>
             </label>
             {debFile && <div className="text-[10px] font-mono text-zinc-500">{debFile.name}</div>}
@@ -1,6 +1,6 @@
 import { useState, useEffect, useMemo, useCallback } from "react";
 import { useParams, useNavigate } from "react-router-dom";
-import { getPreset, createPreset, updatePreset } from "@/api/client";
+import { getPreset, createPreset, updatePreset, getParameterCatalog } from "@/api/client";
 import {
   ALL_DB_KINDS,
   type DatabaseKind,
@@ -11,6 +11,8 @@ import {
   type PicodataTier,
   type YDBTopology,
   type MachineSpec,
+  type DBParam,
+  type AutoTuneProfile,
 } from "@/api/types";
 import { TopologyDiagram } from "@/components/TopologyDiagram";
 import { Button } from "@/components/ui/button";
@@ -323,6 +325,33 @@ function MachineEditor({
   );
 }
 
+// ─── Auto-Tune Select ────────────────────────────────────────────
+
+const AUTO_TUNE_OFF = "off";
+
+function AutoTuneSelect({ value, onChange, disabled }: {
+  value?: AutoTuneProfile; onChange: (v: AutoTuneProfile | undefined) => void; disabled?: boolean;
+}) {
+  return (
+    <div className="space-y-1.5">
+      <Label className="text-[9px] font-mono text-zinc-600 uppercase tracking-wider">Auto-Tune</Label>
+      <Select value={value || AUTO_TUNE_OFF}
+        onValueChange={(v) => onChange(v === AUTO_TUNE_OFF ? undefined : v as AutoTuneProfile)}
+        disabled={disabled}>
+        <SelectTrigger className="h-7 text-xs font-mono">
+          <SelectValue />
+        </SelectTrigger>
+        <SelectContent>
+          <SelectItem value={AUTO_TUNE_OFF}>off (static defaults)</SelectItem>
+          <SelectItem value="oltp">oltp</SelectItem>
+          <SelectItem value="analytic">analytic</SelectItem>
+          <SelectItem value="mixed">mixed</SelectItem>
+        </SelectContent>
+      </Select>
+    </div>
+  );
+}
+
 // ─── Toggle Switch ───────────────────────────────────────────────
 
 function Toggle({ label, checked, onChange, disabled }: {
@@ -397,6 +426,23 @@ const PICO_HAPROXY_DEFAULTS: Record<string, string> = { ...HAPROXY_DEFAULTS };
 
 // ─── Options Editor ──────────────────────────────────────────────
 
+// Parameter catalogs are static per server build; fetch each engine once.
+const paramCatalogs = new Map<string, Promise<DBParam[]>>();
+
+function useParamCatalog(engine?: string): DBParam[] {
+  const [params, setParams] = useState<DBParam[]>([]);
+  useEffect(() => {
+    if (!engine) return;
+    if (!paramCatalogs.has(engine)) {
+      paramCatalogs.set(engine, getParameterCatalog(engine).then((c) => c.params).catch(() => []));
+    }
+    let live = true;
+    paramCatalogs.get(engine)!.then((p) => { if (live) setParams(p); });
+    return () => { live = false; };
+  }, [engine]);
+  return params;
+}
+
 function OptionsEditor({
   label,
   options,
@@ -404,6 +450,7 @@ function OptionsEditor({
   locked,
   lockedHints,
   defaults,
+  engine,
   disabled,
 }: {
   label: string;
@@ -412,11 +459,14 @@ function OptionsEditor({
   locked: Record<string, string>;
   lockedHints: Record<string, string>;
   defaults: Record<string, string>;
+  engine?: string; // parameter catalog used for autocomplete
   disabled?: boolean;
 }) {
   const [draftKey, setDraftKey] = useState("");
   const [draftVal, setDraftVal] = useState("");
   const [showSuggestions, setShowSuggestions] = useState(false);
+  const catalog = useParamCatalog(engine);
+  const catalogByName = useMemo(() => new Map(catalog.map((p) => [p.name, p])), [catalog]);
 
   const opts = options || {};
   const overrideCount = Object.keys(opts).length;
@@ -442,7 +492,7 @@ function OptionsEditor({
     onChange({ ...opts, [key]: val });
   };
 
-  const suggestions = Object.keys(defaults).filter(
+  const suggestions = [...new Set([...Object.keys(defaults), ...catalog.map((p) => p.name)])].filter(
     (k) => !(k in locked) && !(k in opts) && (!draftKey || k.toLowerCase().includes(draftKey.toLowerCase()))
   );
 
@@ -525,7 +575,10 @@ function OptionsEditor({
                       <button key={s} type="button"
                         onMouseDown={(e) => { e.preventDefault(); setDraftKey(s); setDraftVal(defaults[s] || ""); setShowSuggestions(false); }}
                         className="w-full text-left px-2 py-1 text-[10px] font-mono text-zinc-400 hover:bg-zinc-800 hover:text-zinc-200">
-                        {s} <span className="text-zinc-600">= {defaults[s]}</span>
+                        {s}{" "}
+                        {s in defaults
+                          ? <span className="text-zinc-600">= {defaults[s]}</span>
+                          : <span className="text-zinc-600">{catalogByName.get(s)?.description}{catalogByName.get(s)?.restart ? " (restart)" : ""}</span>}
                       </button>
                     ))}
                   </div>
@@ -568,7 +621,7 @@ function PostgresForm({ topology, onChange, disabled }: {
       {/* Master + its config */}
       <MachineEditor label="Master" spec={topology.master} countLocked
         onChange={(s) => onChange({ ...topology, master: s })} disabled={disabled}>
-        <OptionsEditor label="postgresql.conf" options={topology.master_options} locked={PG_MASTER_LOCKED} lockedHints={PG_MASTER_LOCKED_HINTS} defaults={PG_MASTER_DEFAULTS} disabled={disabled}
+        <OptionsEditor label="postgresql.conf" engine="postgres" options={topology.master_options} locked={PG_MASTER_LOCKED} lockedHints={PG_MASTER_LOCKED_HINTS} defaults={PG_MASTER_DEFAULTS} disabled={disabled}
           onChange={(opts) => onChange({ ...topology, master_options: opts })} />
       </MachineEditor>
 
@@ -591,7 +644,7 @@ function PostgresForm({ topology, onChange, disabled }: {
                 onChange({ ...topology, replicas: reps });
               }} disabled={disabled}>
               {i === 0 && (
-                <OptionsEditor label="postgresql.conf (all replicas)" options={topology.replica_options} locked={PG_REPLICA_LOCKED} lockedHints={PG_REPLICA_LOCKED_HINTS} defaults={PG_REPLICA_DEFAULTS} disabled={disabled}
+                <OptionsEditor label="postgresql.conf (all replicas)" engine="postgres" options={topology.replica_options} locked={PG_REPLICA_LOCKED} lockedHints={PG_REPLICA_LOCKED_HINTS} defaults={PG_REPLICA_DEFAULTS} disabled={disabled}
                   onChange={(opts) => onChange({ ...topology, replica_options: opts })} />
               )}
             </MachineEditor>
@@ -612,6 +665,7 @@ function PostgresForm({ topology, onChange, disabled }: {
         <NumericSlider label="Sync Replicas" value={topology.sync_replicas} min={0} max={8}
           onChange={(v) => onChange({ ...topology, sync_replicas: v })} disabled={disabled} />
       </div>
+      <AutoTuneSelect value={topology.auto_tune} onChange={(v) => onChange({ ...topology, auto_tune: v })} disabled={disabled} />
 
       {/* Patroni config (inline when enabled) */}
       {topology.patroni && (
@@ -681,7 +735,7 @@ function MySQLForm({ topology, onChange, disabled }: {
       {/* Primary + config */}
       <MachineEditor label="Primary" spec={topology.primary} countLocked
         onChange={(s) => onChange({ ...topology, primary: s })} disabled={disabled}>
-        <OptionsEditor label="my.cnf" options={topology.primary_options} locked={MYSQL_PRIMARY_LOCKED} lockedHints={MYSQL_PRIMARY_LOCKED_HINTS} defaults={MYSQL_PRIMARY_DEFAULTS} disabled={disabled}
+        <OptionsEditor label="my.cnf" engine="mysql" options={topology.primary_options} locked={MYSQL_PRIMARY_LOCKED} lockedHints={MYSQL_PRIMARY_LOCKED_HINTS} defaults={MYSQL_PRIMARY_DEFAULTS} disabled={disabled}
           onChange={(opts) => onChange({ ...topology, primary_options: opts })} />
       </MachineEditor>
 
@@ -704,7 +758,7 @@ function MySQLForm({ topology, onChange, disabled }: {
                 onChange({ ...topology, replicas: reps });
               }} disabled={disabled}>
               {i === 0 && (
-                <OptionsEditor label="my.cnf (all replicas)" options={topology.replica_options} locked={MYSQL_REPLICA_LOCKED} lockedHints={MYSQL_REPLICA_LOCKED_HINTS} defaults={MYSQL_REPLICA_DEFAULTS} disabled={disabled}
+                <OptionsEditor label="my.cnf (all replicas)" engine="mysql" options={topology.replica_options} locked={MYSQL_REPLICA_LOCKED} lockedHints={MYSQL_REPLICA_LOCKED_HINTS} defaults={MYSQL_REPLICA_DEFAULTS} disabled={disabled}
                   onChange={(opts) => onChange({ ...topology, replica_options: opts })} />
               )}
             </MachineEditor>
@@ -724,6 +778,7 @@ function MySQLForm({ topology, onChange, disabled }: {
         <Toggle label="Semi-Sync Replication" checked={topology.semi_sync}
           onChange={(v) => onChange({ ...topology, semi_sync: v, ...(v ? { group_replication: false } : {}) })} disabled={disabled} />
       </div>
+      <AutoTuneSelect value={topology.auto_tune} onChange={(v) => onChange({ ...topology, auto_tune: v })} disabled={disabled} />
 
       {/* ProxySQL + config */}
       <div>
@@ -796,7 +851,7 @@ function PicodataForm({ topology, onChange, disabled }: {
                 onChange({ ...topology, instances: insts });
               }} disabled={disabled}>
               {i === 0 && (
-                <OptionsEditor label="picodata.yaml (all instances)" options={topology.instance_options} locked={PICO_LOCKED} lockedHints={PICO_LOCKED_HINTS} defaults={PICO_DEFAULTS} disabled={disabled}
+                <OptionsEditor label="picodata.yaml (all instances)" engine="picodata" options={topology.instance_options} locked={PICO_LOCKED} lockedHints={PICO_LOCKED_HINTS} defaults={PICO_DEFAULTS} disabled={disabled}
                   onChange={(opts) => onChange({ ...topology, instance_options: opts })} />
               )}
             </MachineEditor>
@@ -815,6 +870,7 @@ function PicodataForm({ topology, onChange, disabled }: {
         <NumericSlider label="Shards" value={topology.shards} min={1} max={32}
           onChange={(v) => onChange({ ...topology, shards: v })} disabled={disabled} />
       </div>
+      <AutoTuneSelect value={topology.auto_tune} onChange={(v) => onChange({ ...topology, auto_tune: v })} disabled={disabled} />
 
       {/* HAProxy + config */}
       <div>
@@ -959,6 +1015,7 @@ function YDBForm({ topology, onChange, disabled }: {
             className="h-7 text-xs font-mono" placeholder="/Root/testdb" disabled={disabled} />
         </div>
       </div>
+      <AutoTuneSelect value={topology.auto_tune} onChange={(v) => onChange({ ...topology, auto_tune: v })} disabled={disabled} />
     </div>
   );
 }
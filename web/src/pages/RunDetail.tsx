@@ -1,7 +1,7 @@
 import { useEffect, useState, useCallback, useRef, useMemo } from "react";
 import { useParams, useNavigate } from "react-router-dom";
 import { getRunStatus, getGrafanaSettings, deleteRun, cancelRun, createShareLink } from "@/api/client";
-import { ALL_DB_KINDS, type Snapshot, type NodeStatus, type GrafanaSettings, type RunConfig } from "@/api/types";
+import { ALL_DB_KINDS, type Snapshot, type NodeStatus, type GrafanaSettings, type RunConfig, type GateStatus } from "@/api/types";
 import { RunOverview } from "@/components/RunOverview";
 import { LogStream } from "@/components/LogStream";
 import { MetricsPanel } from "@/components/MetricsPanel";
@@ -15,6 +15,22 @@ import { RefreshCw, AlertCircle, Trash2, StopCircle, Share2, Check, RotateCcw }
 // DB-specific dashboards — only show the one matching the run's database kind.
 const DB_DASHBOARDS = ALL_DB_KINDS;
 
+const gateBadgeVariant = {
+  pending: "pending",
+  passed: "success",
+  failed: "destructive",
+  error: "destructive",
+  skipped: "outline",
+} as const;
+
+// gateSummary lists the gate's failed checks, or its error, for a tooltip.
+function gateSummary(gate: GateStatus): string {
+  if (gate.error) return gate.error;
+  const failed = gate.result?.checks.filter((c) => !c.passed) ?? [];
+  if (failed.length === 0) return `against ${gate.baseline_run ?? "baseline"}`;
+  return failed.map((c) => `${c.name || c.key}: ${c.reason ?? "failed"}`).join("\n");
+}
+
 function DashboardSelector({ grafana, runID, dbKind, startedAt, finishedAt }: {
   grafana: GrafanaSettings; runID: string; dbKind?: string; startedAt?: string; finishedAt?: string;
 }) {
@@ -136,7 +152,8 @@ export function RunDetail() {
   useEffect(() => {
     if (snapshot && snapshot.nodes.length > 0) {
       const hasPending = snapshot.nodes.some(n => n.status === "pending");
-      finishedRef.current = !hasPending;
+      // Keep polling until the gate outcome is in.
+      finishedRef.current = !hasPending && snapshot.gate?.status !== "pending";
     }
   }, [snapshot]);
 
@@ -221,6 +238,14 @@ export function RunDetail() {
               <Badge variant="success">Completed</Badge>
             )
           )}
+          {snapshot?.gate && (
+            <Badge
+              variant={gateBadgeVariant[snapshot.gate.status]}
+              title={gateSummary(snapshot.gate)}
+            >
+              Gate {snapshot.gate.name}: {snapshot.gate.status}
+            </Badge>
+          )}
         </div>
         <div className="flex items-center gap-3">
           <Button variant="outline" size="sm" onClick={fetchStatus}>
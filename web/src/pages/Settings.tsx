@@ -11,11 +11,13 @@ import { Label } from "@/components/ui/label";
 import { Card, CardHeader, CardTitle, CardContent } from "@/components/ui/card";
 import { Tabs, TabsList, TabsTrigger, TabsContent } from "@/components/ui/tabs";
 import { Badge } from "@/components/ui/badge";
+import { MetricDefsPanel } from "@/components/MetricDefsPanel";
 import { Save, AlertCircle, Check } from "lucide-react";
 
 export function SettingsPage() {
   const { user } = useAuth();
   const canEdit = !!user && (user.is_root || user.role === "owner");
+  const canEditMetrics = canEdit || user?.role === "operator";
   const [settings, setSettings] = useState<ServerSettings | null>(null);
   const [loading, setLoading] = useState(true);
   const [saving, setSaving] = useState(false);
@@ -111,6 +113,7 @@ export function SettingsPage() {
         <TabsList>
           <TabsTrigger value="cloud">Cloud</TabsTrigger>
           <TabsTrigger value="quotas">Quotas</TabsTrigger>
+          <TabsTrigger value="metrics">Metrics</TabsTrigger>
         </TabsList>
 
         {/* Cloud settings */}
@@ -444,6 +447,11 @@ export function SettingsPage() {
           )}
         </TabsContent>
 
+        {/* Custom metric definitions */}
+        <TabsContent value="metrics">
+          <MetricDefsPanel canEdit={canEditMetrics} />
+        </TabsContent>
+
       </Tabs>
     </div>
   );